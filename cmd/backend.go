@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"FreeBSD-Command-manager/internal"
+	"FreeBSD-Command-manager/internal/config"
+	"FreeBSD-Command-manager/internal/network/backend"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var networkBackend string
+
+var networkBackendsCmd = &cobra.Command{
+	Use:   "backends",
+	Short: "List the network backends and the operations each supports",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		selected, err := selectedBackend()
+		if err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		backends := make([]map[string]interface{}, 0, len(backend.Names()))
+		for _, name := range backend.Names() {
+			b, err := backend.New(name)
+			if err != nil {
+				continue
+			}
+			backends = append(backends, map[string]interface{}{"name": name, "operations": b.Capabilities()})
+		}
+		if err := internal.Output(map[string]interface{}{"backends": backends, "selected": selected.Name()}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+// selectedBackend returns the backend named by --backend, else by the
+// configuration file, else the default one.
+func selectedBackend() (backend.Backend, error) {
+	name := networkBackend
+	if name == "" {
+		c, err := config.Load(configFile)
+		if err != nil {
+			return nil, err
+		}
+		name = c.NetworkBackend
+	}
+	return backend.New(name)
+}
+
+// backendRun runs fn with the selected backend and prints status, or the
+// error it returned
+func backendRun(fn func(backend.Backend) error, status map[string]interface{}) {
+	runOnBackend(fn, status, false)
+}
+
+// backendChange is backendRun for commands that change the configuration,
+// which --persist saves to rc.conf.
+func backendChange(fn func(backend.Backend) error, status map[string]interface{}) {
+	runOnBackend(fn, status, true)
+}
+
+func runOnBackend(fn func(backend.Backend) error, status map[string]interface{}, changed bool) {
+	b, err := selectedBackend()
+	if err == nil {
+		err = fn(b)
+	}
+	if err != nil {
+		if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if changed {
+		err = outputChanged(status)
+	} else {
+		err = internal.Output(status)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// requireBackend checks that the selected backend supports op, printing the
+// error and returning false otherwise. Commands that only the native
+// backend implements call it before running ifconfig.
+func requireBackend(op backend.Operation) bool {
+	b, err := selectedBackend()
+	if err == nil {
+		err = backend.Require(b, op)
+	}
+	if err != nil {
+		if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return false
+	}
+	return true
+}
+
+func init() { //nolint
+	networkCmd.PersistentFlags().StringVar(&networkBackend, "backend", "",
+		"Network backend: bareos, vale, ovs or vpp; native-only commands require bareos (default: fcom_network_backend from --config, else bareos)")
+	networkCmd.AddCommand(networkBackendsCmd)
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"FreeBSD-Command-manager/internal"
+	"FreeBSD-Command-manager/internal/network/backend"
+	"FreeBSD-Command-manager/internal/network/bareos"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	bridgeSetName     string
+	bridgeSetProto    string
+	bridgeSetPriority int
+	bridgeSetMaxAddr  int
+	bridgeSetTimeout  int
+	bridgeSetSpan     []string
+)
+
+var (
+	bridgeMemberBridge   string
+	bridgeMemberIface    string
+	bridgeMemberSTP      bool
+	bridgeMemberLearn    bool
+	bridgeMemberDiscover bool
+	bridgeMemberSticky   bool
+	bridgeMemberPrivate  bool
+	bridgeMemberPriority int
+	bridgeMemberPathCost int
+)
+
+var bridgeSetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Configure bridge STP protocol, priority, address cache and span ports",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if !requireBackend(backend.OpBridgeAttributes) {
+			return
+		}
+		attrs := bareos.BridgeAttrs{Proto: bridgeSetProto, Span: bridgeSetSpan}
+		if cmd.Flags().Changed("priority") {
+			attrs.Priority = &bridgeSetPriority
+		}
+		if cmd.Flags().Changed("maxaddr") {
+			attrs.MaxAddr = &bridgeSetMaxAddr
+		}
+		if cmd.Flags().Changed("timeout") {
+			attrs.Timeout = &bridgeSetTimeout
+		}
+		manager := bareos.DefaultManager()
+		if err := manager.SetBridge(bridgeSetName, attrs); err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		if err := outputChanged(map[string]interface{}{"bridge": bridgeSetName, "status": "updated"}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+var bridgeMemberCmd = &cobra.Command{
+	Use:   "member",
+	Short: "Manage bridge member settings (set)",
+}
+
+var bridgeMemberSetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Configure STP, learning, sticky and private settings of a bridge member",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if !requireBackend(backend.OpBridgeAttributes) {
+			return
+		}
+		var attrs bareos.BridgeMemberAttrs
+		if cmd.Flags().Changed("stp") {
+			attrs.STP = &bridgeMemberSTP
+		}
+		if cmd.Flags().Changed("learn") {
+			attrs.Learn = &bridgeMemberLearn
+		}
+		if cmd.Flags().Changed("discover") {
+			attrs.Discover = &bridgeMemberDiscover
+		}
+		if cmd.Flags().Changed("sticky") {
+			attrs.Sticky = &bridgeMemberSticky
+		}
+		if cmd.Flags().Changed("private") {
+			attrs.Private = &bridgeMemberPrivate
+		}
+		if cmd.Flags().Changed("priority") {
+			attrs.Priority = &bridgeMemberPriority
+		}
+		if cmd.Flags().Changed("path-cost") {
+			attrs.PathCost = &bridgeMemberPathCost
+		}
+		manager := bareos.DefaultManager()
+		if err := manager.SetBridgeMember(bridgeMemberBridge, bridgeMemberIface, attrs); err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		if err := outputChanged(map[string]interface{}{"bridge": bridgeMemberBridge, "interface": bridgeMemberIface, "status": "updated"}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() { //nolint
+	bridgeSetCmd.Flags().StringVar(&bridgeSetName, "name", "", "Bridge name (required)")
+	bridgeSetCmd.Flags().StringVar(&bridgeSetProto, "proto", "", "Spanning tree protocol: stp or rstp")
+	bridgeSetCmd.Flags().IntVar(&bridgeSetPriority, "priority", 0, "Bridge priority (0-61440, steps of 4096)")
+	bridgeSetCmd.Flags().IntVar(&bridgeSetMaxAddr, "maxaddr", 0, "Maximum number of cached addresses")
+	bridgeSetCmd.Flags().IntVar(&bridgeSetTimeout, "timeout", 0, "Address cache timeout in seconds")
+	bridgeSetCmd.Flags().StringSliceVar(&bridgeSetSpan, "span", nil, "Span ports to add, prefix with - to remove (e.g. em2,-em3)")
+	_ = bridgeSetCmd.MarkFlagRequired("name")
+
+	bridgeMemberSetCmd.Flags().StringVar(&bridgeMemberBridge, "bridge", "", "Bridge name (required)")
+	bridgeMemberSetCmd.Flags().StringVar(&bridgeMemberIface, "interface", "", "Member interface (required)")
+	bridgeMemberSetCmd.Flags().BoolVar(&bridgeMemberSTP, "stp", false, "Enable (--stp) or disable (--stp=false) spanning tree")
+	bridgeMemberSetCmd.Flags().BoolVar(&bridgeMemberLearn, "learn", false, "Enable or disable address learning")
+	bridgeMemberSetCmd.Flags().BoolVar(&bridgeMemberDiscover, "discover", false, "Enable or disable flooding of unknown destinations")
+	bridgeMemberSetCmd.Flags().BoolVar(&bridgeMemberSticky, "sticky", false, "Make learned addresses sticky")
+	bridgeMemberSetCmd.Flags().BoolVar(&bridgeMemberPrivate, "private", false, "Mark the member as private")
+	bridgeMemberSetCmd.Flags().IntVar(&bridgeMemberPriority, "priority", 0, "STP port priority (0-240, steps of 16)")
+	bridgeMemberSetCmd.Flags().IntVar(&bridgeMemberPathCost, "path-cost", 0, "STP path cost (0 for automatic)")
+	_ = bridgeMemberSetCmd.MarkFlagRequired("bridge")
+	_ = bridgeMemberSetCmd.MarkFlagRequired("interface")
+
+	bridgeMemberCmd.AddCommand(bridgeMemberSetCmd)
+	bridgeCmd.AddCommand(bridgeSetCmd)
+	bridgeCmd.AddCommand(bridgeMemberCmd)
+}
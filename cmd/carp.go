@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"FreeBSD-Command-manager/internal"
+	"FreeBSD-Command-manager/internal/network/backend"
+	"FreeBSD-Command-manager/internal/network/bareos"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+const defaultCARPPassEnv = "FCOM_CARP_PASS"
+
+var (
+	carpIface    string
+	carpVHID     int
+	carpAdvSkew  int
+	carpAddr     string
+	carpPassFile string
+	carpPassEnv  string
+)
+
+var carpCmd = &cobra.Command{
+	Use:   "carp",
+	Short: "Manage CARP high-availability addresses (add, del, status, demote)",
+}
+
+var carpAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add a CARP virtual address",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if !requireBackend(backend.OpCARP) {
+			return
+		}
+		pass, err := carpPass()
+		if err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		manager := bareos.DefaultManager()
+		if err := manager.AddCARP(carpIface, carpVHID, carpAdvSkew, pass, carpAddr); err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		if err := internal.Output(map[string]interface{}{"interface": carpIface, "vhid": carpVHID, "advskew": carpAdvSkew, "address": carpAddr, "status": "added"}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+var carpDelCmd = &cobra.Command{
+	Use:   "del",
+	Short: "Remove a CARP virtual address",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if !requireBackend(backend.OpCARP) {
+			return
+		}
+		manager := bareos.DefaultManager()
+		if err := manager.RemoveCARP(carpIface, carpAddr); err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		if err := internal.Output(map[string]interface{}{"interface": carpIface, "address": carpAddr, "status": "deleted"}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+var carpStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show CARP state (MASTER/BACKUP, vhid, advbase, advskew)",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if !requireBackend(backend.OpCARP) {
+			return
+		}
+		manager := bareos.DefaultManager()
+		status, err := manager.CARPStatus(carpIface)
+		if err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		if err := internal.Output(map[string]interface{}{"carp": status, "count": len(status)}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+var carpDemoteCmd = &cobra.Command{
+	Use:   "demote",
+	Short: "Force a CARP vhid into the BACKUP state",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if !requireBackend(backend.OpCARP) {
+			return
+		}
+		manager := bareos.DefaultManager()
+		if err := manager.SetCARPState(carpIface, carpVHID, "backup"); err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		if err := internal.Output(map[string]interface{}{"interface": carpIface, "vhid": carpVHID, "status": "demoted"}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+// carpPass reads the CARP pass phrase from --pass-file or the --pass-env
+// variable so it is never passed as an fcom argument.
+func carpPass() (string, error) {
+	if carpPassFile != "" {
+		return readSecretFile(carpPassFile)
+	}
+	return os.Getenv(carpPassEnv), nil
+}
+
+func init() { //nolint
+	carpAddCmd.Flags().StringVar(&carpIface, "iface", "", "Interface name (required)")
+	carpAddCmd.Flags().IntVar(&carpVHID, "vhid", 0, "Virtual host ID 1-255 (required)")
+	carpAddCmd.Flags().IntVar(&carpAdvSkew, "advskew", 0, "Advertisement skew 0-254")
+	carpAddCmd.Flags().StringVar(&carpAddr, "addr", "", "Virtual address, optionally with prefix length (required)")
+	carpAddCmd.Flags().StringVar(&carpPassFile, "pass-file", "", "File containing the pass phrase")
+	carpAddCmd.Flags().StringVar(&carpPassEnv, "pass-env", defaultCARPPassEnv, "Environment variable holding the pass phrase")
+	_ = carpAddCmd.MarkFlagRequired("iface")
+	_ = carpAddCmd.MarkFlagRequired("vhid")
+	_ = carpAddCmd.MarkFlagRequired("addr")
+
+	carpDelCmd.Flags().StringVar(&carpIface, "iface", "", "Interface name (required)")
+	carpDelCmd.Flags().StringVar(&carpAddr, "addr", "", "Virtual address (required)")
+	_ = carpDelCmd.MarkFlagRequired("iface")
+	_ = carpDelCmd.MarkFlagRequired("addr")
+
+	carpStatusCmd.Flags().StringVar(&carpIface, "iface", "", "Interface name (all if omitted)")
+
+	carpDemoteCmd.Flags().StringVar(&carpIface, "iface", "", "Interface name (required)")
+	carpDemoteCmd.Flags().IntVar(&carpVHID, "vhid", 0, "Virtual host ID (required)")
+	_ = carpDemoteCmd.MarkFlagRequired("iface")
+	_ = carpDemoteCmd.MarkFlagRequired("vhid")
+
+	carpCmd.AddCommand(carpAddCmd)
+	carpCmd.AddCommand(carpDelCmd)
+	carpCmd.AddCommand(carpStatusCmd)
+	carpCmd.AddCommand(carpDemoteCmd)
+}
@@ -2,12 +2,16 @@
 package cmd
 
 import (
+	"FreeBSD-Command-manager/internal/config"
 	"fmt"
 	"os"
 
 	"github.com/spf13/cobra"
 )
 
+// configFile is the fcom configuration file
+var configFile string
+
 var cmd = &cobra.Command{
 	Use:   "manager",
 	Short: "FreeBSD Manager CLI",
@@ -25,3 +29,7 @@ func Execute(v, c, b, d string) {
 		os.Exit(1)
 	}
 }
+
+func init() { //nolint
+	cmd.PersistentFlags().StringVar(&configFile, "config", config.DefaultFile, "fcom configuration file")
+}
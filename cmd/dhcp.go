@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"FreeBSD-Command-manager/internal"
+	"FreeBSD-Command-manager/internal/network/backend"
+	"FreeBSD-Command-manager/internal/network/bareos"
+	"FreeBSD-Command-manager/internal/network/dhcp"
+	"FreeBSD-Command-manager/internal/network/persist"
+	pkgdhcp "FreeBSD-Command-manager/pkg/dhcp"
+	"FreeBSD-Command-manager/pkg/neighbor"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	dhcpRelease  bool
+	dhcpShow     bool
+	rtadvEnable  bool
+	rtadvDisable bool
+	rtadvSolicit bool
+	dhcpIface    string
+)
+
+var ipDHCPCmd = &cobra.Command{
+	Use:   "dhcp",
+	Short: "Obtain, show or release a DHCP lease on an interface",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if !requireBackend(backend.OpDHCP) {
+			return
+		}
+		manager := dhcp.DefaultManager()
+		var lease *pkgdhcp.Lease
+		var err error
+		status := "bound"
+		switch {
+		case dhcpRelease:
+			lease, err = manager.Release(dhcpIface)
+			status = "released"
+		case dhcpShow:
+			lease, err = manager.Lease(dhcpIface)
+			status = "unbound"
+			if lease != nil {
+				status = "bound"
+			}
+		default:
+			lease, err = manager.Start(dhcpIface)
+		}
+		if err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		out := map[string]interface{}{"interface": dhcpIface, "lease": lease, "status": status}
+		if dhcpShow {
+			err = internal.Output(out)
+		} else {
+			err = outputDynamic(out, persist.Dynamic{DHCP: map[string]bool{dhcpIface: !dhcpRelease}})
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+var ipRtadvCmd = &cobra.Command{
+	Use:   "rtadv",
+	Short: "Show or toggle IPv6 router advertisement processing (SLAAC) on an interface",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if !requireBackend(backend.OpDHCP) {
+			return
+		}
+		manager := bareos.DefaultManager()
+		var info *neighbor.ND6Info
+		var err error
+		switch {
+		case rtadvEnable || rtadvDisable:
+			info, err = manager.SetAcceptRtadv(dhcpIface, rtadvEnable)
+		default:
+			info, err = manager.ND6(dhcpIface)
+		}
+		if err == nil && rtadvSolicit {
+			err = manager.SolicitRouter(dhcpIface)
+		}
+		if err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		out := map[string]interface{}{"interface": dhcpIface, "nd6": info, "solicited": rtadvSolicit}
+		if rtadvEnable || rtadvDisable {
+			err = outputDynamic(out, persist.Dynamic{Rtadv: map[string]bool{dhcpIface: rtadvEnable}})
+		} else {
+			err = internal.Output(out)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() { //nolint
+	for _, c := range []*cobra.Command{ipDHCPCmd, ipRtadvCmd} {
+		c.Flags().StringVar(&dhcpIface, "iface", "", "Interface name (required)")
+		_ = c.MarkFlagRequired("iface")
+	}
+	ipDHCPCmd.Flags().BoolVar(&dhcpRelease, "release", false, "Stop dhclient and remove the leased address")
+	ipDHCPCmd.Flags().BoolVar(&dhcpShow, "show", false, "Show the active lease without running dhclient")
+	ipDHCPCmd.MarkFlagsMutuallyExclusive("release", "show")
+	ipRtadvCmd.Flags().BoolVar(&rtadvEnable, "enable", false, "Accept router advertisements (sets accept_rtadv, clears disabled)")
+	ipRtadvCmd.Flags().BoolVar(&rtadvDisable, "disable", false, "Ignore router advertisements")
+	ipRtadvCmd.Flags().BoolVar(&rtadvSolicit, "solicit", false, "Send a router solicitation with rtsol")
+	ipRtadvCmd.MarkFlagsMutuallyExclusive("enable", "disable")
+
+	ipCmd.AddCommand(ipDHCPCmd)
+	ipCmd.AddCommand(ipRtadvCmd)
+}
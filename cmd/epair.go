@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"FreeBSD-Command-manager/internal"
+	"FreeBSD-Command-manager/internal/network/backend"
+	"FreeBSD-Command-manager/internal/network/bareos"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var epairName string
+
+var epairCmd = &cobra.Command{
+	Use:   "epair",
+	Short: "Manage virtual ethernet pairs (create, delete)",
+}
+
+var epairCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create an epair interface",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if !requireBackend(backend.OpEpair) {
+			return
+		}
+		manager := bareos.DefaultManager()
+		pair, err := manager.CreateEpair(epairName)
+		if err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		if err := outputChanged(map[string]interface{}{"a": pair.A, "b": pair.B, "status": "created"}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+var epairDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete an epair interface (either end or the pair name)",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if !requireBackend(backend.OpEpair) {
+			return
+		}
+		manager := bareos.DefaultManager()
+		pair, err := manager.DeleteEpair(epairName)
+		if err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		if err := outputChanged(map[string]interface{}{"a": pair.A, "b": pair.B, "status": "deleted"}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() { //nolint
+	epairCreateCmd.Flags().StringVar(&epairName, "name", "", "Pair name, ends become <name>a and <name>b")
+
+	epairDeleteCmd.Flags().StringVar(&epairName, "name", "", "Epair end or pair name (required)")
+	_ = epairDeleteCmd.MarkFlagRequired("name")
+
+	epairCmd.AddCommand(epairCreateCmd)
+	epairCmd.AddCommand(epairDeleteCmd)
+}
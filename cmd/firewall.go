@@ -0,0 +1,450 @@
+package cmd
+
+import (
+	"FreeBSD-Command-manager/internal"
+	"FreeBSD-Command-manager/internal/config"
+	"FreeBSD-Command-manager/internal/firewall/ipfw"
+	"FreeBSD-Command-manager/internal/firewall/pf"
+	pkgpf "FreeBSD-Command-manager/pkg/pf"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/spf13/cobra"
+)
+
+// Firewalls the firewall commands can drive
+const (
+	firewallPF   = "pf"
+	firewallIPFW = "ipfw"
+)
+
+var (
+	fwBackend     string
+	fwAnchor      string
+	fwState       string
+	fwAll         bool
+	fwLoaded      bool
+	fwNr          int
+	fwPosition    int
+	fwTranslation bool
+	fwTable       string
+	fwAddrs       []string
+	fwRule        pkgpf.Rule
+	fwNAT         pkgpf.Translation
+	fwReturn      bool
+	fwOptions     string
+)
+
+var firewallCmd = &cobra.Command{
+	Use:   "firewall",
+	Short: "Manage firewall rules and tables owned by fcom in pf anchors or an ipfw rule range (rule, table, apply)",
+}
+
+// pfRunFunc and ipfwRunFunc implement a firewall command for one firewall
+type (
+	pfRunFunc   func(m pf.ManagerInterface, s *pf.Store, anchor string) error
+	ipfwRunFunc func(m ipfw.ManagerInterface, s *ipfw.Store) error
+)
+
+// firewallRun runs pfFn or ipfwFn, depending on the firewall named by
+// --backend, else by the configuration file, else pf, and prints status or
+// the error returned. A nil function means the command does not apply to
+// that firewall.
+func firewallRun(pfFn pfRunFunc, ipfwFn ipfwRunFunc, status map[string]interface{}) {
+	err := func() error {
+		c, err := config.Load(configFile)
+		if err != nil {
+			return err
+		}
+		name := fwBackend
+		if name == "" {
+			name = c.Firewall
+		}
+		switch name {
+		case "", firewallPF:
+			if pfFn == nil {
+				return fmt.Errorf("this command only applies to the ipfw firewall")
+			}
+			anchor, err := pf.Anchor(fwAnchor)
+			if err != nil {
+				return err
+			}
+			state := fwState
+			if state == "" {
+				state = pf.DefaultStateFile
+			}
+			status["anchor"] = anchor
+			return pfFn(pf.DefaultManager(), pf.NewStore(state), anchor)
+		case firewallIPFW:
+			if ipfwFn == nil {
+				return fmt.Errorf("this command only applies to the pf firewall")
+			}
+			first, last, err := ipfw.ParseRange(c.IPFWRules)
+			if err != nil {
+				return err
+			}
+			if firewallCmd.PersistentFlags().Changed("anchor") {
+				return fmt.Errorf("--anchor only applies to pf, fcom ipfw rules are numbered %d-%d", first, last)
+			}
+			state := fwState
+			if state == "" {
+				state = ipfw.DefaultStateFile
+			}
+			return ipfwFn(ipfw.DefaultManager(first, last), ipfw.NewStore(state))
+		default:
+			return fmt.Errorf("unknown firewall %q: must be pf or ipfw", name)
+		}
+	}()
+	if err != nil {
+		if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if err := internal.Output(status); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+var firewallRuleCmd = &cobra.Command{
+	Use:   "rule",
+	Short: "Manage the filter and translation rules (add, del, list)",
+}
+
+var firewallRuleAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add a filter rule, or a pf nat or rdr rule with --action nat|rdr; takes effect on apply",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		status := map[string]interface{}{"status": "added"}
+		firewallRun(func(_ pf.ManagerInterface, s *pf.Store, anchor string) error {
+			if fwOptions != "" {
+				return fmt.Errorf("--options only applies to ipfw")
+			}
+			return s.Update(func(st *pf.State) error {
+				rs := st.Ruleset(anchor)
+				switch fwRule.Action {
+				case pkgpf.TypeNAT, pkgpf.TypeRDR:
+					t := fwNAT
+					t.Type, t.Interface, t.Family, t.Proto = fwRule.Action, fwRule.Interface, fwRule.Family, fwRule.Proto
+					t.From, t.FromPort, t.To, t.ToPort, t.Log = fwRule.From, fwRule.FromPort, fwRule.To, fwRule.ToPort, fwRule.Log
+					if err := t.Validate(); err != nil {
+						return err
+					}
+					rs.Translations = insertAt(rs.Translations, t, fwPosition)
+				default:
+					r := fwRule
+					if fwReturn && r.Action == pkgpf.ActionBlock {
+						r.Action = pkgpf.ActionBlockReturn
+					}
+					if err := r.Validate(); err != nil {
+						return err
+					}
+					rs.Rules = insertAt(rs.Rules, r, fwPosition)
+				}
+				rs.Renumber()
+				status["ruleset"] = rs
+				return nil
+			})
+		}, func(m ipfw.ManagerInterface, s *ipfw.Store) error {
+			r, err := ipfwRuleFromFlags()
+			if err != nil {
+				return err
+			}
+			return s.Update(func(st *ipfw.State) error {
+				rs := &st.Ruleset
+				pos := -1
+				if fwPosition >= 0 {
+					if pos = rs.Index(fwPosition); pos < 0 {
+						return fmt.Errorf("no rule number %d", fwPosition)
+					}
+				}
+				rs.Rules = insertAt(rs.Rules, r, pos)
+				status["ruleset"] = rs
+				return m.Renumber(rs)
+			})
+		}, status)
+	},
+}
+
+var firewallRuleDelCmd = &cobra.Command{
+	Use:   "del",
+	Short: "Delete a rule by the number shown by rule list; takes effect on apply",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		firewallRun(func(_ pf.ManagerInterface, s *pf.Store, anchor string) error {
+			return s.Update(func(st *pf.State) error {
+				rs := st.Ruleset(anchor)
+				var err error
+				if fwTranslation {
+					rs.Translations, err = deleteAt(rs.Translations, fwNr)
+				} else {
+					rs.Rules, err = deleteAt(rs.Rules, fwNr)
+				}
+				rs.Renumber()
+				return err
+			})
+		}, func(m ipfw.ManagerInterface, s *ipfw.Store) error {
+			return s.Update(func(st *ipfw.State) error {
+				rs := &st.Ruleset
+				var err error
+				if rs.Rules, err = deleteAt(rs.Rules, rs.Index(fwNr)); err != nil {
+					return fmt.Errorf("no rule number %d", fwNr)
+				}
+				return m.Renumber(rs)
+			})
+		}, map[string]interface{}{"nr": fwNr, "translation": fwTranslation, "status": "deleted"})
+	},
+}
+
+var firewallRuleListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the configured rules, or the loaded ones with their counters",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		status := map[string]interface{}{"loaded": fwLoaded}
+		firewallRun(func(m pf.ManagerInterface, s *pf.Store, anchor string) error {
+			if fwLoaded {
+				translations, err := m.Translations(anchor)
+				if err != nil {
+					return err
+				}
+				rules, err := m.Rules(anchor)
+				status["translations"], status["rules"] = translations, rules
+				return err
+			}
+			state, err := s.Load()
+			if err != nil {
+				return err
+			}
+			rs := state.Ruleset(anchor)
+			rs.Renumber()
+			status["translations"], status["rules"] = rs.Translations, rs.Rules
+			return nil
+		}, func(m ipfw.ManagerInterface, s *ipfw.Store) error {
+			if fwLoaded {
+				rules, err := m.Rules()
+				status["rules"] = rules
+				return err
+			}
+			state, err := s.Load()
+			if err != nil {
+				return err
+			}
+			status["rules"] = state.Ruleset.Rules
+			return nil
+		}, status)
+	},
+}
+
+var firewallTableCmd = &cobra.Command{
+	Use:   "table",
+	Short: "Manage address tables (add, del, show)",
+}
+
+var firewallTableAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add addresses to a table, in the configuration and the loaded firewall",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		firewallRun(func(m pf.ManagerInterface, s *pf.Store, anchor string) error {
+			return s.Update(func(st *pf.State) error {
+				rs := st.Ruleset(anchor)
+				t := rs.Table(fwTable)
+				if t == nil {
+					rs.Tables = append(rs.Tables, pkgpf.Table{Name: fwTable, Persist: true})
+					t = &rs.Tables[len(rs.Tables)-1]
+				}
+				for _, a := range fwAddrs {
+					if !slices.Contains(t.Addresses, a) {
+						t.Addresses = append(t.Addresses, a)
+					}
+				}
+				if err := t.Validate(); err != nil {
+					return err
+				}
+				return m.AddTableAddresses(anchor, fwTable, fwAddrs)
+			})
+		}, ipfwTableAdd, map[string]interface{}{"table": fwTable, "addresses": fwAddrs, "status": "added"})
+	},
+}
+
+var firewallTableDelCmd = &cobra.Command{
+	Use:   "del",
+	Short: "Delete addresses from a table, or the whole table without --addr",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		firewallRun(func(m pf.ManagerInterface, s *pf.Store, anchor string) error {
+			return s.Update(func(st *pf.State) error {
+				rs := st.Ruleset(anchor)
+				t := rs.Table(fwTable)
+				if t == nil {
+					return fmt.Errorf("table %s not found in anchor %s", fwTable, anchor)
+				}
+				// a table that was never applied is only removed from the configuration
+				tables, err := m.Tables(anchor)
+				if err != nil {
+					return err
+				}
+				loaded := slices.ContainsFunc(tables, func(t pkgpf.Table) bool { return t.Name == fwTable })
+				if len(fwAddrs) == 0 {
+					rs.Tables = slices.DeleteFunc(rs.Tables, func(t pkgpf.Table) bool { return t.Name == fwTable })
+					if !loaded {
+						return nil
+					}
+					return m.KillTable(anchor, fwTable)
+				}
+				t.Addresses = slices.DeleteFunc(t.Addresses, func(a string) bool { return slices.Contains(fwAddrs, a) })
+				if !loaded {
+					return nil
+				}
+				return m.DeleteTableAddresses(anchor, fwTable, fwAddrs)
+			})
+		}, ipfwTableDel, map[string]interface{}{"table": fwTable, "addresses": fwAddrs, "status": "deleted"})
+	},
+}
+
+var firewallTableShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the configured and loaded tables, or the addresses of one table",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		status := map[string]interface{}{"table": fwTable}
+		firewallRun(func(m pf.ManagerInterface, s *pf.Store, anchor string) error {
+			state, err := s.Load()
+			if err != nil {
+				return err
+			}
+			rs := state.Ruleset(anchor)
+			if fwTable == "" {
+				loaded, err := m.Tables(anchor)
+				status["configured"], status["loaded"] = rs.Tables, loaded
+				return err
+			}
+			var configured []string
+			if t := rs.Table(fwTable); t != nil {
+				configured = t.Addresses
+			}
+			loaded, err := m.TableAddresses(anchor, fwTable)
+			status["configured"], status["loaded"] = configured, loaded
+			return err
+		}, func(m ipfw.ManagerInterface, s *ipfw.Store) error {
+			configured, loaded, err := ipfwTableShow(m, s)
+			status["configured"], status["loaded"] = configured, loaded
+			return err
+		}, status)
+	},
+}
+
+var firewallApplyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Check the configured rules with the firewall and load them (pf: pfctl -nf, then the anchor file)",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		status := map[string]interface{}{"status": "applied"}
+		firewallRun(func(m pf.ManagerInterface, s *pf.Store, anchor string) error {
+			state, err := s.Load()
+			if err != nil {
+				return err
+			}
+			anchors := []string{anchor}
+			if fwAll {
+				anchors = state.AnchorNames()
+			}
+			files := make(map[string]string)
+			status["files"] = files
+			for _, a := range anchors {
+				path, err := m.Apply(a, state.Ruleset(a))
+				if err != nil {
+					return err
+				}
+				files[a] = path
+			}
+			return nil
+		}, func(m ipfw.ManagerInterface, s *ipfw.Store) error {
+			var applyErr error
+			err := s.Update(func(st *ipfw.State) error {
+				status["rules"] = len(st.Ruleset.Rules)
+				// Save the objects created and removed even if a step fails
+				applyErr = m.Apply(&st.Ruleset, &st.Owned)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			return applyErr
+		}, status)
+	},
+}
+
+// insertAt inserts v before position nr, or appends it if nr is negative
+// or past the end
+func insertAt[T any](s []T, v T, nr int) []T {
+	if nr < 0 || nr >= len(s) {
+		return append(s, v)
+	}
+	s = append(s[:nr+1], s[nr:]...)
+	s[nr] = v
+	return s
+}
+
+// deleteAt removes the element at position nr
+func deleteAt[T any](s []T, nr int) ([]T, error) {
+	if nr < 0 || nr >= len(s) {
+		return s, fmt.Errorf("no rule number %d", nr)
+	}
+	return append(s[:nr], s[nr+1:]...), nil
+}
+
+func init() { //nolint
+	firewallCmd.PersistentFlags().StringVar(&fwBackend, "backend", "", "Firewall to manage: pf or ipfw (default: fcom_firewall from the configuration, else pf)")
+	firewallCmd.PersistentFlags().StringVar(&fwAnchor, "anchor", "main", "pf: fcom anchor to manage, loaded as fcom/<anchor>")
+	firewallCmd.PersistentFlags().StringVar(&fwState, "state", "", "File keeping the configured rules (default: "+pf.DefaultStateFile+" or "+ipfw.DefaultStateFile+")")
+
+	f := firewallRuleAddCmd.Flags()
+	f.StringVar(&fwRule.Action, "action", "", "pass, block, match, nat or rdr; ipfw also takes its own actions, e.g. allow, deny, pipe or skipto (required)")
+	f.BoolVar(&fwReturn, "return", false, "Answer blocked packets with TCP RST or ICMP unreachable")
+	f.StringVar(&fwRule.Direction, "direction", "", "in or out (default: both)")
+	f.BoolVar(&fwRule.Quick, "quick", false, "pf: stop evaluating rules when this one matches, as ipfw always does")
+	f.BoolVar(&fwRule.Log, "log", false, "Log matching packets")
+	f.StringVar(&fwRule.Interface, "iface", "", "Interface the rule applies to (required for pf nat and rdr)")
+	f.StringVar(&fwRule.Family, "family", "", "inet or inet6")
+	f.StringVar(&fwRule.Proto, "proto", "", "Protocol, e.g. tcp, udp or icmp")
+	f.StringVar(&fwRule.From, "from", "", "Source address or prefix; pf: <table>, (iface), self; ipfw: table(name), me (default: any)")
+	f.StringVar(&fwRule.FromPort, "from-port", "", "Source port; pf: range (1000:2000) or comparison (\"> 1024\"); ipfw: 1000-2000 or 80,443")
+	f.StringVar(&fwRule.To, "to", "", "Destination, as --from (default: any)")
+	f.StringVar(&fwRule.ToPort, "to-port", "", "Destination port, as --from-port")
+	f.StringVar(&fwRule.Flags, "flags", "", "pf: TCP flags, e.g. S/SA")
+	f.StringVar(&fwRule.State, "keep-state", "", "State tracking: keep; pf also modulate, synproxy or no")
+	f.StringVar(&fwRule.Label, "label", "", "Rule label, an ipfw comment")
+	f.StringVar(&fwNAT.Target, "target", "", "pf: nat source or rdr destination, e.g. (em0); ipfw: nat instance, pipe, queue or skipto rule")
+	f.StringVar(&fwNAT.TargetPort, "target-port", "", "pf: rdr destination port")
+	f.BoolVar(&fwNAT.StaticPort, "static-port", false, "pf: keep the source port on nat")
+	f.BoolVar(&fwNAT.Pass, "pass", false, "pf: pass translated packets without evaluating the filter rules")
+	f.StringVar(&fwOptions, "options", "", "ipfw: other match options, e.g. setup or \"icmptypes 0,8\"")
+	f.IntVar(&fwPosition, "position", -1, "Insert before this rule number (default: append)")
+	_ = firewallRuleAddCmd.MarkFlagRequired("action")
+
+	firewallRuleDelCmd.Flags().IntVar(&fwNr, "nr", 0, "Rule number from rule list (required)")
+	firewallRuleDelCmd.Flags().BoolVar(&fwTranslation, "translation", false, "pf: delete a nat or rdr rule instead of a filter rule")
+	_ = firewallRuleDelCmd.MarkFlagRequired("nr")
+
+	firewallRuleListCmd.Flags().BoolVar(&fwLoaded, "loaded", false, "List the rules loaded in the firewall, with counters")
+
+	for _, c := range []*cobra.Command{firewallTableAddCmd, firewallTableDelCmd} {
+		c.Flags().StringVar(&fwTable, "table", "", "Table name (required)")
+		c.Flags().StringSliceVar(&fwAddrs, "addr", nil, "Addresses or prefixes, comma separated")
+		_ = c.MarkFlagRequired("table")
+	}
+	_ = firewallTableAddCmd.MarkFlagRequired("addr")
+	firewallTableShowCmd.Flags().StringVar(&fwTable, "table", "", "Table name (default: list the tables)")
+
+	firewallApplyCmd.Flags().BoolVar(&fwAll, "all", false, "pf: apply every configured anchor")
+
+	firewallRuleCmd.AddCommand(firewallRuleAddCmd)
+	firewallRuleCmd.AddCommand(firewallRuleDelCmd)
+	firewallRuleCmd.AddCommand(firewallRuleListCmd)
+	firewallTableCmd.AddCommand(firewallTableAddCmd)
+	firewallTableCmd.AddCommand(firewallTableDelCmd)
+	firewallTableCmd.AddCommand(firewallTableShowCmd)
+	firewallCmd.AddCommand(firewallRuleCmd)
+	firewallCmd.AddCommand(firewallTableCmd)
+	firewallCmd.AddCommand(firewallApplyCmd)
+	cmd.AddCommand(firewallCmd)
+}
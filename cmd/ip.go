@@ -0,0 +1,266 @@
+package cmd
+
+import (
+	"FreeBSD-Command-manager/internal"
+	"FreeBSD-Command-manager/internal/network/backend"
+	"FreeBSD-Command-manager/internal/network/bareos"
+	"fmt"
+	"net/netip"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	ipIface      string
+	ipAddr       string
+	ipMask       int
+	ipFamily     string
+	ipAnycast    bool
+	ipTentative  bool
+	ipDeprecated bool
+	ipAutoConf   bool
+	ipVLTime     int
+	ipPLTime     int
+	ipPool       string
+	ipOwner      string
+)
+
+var ipCmd = &cobra.Command{
+	Use:   "ip",
+	Short: "Manage IP addresses on interfaces",
+}
+
+var ipAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add an IP address to an interface",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		runIPAdd("added", false, bareos.ManagerInterface.AddIP)
+	},
+}
+
+var ipAliasCmd = &cobra.Command{
+	Use:   "alias",
+	Short: "Add an alias IP address to an interface",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		runIPAdd("aliased", true, bareos.ManagerInterface.AliasIP)
+	},
+}
+
+var ipDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete an IP address from an interface",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		prefix, err := ipPrefix()
+		var b backend.Backend
+		if err == nil {
+			b, err = selectedBackend()
+		}
+		if err == nil {
+			err = b.DeleteIP(ipIface, prefix)
+		}
+		if err != nil {
+			if e := internal.Output(map[string]any{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		if err := outputChanged(map[string]any{
+			"interface": ipIface,
+			"ip":        prefix.Addr().String(),
+			"family":    ipFamilyOf(prefix.Addr()),
+			"status":    "deleted",
+		}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+var ipListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List IP addresses on interfaces",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		var ips interface{}
+		count := 0
+		b, err := selectedBackend()
+		if err == nil && b.Name() == backend.Bareos {
+			ips, count, err = listNativeIPs()
+		} else if err == nil {
+			ips, count, err = listBackendIPs(b)
+		}
+		if err != nil {
+			if e := internal.Output(map[string]any{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		if ipIface != "" {
+			if err := internal.Output(map[string]any{"interface": ipIface, "addresses": ips, "count": count}); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		if err := internal.Output(map[string]any{"interfaces": ips, "count": count}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+// listNativeIPs returns the addresses of --iface, or of every interface by
+// name, and their count.
+func listNativeIPs() (interface{}, int, error) {
+	ips, err := bareos.DefaultManager().ListIPs(ipIface)
+	if err != nil {
+		return nil, 0, err
+	}
+	if ipIface != "" {
+		return ips[ipIface], len(ips[ipIface]), nil
+	}
+	count := 0
+	for _, addrs := range ips {
+		count += len(addrs)
+	}
+	return ips, count, nil
+}
+
+// listBackendIPs is listNativeIPs for the other backends, which report
+// addresses as prefixes.
+func listBackendIPs(b backend.Backend) (interface{}, int, error) {
+	ifaces, err := b.List()
+	if err != nil {
+		return nil, 0, err
+	}
+	ips := make(map[string][]string)
+	count := 0
+	for _, iface := range ifaces {
+		if len(iface.Addresses) > 0 && (ipIface == "" || iface.Name == ipIface) {
+			ips[iface.Name] = iface.Addresses
+			count += len(iface.Addresses)
+		}
+	}
+	if ipIface != "" {
+		return ips[ipIface], count, nil
+	}
+	return ips, count, nil
+}
+
+// runIPAdd adds the address given on the command line, or allocated from
+// --pool, with fn and prints the result. Other backends than the native one
+// add plain addresses only; options marks operations that need more.
+func runIPAdd(status string, options bool, fn func(bareos.ManagerInterface, string, netip.Prefix, bareos.IPOptions) error) {
+	var prefix netip.Prefix
+	var err error
+	fresh := false
+	owner := ipOwner
+	if owner == "" {
+		owner = ipIface
+	}
+	if ipPool != "" {
+		prefix, fresh, err = allocateFromPool(ipPool, owner, ipIface)
+	} else {
+		prefix, err = ipPrefix()
+	}
+	if err == nil {
+		opts := bareos.IPOptions{
+			Anycast:    ipAnycast,
+			Tentative:  ipTentative,
+			Deprecated: ipDeprecated,
+			AutoConf:   ipAutoConf,
+			VLTime:     ipVLTime,
+			PLTime:     ipPLTime,
+		}
+		var b backend.Backend
+		if b, err = selectedBackend(); err == nil {
+			switch {
+			case b.Name() == backend.Bareos:
+				err = fn(bareos.DefaultManager(), ipIface, prefix, opts)
+			case options || opts != (bareos.IPOptions{}):
+				err = backend.Require(b, backend.OpIPOptions)
+			default:
+				err = b.AddIP(ipIface, prefix)
+			}
+		}
+		if err != nil && fresh {
+			releaseToPool(ipPool, owner)
+		}
+	}
+	if err != nil {
+		if e := internal.Output(map[string]any{"error": err.Error()}); e != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	out := map[string]any{
+		"interface": ipIface,
+		"ip":        prefix.String(),
+		"family":    ipFamilyOf(prefix.Addr()),
+		"status":    status,
+	}
+	if ipPool != "" {
+		out["pool"] = ipPool
+		out["owner"] = owner
+	}
+	if err := outputChanged(out); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// ipPrefix parses --ip and --mask, checking the address against --family when given.
+func ipPrefix() (netip.Prefix, error) {
+	prefix, err := bareos.ParsePrefix(ipAddr, ipMask)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	if ipFamily != "" && ipFamily != ipFamilyOf(prefix.Addr()) {
+		return netip.Prefix{}, fmt.Errorf("address %s is not an %s address", prefix.Addr(), ipFamily)
+	}
+	return prefix, nil
+}
+
+func ipFamilyOf(addr netip.Addr) string {
+	if addr.Is4() {
+		return "inet"
+	}
+	return "inet6"
+}
+
+func init() { //nolint
+	for _, c := range []*cobra.Command{ipAddCmd, ipAliasCmd, ipDeleteCmd} {
+		c.Flags().StringVar(&ipIface, "iface", "", "Interface name (required)")
+		c.Flags().StringVar(&ipAddr, "ip", "", "IP address, optionally in CIDR notation")
+		c.Flags().IntVar(&ipMask, "mask", 0, "Prefix length (default: from --ip, or a host prefix)")
+		c.Flags().StringVar(&ipFamily, "family", "", "Address family (default: detected from the address)")
+		_ = c.MarkFlagRequired("iface")
+	}
+	_ = ipDeleteCmd.MarkFlagRequired("ip")
+	for _, c := range []*cobra.Command{ipAddCmd, ipAliasCmd} {
+		c.Flags().StringVar(&ipPool, "pool", "", "Allocate the address from this IPAM pool instead of --ip")
+		addIPAMStateFlag(c)
+		c.Flags().StringVar(&ipOwner, "owner", "", "IPAM owner of the allocation (default: the interface name)")
+		c.Flags().BoolVar(&ipAnycast, "anycast", false, "Mark an IPv6 address as anycast")
+		c.Flags().BoolVar(&ipTentative, "tentative", false, "Mark an IPv6 address as tentative")
+		c.Flags().BoolVar(&ipDeprecated, "deprecated", false, "Mark an IPv6 address as deprecated")
+		c.Flags().BoolVar(&ipAutoConf, "autoconf", false, "Mark an IPv6 address as autoconfigured")
+		c.Flags().IntVar(&ipVLTime, "vltime", 0, "IPv6 valid lifetime in seconds")
+		c.Flags().IntVar(&ipPLTime, "pltime", 0, "IPv6 preferred lifetime in seconds")
+		c.MarkFlagsOneRequired("ip", "pool")
+		c.MarkFlagsMutuallyExclusive("ip", "pool")
+	}
+	ipListCmd.Flags().StringVar(&ipIface, "iface", "", "Interface name (default: all interfaces)")
+
+	ipCmd.AddCommand(ipAddCmd)
+	ipCmd.AddCommand(ipAliasCmd)
+	ipCmd.AddCommand(ipDeleteCmd)
+	ipCmd.AddCommand(ipListCmd)
+	networkCmd.AddCommand(ipCmd)
+
+	// Add ip commant to top level, do not delete.
+	// cmd.AddCommand(ipCmd)
+}
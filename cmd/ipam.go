@@ -0,0 +1,292 @@
+package cmd
+
+import (
+	"FreeBSD-Command-manager/internal"
+	"FreeBSD-Command-manager/internal/ipam"
+	"FreeBSD-Command-manager/internal/network/bareos"
+	"fmt"
+	"net/netip"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	ipamState    string
+	ipamPool     string
+	ipamCIDR     string
+	ipamGateway  string
+	ipamExclude  []string
+	ipamReserve  []string
+	ipamOwner    string
+	ipamIface    string
+	ipamReleased string
+)
+
+var ipamCmd = &cobra.Command{
+	Use:   "ipam",
+	Short: "Manage local IP address pools and allocations",
+}
+
+var ipamPoolCmd = &cobra.Command{
+	Use:   "pool",
+	Short: "Manage IPAM pools (create, list, delete)",
+}
+
+var ipamPoolCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create an address pool",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		pool := ipam.Pool{Name: ipamPool, CIDR: ipamCIDR, Gateway: ipamGateway, Exclude: ipamExclude}
+		for _, r := range ipamReserve {
+			owner, addr, ok := strings.Cut(r, "=")
+			if !ok {
+				if e := internal.Output(map[string]interface{}{"error": fmt.Sprintf("invalid reservation %q, expected owner=address", r)}); e != nil {
+					fmt.Fprintln(os.Stderr, e)
+					os.Exit(1)
+				}
+				return
+			}
+			pool.Reservations = append(pool.Reservations, ipam.Reservation{Owner: owner, Address: addr})
+		}
+		err := ipam.NewStore(ipamState).Update(func(s *ipam.State) error {
+			if err := s.CreatePool(pool); err != nil {
+				return err
+			}
+			pool = *s.Pool(pool.Name)
+			return nil
+		})
+		if err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		if err := internal.Output(map[string]interface{}{"pool": pool, "status": "created"}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+var ipamPoolListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List address pools",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		state, err := ipam.NewStore(ipamState).Load()
+		if err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		if err := internal.Output(map[string]interface{}{"pools": state.Pools, "count": len(state.Pools)}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+var ipamPoolDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete an address pool without allocations",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		err := ipam.NewStore(ipamState).Update(func(s *ipam.State) error {
+			return s.DeletePool(ipamPool)
+		})
+		if err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		if err := internal.Output(map[string]interface{}{"pool": ipamPool, "status": "deleted"}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+var ipamAllocateCmd = &cobra.Command{
+	Use:   "allocate",
+	Short: "Allocate an address from a pool to an owner",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		var a ipam.Allocation
+		err := ipam.NewStore(ipamState).Update(func(s *ipam.State) error {
+			var err error
+			a, err = s.Allocate(ipamPool, ipamOwner, ipamIface)
+			return err
+		})
+		if err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		if err := internal.Output(map[string]interface{}{"pool": ipamPool, "allocation": a, "status": "allocated"}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+var ipamReleaseCmd = &cobra.Command{
+	Use:   "release",
+	Short: "Release an address by owner or address",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		var a ipam.Allocation
+		err := ipam.NewStore(ipamState).Update(func(s *ipam.State) error {
+			var err error
+			a, err = s.Release(ipamPool, ipamOwner, ipamReleased)
+			return err
+		})
+		if err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		if err := internal.Output(map[string]interface{}{"pool": ipamPool, "allocation": a, "status": "released"}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+var ipamShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show a pool with its allocations",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		state, err := ipam.NewStore(ipamState).Load()
+		if err == nil && state.Pool(ipamPool) == nil {
+			err = fmt.Errorf("pool %s not found", ipamPool)
+		}
+		if err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		pool := state.Pool(ipamPool)
+		out := map[string]interface{}{"pool": pool, "used": len(pool.Allocations)}
+		if next := pool.Next(); next.IsValid() {
+			out["next"] = next.String()
+		}
+		if err := internal.Output(out); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+var ipamReconcileCmd = &cobra.Command{
+	Use:   "reconcile",
+	Short: "Report allocations that are not configured and configured addresses that are not allocated",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		state, err := ipam.NewStore(ipamState).Load()
+		if err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		infos, err := bareos.DefaultManager().List()
+		if err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		findings := ipam.Reconcile(state, infos)
+		if err := internal.Output(map[string]interface{}{"findings": findings, "count": len(findings)}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+// allocateFromPool allocates an address for owner and returns it with the
+// prefix length of the pool. fresh is false when owner already held the
+// address, so callers only release what they allocated.
+func allocateFromPool(pool, owner, iface string) (prefix netip.Prefix, fresh bool, err error) {
+	err = ipam.NewStore(ipamState).Update(func(s *ipam.State) error {
+		p := s.Pool(pool)
+		if p == nil {
+			return fmt.Errorf("pool %s not found", pool)
+		}
+		fresh = p.Find(owner) == nil
+		a, err := s.Allocate(pool, owner, iface)
+		if err != nil {
+			return err
+		}
+		poolPrefix, err := p.Prefix()
+		if err != nil {
+			return err
+		}
+		prefix = netip.PrefixFrom(netip.MustParseAddr(a.Address), poolPrefix.Bits())
+		return nil
+	})
+	return prefix, fresh, err
+}
+
+// releaseToPool releases the allocation of owner after a failed configuration step.
+func releaseToPool(pool, owner string) {
+	_ = ipam.NewStore(ipamState).Update(func(s *ipam.State) error {
+		_, err := s.Release(pool, owner, "")
+		return err
+	})
+}
+
+// addIPAMStateFlag lets commands outside ipam that allocate from a pool
+// use the same state file as the ipam commands.
+func addIPAMStateFlag(c *cobra.Command) {
+	c.Flags().StringVar(&ipamState, "ipam-state", ipam.DefaultStateFile, "IPAM state file used with the pool")
+}
+
+func init() { //nolint
+	ipamCmd.PersistentFlags().StringVar(&ipamState, "state", ipam.DefaultStateFile, "IPAM state file")
+
+	ipamPoolCreateCmd.Flags().StringVar(&ipamPool, "name", "", "Pool name (required)")
+	ipamPoolCreateCmd.Flags().StringVar(&ipamCIDR, "cidr", "", "Pool network, e.g. 10.0.10.0/24 (required)")
+	ipamPoolCreateCmd.Flags().StringVar(&ipamGateway, "gateway", "", "Gateway address, never allocated")
+	ipamPoolCreateCmd.Flags().StringSliceVar(&ipamExclude, "exclude", nil, "Excluded addresses or first-last ranges")
+	ipamPoolCreateCmd.Flags().StringSliceVar(&ipamReserve, "reserve", nil, "Reservations as owner=address")
+	_ = ipamPoolCreateCmd.MarkFlagRequired("name")
+	_ = ipamPoolCreateCmd.MarkFlagRequired("cidr")
+
+	ipamPoolDeleteCmd.Flags().StringVar(&ipamPool, "name", "", "Pool name (required)")
+	_ = ipamPoolDeleteCmd.MarkFlagRequired("name")
+
+	ipamAllocateCmd.Flags().StringVar(&ipamPool, "pool", "", "Pool name (required)")
+	ipamAllocateCmd.Flags().StringVar(&ipamOwner, "owner", "", "Owner, e.g. a jail name (required)")
+	ipamAllocateCmd.Flags().StringVar(&ipamIface, "iface", "", "Interface the address is meant for")
+	_ = ipamAllocateCmd.MarkFlagRequired("pool")
+	_ = ipamAllocateCmd.MarkFlagRequired("owner")
+
+	ipamReleaseCmd.Flags().StringVar(&ipamPool, "pool", "", "Pool name (required)")
+	ipamReleaseCmd.Flags().StringVar(&ipamOwner, "owner", "", "Owner whose address is released")
+	ipamReleaseCmd.Flags().StringVar(&ipamReleased, "ip", "", "Address to release")
+	_ = ipamReleaseCmd.MarkFlagRequired("pool")
+
+	ipamShowCmd.Flags().StringVar(&ipamPool, "pool", "", "Pool name (required)")
+	_ = ipamShowCmd.MarkFlagRequired("pool")
+
+	ipamPoolCmd.AddCommand(ipamPoolCreateCmd)
+	ipamPoolCmd.AddCommand(ipamPoolListCmd)
+	ipamPoolCmd.AddCommand(ipamPoolDeleteCmd)
+	ipamCmd.AddCommand(ipamPoolCmd)
+	ipamCmd.AddCommand(ipamAllocateCmd)
+	ipamCmd.AddCommand(ipamReleaseCmd)
+	ipamCmd.AddCommand(ipamShowCmd)
+	ipamCmd.AddCommand(ipamReconcileCmd)
+	cmd.AddCommand(ipamCmd)
+}
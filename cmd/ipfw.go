@@ -0,0 +1,395 @@
+package cmd
+
+import (
+	"FreeBSD-Command-manager/internal/firewall/ipfw"
+	pkgipfw "FreeBSD-Command-manager/pkg/ipfw"
+	pkgpf "FreeBSD-Command-manager/pkg/pf"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	fwID      int
+	fwIPFWNAT pkgipfw.NAT
+	fwPipe    pkgipfw.Pipe
+	fwQueue   pkgipfw.Queue
+)
+
+// ipfwRuleFromFlags converts the rule add flags into an ipfw rule. pass
+// and block map to allow and deny, block --return to reset.
+func ipfwRuleFromFlags() (pkgipfw.Rule, error) {
+	r := pkgipfw.Rule{
+		Action:    fwRule.Action,
+		Target:    fwNAT.Target,
+		Log:       fwRule.Log,
+		Proto:     fwRule.Proto,
+		From:      fwRule.From,
+		FromPort:  fwRule.FromPort,
+		To:        fwRule.To,
+		ToPort:    fwRule.ToPort,
+		Direction: fwRule.Direction,
+		Interface: fwRule.Interface,
+		Options:   fwOptions,
+		Comment:   fwRule.Label,
+	}
+	switch r.Action {
+	case pkgpf.ActionPass:
+		r.Action = pkgipfw.ActionAllow
+	case pkgpf.ActionBlock:
+		r.Action = pkgipfw.ActionDeny
+		if fwReturn {
+			r.Action = pkgipfw.ActionReset
+		}
+	case pkgpf.TypeRDR:
+		return r, fmt.Errorf("ipfw redirects ports with its nat instance: use firewall nat add --redirect-port")
+	}
+	switch fwRule.Family {
+	case "":
+	case "inet", "inet6":
+		family := map[string]string{"inet": "ip4", "inet6": "ip6"}[fwRule.Family]
+		r.Options = strings.TrimSpace(family + " " + r.Options)
+	default:
+		return r, fmt.Errorf("invalid address family %q: must be inet or inet6", fwRule.Family)
+	}
+	switch fwRule.State {
+	case "":
+	case "keep":
+		r.KeepState = true
+	default:
+		return r, fmt.Errorf("ipfw only supports --keep-state keep")
+	}
+	switch {
+	case fwRule.Flags != "":
+		return r, fmt.Errorf("--flags only applies to pf, use --options, e.g. setup")
+	case fwNAT.TargetPort != "" || fwNAT.Pass:
+		return r, fmt.Errorf("--target-port and --pass only apply to pf")
+	case fwNAT.StaticPort:
+		return r, fmt.Errorf("--static-port only applies to pf, use firewall nat add --same-ports")
+	}
+	return r, r.Validate()
+}
+
+// ipfwTableAdd adds the --addr entries to the configured and loaded table
+func ipfwTableAdd(m ipfw.ManagerInterface, s *ipfw.Store) error {
+	return s.Update(func(st *ipfw.State) error {
+		rs := &st.Ruleset
+		t := rs.Table(fwTable)
+		if t == nil {
+			rs.Tables = append(rs.Tables, pkgipfw.Table{Name: fwTable})
+			t = &rs.Tables[len(rs.Tables)-1]
+		}
+		var added []pkgipfw.TableEntry
+		for _, a := range fwAddrs {
+			exists := slices.ContainsFunc(t.Entries, func(e pkgipfw.TableEntry) bool {
+				return pkgipfw.CanonicalAddress(e.Address) == pkgipfw.CanonicalAddress(a)
+			})
+			if !exists {
+				added = append(added, pkgipfw.TableEntry{Address: a})
+			}
+		}
+		t.Entries = append(t.Entries, added...)
+		if err := t.Validate(); err != nil {
+			return err
+		}
+		if len(added) == 0 {
+			return nil
+		}
+		if !slices.Contains(st.Owned.Tables, fwTable) {
+			loaded, err := m.Tables()
+			if err != nil {
+				return err
+			}
+			if slices.ContainsFunc(loaded, func(t pkgipfw.Table) bool { return t.Name == fwTable }) {
+				return fmt.Errorf("table %s exists and was not created by fcom", fwTable)
+			}
+			st.Owned.Tables = ipfw.Own(st.Owned.Tables, fwTable)
+		}
+		return m.AddTableEntries(fwTable, added)
+	})
+}
+
+// ipfwTableDel removes the --addr entries, or the whole table, from the
+// configuration and the loaded table
+func ipfwTableDel(m ipfw.ManagerInterface, s *ipfw.Store) error {
+	return s.Update(func(st *ipfw.State) error {
+		rs := &st.Ruleset
+		t := rs.Table(fwTable)
+		if t == nil {
+			return fmt.Errorf("table %s not found", fwTable)
+		}
+		// a table that was never applied is only removed from the configuration
+		tables, err := m.Tables()
+		if err != nil {
+			return err
+		}
+		loaded := slices.Contains(st.Owned.Tables, fwTable) &&
+			slices.ContainsFunc(tables, func(t pkgipfw.Table) bool { return t.Name == fwTable })
+		if len(fwAddrs) == 0 {
+			rs.Tables = slices.DeleteFunc(rs.Tables, func(t pkgipfw.Table) bool { return t.Name == fwTable })
+			st.Owned.Tables = ipfw.Disown(st.Owned.Tables, fwTable)
+			if !loaded {
+				return nil
+			}
+			return m.DestroyTable(fwTable)
+		}
+		t.Entries = slices.DeleteFunc(t.Entries, func(e pkgipfw.TableEntry) bool {
+			return slices.ContainsFunc(fwAddrs, func(a string) bool {
+				return pkgipfw.CanonicalAddress(e.Address) == pkgipfw.CanonicalAddress(a)
+			})
+		})
+		if !loaded {
+			return nil
+		}
+		return m.DeleteTableEntries(fwTable, fwAddrs)
+	})
+}
+
+// ipfwTableShow returns the configured and loaded tables, or only the one
+// named by --table
+func ipfwTableShow(m ipfw.ManagerInterface, s *ipfw.Store) (configured, loaded []pkgipfw.Table, err error) {
+	state, err := s.Load()
+	if err != nil {
+		return nil, nil, err
+	}
+	tables, err := m.Tables()
+	if err != nil {
+		return nil, nil, err
+	}
+	configured, loaded = state.Ruleset.Tables, tables
+	if fwTable != "" {
+		other := func(t pkgipfw.Table) bool { return t.Name != fwTable }
+		configured = slices.DeleteFunc(configured, other)
+		loaded = slices.DeleteFunc(loaded, other)
+	}
+	return configured, loaded, nil
+}
+
+var firewallNATCmd = &cobra.Command{
+	Use:   "nat",
+	Short: "Manage ipfw NAT instances, used by rules with --action nat --target <id> (add, del, list)",
+}
+
+var firewallNATAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Create or reconfigure a NAT instance",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		n := fwIPFWNAT
+		n.ID = fwID
+		firewallRun(nil, func(m ipfw.ManagerInterface, s *ipfw.Store) error {
+			return s.Update(func(st *ipfw.State) error {
+				rs := &st.Ruleset
+				if err := m.CheckForeign(&pkgipfw.Ruleset{NATs: []pkgipfw.NAT{n}}, &st.Owned); err != nil {
+					return err
+				}
+				rs.NATs = replaceByID(rs.NATs, n, func(x pkgipfw.NAT) int { return x.ID })
+				st.Owned.NATs = ipfw.Own(st.Owned.NATs, n.ID)
+				return m.ConfigureNAT(n)
+			})
+		}, map[string]interface{}{"nat": n, "status": "configured"})
+	},
+}
+
+var firewallNATDelCmd = &cobra.Command{
+	Use:   "del",
+	Short: "Delete a NAT instance",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		firewallRun(nil, func(m ipfw.ManagerInterface, s *ipfw.Store) error {
+			return s.Update(func(st *ipfw.State) error {
+				var err error
+				if st.Ruleset.NATs, err = deleteByID(st.Ruleset.NATs, "nat", fwID, func(x pkgipfw.NAT) int { return x.ID }); err != nil {
+					return err
+				}
+				st.Owned.NATs = ipfw.Disown(st.Owned.NATs, fwID)
+				return m.DeleteNAT(fwID)
+			})
+		}, map[string]interface{}{"id": fwID, "status": "deleted"})
+	},
+}
+
+var firewallNATListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the configured NAT instances",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		status := make(map[string]interface{})
+		firewallRun(nil, func(_ ipfw.ManagerInterface, s *ipfw.Store) error {
+			state, err := s.Load()
+			if err == nil {
+				status["nat"] = state.Ruleset.NATs
+			}
+			return err
+		}, status)
+	},
+}
+
+var firewallPipeCmd = &cobra.Command{
+	Use:   "pipe",
+	Short: "Manage dummynet pipes for bandwidth shaping, used by rules with --action pipe --target <id> (add, del, list)",
+}
+
+var firewallPipeAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Create or reconfigure a pipe",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		p := fwPipe
+		p.ID = fwID
+		firewallRun(nil, func(m ipfw.ManagerInterface, s *ipfw.Store) error {
+			return s.Update(func(st *ipfw.State) error {
+				rs := &st.Ruleset
+				if err := m.CheckForeign(&pkgipfw.Ruleset{Pipes: []pkgipfw.Pipe{p}}, &st.Owned); err != nil {
+					return err
+				}
+				rs.Pipes = replaceByID(rs.Pipes, p, func(x pkgipfw.Pipe) int { return x.ID })
+				st.Owned.Pipes = ipfw.Own(st.Owned.Pipes, p.ID)
+				return m.ConfigurePipe(p)
+			})
+		}, map[string]interface{}{"pipe": p, "status": "configured"})
+	},
+}
+
+var firewallPipeDelCmd = &cobra.Command{
+	Use:   "del",
+	Short: "Delete a pipe",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		firewallRun(nil, func(m ipfw.ManagerInterface, s *ipfw.Store) error {
+			return s.Update(func(st *ipfw.State) error {
+				rs := &st.Ruleset
+				for _, q := range rs.Queues {
+					if q.Pipe == fwID {
+						return fmt.Errorf("pipe %d is used by queue %d", fwID, q.ID)
+					}
+				}
+				var err error
+				if rs.Pipes, err = deleteByID(rs.Pipes, "pipe", fwID, func(x pkgipfw.Pipe) int { return x.ID }); err != nil {
+					return err
+				}
+				st.Owned.Pipes = ipfw.Disown(st.Owned.Pipes, fwID)
+				return m.DeletePipe(fwID)
+			})
+		}, map[string]interface{}{"id": fwID, "status": "deleted"})
+	},
+}
+
+var firewallPipeListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the configured pipes and queues",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		status := make(map[string]interface{})
+		firewallRun(nil, func(_ ipfw.ManagerInterface, s *ipfw.Store) error {
+			state, err := s.Load()
+			if err == nil {
+				status["pipes"], status["queues"] = state.Ruleset.Pipes, state.Ruleset.Queues
+			}
+			return err
+		}, status)
+	},
+}
+
+var firewallQueueCmd = &cobra.Command{
+	Use:   "queue",
+	Short: "Manage dummynet queues sharing a pipe by weight, used by rules with --action queue --target <id> (add, del)",
+}
+
+var firewallQueueAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Create or reconfigure a queue",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		q := fwQueue
+		q.ID = fwID
+		firewallRun(nil, func(m ipfw.ManagerInterface, s *ipfw.Store) error {
+			return s.Update(func(st *ipfw.State) error {
+				rs := &st.Ruleset
+				if !slices.ContainsFunc(rs.Pipes, func(p pkgipfw.Pipe) bool { return p.ID == q.Pipe }) {
+					return fmt.Errorf("pipe %d not found", q.Pipe)
+				}
+				if err := m.CheckForeign(&pkgipfw.Ruleset{Queues: []pkgipfw.Queue{q}}, &st.Owned); err != nil {
+					return err
+				}
+				rs.Queues = replaceByID(rs.Queues, q, func(x pkgipfw.Queue) int { return x.ID })
+				st.Owned.Queues = ipfw.Own(st.Owned.Queues, q.ID)
+				return m.ConfigureQueue(q)
+			})
+		}, map[string]interface{}{"queue": q, "status": "configured"})
+	},
+}
+
+var firewallQueueDelCmd = &cobra.Command{
+	Use:   "del",
+	Short: "Delete a queue",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		firewallRun(nil, func(m ipfw.ManagerInterface, s *ipfw.Store) error {
+			return s.Update(func(st *ipfw.State) error {
+				var err error
+				if st.Ruleset.Queues, err = deleteByID(st.Ruleset.Queues, "queue", fwID, func(x pkgipfw.Queue) int { return x.ID }); err != nil {
+					return err
+				}
+				st.Owned.Queues = ipfw.Disown(st.Owned.Queues, fwID)
+				return m.DeleteQueue(fwID)
+			})
+		}, map[string]interface{}{"id": fwID, "status": "deleted"})
+	},
+}
+
+// replaceByID replaces the element with the id of v, or appends v
+func replaceByID[T any](s []T, v T, id func(T) int) []T {
+	for i := range s {
+		if id(s[i]) == id(v) {
+			s[i] = v
+			return s
+		}
+	}
+	return append(s, v)
+}
+
+// deleteByID removes the element numbered n, a kind such as pipe
+func deleteByID[T any](s []T, kind string, n int, id func(T) int) ([]T, error) {
+	for i := range s {
+		if id(s[i]) == n {
+			return append(s[:i], s[i+1:]...), nil
+		}
+	}
+	return s, fmt.Errorf("%s %d not found", kind, n)
+}
+
+func init() { //nolint
+	for _, c := range []*cobra.Command{
+		firewallNATAddCmd, firewallNATDelCmd, firewallPipeAddCmd, firewallPipeDelCmd, firewallQueueAddCmd, firewallQueueDelCmd,
+	} {
+		c.Flags().IntVar(&fwID, "id", 0, "Number, 1 to 65535 (required)")
+		_ = c.MarkFlagRequired("id")
+	}
+
+	f := firewallNATAddCmd.Flags()
+	f.StringVar(&fwIPFWNAT.Interface, "iface", "", "Translate to the address of this interface")
+	f.StringVar(&fwIPFWNAT.Address, "ip", "", "Translate to this IPv4 address instead")
+	f.BoolVar(&fwIPFWNAT.SamePorts, "same-ports", false, "Keep the source ports where possible")
+	f.BoolVar(&fwIPFWNAT.UnregOnly, "unreg-only", false, "Only translate private (RFC 1918) sources")
+	f.BoolVar(&fwIPFWNAT.Reset, "reset", false, "Reset the translation table when the interface address changes")
+	f.StringArrayVar(&fwIPFWNAT.RedirectPorts, "redirect-port", nil, "Port forward, e.g. \"tcp 10.0.0.5:80 8080\" (repeatable)")
+
+	f = firewallPipeAddCmd.Flags()
+	f.StringVar(&fwPipe.Bandwidth, "bw", "", "Bandwidth, e.g. 10Mbit/s (default: unlimited)")
+	f.IntVar(&fwPipe.Delay, "delay", 0, "Delay in milliseconds")
+	f.StringVar(&fwPipe.QueueSize, "queue-size", "", "Queue size in slots or KBytes, e.g. 50 or 100KBytes")
+	f.StringVar(&fwPipe.Mask, "mask", "", "Flow mask giving each flow its own pipe, e.g. \"src-ip 0xffffffff\"")
+
+	f = firewallQueueAddCmd.Flags()
+	f.IntVar(&fwQueue.Pipe, "pipe", 0, "Pipe the queue shares (required)")
+	f.IntVar(&fwQueue.Weight, "weight", 0, "Weight, 1 to 100")
+	f.StringVar(&fwQueue.Mask, "mask", "", "Flow mask, e.g. \"dst-ip 0xffffffff\"")
+	_ = firewallQueueAddCmd.MarkFlagRequired("pipe")
+
+	firewallNATCmd.AddCommand(firewallNATAddCmd)
+	firewallNATCmd.AddCommand(firewallNATDelCmd)
+	firewallNATCmd.AddCommand(firewallNATListCmd)
+	firewallPipeCmd.AddCommand(firewallPipeAddCmd)
+	firewallPipeCmd.AddCommand(firewallPipeDelCmd)
+	firewallPipeCmd.AddCommand(firewallPipeListCmd)
+	firewallQueueCmd.AddCommand(firewallQueueAddCmd)
+	firewallQueueCmd.AddCommand(firewallQueueDelCmd)
+	firewallCmd.AddCommand(firewallNATCmd)
+	firewallCmd.AddCommand(firewallPipeCmd)
+	firewallCmd.AddCommand(firewallQueueCmd)
+}
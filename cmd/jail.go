@@ -4,12 +4,14 @@ import (
 	"FreeBSD-Command-manager/internal"
 	"FreeBSD-Command-manager/internal/jail"
 	"fmt"
+	"net/netip"
 	"os"
 
 	"github.com/spf13/cobra"
 )
 
-var jailName, jailPath, jailIP, jailMount string
+var jailName, jailPath, jailIP, jailMount, jailIPPool string
+var jailFIB int
 
 var jailCmd = &cobra.Command{
 	Use:   "jail",
@@ -22,15 +24,34 @@ var jailCreateCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
 		manager := jail.DefaultManager()
 
+		fresh := false
+		if jailIPPool != "" {
+			prefix, allocated, err := allocateJailIP()
+			if err != nil {
+				if e := internal.Output(map[string]interface{}{
+					"error": err.Error(),
+				}); e != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				return
+			}
+			jailIP, fresh = prefix.Addr().String(), allocated
+		}
+
 		cfg := jail.Config{
 			Name:  jailName,
 			Path:  jailPath,
 			IP:    jailIP,
 			Mount: jailMount,
+			FIB:   jailFIB,
 		}
 
 		err := manager.Create(cfg)
 		if err != nil {
+			if fresh {
+				releaseToPool(jailIPPool, jailName)
+			}
 			if e := internal.Output(map[string]interface{}{
 				"error": err.Error(),
 			}); e != nil {
@@ -182,12 +203,31 @@ var jailInfoCmd = &cobra.Command{
 	},
 }
 
+// allocateJailIP allocates the jail address from --ip-pool. Jails are
+// created with ip4.addr, so the pool has to be an IPv4 pool.
+func allocateJailIP() (netip.Prefix, bool, error) {
+	prefix, fresh, err := allocateFromPool(jailIPPool, jailName, "")
+	if err != nil {
+		return netip.Prefix{}, false, err
+	}
+	if !prefix.Addr().Is4() {
+		if fresh {
+			releaseToPool(jailIPPool, jailName)
+		}
+		return netip.Prefix{}, false, fmt.Errorf("pool %s is not an IPv4 pool", jailIPPool)
+	}
+	return prefix, fresh, nil
+}
+
 func init() { //nolint
 	// Create command flags
 	jailCreateCmd.Flags().StringVar(&jailName, "name", "", "Jail name (required)")
 	jailCreateCmd.Flags().StringVar(&jailPath, "path", "", "Jail path (required)")
-	jailCreateCmd.Flags().StringVar(&jailIP, "ip", "", "Jail IP address (required)")
+	jailCreateCmd.Flags().StringVar(&jailIP, "ip", "", "Jail IP address (required unless --ip-pool is given)")
+	jailCreateCmd.Flags().StringVar(&jailIPPool, "ip-pool", "", "IPAM pool to allocate the jail IP address from")
+	addIPAMStateFlag(jailCreateCmd)
 	jailCreateCmd.Flags().StringVar(&jailMount, "mount", "", "ZFS dataset or image to mount (optional)")
+	jailCreateCmd.Flags().IntVar(&jailFIB, "fib", 0, "FIB used by processes in the jail (exec.fib)")
 	// check required params
 	if err := jailCreateCmd.MarkFlagRequired("name"); err != nil {
 		fmt.Fprintln(os.Stderr, err)
@@ -197,10 +237,8 @@ func init() { //nolint
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
-	if err := jailCreateCmd.MarkFlagRequired("ip"); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
-	}
+	jailCreateCmd.MarkFlagsOneRequired("ip", "ip-pool")
+	jailCreateCmd.MarkFlagsMutuallyExclusive("ip", "ip-pool")
 
 	// Start command flags
 	jailStartCmd.Flags().StringVar(&jailName, "name", "", "Jail name (required)")
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"FreeBSD-Command-manager/internal"
+	"FreeBSD-Command-manager/internal/network/backend"
+	"FreeBSD-Command-manager/internal/network/bareos"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	laggName  string
+	laggProto string
+	laggPorts []string
+	laggHash  []string
+	laggPort  string
+)
+
+var laggCmd = &cobra.Command{
+	Use:   "lagg",
+	Short: "Manage link aggregation interfaces (create, add-port, remove-port, delete)",
+}
+
+var laggCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a lagg interface",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if !requireBackend(backend.OpLAGG) {
+			return
+		}
+		manager := bareos.DefaultManager()
+		name, err := manager.CreateLAGG(laggName, laggProto, laggPorts)
+		if err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		if len(laggHash) > 0 {
+			if err := manager.SetLAGGHash(name, laggHash); err != nil {
+				if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				return
+			}
+		}
+		if err := outputChanged(map[string]interface{}{"lagg": name, "proto": laggProto, "ports": laggPorts, "lagghash": laggHash, "status": "created"}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+var laggAddPortCmd = &cobra.Command{
+	Use:   "add-port",
+	Short: "Add a port to a lagg interface",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if !requireBackend(backend.OpLAGG) {
+			return
+		}
+		manager := bareos.DefaultManager()
+		if err := manager.AddLAGGPort(laggName, laggPort); err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		if err := outputChanged(map[string]interface{}{"lagg": laggName, "port": laggPort, "status": "added"}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+var laggRemovePortCmd = &cobra.Command{
+	Use:   "remove-port",
+	Short: "Remove a port from a lagg interface",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if !requireBackend(backend.OpLAGG) {
+			return
+		}
+		manager := bareos.DefaultManager()
+		if err := manager.RemoveLAGGPort(laggName, laggPort); err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		if err := outputChanged(map[string]interface{}{"lagg": laggName, "port": laggPort, "status": "removed"}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+var laggDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete a lagg interface",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if !requireBackend(backend.OpLAGG) {
+			return
+		}
+		manager := bareos.DefaultManager()
+		if err := manager.DeleteLAGG(laggName); err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		if err := outputChanged(map[string]interface{}{"lagg": laggName, "status": "deleted"}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() { //nolint
+	laggCreateCmd.Flags().StringVar(&laggName, "name", "", "Lagg interface name")
+	laggCreateCmd.Flags().StringVar(&laggProto, "proto", bareos.LAGGLACP, "Protocol: failover, lacp, loadbalance or roundrobin")
+	laggCreateCmd.Flags().StringSliceVar(&laggPorts, "ports", nil, "Member ports, comma separated (required)")
+	laggCreateCmd.Flags().StringSliceVar(&laggHash, "lagghash", nil, "Hash layers for lacp/loadbalance: l2,l3,l4")
+	_ = laggCreateCmd.MarkFlagRequired("ports")
+
+	laggAddPortCmd.Flags().StringVar(&laggName, "name", "", "Lagg interface name (required)")
+	laggAddPortCmd.Flags().StringVar(&laggPort, "port", "", "Port to add (required)")
+	_ = laggAddPortCmd.MarkFlagRequired("name")
+	_ = laggAddPortCmd.MarkFlagRequired("port")
+
+	laggRemovePortCmd.Flags().StringVar(&laggName, "name", "", "Lagg interface name (required)")
+	laggRemovePortCmd.Flags().StringVar(&laggPort, "port", "", "Port to remove (required)")
+	_ = laggRemovePortCmd.MarkFlagRequired("name")
+	_ = laggRemovePortCmd.MarkFlagRequired("port")
+
+	laggDeleteCmd.Flags().StringVar(&laggName, "name", "", "Lagg interface name (required)")
+	_ = laggDeleteCmd.MarkFlagRequired("name")
+
+	laggCmd.AddCommand(laggCreateCmd)
+	laggCmd.AddCommand(laggAddPortCmd)
+	laggCmd.AddCommand(laggRemovePortCmd)
+	laggCmd.AddCommand(laggDeleteCmd)
+}
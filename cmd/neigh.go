@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"FreeBSD-Command-manager/internal"
+	"FreeBSD-Command-manager/internal/network/backend"
+	"FreeBSD-Command-manager/internal/network/bareos"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	neighIface   string
+	neighFamily  string
+	neighIP      string
+	neighMAC     string
+	neighStatic  bool
+	neighPublish bool
+)
+
+var neighCmd = &cobra.Command{
+	Use:   "neigh",
+	Short: "Manage the ARP and NDP neighbor tables (list, add, del, flush)",
+}
+
+var neighListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List ARP and NDP neighbor entries",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if !requireBackend(backend.OpNeighbors) {
+			return
+		}
+		manager := bareos.DefaultManager()
+		entries, err := manager.ListNeighbors(neighIface, neighFamily)
+		if err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		if err := internal.Output(map[string]interface{}{"neighbors": entries, "count": len(entries)}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+var neighAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add a neighbor entry",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if !requireBackend(backend.OpNeighbors) {
+			return
+		}
+		manager := bareos.DefaultManager()
+		spec := bareos.NeighborSpec{Interface: neighIface, IP: neighIP, MAC: neighMAC, Static: neighStatic, Publish: neighPublish}
+		if err := manager.AddNeighbor(spec); err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		if err := internal.Output(map[string]interface{}{"ip": neighIP, "mac": neighMAC, "static": neighStatic, "published": neighPublish, "status": "added"}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+var neighDelCmd = &cobra.Command{
+	Use:   "del",
+	Short: "Delete a neighbor entry",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if !requireBackend(backend.OpNeighbors) {
+			return
+		}
+		manager := bareos.DefaultManager()
+		if err := manager.DeleteNeighbor(neighIface, neighIP); err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		if err := internal.Output(map[string]interface{}{"ip": neighIP, "status": "deleted"}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+var neighFlushCmd = &cobra.Command{
+	Use:   "flush",
+	Short: "Delete all neighbor entries, optionally of one interface or family",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if !requireBackend(backend.OpNeighbors) {
+			return
+		}
+		manager := bareos.DefaultManager()
+		if err := manager.FlushNeighbors(neighIface, neighFamily); err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		if err := internal.Output(map[string]interface{}{"interface": neighIface, "family": neighFamily, "status": "flushed"}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() { //nolint
+	for _, c := range []*cobra.Command{neighListCmd, neighFlushCmd} {
+		c.Flags().StringVar(&neighIface, "iface", "", "Interface name (default: all interfaces)")
+		c.Flags().StringVar(&neighFamily, "family", "", "Address family (inet, inet6, or empty for both)")
+	}
+
+	neighAddCmd.Flags().StringVar(&neighIP, "ip", "", "IP address (required)")
+	neighAddCmd.Flags().StringVar(&neighMAC, "mac", "", "MAC address (required)")
+	neighAddCmd.Flags().StringVar(&neighIface, "iface", "", "Interface, required for IPv6 link-local addresses")
+	neighAddCmd.Flags().BoolVar(&neighStatic, "static", false, "Add a permanent entry instead of one that expires")
+	neighAddCmd.Flags().BoolVar(&neighPublish, "publish", false, "Answer requests for the address (proxy ARP/NDP)")
+	_ = neighAddCmd.MarkFlagRequired("ip")
+	_ = neighAddCmd.MarkFlagRequired("mac")
+
+	neighDelCmd.Flags().StringVar(&neighIP, "ip", "", "IP address (required)")
+	neighDelCmd.Flags().StringVar(&neighIface, "iface", "", "Interface, required for IPv6 link-local addresses")
+	_ = neighDelCmd.MarkFlagRequired("ip")
+
+	neighCmd.AddCommand(neighListCmd)
+	neighCmd.AddCommand(neighAddCmd)
+	neighCmd.AddCommand(neighDelCmd)
+	neighCmd.AddCommand(neighFlushCmd)
+	networkCmd.AddCommand(neighCmd)
+}
@@ -2,19 +2,16 @@ package cmd
 
 import (
 	"FreeBSD-Command-manager/internal"
+	"FreeBSD-Command-manager/internal/network/backend"
 	"FreeBSD-Command-manager/internal/network/bareos"
+	"FreeBSD-Command-manager/pkg/ifconfig"
 	"fmt"
 	"os"
+	"strconv"
 
 	"github.com/spf13/cobra"
 )
 
-// IPs holds lists of IPv4 and IPv6 addresses for output.
-type IPs struct {
-	IPv4 []string
-	IPv6 []string
-}
-
 var (
 	ifName       string
 	delIfaceName string
@@ -38,8 +35,16 @@ var (
 )
 
 var (
-	greName, greRemote, greLocal string
-	delGreName                   string
+	greName, greRemote, greLocal  string
+	greInnerLocal, greInnerRemote string
+	greKey                        uint32
+	delGreName                    string
+)
+
+var (
+	gifName, gifRemote, gifLocal  string
+	gifInnerLocal, gifInnerRemote string
+	delGifName                    string
 )
 
 var (
@@ -49,10 +54,14 @@ var (
 )
 
 var (
-	ipIface  string
-	ipAddr   string
-	ipMask   int
-	ipFamily string
+	setName         string
+	setMTU          int
+	setDescription  string
+	setUp, setDown  bool
+	setEther        string
+	setFIB          int
+	setGroup        string
+	setCapabilities []string
 )
 
 var networkCmd = &cobra.Command{
@@ -65,18 +74,8 @@ var ifaceCmd = &cobra.Command{
 	Use:   "iface",
 	Short: "Create a generic interface",
 	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
-		manager := bareos.DefaultManager()
-		if err := manager.CreateInterface(ifName); err != nil {
-			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
-				fmt.Fprintln(os.Stderr, err)
-				os.Exit(1)
-			}
-			return
-		}
-		if err := internal.Output(map[string]interface{}{"interface": ifName, "status": "created"}); err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
-		}
+		backendChange(func(b backend.Backend) error { return b.CreateInterface(ifName) },
+			map[string]interface{}{"interface": ifName, "status": "created"})
 	},
 }
 
@@ -84,18 +83,8 @@ var delIfaceCmd = &cobra.Command{
 	Use:   "delete-iface",
 	Short: "Delete a network interface",
 	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
-		manager := bareos.DefaultManager()
-		if err := manager.DeleteInterface(delIfaceName); err != nil {
-			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
-				fmt.Fprintln(os.Stderr, err)
-				os.Exit(1)
-			}
-			return
-		}
-		if err := internal.Output(map[string]interface{}{"interface": delIfaceName, "status": "deleted"}); err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
-		}
+		backendChange(func(b backend.Backend) error { return b.DeleteInterface(delIfaceName) },
+			map[string]interface{}{"interface": delIfaceName, "status": "deleted"})
 	},
 }
 
@@ -104,18 +93,8 @@ var bridgeCmd = &cobra.Command{
 	Use:   "bridge",
 	Short: "Create a bridge interface",
 	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
-		manager := bareos.DefaultManager()
-		if err := manager.CreateBridge(bridgeName); err != nil {
-			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
-				fmt.Fprintln(os.Stderr, err)
-				os.Exit(1)
-			}
-			return
-		}
-		if err := internal.Output(map[string]interface{}{"bridge": bridgeName, "status": "created"}); err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
-		}
+		backendChange(func(b backend.Backend) error { return b.CreateBridge(bridgeName) },
+			map[string]interface{}{"bridge": bridgeName, "status": "created"})
 	},
 }
 
@@ -123,18 +102,8 @@ var delBridgeCmd = &cobra.Command{
 	Use:   "delete-bridge",
 	Short: "Delete a bridge interface",
 	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
-		manager := bareos.DefaultManager()
-		if err := manager.DeleteBridge(delBridgeName); err != nil {
-			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
-				fmt.Fprintln(os.Stderr, err)
-				os.Exit(1)
-			}
-			return
-		}
-		if err := internal.Output(map[string]interface{}{"bridge": delBridgeName, "status": "deleted"}); err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
-		}
+		backendChange(func(b backend.Backend) error { return b.DeleteBridge(delBridgeName) },
+			map[string]interface{}{"bridge": delBridgeName, "status": "deleted"})
 	},
 }
 
@@ -142,18 +111,9 @@ var addInterfaceToBridgeCmd = &cobra.Command{
 	Use:   "add-interface-to-bridge",
 	Short: "Add an interface to a bridge",
 	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
-		manager := bareos.DefaultManager()
-		if err := manager.AddInterfaceToBridge(bridgeInterfaceName, bridgeInterfaceToAdd); err != nil {
-			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
-				fmt.Fprintln(os.Stderr, err)
-				os.Exit(1)
-			}
-			return
-		}
-		if err := internal.Output(map[string]interface{}{"bridge": bridgeInterfaceName, "interface": bridgeInterfaceToAdd, "status": "added"}); err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
-		}
+		backendChange(func(b backend.Backend) error {
+			return b.AddInterfaceToBridge(bridgeInterfaceName, bridgeInterfaceToAdd)
+		}, map[string]interface{}{"bridge": bridgeInterfaceName, "interface": bridgeInterfaceToAdd, "status": "added"})
 	},
 }
 
@@ -161,18 +121,9 @@ var removeInterfaceFromBridgeCmd = &cobra.Command{
 	Use:   "remove-interface-from-bridge",
 	Short: "Remove an interface from a bridge",
 	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
-		manager := bareos.DefaultManager()
-		if err := manager.RemoveInterfaceFromBridge(bridgeInterfaceName, bridgeInterfaceToRemove); err != nil {
-			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
-				fmt.Fprintln(os.Stderr, err)
-				os.Exit(1)
-			}
-			return
-		}
-		if err := internal.Output(map[string]interface{}{"bridge": bridgeInterfaceName, "interface": bridgeInterfaceToRemove, "status": "removed"}); err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
-		}
+		backendChange(func(b backend.Backend) error {
+			return b.RemoveInterfaceFromBridge(bridgeInterfaceName, bridgeInterfaceToRemove)
+		}, map[string]interface{}{"bridge": bridgeInterfaceName, "interface": bridgeInterfaceToRemove, "status": "removed"})
 	},
 }
 
@@ -181,6 +132,9 @@ var vlanCmd = &cobra.Command{
 	Use:   "vlan",
 	Short: "Create VLAN interface",
 	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if !requireBackend(backend.OpVLAN) {
+			return
+		}
 		manager := bareos.DefaultManager()
 		if err := manager.CreateVLAN(vlanName, vlanParent, vlanID); err != nil {
 			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
@@ -189,7 +143,7 @@ var vlanCmd = &cobra.Command{
 			}
 			return
 		}
-		if err := internal.Output(map[string]interface{}{"vlan": vlanName, "parent": vlanParent, "vlan_id": vlanID, "status": "created"}); err != nil {
+		if err := outputChanged(map[string]interface{}{"vlan": vlanName, "parent": vlanParent, "vlan_id": vlanID, "status": "created"}); err != nil {
 			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
@@ -200,6 +154,9 @@ var delVlanCmd = &cobra.Command{
 	Use:   "delete-vlan",
 	Short: "Delete a VLAN interface",
 	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if !requireBackend(backend.OpVLAN) {
+			return
+		}
 		manager := bareos.DefaultManager()
 		if err := manager.DeleteVLAN(delVlanName); err != nil {
 			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
@@ -208,7 +165,7 @@ var delVlanCmd = &cobra.Command{
 			}
 			return
 		}
-		if err := internal.Output(map[string]interface{}{"vlan": delVlanName, "status": "deleted"}); err != nil {
+		if err := outputChanged(map[string]interface{}{"vlan": delVlanName, "status": "deleted"}); err != nil {
 			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
@@ -220,15 +177,19 @@ var greCmd = &cobra.Command{
 	Use:   "gre",
 	Short: "Create a GRE tunnel interface",
 	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if !requireBackend(backend.OpTunnel) {
+			return
+		}
 		manager := bareos.DefaultManager()
-		if err := manager.CreateGRE(greName, greRemote, greLocal); err != nil {
+		opts := bareos.TunnelOptions{InnerLocal: greInnerLocal, InnerRemote: greInnerRemote, Key: greKey}
+		if err := manager.CreateGRE(greName, greRemote, greLocal, opts); err != nil {
 			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
 				fmt.Fprintln(os.Stderr, err)
 				os.Exit(1)
 			}
 			return
 		}
-		if err := internal.Output(map[string]interface{}{"gre": greName, "remote": greRemote, "local": greLocal, "status": "created"}); err != nil {
+		if err := outputChanged(map[string]interface{}{"gre": greName, "remote": greRemote, "local": greLocal, "inner_local": greInnerLocal, "inner_remote": greInnerRemote, "key": greKey, "status": "created"}); err != nil {
 			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
@@ -239,6 +200,9 @@ var delGreCmd = &cobra.Command{
 	Use:   "delete-gre",
 	Short: "Delete a GRE tunnel interface",
 	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if !requireBackend(backend.OpTunnel) {
+			return
+		}
 		manager := bareos.DefaultManager()
 		if err := manager.DeleteGRE(delGreName); err != nil {
 			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
@@ -247,211 +211,262 @@ var delGreCmd = &cobra.Command{
 			}
 			return
 		}
-		if err := internal.Output(map[string]interface{}{"gre": delGreName, "status": "deleted"}); err != nil {
+		if err := outputChanged(map[string]interface{}{"gre": delGreName, "status": "deleted"}); err != nil {
 			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 	},
 }
 
-// vxlan
-var vxlanCmd = &cobra.Command{
-	Use:   "vxlan",
-	Short: "Create a VXLAN tunnel interface",
+// gif
+var gifCmd = &cobra.Command{
+	Use:   "gif",
+	Short: "Create a gif tunnel interface (e.g. 6in4, IPIP)",
 	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if !requireBackend(backend.OpTunnel) {
+			return
+		}
 		manager := bareos.DefaultManager()
-		if err := manager.CreateVXLAN(vxlanName, vxlanLocal, vxlanRemote, vxlanGroup, vxlanDev, vxlanID); err != nil {
+		opts := bareos.TunnelOptions{InnerLocal: gifInnerLocal, InnerRemote: gifInnerRemote}
+		if err := manager.CreateGIF(gifName, gifRemote, gifLocal, opts); err != nil {
 			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
 				fmt.Fprintln(os.Stderr, err)
 				os.Exit(1)
 			}
 			return
 		}
-		if err := internal.Output(map[string]interface{}{"vxlan": vxlanName, "local": vxlanLocal, "remote": vxlanRemote, "group": vxlanGroup, "dev": vxlanDev, "vni": vxlanID, "status": "created"}); err != nil {
+		if err := outputChanged(map[string]interface{}{"gif": gifName, "remote": gifRemote, "local": gifLocal, "inner_local": gifInnerLocal, "inner_remote": gifInnerRemote, "status": "created"}); err != nil {
 			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 	},
 }
 
-var delVxlanCmd = &cobra.Command{
-	Use:   "delete-vxlan",
-	Short: "Delete a VXLAN tunnel interface",
+var delGifCmd = &cobra.Command{
+	Use:   "delete-gif",
+	Short: "Delete a gif tunnel interface",
 	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if !requireBackend(backend.OpTunnel) {
+			return
+		}
 		manager := bareos.DefaultManager()
-		if err := manager.DeleteVXLAN(delVxlanName); err != nil {
+		if err := manager.DeleteGIF(delGifName); err != nil {
 			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
 				fmt.Fprintln(os.Stderr, err)
 				os.Exit(1)
 			}
 			return
 		}
-		if err := internal.Output(map[string]interface{}{"vxlan": delVxlanName, "status": "deleted"}); err != nil {
+		if err := outputChanged(map[string]interface{}{"gif": delGifName, "status": "deleted"}); err != nil {
 			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 	},
 }
 
-var networkListCmd = &cobra.Command{
-	Use:   "list",
-	Short: "List all network interfaces",
+// vxlan
+var vxlanCmd = &cobra.Command{
+	Use:   "vxlan",
+	Short: "Create a VXLAN tunnel interface",
 	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if !requireBackend(backend.OpTunnel) {
+			return
+		}
 		manager := bareos.DefaultManager()
-		interfaces, err := manager.List()
-		if err != nil {
+		if err := manager.CreateVXLAN(vxlanName, vxlanLocal, vxlanRemote, vxlanGroup, vxlanDev, vxlanID); err != nil {
 			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
 				fmt.Fprintln(os.Stderr, err)
 				os.Exit(1)
 			}
 			return
 		}
-		if err := internal.Output(map[string]interface{}{"interfaces": interfaces, "count": len(interfaces)}); err != nil {
+		if err := outputChanged(map[string]interface{}{"vxlan": vxlanName, "local": vxlanLocal, "remote": vxlanRemote, "group": vxlanGroup, "dev": vxlanDev, "vni": vxlanID, "status": "created"}); err != nil {
 			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 	},
 }
 
-var networkInfoCmd = &cobra.Command{
-	Use:   "info",
-	Short: "Get information about a network interface",
+var delVxlanCmd = &cobra.Command{
+	Use:   "delete-vxlan",
+	Short: "Delete a VXLAN tunnel interface",
 	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if !requireBackend(backend.OpTunnel) {
+			return
+		}
 		manager := bareos.DefaultManager()
-		info, err := manager.GetInfo(ifName)
-		if err != nil {
+		if err := manager.DeleteVXLAN(delVxlanName); err != nil {
 			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
 				fmt.Fprintln(os.Stderr, err)
 				os.Exit(1)
 			}
 			return
 		}
-		if err := internal.Output(map[string]interface{}{"interface_info": info}); err != nil {
+		if err := outputChanged(map[string]interface{}{"vxlan": delVxlanName, "status": "deleted"}); err != nil {
 			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 	},
 }
 
-var ipCmd = &cobra.Command{
-	Use:   "ip",
-	Short: "Manage IP addresses on interfaces",
+var networkListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all network interfaces",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		var interfaces interface{}
+		count := 0
+		backendRun(func(b backend.Backend) error {
+			// The native backend keeps the full ifconfig view
+			if b.Name() == backend.Bareos {
+				infos, err := bareos.DefaultManager().List()
+				interfaces, count = infos, len(infos)
+				return err
+			}
+			ifaces, err := b.List()
+			interfaces, count = ifaces, len(ifaces)
+			return err
+		}, map[string]interface{}{"interfaces": &interfaces, "count": &count})
+	},
 }
 
-var ipAddCmd = &cobra.Command{
-	Use:   "add",
-	Short: "Add an IP address to an interface",
-	Run: func(cmd *cobra.Command, args []string) { //nolint:revive
-		err := bareos.AddIP(ipIface, ipAddr, ipMask, ipFamily)
-		if err != nil {
-			if e := internal.Output(map[string]any{"error": err.Error()}); e != nil {
-				fmt.Fprintln(os.Stderr, err)
-				os.Exit(1)
+var networkInfoCmd = &cobra.Command{
+	Use:   "info",
+	Short: "Get information about a network interface",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		var info interface{}
+		backendRun(func(b backend.Backend) (err error) {
+			if b.Name() == backend.Bareos {
+				info, err = nativeInfo(ifName)
+				return err
 			}
-			return
-		}
-		if err := internal.Output(map[string]any{
-			"interface": ipIface,
-			"ip":        ipAddr,
-			"mask":      ipMask,
-			"family":    ipFamily,
-			"status":    "added",
-		}); err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
-		}
+			ifaces, err := b.List()
+			if err != nil {
+				return err
+			}
+			for _, iface := range ifaces {
+				if iface.Name == ifName {
+					info = iface
+					return nil
+				}
+			}
+			return fmt.Errorf("interface %s not found", ifName)
+		}, map[string]interface{}{"interface_info": &info})
 	},
 }
 
-var ipAliasCmd = &cobra.Command{
-	Use:   "alias",
-	Short: "Add an alias IP address to an interface",
-	Run: func(cmd *cobra.Command, args []string) { //nolint:revive
-		err := bareos.AliasIP(ipIface, ipAddr, ipMask, ipFamily)
+var networkSetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Change attributes of an existing network interface",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if setUp && setDown {
+			if e := internal.Output(map[string]interface{}{"error": "--up and --down are mutually exclusive"}); e != nil {
+				fmt.Fprintln(os.Stderr, e)
+				os.Exit(1)
+			}
+			return
+		}
+		b, err := selectedBackend()
 		if err != nil {
-			if e := internal.Output(map[string]any{"error": err.Error()}); e != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
 				fmt.Fprintln(os.Stderr, err)
 				os.Exit(1)
 			}
 			return
 		}
-		if err := internal.Output(map[string]any{
-			"interface": ipIface,
-			"ip":        ipAddr,
-			"mask":      ipMask,
-			"family":    ipFamily,
-			"status":    "aliased",
-		}); err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
+		if b.Name() != backend.Bareos {
+			setWithBackend(cmd, b)
+			return
 		}
-	},
-}
-
-var ipDeleteCmd = &cobra.Command{
-	Use:   "delete",
-	Short: "Delete an IP address from an interface",
-	Run: func(cmd *cobra.Command, args []string) { //nolint:revive
-		err := bareos.DeleteIP(ipIface, ipAddr, ipMask, ipFamily)
+		attrs := bareos.InterfaceAttrs{
+			Ether:        setEther,
+			Group:        setGroup,
+			Capabilities: setCapabilities,
+		}
+		if cmd.Flags().Changed("mtu") {
+			attrs.MTU = &setMTU
+		}
+		if cmd.Flags().Changed("description") {
+			attrs.Description = &setDescription
+		}
+		if cmd.Flags().Changed("fib") {
+			attrs.FIB = &setFIB
+		}
+		if setUp || setDown {
+			attrs.Up = &setUp
+		}
+		manager := bareos.DefaultManager()
+		changes, err := manager.SetInterface(setName, attrs)
 		if err != nil {
-			if e := internal.Output(map[string]any{"error": err.Error()}); e != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error(), "changes": changes}); e != nil {
 				fmt.Fprintln(os.Stderr, err)
 				os.Exit(1)
 			}
 			return
 		}
-		if err := internal.Output(map[string]any{
-			"interface": ipIface,
-			"ip":        ipAddr,
-			"mask":      ipMask,
-			"family":    ipFamily,
-			"status":    "deleted",
-		}); err != nil {
+		if err := outputChanged(map[string]interface{}{"interface": setName, "changes": changes, "count": len(changes)}); err != nil {
 			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 	},
 }
 
-var ipListCmd = &cobra.Command{
-	Use:   "list",
-	Short: "List IP addresses on interfaces",
-	Run: func(cmd *cobra.Command, args []string) { //nolint:revive
-		// use ifconfig parser to list all IPs
-		manager := bareos.DefaultManager()
-		if ipIface != "" {
-			info, err := manager.GetInfo(ipIface)
-			if err != nil {
-				if e := internal.Output(map[string]any{"error": err.Error()}); e != nil {
-					fmt.Fprintln(os.Stderr, err)
-					os.Exit(1)
-				}
-				return
-			}
-			if err := internal.Output(map[string]any{"interface": ipIface, "ipv6": info.IPv6, "ipv4": info.IPv4, "count": len(info.IPv4) + len(info.IPv4)}); err != nil {
-				fmt.Fprintln(os.Stderr, err)
-				os.Exit(1)
+// nativeInfo returns the ifconfig view of an interface, with the address
+// cache of a bridge.
+func nativeInfo(name string) (*ifconfig.Info, error) {
+	manager := bareos.DefaultManager()
+	info, err := manager.GetInfo(name)
+	if err != nil {
+		return nil, err
+	}
+	if info.Bridge != nil {
+		addrs, err := manager.BridgeAddresses(name)
+		if err != nil {
+			return nil, err
+		}
+		info.Bridge.Addresses = addrs
+	}
+	return info, nil
+}
+
+// setWithBackend applies --mtu, --up and --down through a backend other than
+// the native one; the other attributes only exist natively.
+func setWithBackend(cmd *cobra.Command, b backend.Backend) {
+	var changes []bareos.AttrChange
+	err := func() error {
+		for _, flag := range []string{"description", "ether", "fib", "group", "capabilities"} {
+			if cmd.Flags().Changed(flag) {
+				return backend.Require(b, backend.OpSetAttributes)
 			}
-			return
 		}
-		var allIPs IPs
-		info, err := manager.List()
-		if err != nil {
-			if e := internal.Output(map[string]any{"error": err.Error()}); e != nil {
-				fmt.Fprintln(os.Stderr, err)
-				os.Exit(1)
+		if cmd.Flags().Changed("mtu") {
+			if err := b.SetMTU(setName, setMTU); err != nil {
+				return err
 			}
-			return
+			changes = append(changes, bareos.AttrChange{Attribute: "mtu", After: strconv.Itoa(setMTU)})
 		}
-		for _, ip := range info {
-			allIPs.IPv6 = append(allIPs.IPv6, ip.IPv6...) // assuming Info has IPv6 []string
-			allIPs.IPv4 = append(allIPs.IPv4, ip.IPv4...) // assuming Info has IPv4 []string
+		if setUp || setDown {
+			if err := b.SetState(setName, setUp); err != nil {
+				return err
+			}
+			state := "down"
+			if setUp {
+				state = "up"
+			}
+			changes = append(changes, bareos.AttrChange{Attribute: "state", After: state})
 		}
-		if err := internal.Output(map[string]any{"ipv6": allIPs.IPv6, "ipv4": allIPs.IPv4, "count": len(allIPs.IPv4) + len(allIPs.IPv6)}); err != nil {
+		return nil
+	}()
+	if err != nil {
+		if e := internal.Output(map[string]interface{}{"error": err.Error(), "changes": changes}); e != nil {
 			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
-	},
+		return
+	}
+	if err := outputChanged(map[string]interface{}{"interface": setName, "changes": changes, "count": len(changes)}); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 }
 
 func init() { //nolint
@@ -539,6 +554,9 @@ func init() { //nolint
 	greCmd.Flags().StringVar(&greName, "name", "", "GRE interface name (required)")
 	greCmd.Flags().StringVar(&greLocal, "local", "", "Local address (required)")
 	greCmd.Flags().StringVar(&greRemote, "remote", "", "Remote address (required)")
+	greCmd.Flags().StringVar(&greInnerLocal, "inner-local", "", "Local point-to-point address inside the tunnel")
+	greCmd.Flags().StringVar(&greInnerRemote, "inner-remote", "", "Remote point-to-point address inside the tunnel")
+	greCmd.Flags().Uint32Var(&greKey, "key", 0, "GRE key")
 	if err := greCmd.MarkFlagRequired("name"); err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
@@ -559,6 +577,29 @@ func init() { //nolint
 		os.Exit(1)
 	}
 
+	// gif
+	networkCmd.AddCommand(gifCmd)
+	gifCmd.Flags().StringVar(&gifName, "name", "", "gif interface name")
+	gifCmd.Flags().StringVar(&gifLocal, "local", "", "Local outer address, IPv4 or IPv6 (required)")
+	gifCmd.Flags().StringVar(&gifRemote, "remote", "", "Remote outer address, IPv4 or IPv6 (required)")
+	gifCmd.Flags().StringVar(&gifInnerLocal, "inner-local", "", "Local point-to-point address inside the tunnel")
+	gifCmd.Flags().StringVar(&gifInnerRemote, "inner-remote", "", "Remote point-to-point address inside the tunnel")
+	if err := gifCmd.MarkFlagRequired("local"); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := gifCmd.MarkFlagRequired("remote"); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	networkCmd.AddCommand(delGifCmd)
+	delGifCmd.Flags().StringVar(&delGifName, "name", "", "gif interface name (required)")
+	if err := delGifCmd.MarkFlagRequired("name"); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
 	// vxlan
 	networkCmd.AddCommand(vxlanCmd)
 	vxlanCmd.Flags().StringVar(&vxlanName, "name", "", "VXLAN interface name (required)")
@@ -591,39 +632,8 @@ func init() { //nolint
 		os.Exit(1)
 	}
 
-	// IP commands
-	ipAddCmd.Flags().StringVar(&ipIface, "iface", "", "Interface name (required)")
-	ipAddCmd.Flags().StringVar(&ipAddr, "ip", "", "IP address (required)")
-	ipAddCmd.Flags().IntVar(&ipMask, "mask", 0, "Netmask/CIDR (required)")
-	ipAddCmd.Flags().StringVar(&ipFamily, "family", "inet", "Address family (inet or inet6)")
-	_ = ipAddCmd.MarkFlagRequired("iface")
-	_ = ipAddCmd.MarkFlagRequired("ip")
-	_ = ipAddCmd.MarkFlagRequired("mask")
-
-	ipAliasCmd.Flags().StringVar(&ipIface, "iface", "", "Interface name (required)")
-	ipAliasCmd.Flags().StringVar(&ipAddr, "ip", "", "IP address (required)")
-	ipAliasCmd.Flags().IntVar(&ipMask, "mask", 0, "Netmask/CIDR (required)")
-	ipAliasCmd.Flags().StringVar(&ipFamily, "family", "inet", "Address family (inet or inet6)")
-	_ = ipAliasCmd.MarkFlagRequired("iface")
-	_ = ipAliasCmd.MarkFlagRequired("ip")
-	_ = ipAliasCmd.MarkFlagRequired("mask")
-
-	ipDeleteCmd.Flags().StringVar(&ipIface, "iface", "", "Interface name (required)")
-	ipDeleteCmd.Flags().StringVar(&ipAddr, "ip", "", "IP address (required)")
-	ipDeleteCmd.Flags().IntVar(&ipMask, "mask", 0, "Netmask/CIDR (required)")
-	ipDeleteCmd.Flags().StringVar(&ipFamily, "family", "inet", "Address family (inet or inet6)")
-	_ = ipDeleteCmd.MarkFlagRequired("iface")
-	_ = ipDeleteCmd.MarkFlagRequired("ip")
-	_ = ipDeleteCmd.MarkFlagRequired("mask")
-
-	ipCmd.AddCommand(ipAddCmd)
-	ipCmd.AddCommand(ipAliasCmd)
-	ipCmd.AddCommand(ipDeleteCmd)
-	ipCmd.AddCommand(ipListCmd)
-	networkCmd.AddCommand(ipCmd)
-
-	// Add ip commant to top level, do not delete.
-	// cmd.AddCommand(ipCmd)
+	// WireGuard
+	networkCmd.AddCommand(wgCmd)
 
 	// List and Info commands
 	networkCmd.AddCommand(networkListCmd)
@@ -635,7 +645,25 @@ func init() { //nolint
 		os.Exit(1)
 	}
 
-	networkCmd.AddCommand(routeCmd)
+	networkCmd.AddCommand(networkSetCmd)
+	networkSetCmd.Flags().StringVar(&setName, "name", "", "Interface name (required)")
+	networkSetCmd.Flags().IntVar(&setMTU, "mtu", 0, "MTU")
+	networkSetCmd.Flags().StringVar(&setDescription, "description", "", "Interface description (empty to clear)")
+	networkSetCmd.Flags().BoolVar(&setUp, "up", false, "Bring the interface up")
+	networkSetCmd.Flags().BoolVar(&setDown, "down", false, "Bring the interface down")
+	networkSetCmd.Flags().StringVar(&setEther, "ether", "", "MAC address")
+	networkSetCmd.Flags().IntVar(&setFIB, "fib", 0, "FIB number")
+	networkSetCmd.Flags().StringVar(&setGroup, "group", "", "Interface group to join")
+	networkSetCmd.Flags().StringSliceVar(&setCapabilities, "capabilities", nil, "Capabilities to toggle, e.g. -tso,+lro")
+	if err := networkSetCmd.MarkFlagRequired("name"); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	networkCmd.AddCommand(laggCmd)
+	networkCmd.AddCommand(epairCmd)
+	networkCmd.AddCommand(tapCmd)
+	networkCmd.AddCommand(carpCmd)
 
 	cmd.AddCommand(networkCmd)
 }
@@ -0,0 +1,246 @@
+package cmd
+
+import (
+	"FreeBSD-Command-manager/internal"
+	"FreeBSD-Command-manager/internal/network/ovs"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	ovsBridge   string
+	ovsName     string
+	ovsTag      int
+	ovsTrunks   []int
+	ovsType     string
+	ovsRemoteIP string
+	ovsLocalIP  string
+	ovsKey      string
+	ovsDstPort  int
+	ovsOptions  []string
+	ovsTable    string
+)
+
+var ovsCmd = &cobra.Command{
+	Use:   "ovs",
+	Short: "Manage Open vSwitch bridges, ports and interfaces (bridge, port, iface, list, show)",
+}
+
+// ovsRun runs fn and prints status, or the error it returned
+func ovsRun(fn func(ovs.ManagerInterface) error, status map[string]interface{}) {
+	if err := fn(ovs.DefaultManager()); err != nil {
+		if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if err := internal.Output(status); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// ovsInterfaceOptions collects the interface options from the tunnel flags
+// and --option key=value pairs.
+func ovsInterfaceOptions() (map[string]string, error) {
+	options := make(map[string]string)
+	for _, kv := range ovsOptions {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid option %q: must be key=value", kv)
+		}
+		options[k] = v
+	}
+	if ovsRemoteIP != "" {
+		options["remote_ip"] = ovsRemoteIP
+	}
+	if ovsLocalIP != "" {
+		options["local_ip"] = ovsLocalIP
+	}
+	if ovsKey != "" {
+		options["key"] = ovsKey
+	}
+	if ovsDstPort != 0 {
+		options["dst_port"] = strconv.Itoa(ovsDstPort)
+	}
+	return options, nil
+}
+
+var ovsBridgeCmd = &cobra.Command{
+	Use:   "bridge",
+	Short: "Manage Open vSwitch bridges (add, del, list)",
+}
+
+var ovsBridgeAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add a bridge",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		ovsRun(func(m ovs.ManagerInterface) error { return m.AddBridge(ovsName) },
+			map[string]interface{}{"bridge": ovsName, "status": "added"})
+	},
+}
+
+var ovsBridgeDelCmd = &cobra.Command{
+	Use:   "del",
+	Short: "Delete a bridge and its ports",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		ovsRun(func(m ovs.ManagerInterface) error { return m.DeleteBridge(ovsName) },
+			map[string]interface{}{"bridge": ovsName, "status": "deleted"})
+	},
+}
+
+var ovsBridgeListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List bridge names",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		var bridges []string
+		ovsRun(func(m ovs.ManagerInterface) (err error) {
+			bridges, err = m.ListBridges()
+			return err
+		}, map[string]interface{}{"bridges": &bridges})
+	},
+}
+
+var ovsPortCmd = &cobra.Command{
+	Use:   "port",
+	Short: "Manage Open vSwitch ports (add, del, list)",
+}
+
+var ovsPortAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add a port to a bridge, optionally as access/trunk port or tunnel",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		ovsRun(func(m ovs.ManagerInterface) error {
+			options, err := ovsInterfaceOptions()
+			if err != nil {
+				return err
+			}
+			return m.AddPort(ovsBridge, ovsName, ovs.PortOptions{Tag: ovsTag, Trunks: ovsTrunks, Type: ovsType, Options: options})
+		}, map[string]interface{}{"bridge": ovsBridge, "port": ovsName, "status": "added"})
+	},
+}
+
+var ovsPortDelCmd = &cobra.Command{
+	Use:   "del",
+	Short: "Delete a port",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		ovsRun(func(m ovs.ManagerInterface) error { return m.DeletePort(ovsBridge, ovsName) },
+			map[string]interface{}{"bridge": ovsBridge, "port": ovsName, "status": "deleted"})
+	},
+}
+
+var ovsPortListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the port names of a bridge",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		var ports []string
+		ovsRun(func(m ovs.ManagerInterface) (err error) {
+			ports, err = m.ListPorts(ovsBridge)
+			return err
+		}, map[string]interface{}{"bridge": ovsBridge, "ports": &ports})
+	},
+}
+
+var ovsIfaceCmd = &cobra.Command{
+	Use:   "iface",
+	Short: "Configure Open vSwitch interfaces (set)",
+}
+
+var ovsIfaceSetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Set the type and options of an interface, e.g. a VXLAN or GRE tunnel",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		ovsRun(func(m ovs.ManagerInterface) error {
+			options, err := ovsInterfaceOptions()
+			if err != nil {
+				return err
+			}
+			return m.SetInterface(ovsName, ovsType, options)
+		}, map[string]interface{}{"interface": ovsName, "type": ovsType, "status": "configured"})
+	},
+}
+
+var ovsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the rows of the Bridge, Port or Interface table",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		var rows interface{}
+		ovsRun(func(m ovs.ManagerInterface) (err error) {
+			switch strings.ToLower(ovsTable) {
+			case "bridge":
+				rows, err = m.Bridges()
+			case "port":
+				rows, err = m.Ports()
+			case "interface":
+				rows, err = m.Interfaces()
+			default:
+				err = fmt.Errorf("invalid table %q: must be Bridge, Port or Interface", ovsTable)
+			}
+			return err
+		}, map[string]interface{}{"table": ovsTable, "rows": &rows})
+	},
+}
+
+var ovsShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show all bridges with their ports and interfaces",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		var bridges interface{}
+		ovsRun(func(m ovs.ManagerInterface) (err error) {
+			bridges, err = m.Show()
+			return err
+		}, map[string]interface{}{"bridges": &bridges})
+	},
+}
+
+func init() { //nolint
+	for _, c := range []*cobra.Command{ovsBridgeAddCmd, ovsBridgeDelCmd} {
+		c.Flags().StringVar(&ovsName, "name", "", "Bridge name (required)")
+		_ = c.MarkFlagRequired("name")
+	}
+
+	ovsPortAddCmd.Flags().StringVar(&ovsBridge, "bridge", "", "Bridge name (required)")
+	ovsPortAddCmd.Flags().IntVar(&ovsTag, "tag", 0, "Access VLAN")
+	ovsPortAddCmd.Flags().IntSliceVar(&ovsTrunks, "trunks", nil, "VLANs carried by a trunk port, comma separated")
+	_ = ovsPortAddCmd.MarkFlagRequired("bridge")
+	ovsPortAddCmd.MarkFlagsMutuallyExclusive("tag", "trunks")
+
+	ovsPortDelCmd.Flags().StringVar(&ovsBridge, "bridge", "", "Bridge name (default: the bridge that has the port)")
+	ovsPortListCmd.Flags().StringVar(&ovsBridge, "bridge", "", "Bridge name (required)")
+	_ = ovsPortListCmd.MarkFlagRequired("bridge")
+
+	for _, c := range []*cobra.Command{ovsPortAddCmd, ovsPortDelCmd, ovsIfaceSetCmd} {
+		c.Flags().StringVar(&ovsName, "name", "", "Port or interface name (required)")
+		_ = c.MarkFlagRequired("name")
+	}
+	for _, c := range []*cobra.Command{ovsPortAddCmd, ovsIfaceSetCmd} {
+		c.Flags().StringVar(&ovsType, "type", "", "Interface type: internal, vxlan or gre")
+		c.Flags().StringVar(&ovsRemoteIP, "remote-ip", "", "Tunnel remote endpoint")
+		c.Flags().StringVar(&ovsLocalIP, "local-ip", "", "Tunnel local endpoint")
+		c.Flags().StringVar(&ovsKey, "key", "", "Tunnel key (VXLAN VNI or GRE key), or \"flow\"")
+		c.Flags().IntVar(&ovsDstPort, "dst-port", 0, "VXLAN UDP destination port")
+		c.Flags().StringSliceVar(&ovsOptions, "option", nil, "Other interface option as key=value (repeatable)")
+	}
+	_ = ovsIfaceSetCmd.MarkFlagRequired("type")
+
+	ovsListCmd.Flags().StringVar(&ovsTable, "table", "Bridge", "Table to list (Bridge, Port or Interface)")
+
+	ovsBridgeCmd.AddCommand(ovsBridgeAddCmd)
+	ovsBridgeCmd.AddCommand(ovsBridgeDelCmd)
+	ovsBridgeCmd.AddCommand(ovsBridgeListCmd)
+	ovsPortCmd.AddCommand(ovsPortAddCmd)
+	ovsPortCmd.AddCommand(ovsPortDelCmd)
+	ovsPortCmd.AddCommand(ovsPortListCmd)
+	ovsIfaceCmd.AddCommand(ovsIfaceSetCmd)
+	ovsCmd.AddCommand(ovsBridgeCmd)
+	ovsCmd.AddCommand(ovsPortCmd)
+	ovsCmd.AddCommand(ovsIfaceCmd)
+	ovsCmd.AddCommand(ovsListCmd)
+	ovsCmd.AddCommand(ovsShowCmd)
+	networkCmd.AddCommand(ovsCmd)
+}
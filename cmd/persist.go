@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"FreeBSD-Command-manager/internal"
+	"FreeBSD-Command-manager/internal/network/backend"
+	"FreeBSD-Command-manager/internal/network/bareos"
+	"FreeBSD-Command-manager/internal/network/dhcp"
+	"FreeBSD-Command-manager/internal/network/persist"
+	"FreeBSD-Command-manager/pkg/rcconf"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	persistEnabled bool
+	persistRCConf  string
+	persistDiff    bool
+)
+
+var networkPersistCmd = &cobra.Command{
+	Use:   "persist",
+	Short: "Save the current interfaces and routes to rc.conf",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if !requireBackend(backend.OpPersist) {
+			return
+		}
+		changes, err := saveNetworkConfig(persistDiff, persist.Dynamic{})
+		if err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		status := "saved"
+		if persistDiff {
+			status = "diff"
+		}
+		if err := internal.Output(map[string]interface{}{"rc_conf": persistRCConf, "changes": changes, "count": len(changes), "status": status}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+// saveNetworkConfig snapshots the running interfaces and routes into rc.conf.
+// Interfaces with a running dhclient are saved as DHCP unless dyn says
+// otherwise.
+func saveNetworkConfig(dryRun bool, dyn persist.Dynamic) ([]rcconf.Change, error) {
+	manager := bareos.DefaultManager()
+	infos, err := manager.List()
+	if err != nil {
+		return nil, err
+	}
+	routes, err := manager.ListAllFIBRoutes("")
+	if err != nil {
+		return nil, err
+	}
+	dhcpManager := dhcp.DefaultManager()
+	running := make(map[string]bool)
+	for _, info := range infos {
+		if on, ok := dyn.DHCP[info.Name]; ok {
+			running[info.Name] = on
+			continue
+		}
+		on, err := dhcpManager.Running(info.Name)
+		if err != nil {
+			return nil, err
+		}
+		if on {
+			running[info.Name] = true
+		}
+	}
+	dyn.DHCP = running
+	return persist.Save(persistRCConf, infos, routes, dyn, dryRun)
+}
+
+// outputChanged prints the result of a network command that changed the
+// running configuration. With --persist the configuration is saved to
+// rc.conf first and the number of rc.conf changes, or the error, is part of
+// the same result.
+func outputChanged(status map[string]interface{}) error {
+	return outputDynamic(status, persist.Dynamic{})
+}
+
+// outputDynamic is outputChanged for commands that change how an interface
+// obtains its addresses, which dyn records in rc.conf.
+func outputDynamic(status map[string]interface{}, dyn persist.Dynamic) error {
+	if !persistEnabled {
+		return internal.Output(status)
+	}
+	b, err := selectedBackend()
+	if err == nil {
+		err = backend.Require(b, backend.OpPersist)
+	}
+	var changes []rcconf.Change
+	if err == nil {
+		changes, err = saveNetworkConfig(false, dyn)
+	}
+	if err != nil {
+		status["error"] = "persist: " + err.Error()
+	} else {
+		status["persisted"] = len(changes)
+	}
+	return internal.Output(status)
+}
+
+func init() { //nolint
+	networkCmd.PersistentFlags().BoolVar(&persistEnabled, "persist", false, "Save the resulting configuration to rc.conf after commands that change it")
+	networkCmd.PersistentFlags().StringVar(&persistRCConf, "rc-conf", persist.DefaultRCConf, "rc.conf file used for persistence")
+
+	networkPersistCmd.Flags().BoolVar(&persistDiff, "diff", false, "Show the rc.conf changes without writing them")
+	networkCmd.AddCommand(networkPersistCmd)
+}
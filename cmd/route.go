@@ -2,10 +2,18 @@ package cmd
 
 import (
 	"FreeBSD-Command-manager/internal/network/bareos"
+	"FreeBSD-Command-manager/pkg/netstat"
+	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
 	"FreeBSD-Command-manager/internal"
+	"FreeBSD-Command-manager/internal/network/backend"
 
 	"github.com/spf13/cobra"
 )
@@ -15,25 +23,62 @@ var (
 	routeNet    string
 	routeGW     string
 	routeIface  string // new flag for interface
+	routeFIB    string
+	routeOnLink bool
+	routeBH     bool
+	routeReject bool
+	routeMTU    int
+	routeExpire int
+	routeWeight int
+	routeDst    string
 )
 
+const allFIBs = "all"
+
 var routeCmd = &cobra.Command{
 	Use:   "route",
-	Short: "Manage routes (add, del, list)",
+	Short: "Manage routes (add, change, del, get, list, watch)",
 }
 
 var routeAddCmd = &cobra.Command{
 	Use:   "add",
 	Short: "Add a route",
 	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
-		if routeNet == "" || routeGW == "" {
-			if e := internal.Output(map[string]interface{}{"error": "--net and --gw are required"}); e != nil {
+		if !requireBackend(backend.OpRoutes) {
+			return
+		}
+		runRouteSpec("Route added successfully", bareos.ManagerInterface.AddRoute)
+	},
+}
+
+var routeChangeCmd = &cobra.Command{
+	Use:   "change",
+	Short: "Change the gateway or attributes of a route",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if !requireBackend(backend.OpRoutes) {
+			return
+		}
+		runRouteSpec("Route changed successfully", bareos.ManagerInterface.ChangeRoute)
+	},
+}
+
+var routeGetCmd = &cobra.Command{
+	Use:   "get",
+	Short: "Look up the route to a destination",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if !requireBackend(backend.OpRoutes) {
+			return
+		}
+		fib, err := parseFIB(routeFIB)
+		if err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
 				fmt.Fprintln(os.Stderr, e)
 				os.Exit(1)
 			}
 			return
 		}
-		err := bareos.AddRouteWithIface(routeFamily, routeNet, routeGW, routeIface)
+		manager := bareos.DefaultManager()
+		lookup, err := manager.GetRoute(routeFamily, routeDst, fib)
 		if err != nil {
 			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
 				fmt.Fprintln(os.Stderr, e)
@@ -41,17 +86,63 @@ var routeAddCmd = &cobra.Command{
 			}
 			return
 		}
-		if err := internal.Output(map[string]interface{}{"status": "Route added successfully"}); err != nil {
+		if err := internal.Output(map[string]interface{}{"route": lookup}); err != nil {
 			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 	},
 }
 
+// runRouteSpec builds a RouteSpec from the flags and applies it with fn.
+func runRouteSpec(status string, fn func(bareos.ManagerInterface, bareos.RouteSpec) error) {
+	if routeNet == "" {
+		if e := internal.Output(map[string]interface{}{"error": "--net is required"}); e != nil {
+			fmt.Fprintln(os.Stderr, e)
+			os.Exit(1)
+		}
+		return
+	}
+	fib, err := parseFIB(routeFIB)
+	if err != nil {
+		if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+			fmt.Fprintln(os.Stderr, e)
+			os.Exit(1)
+		}
+		return
+	}
+	spec := bareos.RouteSpec{
+		Family:    routeFamily,
+		Network:   routeNet,
+		Gateway:   routeGW,
+		Iface:     routeIface,
+		OnLink:    routeOnLink,
+		Blackhole: routeBH,
+		Reject:    routeReject,
+		MTU:       routeMTU,
+		Expire:    routeExpire,
+		Weight:    routeWeight,
+		FIB:       fib,
+	}
+	if err := fn(bareos.DefaultManager(), spec); err != nil {
+		if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+			fmt.Fprintln(os.Stderr, e)
+			os.Exit(1)
+		}
+		return
+	}
+	if err := outputChanged(map[string]interface{}{"status": status}); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
 var routeDelCmd = &cobra.Command{
 	Use:   "del",
 	Short: "Delete a route",
 	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if !requireBackend(backend.OpRoutes) {
+			return
+		}
 		if routeNet == "" {
 			if e := internal.Output(map[string]interface{}{"error": "--net is required"}); e != nil {
 				fmt.Fprintln(os.Stderr, e)
@@ -59,7 +150,16 @@ var routeDelCmd = &cobra.Command{
 			}
 			return
 		}
-		err := bareos.DelRoute(routeFamily, routeNet)
+		fib, err := parseFIB(routeFIB)
+		if err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, e)
+				os.Exit(1)
+			}
+			return
+		}
+		manager := bareos.DefaultManager()
+		err = manager.DelRoute(routeFamily, routeNet, routeGW, fib)
 		if err != nil {
 			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
 				fmt.Fprintln(os.Stderr, e)
@@ -67,7 +167,7 @@ var routeDelCmd = &cobra.Command{
 			}
 			return
 		}
-		if err := internal.Output(map[string]interface{}{"status": "Route deleted successfully"}); err != nil {
+		if err := outputChanged(map[string]interface{}{"status": "Route deleted successfully"}); err != nil {
 			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
@@ -78,7 +178,20 @@ var routeListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List routes",
 	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
-		out, err := bareos.ListAllRoutes(routeFamily)
+		if !requireBackend(backend.OpRoutes) {
+			return
+		}
+		manager := bareos.DefaultManager()
+		var out []netstat.Route
+		var err error
+		if routeFIB == allFIBs {
+			out, err = manager.ListAllFIBRoutes(routeFamily)
+		} else {
+			var fib int
+			if fib, err = parseFIB(routeFIB); err == nil {
+				out, err = manager.ListRoutes(routeFamily, fib)
+			}
+		}
 		if err != nil {
 			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
 				fmt.Fprintln(os.Stderr, e)
@@ -93,20 +206,85 @@ var routeListCmd = &cobra.Command{
 	},
 }
 
+var routeWatchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Print routing table and interface changes as JSON lines until interrupted",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if !requireBackend(backend.OpRoutes) {
+			return
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		enc := json.NewEncoder(os.Stdout)
+		manager := bareos.DefaultManager()
+		err := manager.WatchRoutes(ctx, routeFamily, routeDst, func(m netstat.RouteMessage) {
+			if m.Time.IsZero() {
+				m.Time = time.Now()
+			}
+			if err := enc.Encode(m); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		})
+		if err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+// parseFIB converts a --fib value to a FIB number; empty selects the current FIB.
+func parseFIB(value string) (int, error) {
+	if value == "" {
+		return bareos.CurrentFIB, nil
+	}
+	fib, err := strconv.Atoi(value)
+	if err != nil || fib < 0 {
+		return 0, fmt.Errorf("invalid FIB: %s", value)
+	}
+	return fib, nil
+}
+
 func init() { //nolint
-	routeAddCmd.Flags().StringVar(&routeFamily, "family", "inet", "Address family (inet or inet6)")
-	routeAddCmd.Flags().StringVar(&routeNet, "net", "", "Network or destination (required)")
-	routeAddCmd.Flags().StringVar(&routeGW, "gw", "", "Gateway (required)")
-	routeAddCmd.Flags().StringVar(&routeIface, "iface", "", "Outgoing interface (optional)")
+	for _, c := range []*cobra.Command{routeAddCmd, routeChangeCmd} {
+		c.Flags().StringVar(&routeFamily, "family", "inet", "Address family (inet or inet6)")
+		c.Flags().StringVar(&routeNet, "net", "", "Network or destination (required)")
+		c.Flags().StringVar(&routeGW, "gw", "", "Gateway, or interface name with --on-link (optional for --blackhole/--reject)")
+		c.Flags().StringVar(&routeIface, "iface", "", "Outgoing interface (optional)")
+		c.Flags().StringVar(&routeFIB, "fib", "", "FIB number (default: current FIB)")
+		c.Flags().BoolVar(&routeOnLink, "on-link", false, "Destination is directly reachable through the --gw interface")
+		c.Flags().BoolVar(&routeBH, "blackhole", false, "Silently discard matching packets")
+		c.Flags().BoolVar(&routeReject, "reject", false, "Discard matching packets and report unreachable")
+		c.Flags().IntVar(&routeMTU, "mtu", 0, "Route MTU")
+		c.Flags().IntVar(&routeExpire, "expire", 0, "Lifetime in seconds")
+		c.Flags().IntVar(&routeWeight, "weight", 0, "Multipath (ECMP) weight")
+	}
+
+	routeGetCmd.Flags().StringVar(&routeFamily, "family", "inet", "Address family (inet or inet6)")
+	routeGetCmd.Flags().StringVar(&routeDst, "dst", "", "Destination to look up (required)")
+	routeGetCmd.Flags().StringVar(&routeFIB, "fib", "", "FIB number (default: current FIB)")
+	_ = routeGetCmd.MarkFlagRequired("dst")
 
 	routeDelCmd.Flags().StringVar(&routeFamily, "family", "inet", "Address family (inet or inet6)")
 	routeDelCmd.Flags().StringVar(&routeNet, "net", "", "Network or destination (required)")
+	routeDelCmd.Flags().StringVar(&routeGW, "gw", "", "Gateway of the path to remove from a multipath route (optional)")
+	routeDelCmd.Flags().StringVar(&routeFIB, "fib", "", "FIB number (default: current FIB)")
 
 	routeListCmd.Flags().StringVar(&routeFamily, "family", "", "Address family (inet, inet6, or empty for all)")
+	routeListCmd.Flags().StringVar(&routeFIB, "fib", "", "FIB number or \"all\" (default: current FIB)")
+
+	routeWatchCmd.Flags().StringVar(&routeFamily, "family", "", "Address family (inet, inet6, or empty for all)")
+	routeWatchCmd.Flags().StringVar(&routeDst, "dst", "", "Only show changes to \"default\", an address or routes within a prefix")
 
 	routeCmd.AddCommand(routeAddCmd)
+	routeCmd.AddCommand(routeChangeCmd)
+	routeCmd.AddCommand(routeGetCmd)
 	routeCmd.AddCommand(routeDelCmd)
 	routeCmd.AddCommand(routeListCmd)
+	routeCmd.AddCommand(routeWatchCmd)
+	networkCmd.AddCommand(routeCmd)
 
 	// Add route to top level, do not delete.
 	// cmd.AddCommand(routeCmd)
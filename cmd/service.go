@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"FreeBSD-Command-manager/internal"
+	"FreeBSD-Command-manager/internal/network/service"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	serviceIfaces    []string
+	serviceDomain    string
+	serviceRange     string
+	serviceLeaseTime string
+	serviceDryRun    bool
+)
+
+var serviceCmd = &cobra.Command{
+	Use:   "service",
+	Short: "Serve DNS names and DHCP addresses to the running jails with dnsmasq (dns, dhcp)",
+}
+
+var serviceDNSCmd = &cobra.Command{
+	Use:   "dns",
+	Short: "Generate dnsmasq host records for the running jails and restart dnsmasq",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		runService(func(m service.ManagerInterface) (*service.Result, error) {
+			return m.DNS(service.DNSOptions{Interfaces: serviceIfaces, Domain: serviceDomain, DryRun: serviceDryRun})
+		})
+	},
+}
+
+var serviceDHCPCmd = &cobra.Command{
+	Use:   "dhcp",
+	Short: "Generate dnsmasq DHCP ranges for jail networks and restart dnsmasq",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		runService(func(m service.ManagerInterface) (*service.Result, error) {
+			return m.DHCP(service.DHCPOptions{
+				Interfaces: serviceIfaces,
+				Range:      serviceRange,
+				LeaseTime:  serviceLeaseTime,
+				DryRun:     serviceDryRun,
+			})
+		})
+	},
+}
+
+// runService applies the configuration rendered by fn and prints the result
+func runService(fn func(service.ManagerInterface) (*service.Result, error)) {
+	res, err := fn(service.DefaultManager())
+	if err != nil {
+		if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if err := internal.Output(res); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func init() { //nolint
+	for _, c := range []*cobra.Command{serviceDNSCmd, serviceDHCPCmd} {
+		c.Flags().BoolVar(&serviceDryRun, "dry-run", false, "Print the configuration without writing it or restarting dnsmasq")
+	}
+	serviceDNSCmd.Flags().StringSliceVar(&serviceIfaces, "iface", nil, "Interfaces to answer on, comma separated (default: all)")
+	serviceDNSCmd.Flags().StringVar(&serviceDomain, "domain", "", "Local domain of the jail names, e.g. jail.lan")
+	serviceDHCPCmd.Flags().StringSliceVar(&serviceIfaces, "iface", nil, "Interfaces to serve, comma separated (required)")
+	serviceDHCPCmd.Flags().StringVar(&serviceRange, "range", "", "Address range as first-last, with a single --iface (default: upper half of the subnet)")
+	serviceDHCPCmd.Flags().StringVar(&serviceLeaseTime, "lease-time", "", "Lease time, e.g. 45m, 12h or infinite (default 12h)")
+	_ = serviceDHCPCmd.MarkFlagRequired("iface")
+
+	serviceCmd.AddCommand(serviceDNSCmd)
+	serviceCmd.AddCommand(serviceDHCPCmd)
+	networkCmd.AddCommand(serviceCmd)
+}
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"FreeBSD-Command-manager/internal"
+	"FreeBSD-Command-manager/internal/network/backend"
+	"FreeBSD-Command-manager/internal/network/bareos"
+	"FreeBSD-Command-manager/pkg/netstat"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	statsName     string
+	statsInterval time.Duration
+	statsCount    int
+)
+
+// statsSample holds the per-second rates of each interface between two
+// consecutive snapshots.
+type statsSample struct {
+	Time     time.Time                `json:"time"`
+	Interval string                   `json:"interval"`
+	Rates    map[string]netstat.Rates `json:"rates"`
+}
+
+var networkStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show interface packet, byte, error, drop and collision counters and rates",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if !requireBackend(backend.OpStats) {
+			return
+		}
+		if statsInterval < 0 || statsCount < 1 {
+			if e := internal.Output(map[string]interface{}{"error": "interval and count must be positive"}); e != nil {
+				fmt.Fprintln(os.Stderr, e)
+				os.Exit(1)
+			}
+			return
+		}
+		manager := bareos.DefaultManager()
+		var samples []statsSample
+		var err error
+		switch {
+		case statsInterval == 0:
+		case statsName != "" && statsInterval%time.Second == 0:
+			// netstat samples a single interface itself in whole seconds
+			samples, err = netstatSamples(manager)
+		default:
+			samples, err = pollSamples(manager)
+		}
+		var stats []netstat.InterfaceStats
+		if err == nil {
+			stats, err = manager.InterfaceStats(statsName)
+		}
+		if err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		out := map[string]interface{}{"interfaces": stats, "count": len(stats)}
+		if samples != nil {
+			out["samples"] = samples
+		}
+		if err := internal.Output(out); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+// netstatSamples takes the --count samples of the --name interface with
+// "netstat -w", which reports the increments of each interval.
+func netstatSamples(manager bareos.ManagerInterface) ([]statsSample, error) {
+	start := time.Now()
+	counters, err := manager.InterfaceSamples(statsName, int(statsInterval/time.Second), statsCount)
+	if err != nil {
+		return nil, err
+	}
+	samples := make([]statsSample, 0, len(counters))
+	for i, c := range counters {
+		samples = append(samples, statsSample{
+			Time:     start.Add(time.Duration(i+1) * statsInterval),
+			Interval: statsInterval.String(),
+			Rates:    map[string]netstat.Rates{statsName: netstat.PerSecond(c, statsInterval)},
+		})
+	}
+	return samples, nil
+}
+
+// pollSamples takes --count snapshots of the counters --interval apart and
+// computes the rates between consecutive ones.
+func pollSamples(manager bareos.ManagerInterface) ([]statsSample, error) {
+	prev, err := manager.InterfaceStats(statsName)
+	if err != nil {
+		return nil, err
+	}
+	var samples []statsSample
+	prevTime := time.Now()
+	for i := 0; i < statsCount; i++ {
+		time.Sleep(statsInterval)
+		cur, err := manager.InterfaceStats(statsName)
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		samples = append(samples, statsSample{Time: now, Interval: now.Sub(prevTime).Round(time.Millisecond).String(), Rates: statsRates(prev, cur, now.Sub(prevTime))})
+		prev, prevTime = cur, now
+	}
+	return samples, nil
+}
+
+// statsRates computes the rates of every interface present in both snapshots.
+func statsRates(prev, cur []netstat.InterfaceStats, elapsed time.Duration) map[string]netstat.Rates {
+	before := make(map[string]netstat.Counters, len(prev))
+	for _, s := range prev {
+		before[s.Name] = s.Counters
+	}
+	rates := make(map[string]netstat.Rates, len(cur))
+	for _, s := range cur {
+		if c, ok := before[s.Name]; ok {
+			rates[s.Name] = netstat.Rate(c, s.Counters, elapsed)
+		}
+	}
+	return rates
+}
+
+func init() { //nolint
+	networkStatsCmd.Flags().StringVar(&statsName, "name", "", "Interface name (default: all interfaces)")
+	networkStatsCmd.Flags().DurationVar(&statsInterval, "interval", 0, "Sample interval for rates, e.g. 5s (default: counters only)")
+	networkStatsCmd.Flags().IntVar(&statsCount, "count", 1, "Number of rate samples")
+	networkCmd.AddCommand(networkStatsCmd)
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"FreeBSD-Command-manager/internal"
+	"FreeBSD-Command-manager/internal/network/backend"
+	"FreeBSD-Command-manager/internal/network/bareos"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	tapName  string
+	tapOwner string
+	tapKeep  bool
+)
+
+var tapCmd = &cobra.Command{
+	Use:   "tap",
+	Short: "Manage tap interfaces (create, delete)",
+}
+
+var tapCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a tap interface",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if !requireBackend(backend.OpTap) {
+			return
+		}
+		manager := bareos.DefaultManager()
+		name, err := manager.CreateTap(tapName, bareos.TapOptions{Owner: tapOwner, Persist: tapKeep})
+		if err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		if err := outputChanged(map[string]interface{}{"tap": name, "owner": tapOwner, "keep_configured": tapKeep, "status": "created"}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+var tapDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete a tap interface",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if !requireBackend(backend.OpTap) {
+			return
+		}
+		manager := bareos.DefaultManager()
+		if err := manager.DeleteTap(tapName); err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		if err := outputChanged(map[string]interface{}{"tap": tapName, "status": "deleted"}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() { //nolint
+	tapCreateCmd.Flags().StringVar(&tapName, "name", "", "Tap interface name")
+	tapCreateCmd.Flags().StringVar(&tapOwner, "owner", "", "Owner of the device node (user or user:group)")
+	tapCreateCmd.Flags().BoolVar(&tapKeep, "keep-configured", false, "Keep the interface configured when its device is closed")
+
+	tapDeleteCmd.Flags().StringVar(&tapName, "name", "", "Tap interface name (required)")
+	_ = tapDeleteCmd.MarkFlagRequired("name")
+
+	tapCmd.AddCommand(tapCreateCmd)
+	tapCmd.AddCommand(tapDeleteCmd)
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"FreeBSD-Command-manager/internal"
+	"FreeBSD-Command-manager/internal/jail"
+	"FreeBSD-Command-manager/internal/network/backend"
+	"FreeBSD-Command-manager/internal/network/bareos"
+	"FreeBSD-Command-manager/pkg/topology"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var topologyFormat string
+
+var networkTopologyCmd = &cobra.Command{
+	Use:   "topology",
+	Short: "Show interfaces, their relations, jails and gateways as a graph (json, dot, mermaid)",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if !requireBackend(backend.OpTopology) {
+			return
+		}
+		graph, err := buildTopology()
+		if err == nil && topologyFormat != "json" && topologyFormat != "dot" && topologyFormat != "mermaid" {
+			err = fmt.Errorf("invalid format %q: must be json, dot or mermaid", topologyFormat)
+		}
+		if err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		switch topologyFormat {
+		case "dot":
+			fmt.Print(graph.DOT())
+		case "mermaid":
+			fmt.Print(graph.Mermaid())
+		default:
+			if err := internal.Output(graph); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+// buildTopology collects the host interfaces and routes and the interfaces
+// of every jail. Jails that cannot be entered (stopped, no jexec) and a
+// missing jail subsystem are skipped rather than failing the whole graph.
+func buildTopology() (*topology.Graph, error) {
+	manager := bareos.DefaultManager()
+	infos, err := manager.List()
+	if err != nil {
+		return nil, err
+	}
+	routes, err := manager.ListRoutes("", bareos.CurrentFIB)
+	if err != nil {
+		return nil, err
+	}
+
+	var jails []topology.Jail
+	if list, err := jail.DefaultManager().List(); err == nil {
+		for _, j := range list {
+			ifaces, err := manager.JailInterfaces(j.Name)
+			if err != nil {
+				continue
+			}
+			jails = append(jails, topology.Jail{Name: j.Name, Interfaces: ifaces})
+		}
+	}
+	return topology.Build(topology.Input{Interfaces: infos, Routes: routes, Jails: jails}), nil
+}
+
+func init() { //nolint
+	networkTopologyCmd.Flags().StringVar(&topologyFormat, "format", "json", "Output format (json, dot, mermaid)")
+	networkCmd.AddCommand(networkTopologyCmd)
+}
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"FreeBSD-Command-manager/internal"
+	"FreeBSD-Command-manager/internal/network/vale"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	valeSwitch string
+	valePorts  []string
+	valeIface  string
+	valeHost   bool
+)
+
+var valeCmd = &cobra.Command{
+	Use:   "vale",
+	Short: "Manage VALE switches and ports (create, destroy, attach, detach, list)",
+}
+
+// valeRun runs fn and prints status, or the error it returned
+func valeRun(fn func() error, status map[string]interface{}) {
+	if err := fn(); err != nil {
+		if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if err := internal.Output(status); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+var valeCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create persistent VALE ports, attached to a switch when --switch is given",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		manager := vale.DefaultManager()
+		valeRun(func() error {
+			if valeSwitch != "" {
+				return manager.CreateSwitch(valeSwitch, valePorts)
+			}
+			for _, p := range valePorts {
+				if err := manager.CreatePort(p); err != nil {
+					return err
+				}
+			}
+			return nil
+		}, map[string]interface{}{"switch": valeSwitch, "ports": valePorts, "status": "created"})
+	},
+}
+
+var valeDestroyCmd = &cobra.Command{
+	Use:   "destroy",
+	Short: "Detach all ports of a switch, or remove persistent VALE ports",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		manager := vale.DefaultManager()
+		valeRun(func() error {
+			if valeSwitch != "" {
+				return manager.DestroySwitch(valeSwitch)
+			}
+			for _, p := range valePorts {
+				if err := manager.DestroyPort(p); err != nil {
+					return err
+				}
+			}
+			return nil
+		}, map[string]interface{}{"switch": valeSwitch, "ports": valePorts, "status": "destroyed"})
+	},
+}
+
+var valeAttachCmd = &cobra.Command{
+	Use:   "attach",
+	Short: "Attach an interface or VALE port to a switch",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		manager := vale.DefaultManager()
+		valeRun(func() error { return manager.Attach(valeSwitch, valeIface, valeHost) },
+			map[string]interface{}{"switch": valeSwitch, "interface": valeIface, "host": valeHost, "status": "attached"})
+	},
+}
+
+var valeDetachCmd = &cobra.Command{
+	Use:   "detach",
+	Short: "Detach an interface or VALE port from a switch",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		manager := vale.DefaultManager()
+		valeRun(func() error { return manager.Detach(valeSwitch, valeIface) },
+			map[string]interface{}{"switch": valeSwitch, "interface": valeIface, "status": "detached"})
+	},
+}
+
+var valeListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List VALE switches and their ports",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		manager := vale.DefaultManager()
+		switches, err := manager.List()
+		if err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		if valeSwitch != "" {
+			filtered := switches[:0]
+			for _, sw := range switches {
+				if sw.Name == valeSwitch {
+					filtered = append(filtered, sw)
+				}
+			}
+			switches = filtered
+		}
+		if err := internal.Output(map[string]interface{}{"switches": switches, "count": len(switches)}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() { //nolint
+	valeCreateCmd.Flags().StringVar(&valeSwitch, "switch", "", "Switch to attach the ports to, e.g. vale0 (optional)")
+	valeCreateCmd.Flags().StringSliceVar(&valePorts, "port", nil, "Persistent VALE port to create (repeatable, required)")
+	_ = valeCreateCmd.MarkFlagRequired("port")
+
+	valeDestroyCmd.Flags().StringVar(&valeSwitch, "switch", "", "Switch whose ports to detach")
+	valeDestroyCmd.Flags().StringSliceVar(&valePorts, "port", nil, "Persistent VALE port to remove (repeatable)")
+	valeDestroyCmd.MarkFlagsOneRequired("switch", "port")
+	valeDestroyCmd.MarkFlagsMutuallyExclusive("switch", "port")
+
+	for _, c := range []*cobra.Command{valeAttachCmd, valeDetachCmd} {
+		c.Flags().StringVar(&valeSwitch, "switch", "", "Switch name, e.g. vale0 (required)")
+		c.Flags().StringVar(&valeIface, "iface", "", "Interface or persistent VALE port (required)")
+		_ = c.MarkFlagRequired("switch")
+		_ = c.MarkFlagRequired("iface")
+	}
+	valeAttachCmd.Flags().BoolVar(&valeHost, "host", false, "Also attach the host stack of the interface")
+
+	valeListCmd.Flags().StringVar(&valeSwitch, "switch", "", "Only list this switch")
+
+	valeCmd.AddCommand(valeCreateCmd)
+	valeCmd.AddCommand(valeDestroyCmd)
+	valeCmd.AddCommand(valeAttachCmd)
+	valeCmd.AddCommand(valeDetachCmd)
+	valeCmd.AddCommand(valeListCmd)
+	networkCmd.AddCommand(valeCmd)
+}
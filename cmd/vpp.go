@@ -0,0 +1,350 @@
+package cmd
+
+import (
+	"FreeBSD-Command-manager/internal"
+	"FreeBSD-Command-manager/internal/network/bareos"
+	"FreeBSD-Command-manager/internal/network/vpp"
+	pkgvpp "FreeBSD-Command-manager/pkg/vpp"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	vppName     string
+	vppType     string
+	vppHostIf   string
+	vppID       int
+	vppSocketID int
+	vppSocket   string
+	vppMaster   bool
+	vppUp       bool
+	vppDown     bool
+	vppMTU      int
+	vppIP       string
+	vppMask     int
+	vppBridgeID int
+	vppBVI      bool
+	vppPeer     string
+	vppBDOpts   = vpp.DefaultBridgeDomainOptions()
+)
+
+var vppCmd = &cobra.Command{
+	Use:   "vpp",
+	Short: "Manage VPP interfaces, bridge domains and cross-connects",
+}
+
+// vppRun runs fn and prints status, or the error it returned
+func vppRun(fn func(vpp.ManagerInterface) error, status map[string]interface{}) {
+	if err := fn(vpp.DefaultManager()); err != nil {
+		if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if err := internal.Output(status); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+var vppIfaceCmd = &cobra.Command{
+	Use:   "iface",
+	Short: "Create a host, tap, memif or loopback interface",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		var name string
+		vppRun(func(m vpp.ManagerInterface) (err error) {
+			name, err = m.CreateInterface(vpp.InterfaceSpec{
+				Type:     vppType,
+				HostIf:   vppHostIf,
+				ID:       vppID,
+				SocketID: vppSocketID,
+				Socket:   vppSocket,
+				Master:   vppMaster,
+			})
+			return err
+		}, map[string]interface{}{"interface": &name, "type": vppType, "status": "created"})
+	},
+}
+
+var vppDelIfaceCmd = &cobra.Command{
+	Use:   "delete-iface",
+	Short: "Delete a host, tap, memif or loopback interface",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		vppRun(func(m vpp.ManagerInterface) error { return m.DeleteInterface(vppName) },
+			map[string]interface{}{"interface": vppName, "status": "deleted"})
+	},
+}
+
+var vppSetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Change the state or MTU of an interface",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		var changes []string
+		count := 0
+		vppRun(func(m vpp.ManagerInterface) error {
+			if cmd.Flags().Changed("mtu") {
+				if err := m.SetMTU(vppName, vppMTU); err != nil {
+					return err
+				}
+				changes = append(changes, fmt.Sprintf("mtu %d", vppMTU))
+			}
+			if vppUp || vppDown {
+				if err := m.SetState(vppName, vppUp); err != nil {
+					return err
+				}
+				if vppUp {
+					changes = append(changes, "up")
+				} else {
+					changes = append(changes, "down")
+				}
+			}
+			count = len(changes)
+			return nil
+		}, map[string]interface{}{"interface": vppName, "changes": &changes, "count": &count})
+	},
+}
+
+var vppIPCmd = &cobra.Command{
+	Use:   "ip",
+	Short: "Manage IP addresses on VPP interfaces (add, delete, list)",
+}
+
+var vppIPAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add an IP address to an interface",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		prefix, err := bareos.ParsePrefix(vppIP, vppMask)
+		vppRun(func(m vpp.ManagerInterface) error {
+			if err != nil {
+				return err
+			}
+			return m.AddAddress(vppName, prefix)
+		}, map[string]interface{}{"interface": vppName, "ip": prefix.String(), "family": ipFamilyOf(prefix.Addr()), "status": "added"})
+	},
+}
+
+var vppIPDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete an IP address from an interface",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		prefix, err := bareos.ParsePrefix(vppIP, vppMask)
+		vppRun(func(m vpp.ManagerInterface) error {
+			if err != nil {
+				return err
+			}
+			return m.DeleteAddress(vppName, prefix)
+		}, map[string]interface{}{"interface": vppName, "ip": prefix.String(), "family": ipFamilyOf(prefix.Addr()), "status": "deleted"})
+	},
+}
+
+var vppIPListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List IP addresses on interfaces",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		ips := make(map[string][]string)
+		count := 0
+		vppRun(func(m vpp.ManagerInterface) error {
+			ifaces, err := m.List()
+			if err != nil {
+				return err
+			}
+			for _, iface := range ifaces {
+				if len(iface.Addresses) > 0 && (vppName == "" || iface.Name == vppName) {
+					ips[iface.Name] = iface.Addresses
+					count += len(iface.Addresses)
+				}
+			}
+			return nil
+		}, map[string]interface{}{"interfaces": ips, "count": &count})
+	},
+}
+
+var vppBridgeCmd = &cobra.Command{
+	Use:   "bridge",
+	Short: "Create a bridge domain",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		vppRun(func(m vpp.ManagerInterface) error { return m.CreateBridgeDomain(vppBridgeID, vppBDOpts) },
+			map[string]interface{}{"bridge": vppBridgeID, "status": "created"})
+	},
+}
+
+var vppDelBridgeCmd = &cobra.Command{
+	Use:   "delete-bridge",
+	Short: "Delete a bridge domain",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		vppRun(func(m vpp.ManagerInterface) error { return m.DeleteBridgeDomain(vppBridgeID) },
+			map[string]interface{}{"bridge": vppBridgeID, "status": "deleted"})
+	},
+}
+
+var vppAddInterfaceToBridgeCmd = &cobra.Command{
+	Use:   "add-interface-to-bridge",
+	Short: "Add an interface to a bridge domain",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		vppRun(func(m vpp.ManagerInterface) error { return m.AddBridgeMember(vppBridgeID, vppName, vppBVI) },
+			map[string]interface{}{"bridge": vppBridgeID, "interface": vppName, "bvi": vppBVI, "status": "added"})
+	},
+}
+
+var vppRemoveInterfaceFromBridgeCmd = &cobra.Command{
+	Use:   "remove-interface-from-bridge",
+	Short: "Remove an interface from its bridge domain, returning it to L3 mode",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		vppRun(func(m vpp.ManagerInterface) error { return m.SetL3(vppName) },
+			map[string]interface{}{"interface": vppName, "status": "removed"})
+	},
+}
+
+var vppXConnectCmd = &cobra.Command{
+	Use:   "xconnect",
+	Short: "Cross-connect two interfaces at L2 in both directions",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		vppRun(func(m vpp.ManagerInterface) error { return m.AddXConnect(vppName, vppPeer) },
+			map[string]interface{}{"interface": vppName, "peer": vppPeer, "status": "connected"})
+	},
+}
+
+var vppDelXConnectCmd = &cobra.Command{
+	Use:   "delete-xconnect",
+	Short: "Remove the cross-connect of two interfaces, returning both to L3 mode",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		vppRun(func(m vpp.ManagerInterface) error {
+			if err := m.SetL3(vppName); err != nil {
+				return err
+			}
+			return m.SetL3(vppPeer)
+		}, map[string]interface{}{"interface": vppName, "peer": vppPeer, "status": "disconnected"})
+	},
+}
+
+var vppListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all VPP interfaces",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		var interfaces []pkgvpp.Interface
+		count := 0
+		vppRun(func(m vpp.ManagerInterface) (err error) {
+			interfaces, err = m.List()
+			count = len(interfaces)
+			return err
+		}, map[string]interface{}{"interfaces": &interfaces, "count": &count})
+	},
+}
+
+var vppInfoCmd = &cobra.Command{
+	Use:   "info",
+	Short: "Get information about a VPP interface",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		var info pkgvpp.Interface
+		vppRun(func(m vpp.ManagerInterface) error {
+			interfaces, err := m.List()
+			if err != nil {
+				return err
+			}
+			for _, iface := range interfaces {
+				if iface.Name == vppName {
+					info = iface
+					return nil
+				}
+			}
+			return fmt.Errorf("interface %s not found", vppName)
+		}, map[string]interface{}{"interface_info": &info})
+	},
+}
+
+var vppListBridgesCmd = &cobra.Command{
+	Use:   "list-bridges",
+	Short: "List bridge domains and their members",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		var domains []pkgvpp.BridgeDomain
+		vppRun(func(m vpp.ManagerInterface) (err error) {
+			domains, err = m.BridgeDomains()
+			return err
+		}, map[string]interface{}{"bridges": &domains})
+	},
+}
+
+var vppModesCmd = &cobra.Command{
+	Use:   "modes",
+	Short: "Show the L2/L3 mode of every interface",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		var modes []pkgvpp.Mode
+		vppRun(func(m vpp.ManagerInterface) (err error) {
+			modes, err = m.Modes()
+			return err
+		}, map[string]interface{}{"modes": &modes})
+	},
+}
+
+func init() { //nolint
+	vppIfaceCmd.Flags().StringVar(&vppType, "type", "", "Interface type: host, tap, memif or loopback (required)")
+	vppIfaceCmd.Flags().StringVar(&vppHostIf, "host-if", "", "Host interface to bind (host), or host side name (tap)")
+	vppIfaceCmd.Flags().IntVar(&vppID, "id", 0, "Tap or memif ID")
+	vppIfaceCmd.Flags().IntVar(&vppSocketID, "socket-id", 0, "Memif socket ID")
+	vppIfaceCmd.Flags().StringVar(&vppSocket, "socket", "", "Memif socket file to register under --socket-id")
+	vppIfaceCmd.Flags().BoolVar(&vppMaster, "master", false, "Create the memif as master (default: slave)")
+	_ = vppIfaceCmd.MarkFlagRequired("type")
+
+	for _, c := range []*cobra.Command{vppDelIfaceCmd, vppSetCmd, vppInfoCmd} {
+		c.Flags().StringVar(&vppName, "name", "", "Interface name (required)")
+		_ = c.MarkFlagRequired("name")
+	}
+	vppSetCmd.Flags().BoolVar(&vppUp, "up", false, "Bring the interface up")
+	vppSetCmd.Flags().BoolVar(&vppDown, "down", false, "Bring the interface down")
+	vppSetCmd.Flags().IntVar(&vppMTU, "mtu", 0, "Packet MTU")
+	vppSetCmd.MarkFlagsMutuallyExclusive("up", "down")
+	vppSetCmd.MarkFlagsOneRequired("up", "down", "mtu")
+
+	for _, c := range []*cobra.Command{vppIPAddCmd, vppIPDeleteCmd} {
+		c.Flags().StringVar(&vppName, "iface", "", "Interface name (required)")
+		c.Flags().StringVar(&vppIP, "ip", "", "IP address, optionally in CIDR notation (required)")
+		c.Flags().IntVar(&vppMask, "mask", 0, "Prefix length (default: from --ip, or a host prefix)")
+		_ = c.MarkFlagRequired("iface")
+		_ = c.MarkFlagRequired("ip")
+	}
+	vppIPListCmd.Flags().StringVar(&vppName, "iface", "", "Interface name (default: all interfaces)")
+
+	for _, c := range []*cobra.Command{vppBridgeCmd, vppDelBridgeCmd} {
+		c.Flags().IntVar(&vppBridgeID, "id", 0, "Bridge domain ID (required)")
+		_ = c.MarkFlagRequired("id")
+	}
+	vppBridgeCmd.Flags().BoolVar(&vppBDOpts.Learn, "learn", vppBDOpts.Learn, "Learn MAC addresses")
+	vppBridgeCmd.Flags().BoolVar(&vppBDOpts.Forward, "forward", vppBDOpts.Forward, "Forward known unicast")
+	vppBridgeCmd.Flags().BoolVar(&vppBDOpts.Flood, "flood", vppBDOpts.Flood, "Flood broadcast and multicast")
+	vppBridgeCmd.Flags().BoolVar(&vppBDOpts.UUFlood, "uu-flood", vppBDOpts.UUFlood, "Flood unknown unicast")
+	vppBridgeCmd.Flags().BoolVar(&vppBDOpts.ARPTerm, "arp-term", vppBDOpts.ARPTerm, "Terminate ARP requests in VPP")
+
+	vppAddInterfaceToBridgeCmd.Flags().IntVar(&vppBridgeID, "bridge", 0, "Bridge domain ID (required)")
+	vppAddInterfaceToBridgeCmd.Flags().BoolVar(&vppBVI, "bvi", false, "Make the interface the bridge virtual interface")
+	_ = vppAddInterfaceToBridgeCmd.MarkFlagRequired("bridge")
+	for _, c := range []*cobra.Command{vppAddInterfaceToBridgeCmd, vppRemoveInterfaceFromBridgeCmd, vppXConnectCmd, vppDelXConnectCmd} {
+		c.Flags().StringVar(&vppName, "interface", "", "Interface name (required)")
+		_ = c.MarkFlagRequired("interface")
+	}
+	for _, c := range []*cobra.Command{vppXConnectCmd, vppDelXConnectCmd} {
+		c.Flags().StringVar(&vppPeer, "peer", "", "Peer interface name (required)")
+		_ = c.MarkFlagRequired("peer")
+	}
+
+	vppIPCmd.AddCommand(vppIPAddCmd)
+	vppIPCmd.AddCommand(vppIPDeleteCmd)
+	vppIPCmd.AddCommand(vppIPListCmd)
+	vppCmd.AddCommand(vppIfaceCmd)
+	vppCmd.AddCommand(vppDelIfaceCmd)
+	vppCmd.AddCommand(vppSetCmd)
+	vppCmd.AddCommand(vppIPCmd)
+	vppCmd.AddCommand(vppBridgeCmd)
+	vppCmd.AddCommand(vppDelBridgeCmd)
+	vppCmd.AddCommand(vppAddInterfaceToBridgeCmd)
+	vppCmd.AddCommand(vppRemoveInterfaceFromBridgeCmd)
+	vppCmd.AddCommand(vppXConnectCmd)
+	vppCmd.AddCommand(vppDelXConnectCmd)
+	vppCmd.AddCommand(vppListCmd)
+	vppCmd.AddCommand(vppInfoCmd)
+	vppCmd.AddCommand(vppListBridgesCmd)
+	vppCmd.AddCommand(vppModesCmd)
+	networkCmd.AddCommand(vppCmd)
+}
@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"FreeBSD-Command-manager/internal"
+	"FreeBSD-Command-manager/internal/network/backend"
+	"FreeBSD-Command-manager/internal/network/wg"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	wgName           string
+	wgPort           int
+	wgPrivateKeyFile string
+	wgPrivateKeyOut  string
+	wgPeerKey        string
+	wgEndpoint       string
+	wgAllowedIPs     []string
+	wgKeepalive      int
+	wgPSKFile        string
+)
+
+var wgCmd = &cobra.Command{
+	Use:   "wg",
+	Short: "Manage WireGuard interfaces and peers (create, delete, peer, show)",
+}
+
+var wgCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a WireGuard interface",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if !requireBackend(backend.OpWireGuard) {
+			return
+		}
+		var kp wg.KeyPair
+		var err error
+		switch {
+		case wgPrivateKeyFile != "" && wgPrivateKeyOut != "":
+			err = fmt.Errorf("--private-key-file and --private-key-out are mutually exclusive")
+		case wgPrivateKeyFile != "":
+			kp.PrivateKey, err = readSecretFile(wgPrivateKeyFile)
+			if err == nil {
+				kp.PublicKey, err = wg.PublicKey(kp.PrivateKey)
+			}
+		case wgPrivateKeyOut != "":
+			// Save the generated key first so it is never lost
+			kp, err = wg.GenerateKeyPair()
+			if err == nil {
+				err = wg.WritePrivateKey(wgPrivateKeyOut, kp.PrivateKey)
+			}
+		default:
+			err = fmt.Errorf("--private-key-file or --private-key-out is required")
+		}
+		if err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		manager := wg.DefaultManager()
+		name, err := manager.CreateInterface(wgName, wgPort, kp.PrivateKey)
+		if err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		if err := outputChanged(map[string]interface{}{"wg": name, "listen_port": wgPort, "public_key": kp.PublicKey, "status": "created"}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+var wgDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete a WireGuard interface",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if !requireBackend(backend.OpWireGuard) {
+			return
+		}
+		manager := wg.DefaultManager()
+		if err := manager.DeleteInterface(wgName); err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		if err := outputChanged(map[string]interface{}{"wg": wgName, "status": "deleted"}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+var wgPeerCmd = &cobra.Command{
+	Use:   "peer",
+	Short: "Manage WireGuard peers (add, remove)",
+}
+
+var wgPeerAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add or update a peer",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if !requireBackend(backend.OpWireGuard) {
+			return
+		}
+		peer := wg.PeerConfig{
+			PublicKey:           wgPeerKey,
+			Endpoint:            wgEndpoint,
+			AllowedIPs:          wgAllowedIPs,
+			PersistentKeepalive: wgKeepalive,
+		}
+		if wgPSKFile != "" {
+			psk, err := readSecretFile(wgPSKFile)
+			if err != nil {
+				if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				return
+			}
+			peer.PresharedKey = psk
+		}
+		manager := wg.DefaultManager()
+		if err := manager.AddPeer(wgName, peer); err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		if err := internal.Output(map[string]interface{}{"wg": wgName, "peer": wgPeerKey, "endpoint": wgEndpoint, "allowed_ips": wgAllowedIPs, "status": "added"}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+var wgPeerRemoveCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "Remove a peer",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if !requireBackend(backend.OpWireGuard) {
+			return
+		}
+		manager := wg.DefaultManager()
+		if err := manager.RemovePeer(wgName, wgPeerKey); err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		if err := internal.Output(map[string]interface{}{"wg": wgName, "peer": wgPeerKey, "status": "removed"}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+var wgShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show WireGuard interfaces and peers",
+	Run: func(cmd *cobra.Command, args []string) { //nolint:revive // cmd is required by cobra interface
+		if !requireBackend(backend.OpWireGuard) {
+			return
+		}
+		manager := wg.DefaultManager()
+		interfaces, err := manager.Show(wgName)
+		if err != nil {
+			if e := internal.Output(map[string]interface{}{"error": err.Error()}); e != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+		if err := internal.Output(map[string]interface{}{"interfaces": interfaces, "count": len(interfaces)}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	},
+}
+
+// readSecretFile reads a key or pass phrase from a file so it never appears on the command line.
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // path is provided by the operator
+	if err != nil {
+		return "", fmt.Errorf("failed to read key file: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func init() { //nolint
+	wgCreateCmd.Flags().StringVar(&wgName, "name", "", "WireGuard interface name")
+	wgCreateCmd.Flags().IntVar(&wgPort, "port", 51820, "Listen port")
+	wgCreateCmd.Flags().StringVar(&wgPrivateKeyFile, "private-key-file", "", "File with base64 private key")
+	wgCreateCmd.Flags().StringVar(&wgPrivateKeyOut, "private-key-out", "", "Generate a private key and save it to this new file (mode 0600)")
+
+	wgDeleteCmd.Flags().StringVar(&wgName, "name", "", "WireGuard interface name (required)")
+	_ = wgDeleteCmd.MarkFlagRequired("name")
+
+	wgPeerAddCmd.Flags().StringVar(&wgName, "iface", "", "WireGuard interface name (required)")
+	wgPeerAddCmd.Flags().StringVar(&wgPeerKey, "public-key", "", "Peer public key (required)")
+	wgPeerAddCmd.Flags().StringVar(&wgEndpoint, "endpoint", "", "Peer endpoint host:port")
+	wgPeerAddCmd.Flags().StringSliceVar(&wgAllowedIPs, "allowed-ips", nil, "Allowed IP prefixes, comma separated")
+	wgPeerAddCmd.Flags().IntVar(&wgKeepalive, "keepalive", 0, "Persistent keepalive interval in seconds")
+	wgPeerAddCmd.Flags().StringVar(&wgPSKFile, "preshared-key-file", "", "File with base64 preshared key")
+	_ = wgPeerAddCmd.MarkFlagRequired("iface")
+	_ = wgPeerAddCmd.MarkFlagRequired("public-key")
+
+	wgPeerRemoveCmd.Flags().StringVar(&wgName, "iface", "", "WireGuard interface name (required)")
+	wgPeerRemoveCmd.Flags().StringVar(&wgPeerKey, "public-key", "", "Peer public key (required)")
+	_ = wgPeerRemoveCmd.MarkFlagRequired("iface")
+	_ = wgPeerRemoveCmd.MarkFlagRequired("public-key")
+
+	wgShowCmd.Flags().StringVar(&wgName, "iface", "", "WireGuard interface name (all if omitted)")
+
+	wgPeerCmd.AddCommand(wgPeerAddCmd)
+	wgPeerCmd.AddCommand(wgPeerRemoveCmd)
+
+	wgCmd.AddCommand(wgCreateCmd)
+	wgCmd.AddCommand(wgDeleteCmd)
+	wgCmd.AddCommand(wgPeerCmd)
+	wgCmd.AddCommand(wgShowCmd)
+}
@@ -0,0 +1,47 @@
+// Package config reads the fcom configuration file, an rc.conf style file of
+// fcom_* variables:
+//
+//	fcom_network_backend="vpp"
+//	fcom_firewall="ipfw"
+//	fcom_ipfw_rules="10000-19999"
+package config
+
+import (
+	"FreeBSD-Command-manager/pkg/rcconf"
+	"fmt"
+)
+
+const (
+	// DefaultFile is the configuration file read by default
+	DefaultFile = "/usr/local/etc/fcom.conf"
+
+	networkBackendKey = "fcom_network_backend"
+	firewallKey       = "fcom_firewall"
+	ipfwRulesKey      = "fcom_ipfw_rules"
+)
+
+// Config holds the fcom settings. Empty values mean the built-in default.
+type Config struct {
+	NetworkBackend string // backend of the generic network commands
+	Firewall       string // pf or ipfw, used by the firewall commands
+	IPFWRules      string // range of ipfw rule numbers owned by fcom
+}
+
+// Load reads the configuration file at path. A missing file is an empty
+// configuration.
+func Load(path string) (*Config, error) {
+	f, err := rcconf.Load(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration: %w", err)
+	}
+	return Parse(f), nil
+}
+
+// Parse extracts the settings from a parsed configuration file.
+func Parse(f *rcconf.File) *Config {
+	c := &Config{}
+	c.NetworkBackend, _ = f.Get(networkBackendKey)
+	c.Firewall, _ = f.Get(firewallKey)
+	c.IPFWRules, _ = f.Get(ipfwRulesKey)
+	return c
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"FreeBSD-Command-manager/pkg/rcconf"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	c := Parse(rcconf.Parse("# fcom settings\nfcom_network_backend=\"ovs\" # bridges live in OVS\nother=1\n" +
+		"fcom_firewall=ipfw\nfcom_ipfw_rules=\"500-999\"\n"))
+	if c.NetworkBackend != "ovs" {
+		t.Errorf("expected network backend ovs, got %q", c.NetworkBackend)
+	}
+	if c.Firewall != "ipfw" || c.IPFWRules != "500-999" {
+		t.Errorf("expected firewall ipfw with rules 500-999, got %+v", c)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	c, err := Load(filepath.Join(dir, "missing.conf"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.NetworkBackend != "" {
+		t.Errorf("expected empty configuration, got %+v", c)
+	}
+
+	path := filepath.Join(dir, "fcom.conf")
+	if err := os.WriteFile(path, []byte("fcom_network_backend=vpp\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if c, err = Load(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.NetworkBackend != "vpp" {
+		t.Errorf("expected network backend vpp, got %q", c.NetworkBackend)
+	}
+}
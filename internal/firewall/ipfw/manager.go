@@ -0,0 +1,428 @@
+// Package ipfw manages the part of the ipfw(8) configuration owned by fcom:
+// a range of rule numbers, lookup tables, in-kernel NAT instances and
+// dummynet pipes and queues. Rules outside the range are left alone.
+//
+// fcom rules are loaded in set 20. A new ruleset is added to the disabled
+// set 21 first and swapped with set 20 in one step, so packets never see a
+// half loaded ruleset.
+package ipfw
+
+import (
+	"FreeBSD-Command-manager/internal/network/bareos"
+	pkgipfw "FreeBSD-Command-manager/pkg/ipfw"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	ipfw = "ipfw"
+	// DefaultFirstRule is the first rule number owned by fcom
+	DefaultFirstRule = 10000
+	// DefaultLastRule is the last rule number owned by fcom
+	DefaultLastRule = 19999
+	// RuleSet is the ipfw set holding the loaded fcom rules
+	RuleSet = 20
+	// stagingSet holds a new ruleset, disabled, until it replaces RuleSet
+	stagingSet = 21
+	// lastUserRule is the highest number a rule can have; 65535 is the
+	// default rule
+	lastUserRule = 65534
+)
+
+// ManagerInterface defines the interface for ipfw operations
+type ManagerInterface interface {
+	Renumber(rs *pkgipfw.Ruleset) error
+	Apply(rs *pkgipfw.Ruleset, owned *Owned) error
+	CheckForeign(rs *pkgipfw.Ruleset, owned *Owned) error
+	Rules() ([]pkgipfw.Rule, error)
+	Tables() ([]pkgipfw.Table, error)
+	AddTableEntries(table string, entries []pkgipfw.TableEntry) error
+	DeleteTableEntries(table string, addrs []string) error
+	DestroyTable(table string) error
+	ConfigureNAT(n pkgipfw.NAT) error
+	DeleteNAT(id int) error
+	ConfigurePipe(p pkgipfw.Pipe) error
+	DeletePipe(id int) error
+	ConfigureQueue(q pkgipfw.Queue) error
+	DeleteQueue(id int) error
+}
+
+// CommandExecutor defines the interface for executing system commands
+type CommandExecutor interface {
+	Execute(name string, args ...string) (string, error)
+}
+
+// Manager implements ManagerInterface using ipfw(8)
+type Manager struct {
+	cmdExec CommandExecutor
+	first   int
+	last    int
+}
+
+// NewManager creates a new ipfw manager owning the rules numbered first to
+// last
+func NewManager(cmdExec CommandExecutor, first, last int) *Manager {
+	return &Manager{
+		cmdExec: cmdExec,
+		first:   first,
+		last:    last,
+	}
+}
+
+// DefaultManager returns the default ipfw manager instance
+func DefaultManager(first, last int) ManagerInterface {
+	return NewManager(bareos.NewRealCommandExecutor(), first, last)
+}
+
+// ParseRange parses a rule range such as "10000-19999". An empty range is
+// the default one.
+func ParseRange(s string) (first, last int, err error) {
+	if s == "" {
+		return DefaultFirstRule, DefaultLastRule, nil
+	}
+	a, b, ok := strings.Cut(s, "-")
+	first, ferr := strconv.Atoi(a)
+	last, lerr := strconv.Atoi(b)
+	if !ok || ferr != nil || lerr != nil || first < 1 || first > last || last > lastUserRule {
+		return 0, 0, fmt.Errorf("invalid rule range %q: must be like 10000-19999, within 1-%d", s, lastUserRule)
+	}
+	return first, last, nil
+}
+
+// Renumber numbers the rules of the ruleset within the fcom range
+func (m *Manager) Renumber(rs *pkgipfw.Ruleset) error {
+	if err := rs.Renumber(m.first, m.last); err != nil {
+		return fmt.Errorf("failed to number rules: %w", err)
+	}
+	return nil
+}
+
+// Apply loads the ruleset: it configures the NAT instances, pipes and
+// queues, brings the tables in line with it, replaces the fcom rules and
+// removes the owned objects the ruleset no longer has. Every rule is checked
+// with "ipfw -n" first; a ruleset ipfw rejects, or one naming a table, NAT
+// instance, pipe or queue fcom did not create, changes nothing. owned is updated with what was created
+// and removed, also when a later step fails.
+func (m *Manager) Apply(rs *pkgipfw.Ruleset, owned *Owned) error {
+	if err := rs.Validate(); err != nil {
+		return fmt.Errorf("invalid ruleset: %w", err)
+	}
+	for _, r := range rs.Rules {
+		if r.Number < m.first || r.Number > m.last {
+			return fmt.Errorf("rule %d is outside the fcom rule range %d-%d", r.Number, m.first, m.last)
+		}
+	}
+	for _, r := range rs.Rules {
+		args := append([]string{"-n", "add", strconv.Itoa(r.Number)}, r.Args()...)
+		if output, err := m.cmdExec.Execute(ipfw, args...); err != nil {
+			return fmt.Errorf("ipfw rejected rule %d (%s): %v, output: %s", r.Number, r.String(), err, output)
+		}
+	}
+	loaded, err := m.Tables()
+	if err != nil {
+		return err
+	}
+	for _, t := range loaded {
+		if rs.Table(t.Name) != nil && !slices.Contains(owned.Tables, t.Name) {
+			return fmt.Errorf("table %s exists and was not created by fcom", t.Name)
+		}
+	}
+	if err := m.CheckForeign(rs, owned); err != nil {
+		return err
+	}
+
+	for _, n := range rs.NATs {
+		if err := m.ConfigureNAT(n); err != nil {
+			return err
+		}
+		owned.NATs = Own(owned.NATs, n.ID)
+	}
+	for _, p := range rs.Pipes {
+		if err := m.ConfigurePipe(p); err != nil {
+			return err
+		}
+		owned.Pipes = Own(owned.Pipes, p.ID)
+	}
+	for _, q := range rs.Queues {
+		if err := m.ConfigureQueue(q); err != nil {
+			return err
+		}
+		owned.Queues = Own(owned.Queues, q.ID)
+	}
+	if err := m.syncTables(rs.Tables, loaded, owned); err != nil {
+		return err
+	}
+	if err := m.loadRules(rs.Rules); err != nil {
+		return err
+	}
+	return m.removeDropped(rs, owned)
+}
+
+// CheckForeign returns an error when a NAT instance, pipe or queue of the
+// ruleset exists but was not created by fcom, so it is never reconfigured
+// or later deleted by fcom.
+func (m *Manager) CheckForeign(rs *pkgipfw.Ruleset, owned *Owned) error {
+	if err := checkForeign(m, "nat", rs.NATs, func(n pkgipfw.NAT) int { return n.ID }, owned.NATs, pkgipfw.ParseNATIDs); err != nil {
+		return err
+	}
+	if err := checkForeign(m, "pipe", rs.Pipes, func(p pkgipfw.Pipe) int { return p.ID }, owned.Pipes, pkgipfw.ParsePipeIDs); err != nil {
+		return err
+	}
+	return checkForeign(m, "queue", rs.Queues, func(q pkgipfw.Queue) int { return q.ID }, owned.Queues, pkgipfw.ParseQueueIDs)
+}
+
+// checkForeign lists the loaded objects of a kind, such as pipe, and
+// checks that the configured ones among them are owned
+func checkForeign[T any](m *Manager, kind string, configured []T, id func(T) int, owned []int, parse func(string) []int) error {
+	if len(configured) == 0 {
+		return nil
+	}
+	args := []string{kind, "show"}
+	if kind == "nat" {
+		args = append(args, "config")
+	}
+	output, err := m.cmdExec.Execute(ipfw, args...)
+	if err != nil {
+		return fmt.Errorf("failed to list %s: %v, output: %s", kind, err, output)
+	}
+	loaded := parse(output)
+	for _, c := range configured {
+		if n := id(c); slices.Contains(loaded, n) && !slices.Contains(owned, n) {
+			return fmt.Errorf("%s %d exists and was not created by fcom", kind, n)
+		}
+	}
+	return nil
+}
+
+// removeDropped deletes the owned queues, pipes, NAT instances and tables
+// the ruleset no longer has, once the rules using them are gone
+func (m *Manager) removeDropped(rs *pkgipfw.Ruleset, owned *Owned) error {
+	var err error
+	owned.Queues, err = dropStale(owned.Queues, rs.Queues, func(q pkgipfw.Queue) int { return q.ID }, m.DeleteQueue)
+	if err != nil {
+		return err
+	}
+	owned.Pipes, err = dropStale(owned.Pipes, rs.Pipes, func(p pkgipfw.Pipe) int { return p.ID }, m.DeletePipe)
+	if err != nil {
+		return err
+	}
+	owned.NATs, err = dropStale(owned.NATs, rs.NATs, func(n pkgipfw.NAT) int { return n.ID }, m.DeleteNAT)
+	if err != nil {
+		return err
+	}
+	owned.Tables, err = dropStale(owned.Tables, rs.Tables, func(t pkgipfw.Table) string { return t.Name }, m.DestroyTable)
+	return err
+}
+
+// dropStale removes the owned objects without a configured counterpart and
+// returns the ones left
+func dropStale[K comparable, T any](owned []K, configured []T, key func(T) K, remove func(K) error) ([]K, error) {
+	var kept []K
+	for i, k := range owned {
+		if slices.ContainsFunc(configured, func(t T) bool { return key(t) == k }) {
+			kept = append(kept, k)
+			continue
+		}
+		if err := remove(k); err != nil {
+			return append(kept, owned[i:]...), err
+		}
+	}
+	return kept, nil
+}
+
+// loadRules adds the rules to the disabled staging set and swaps it with
+// the fcom set
+func (m *Manager) loadRules(rules []pkgipfw.Rule) error {
+	staging := strconv.Itoa(stagingSet)
+	if err := m.run("set", "enable", strconv.Itoa(RuleSet), "disable", staging); err != nil {
+		return err
+	}
+	if err := m.run("delete", "set", staging); err != nil {
+		return err
+	}
+	for _, r := range rules {
+		args := append([]string{"add", strconv.Itoa(r.Number), "set", staging}, r.Args()...)
+		if err := m.run(args...); err != nil {
+			_ = m.run("delete", "set", staging)
+			return err
+		}
+	}
+	if err := m.run("set", "swap", strconv.Itoa(RuleSet), staging); err != nil {
+		return err
+	}
+	return m.run("delete", "set", staging)
+}
+
+// syncTables creates the missing tables and adds and removes entries so
+// each table holds exactly the configured addresses
+func (m *Manager) syncTables(tables, loaded []pkgipfw.Table, owned *Owned) error {
+	current := make(map[string]map[string]bool, len(loaded))
+	for _, t := range loaded {
+		addrs := make(map[string]bool, len(t.Entries))
+		for _, e := range t.Entries {
+			addrs[e.Address] = true
+		}
+		current[t.Name] = addrs
+	}
+
+	for _, t := range tables {
+		have, exists := current[t.Name]
+		if !exists {
+			if err := m.run("table", t.Name, "create", "type", "addr"); err != nil {
+				return err
+			}
+			owned.Tables = Own(owned.Tables, t.Name)
+		}
+		want := make(map[string]bool, len(t.Entries))
+		for _, e := range t.Entries {
+			addr := pkgipfw.CanonicalAddress(e.Address)
+			want[addr] = true
+			if !have[addr] {
+				if err := m.addEntry(t.Name, e); err != nil {
+					return err
+				}
+			}
+		}
+		for addr := range have {
+			if !want[addr] {
+				if err := m.run("table", t.Name, "delete", addr); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Rules returns the loaded rules in the fcom range with their counters
+func (m *Manager) Rules() ([]pkgipfw.Rule, error) {
+	output, err := m.cmdExec.Execute(ipfw, "-a", "list", fmt.Sprintf("%d-%d", m.first, m.last))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rules: %v, output: %s", err, output)
+	}
+	rules, err := pkgipfw.ParseRules(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ipfw rules: %w", err)
+	}
+	return rules, nil
+}
+
+// Tables returns every loaded table with its entries
+func (m *Manager) Tables() ([]pkgipfw.Table, error) {
+	output, err := m.cmdExec.Execute(ipfw, "table", "all", "list")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %v, output: %s", err, output)
+	}
+	tables, err := pkgipfw.ParseTables(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ipfw tables: %w", err)
+	}
+	return tables, nil
+}
+
+// AddTableEntries adds entries to a loaded table, creating it if needed
+func (m *Manager) AddTableEntries(table string, entries []pkgipfw.TableEntry) error {
+	if err := (pkgipfw.Table{Name: table, Entries: entries}).Validate(); err != nil {
+		return fmt.Errorf("invalid table: %w", err)
+	}
+	loaded, err := m.Tables()
+	if err != nil {
+		return err
+	}
+	exists := false
+	for _, t := range loaded {
+		exists = exists || t.Name == table
+	}
+	if !exists {
+		if err := m.run("table", table, "create", "type", "addr"); err != nil {
+			return err
+		}
+	}
+	for _, e := range entries {
+		if err := m.addEntry(table, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteTableEntries removes addresses from a loaded table
+func (m *Manager) DeleteTableEntries(table string, addrs []string) error {
+	entries := make([]pkgipfw.TableEntry, 0, len(addrs))
+	for _, a := range addrs {
+		entries = append(entries, pkgipfw.TableEntry{Address: a})
+	}
+	if err := (pkgipfw.Table{Name: table, Entries: entries}).Validate(); err != nil {
+		return fmt.Errorf("invalid table: %w", err)
+	}
+	for _, a := range addrs {
+		if err := m.run("table", table, "delete", a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DestroyTable removes a loaded table
+func (m *Manager) DestroyTable(table string) error {
+	if err := (pkgipfw.Table{Name: table}).Validate(); err != nil {
+		return fmt.Errorf("invalid table: %w", err)
+	}
+	return m.run("table", table, "destroy")
+}
+
+// ConfigureNAT creates or reconfigures a NAT instance
+func (m *Manager) ConfigureNAT(n pkgipfw.NAT) error {
+	if err := n.Validate(); err != nil {
+		return fmt.Errorf("invalid nat: %w", err)
+	}
+	return m.run(n.Args()...)
+}
+
+// DeleteNAT removes a NAT instance
+func (m *Manager) DeleteNAT(id int) error {
+	return m.run("nat", strconv.Itoa(id), "delete")
+}
+
+// ConfigurePipe creates or reconfigures a dummynet pipe
+func (m *Manager) ConfigurePipe(p pkgipfw.Pipe) error {
+	if err := p.Validate(); err != nil {
+		return fmt.Errorf("invalid pipe: %w", err)
+	}
+	return m.run(p.Args()...)
+}
+
+// DeletePipe removes a dummynet pipe
+func (m *Manager) DeletePipe(id int) error {
+	return m.run("pipe", strconv.Itoa(id), "delete")
+}
+
+// ConfigureQueue creates or reconfigures a dummynet queue
+func (m *Manager) ConfigureQueue(q pkgipfw.Queue) error {
+	if err := q.Validate(); err != nil {
+		return fmt.Errorf("invalid queue: %w", err)
+	}
+	return m.run(q.Args()...)
+}
+
+// DeleteQueue removes a dummynet queue
+func (m *Manager) DeleteQueue(id int) error {
+	return m.run("queue", strconv.Itoa(id), "delete")
+}
+
+func (m *Manager) addEntry(table string, e pkgipfw.TableEntry) error {
+	args := []string{"table", table, "add", e.Address}
+	if e.Value != "" {
+		args = append(args, e.Value)
+	}
+	return m.run(args...)
+}
+
+func (m *Manager) run(args ...string) error {
+	if output, err := m.cmdExec.Execute(ipfw, args...); err != nil {
+		return fmt.Errorf("ipfw %s failed: %v, output: %s", strings.Join(args, " "), err, output)
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+package ipfw
+
+import (
+	"FreeBSD-Command-manager/internal/network/bareos"
+	pkgipfw "FreeBSD-Command-manager/pkg/ipfw"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseRange(t *testing.T) {
+	first, last, err := ParseRange("")
+	if err != nil || first != DefaultFirstRule || last != DefaultLastRule {
+		t.Errorf("expected the default range, got %d-%d, %v", first, last, err)
+	}
+	first, last, err = ParseRange("500-999")
+	if err != nil || first != 500 || last != 999 {
+		t.Errorf("expected 500-999, got %d-%d, %v", first, last, err)
+	}
+	for _, s := range []string{"500", "999-500", "0-10", "100-65535", "a-b"} {
+		if _, _, err := ParseRange(s); err == nil {
+			t.Errorf("expected error for range %q", s)
+		}
+	}
+}
+
+func TestManager_Apply(t *testing.T) {
+	mockCmd := bareos.NewMockCommandExecutor()
+	mockCmd.SetOutput("ipfw table all list", `--- table(jails), set(0) ---
+10.0.0.5/32 0
+10.0.0.9/32 0
+`)
+	// nat 1 was created by fcom before
+	mockCmd.SetOutput("ipfw nat show config", "ipfw nat 1 config if em0\n")
+	manager := NewManager(mockCmd, 10000, 10999)
+	rs := &pkgipfw.Ruleset{
+		Tables: []pkgipfw.Table{
+			{Name: "jails", Entries: []pkgipfw.TableEntry{{Address: "10.0.0.5"}, {Address: "10.0.0.6"}}},
+			{Name: "admins", Entries: []pkgipfw.TableEntry{{Address: "192.0.2.0/24"}}},
+		},
+		NATs:   []pkgipfw.NAT{{ID: 1, Interface: "em0", SamePorts: true}},
+		Pipes:  []pkgipfw.Pipe{{ID: 1, Bandwidth: "10Mbit/s"}},
+		Queues: []pkgipfw.Queue{{ID: 1, Pipe: 1, Weight: 10}},
+		Rules: []pkgipfw.Rule{
+			{Action: pkgipfw.ActionNAT, Target: "1", From: "table(jails)", Direction: "out", Interface: "em0"},
+			{Action: pkgipfw.ActionQueue, Target: "1", From: "10.0.0.5"},
+		},
+	}
+	if err := manager.Renumber(rs); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// nat 2, queue 4 and table old were dropped from the ruleset
+	owned := &Owned{Tables: []string{"jails", "old"}, NATs: []int{1, 2}, Queues: []int{4}}
+	if err := manager.Apply(rs, owned); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{
+		"ipfw -n add 10000 nat 1 ip from table(jails) to any out via em0",
+		"ipfw -n add 10010 queue 1 ip from 10.0.0.5 to any",
+		"ipfw table all list",
+		"ipfw nat show config",
+		"ipfw pipe show",
+		"ipfw queue show",
+		"ipfw nat 1 config if em0 same_ports",
+		"ipfw pipe 1 config bw 10Mbit/s",
+		"ipfw queue 1 config pipe 1 weight 10",
+		"ipfw table jails add 10.0.0.6",
+		"ipfw table jails delete 10.0.0.9/32",
+		"ipfw table admins create type addr",
+		"ipfw table admins add 192.0.2.0/24",
+		"ipfw set enable 20 disable 21",
+		"ipfw delete set 21",
+		"ipfw add 10000 set 21 nat 1 ip from table(jails) to any out via em0",
+		"ipfw add 10010 set 21 queue 1 ip from 10.0.0.5 to any",
+		"ipfw set swap 20 21",
+		"ipfw delete set 21",
+		"ipfw queue 4 delete",
+		"ipfw nat 2 delete",
+		"ipfw table old destroy",
+	}
+	if commands := mockCmd.GetCommands(); !reflect.DeepEqual(commands, expected) {
+		t.Errorf("expected commands:\n%v\ngot:\n%v", expected, commands)
+	}
+	want := &Owned{Tables: []string{"jails", "admins"}, NATs: []int{1}, Pipes: []int{1}, Queues: []int{1}}
+	if !reflect.DeepEqual(owned, want) {
+		t.Errorf("expected owned %+v, got %+v", want, owned)
+	}
+}
+
+func TestManager_Apply_ForeignTable(t *testing.T) {
+	mockCmd := bareos.NewMockCommandExecutor()
+	mockCmd.SetOutput("ipfw table all list", "--- table(blocklist), set(0) ---\n198.51.100.7/32 0\n")
+	manager := NewManager(mockCmd, 10000, 10999)
+	rs := &pkgipfw.Ruleset{Tables: []pkgipfw.Table{{Name: "blocklist", Entries: []pkgipfw.TableEntry{{Address: "192.0.2.1"}}}}}
+	if err := manager.Apply(rs, &Owned{}); err == nil {
+		t.Fatal("expected error but got none")
+	}
+	if commands := mockCmd.GetCommands(); !reflect.DeepEqual(commands, []string{"ipfw table all list"}) {
+		t.Errorf("expected no changes, got %v", commands)
+	}
+}
+
+func TestManager_Apply_ForeignObjects(t *testing.T) {
+	mockCmd := bareos.NewMockCommandExecutor()
+	mockCmd.SetOutput("ipfw pipe show", "00001:  50.000 Mbit/s    0 ms burst 0\n")
+	manager := NewManager(mockCmd, 10000, 10999)
+	rs := &pkgipfw.Ruleset{
+		NATs:  []pkgipfw.NAT{{ID: 1, Interface: "em0"}},
+		Pipes: []pkgipfw.Pipe{{ID: 1, Bandwidth: "10Mbit/s"}},
+	}
+	if err := manager.Apply(rs, &Owned{}); err == nil {
+		t.Fatal("expected error but got none")
+	}
+	expected := []string{"ipfw table all list", "ipfw nat show config", "ipfw pipe show"}
+	if commands := mockCmd.GetCommands(); !reflect.DeepEqual(commands, expected) {
+		t.Errorf("expected no changes, got %v", commands)
+	}
+
+	// a pipe fcom created is reconfigured
+	if err := manager.CheckForeign(rs, &Owned{Pipes: []int{1}}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestManager_Apply_Rejected(t *testing.T) {
+	mockCmd := bareos.NewMockCommandExecutor()
+	mockCmd.SetError("ipfw -n add 10000 allow ip from any to any via em0 frag", errors.New("exit status 64"))
+	manager := NewManager(mockCmd, 10000, 10999)
+	rs := &pkgipfw.Ruleset{Rules: []pkgipfw.Rule{{Number: 10000, Action: pkgipfw.ActionAllow, Interface: "em0", Options: "frag"}}}
+	if err := manager.Apply(rs, &Owned{}); err == nil {
+		t.Fatal("expected error but got none")
+	}
+	if commands := mockCmd.GetCommands(); len(commands) != 1 {
+		t.Errorf("expected only the check, got %v", commands)
+	}
+
+	rs.Rules[0].Number = 20000
+	if err := manager.Apply(rs, &Owned{}); err == nil {
+		t.Error("expected error for a rule outside the range")
+	}
+	if err := manager.Renumber(&pkgipfw.Ruleset{Rules: make([]pkgipfw.Rule, 101)}); err == nil {
+		t.Error("expected error for rules that do not fit the range")
+	}
+}
+
+func TestManager_Apply_AddFails(t *testing.T) {
+	mockCmd := bareos.NewMockCommandExecutor()
+	mockCmd.SetError("ipfw add 10000 set 21 deny ip from any to any", errors.New("exit status 71"))
+	manager := NewManager(mockCmd, 10000, 10999)
+	rs := &pkgipfw.Ruleset{Rules: []pkgipfw.Rule{{Number: 10000, Action: pkgipfw.ActionDeny}}}
+	if err := manager.Apply(rs, &Owned{}); err == nil {
+		t.Fatal("expected error but got none")
+	}
+	commands := mockCmd.GetCommands()
+	if last := commands[len(commands)-1]; last != "ipfw delete set 21" {
+		t.Errorf("expected the staging set to be cleared, got %v", commands)
+	}
+}
+
+func TestManager_List(t *testing.T) {
+	mockCmd := bareos.NewMockCommandExecutor()
+	mockCmd.SetOutput("ipfw -a list 10000-10999", "10000     4      240 allow tcp from any to table(jails) 22 keep-state :default\n")
+	manager := NewManager(mockCmd, 10000, 10999)
+
+	rules, err := manager.Rules()
+	if err != nil || len(rules) != 1 || rules[0].ToPort != "22" || rules[0].Stats.Packets != 4 {
+		t.Errorf("unexpected rules %+v, %v", rules, err)
+	}
+}
+
+func TestManager_Objects(t *testing.T) {
+	mockCmd := bareos.NewMockCommandExecutor()
+	mockCmd.SetOutput("ipfw table all list", "--- table(jails), set(0) ---\n")
+	manager := NewManager(mockCmd, 10000, 10999)
+
+	if err := manager.AddTableEntries("jails", []pkgipfw.TableEntry{{Address: "10.0.0.5"}, {Address: "10.0.0.0/24", Value: "2"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := manager.AddTableEntries("web", []pkgipfw.TableEntry{{Address: "10.0.1.5"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := manager.DeleteTableEntries("jails", []string{"10.0.0.5"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := manager.DestroyTable("web"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := manager.ConfigurePipe(pkgipfw.Pipe{ID: 2, Bandwidth: "1Mbit/s", Mask: "dst-ip 0xffffffff"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := manager.DeletePipe(2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := manager.DeleteQueue(3); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := manager.DeleteNAT(1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{
+		"ipfw table all list",
+		"ipfw table jails add 10.0.0.5",
+		"ipfw table jails add 10.0.0.0/24 2",
+		"ipfw table all list",
+		"ipfw table web create type addr",
+		"ipfw table web add 10.0.1.5",
+		"ipfw table jails delete 10.0.0.5",
+		"ipfw table web destroy",
+		"ipfw pipe 2 config bw 1Mbit/s mask dst-ip 0xffffffff",
+		"ipfw pipe 2 delete",
+		"ipfw queue 3 delete",
+		"ipfw nat 1 delete",
+	}
+	if commands := mockCmd.GetCommands(); !reflect.DeepEqual(commands, expected) {
+		t.Errorf("expected commands:\n%v\ngot:\n%v", expected, commands)
+	}
+
+	for _, err := range []error{
+		manager.AddTableEntries("jails", []pkgipfw.TableEntry{{Address: "jail1"}}),
+		manager.DeleteTableEntries("bad name", []string{"10.0.0.5"}),
+		manager.ConfigureNAT(pkgipfw.NAT{ID: 1}),
+		manager.ConfigureQueue(pkgipfw.Queue{ID: 1}),
+	} {
+		if err == nil {
+			t.Error("expected error but got none")
+		}
+	}
+}
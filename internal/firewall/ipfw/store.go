@@ -0,0 +1,48 @@
+package ipfw
+
+import (
+	"FreeBSD-Command-manager/internal/jsonstore"
+	pkgipfw "FreeBSD-Command-manager/pkg/ipfw"
+	"slices"
+)
+
+// DefaultStateFile keeps the fcom ipfw configuration between runs
+const DefaultStateFile = "/var/db/fcom/ipfw.json"
+
+// State holds the configured fcom ruleset and the kernel objects fcom created
+type State struct {
+	Ruleset pkgipfw.Ruleset `json:"ruleset"`
+	Owned   Owned           `json:"owned"`
+}
+
+// Owned lists the tables, NAT instances, pipes and queues fcom created. Only
+// these are changed or removed; objects configured outside fcom are left
+// alone even when they share a name or number with the ruleset.
+type Owned struct {
+	Tables []string `json:"tables,omitempty"`
+	NATs   []int    `json:"nat,omitempty"`
+	Pipes  []int    `json:"pipes,omitempty"`
+	Queues []int    `json:"queues,omitempty"`
+}
+
+// Own adds v to the owned objects s
+func Own[K comparable](s []K, v K) []K {
+	if slices.Contains(s, v) {
+		return s
+	}
+	return append(s, v)
+}
+
+// Disown removes v from the owned objects s
+func Disown[K comparable](s []K, v K) []K {
+	return slices.DeleteFunc(s, func(x K) bool { return x == v })
+}
+
+// Store reads and writes the state file. Its lock keeps concurrent fcom
+// invocations from losing each other's rules.
+type Store = jsonstore.Store[State]
+
+// NewStore returns a store for the state file at path.
+func NewStore(path string) *Store {
+	return jsonstore.New[State](path)
+}
@@ -0,0 +1,43 @@
+package ipfw
+
+import (
+	pkgipfw "FreeBSD-Command-manager/pkg/ipfw"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "db", "ipfw.json"))
+
+	state, err := store.Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(state.Ruleset.Rules) != 0 {
+		t.Errorf("expected empty state, got %+v", state)
+	}
+
+	pipe := pkgipfw.Pipe{ID: 1, Bandwidth: "10Mbit/s"}
+	if err := store.Update(func(s *State) error {
+		s.Ruleset.Pipes = append(s.Ruleset.Pipes, pipe)
+		return nil
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := store.Update(func(s *State) error {
+		s.Ruleset.Pipes = nil
+		return errors.New("rejected")
+	}); err == nil {
+		t.Fatal("expected error but got none")
+	}
+
+	state, err = store.Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(state.Ruleset.Pipes, []pkgipfw.Pipe{pipe}) {
+		t.Errorf("unexpected pipes %+v", state.Ruleset.Pipes)
+	}
+}
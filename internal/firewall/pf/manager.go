@@ -0,0 +1,225 @@
+// Package pf manages the pf(4) anchors owned by fcom using pfctl(8). The
+// main pf.conf hands over to them with:
+//
+//	nat-anchor "fcom/*"
+//	rdr-anchor "fcom/*"
+//	anchor "fcom/*"
+package pf
+
+import (
+	"FreeBSD-Command-manager/internal/network/bareos"
+	"FreeBSD-Command-manager/pkg/atomicfile"
+	pkgpf "FreeBSD-Command-manager/pkg/pf"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	pfctl = "pfctl"
+	// RootAnchor is the anchor under which fcom loads its rulesets
+	RootAnchor = "fcom"
+	// DefaultAnchorDir keeps the rendered anchor files, which pf.conf can
+	// also load at boot with "load anchor"
+	DefaultAnchorDir = "/usr/local/etc/fcom/pf"
+)
+
+var (
+	anchorName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	tableName  = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+)
+
+// ManagerInterface defines the interface for pf operations
+type ManagerInterface interface {
+	Apply(anchor string, rs *pkgpf.Ruleset) (string, error)
+	Rules(anchor string) ([]pkgpf.Rule, error)
+	Translations(anchor string) ([]pkgpf.Translation, error)
+	Tables(anchor string) ([]pkgpf.Table, error)
+	TableAddresses(anchor, table string) ([]string, error)
+	AddTableAddresses(anchor, table string, addrs []string) error
+	DeleteTableAddresses(anchor, table string, addrs []string) error
+	KillTable(anchor, table string) error
+}
+
+// CommandExecutor defines the interface for executing system commands
+type CommandExecutor interface {
+	Execute(name string, args ...string) (string, error)
+}
+
+// Manager implements ManagerInterface using pfctl(8)
+type Manager struct {
+	cmdExec CommandExecutor
+	dir     string
+}
+
+// NewManager creates a new pf manager writing anchor files to dir
+func NewManager(cmdExec CommandExecutor, dir string) *Manager {
+	return &Manager{
+		cmdExec: cmdExec,
+		dir:     dir,
+	}
+}
+
+// DefaultManager returns the default pf manager instance
+func DefaultManager() ManagerInterface {
+	return NewManager(bareos.NewRealCommandExecutor(), DefaultAnchorDir)
+}
+
+// Anchor returns the path of the fcom anchor called name, e.g. "fcom/web"
+func Anchor(name string) (string, error) {
+	if !anchorName.MatchString(name) {
+		return "", fmt.Errorf("invalid anchor name %q", name)
+	}
+	return RootAnchor + "/" + name, nil
+}
+
+// Apply renders the ruleset into the anchor file, checks it with
+// "pfctl -nf" and loads it into the anchor, replacing its rules and tables.
+// It returns the path of the anchor file. A ruleset pfctl rejects leaves the
+// previous file and the loaded rules untouched.
+func (m *Manager) Apply(anchor string, rs *pkgpf.Ruleset) (string, error) {
+	name, err := anchorFile(anchor)
+	if err != nil {
+		return "", err
+	}
+	if err := rs.Validate(); err != nil {
+		return "", fmt.Errorf("invalid ruleset for anchor %s: %w", anchor, err)
+	}
+	if err := os.MkdirAll(m.dir, DirectoryPermissions); err != nil {
+		return "", fmt.Errorf("failed to create anchor directory: %w", err)
+	}
+	conf := fmt.Sprintf("# Anchor %s, generated by fcom; changes will be overwritten\n%s", anchor, pkgpf.Render(rs))
+
+	path := filepath.Join(m.dir, name)
+	check := func(tmp string) error {
+		if output, err := m.cmdExec.Execute(pfctl, "-a", anchor, "-nf", tmp); err != nil {
+			return fmt.Errorf("pfctl rejected the ruleset for anchor %s: %v, output: %s", anchor, err, output)
+		}
+		return nil
+	}
+	if err := atomicfile.WriteFileChecked(path, []byte(conf), FilePermissions, check); err != nil {
+		return "", err
+	}
+	if output, err := m.cmdExec.Execute(pfctl, "-a", anchor, "-f", path); err != nil {
+		return path, fmt.Errorf("failed to load anchor %s: %v, output: %s", anchor, err, output)
+	}
+	return path, nil
+}
+
+// Rules returns the loaded filter rules of an anchor with their counters
+func (m *Manager) Rules(anchor string) ([]pkgpf.Rule, error) {
+	output, err := m.show(anchor, "-vvsr")
+	if err != nil {
+		return nil, err
+	}
+	rules, err := pkgpf.ParseRules(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse rules of anchor %s: %w", anchor, err)
+	}
+	return rules, nil
+}
+
+// Translations returns the loaded nat and rdr rules of an anchor
+func (m *Manager) Translations(anchor string) ([]pkgpf.Translation, error) {
+	output, err := m.show(anchor, "-sn")
+	if err != nil {
+		return nil, err
+	}
+	translations, err := pkgpf.ParseTranslations(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse translation rules of anchor %s: %w", anchor, err)
+	}
+	return translations, nil
+}
+
+// Tables returns the loaded tables of an anchor
+func (m *Manager) Tables(anchor string) ([]pkgpf.Table, error) {
+	output, err := m.show(anchor, "-s", "Tables")
+	if err != nil {
+		return nil, err
+	}
+	return pkgpf.ParseTables(output), nil
+}
+
+// TableAddresses returns the addresses in a loaded table
+func (m *Manager) TableAddresses(anchor, table string) ([]string, error) {
+	output, err := m.table(anchor, table, "show")
+	if err != nil {
+		return nil, err
+	}
+	return pkgpf.ParseTableAddresses(output), nil
+}
+
+// AddTableAddresses adds addresses to a loaded table, creating it if needed
+func (m *Manager) AddTableAddresses(anchor, table string, addrs []string) error {
+	if err := validateAddresses(addrs); err != nil {
+		return err
+	}
+	_, err := m.table(anchor, table, "add", addrs...)
+	return err
+}
+
+// DeleteTableAddresses removes addresses from a loaded table
+func (m *Manager) DeleteTableAddresses(anchor, table string, addrs []string) error {
+	if err := validateAddresses(addrs); err != nil {
+		return err
+	}
+	_, err := m.table(anchor, table, "delete", addrs...)
+	return err
+}
+
+// KillTable removes a loaded table
+func (m *Manager) KillTable(anchor, table string) error {
+	_, err := m.table(anchor, table, "kill")
+	return err
+}
+
+func (m *Manager) show(anchor string, args ...string) (string, error) {
+	if _, err := anchorFile(anchor); err != nil {
+		return "", err
+	}
+	output, err := m.cmdExec.Execute(pfctl, append([]string{"-a", anchor}, args...)...)
+	if err != nil {
+		return "", fmt.Errorf("failed to list anchor %s: %v, output: %s", anchor, err, output)
+	}
+	return output, nil
+}
+
+func (m *Manager) table(anchor, table, command string, addrs ...string) (string, error) {
+	if _, err := anchorFile(anchor); err != nil {
+		return "", err
+	}
+	if !tableName.MatchString(table) {
+		return "", fmt.Errorf("invalid table name %q", table)
+	}
+	args := append([]string{"-a", anchor, "-t", table, "-T", command}, addrs...)
+	output, err := m.cmdExec.Execute(pfctl, args...)
+	if err != nil {
+		return "", fmt.Errorf("failed to %s table %s in anchor %s: %v, output: %s", command, table, anchor, err, output)
+	}
+	return output, nil
+}
+
+// anchorFile checks that anchor is owned by fcom and returns the name of its
+// file, e.g. "web.conf" for "fcom/web"
+func anchorFile(anchor string) (string, error) {
+	name, ok := strings.CutPrefix(anchor, RootAnchor+"/")
+	if !ok || !anchorName.MatchString(name) {
+		return "", fmt.Errorf("anchor %q is not an fcom anchor (%s/<name>)", anchor, RootAnchor)
+	}
+	return name + ".conf", nil
+}
+
+func validateAddresses(addrs []string) error {
+	if len(addrs) == 0 {
+		return fmt.Errorf("at least one address is required")
+	}
+	for _, a := range addrs {
+		if err := pkgpf.ValidateTableAddress(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package pf
+
+import (
+	"FreeBSD-Command-manager/internal/network/bareos"
+	pkgpf "FreeBSD-Command-manager/pkg/pf"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAnchor(t *testing.T) {
+	anchor, err := Anchor("web")
+	if err != nil || anchor != "fcom/web" {
+		t.Errorf("expected fcom/web, got %q, %v", anchor, err)
+	}
+	for _, name := range []string{"", "a/b", "a b", "*"} {
+		if _, err := Anchor(name); err == nil {
+			t.Errorf("expected error for anchor name %q", name)
+		}
+	}
+}
+
+// applyCommands returns the commands of Apply with the temporary file name
+// replaced by TMP
+func applyCommands(mockCmd *bareos.MockCommandExecutor) []string {
+	commands := mockCmd.GetCommands()
+	for i, c := range commands {
+		if f := strings.Fields(c); len(f) == 5 && f[3] == "-nf" {
+			f[4] = "TMP"
+			commands[i] = strings.Join(f, " ")
+		}
+	}
+	return commands
+}
+
+func TestManager_Apply(t *testing.T) {
+	dir := t.TempDir()
+	mockCmd := bareos.NewMockCommandExecutor()
+	manager := NewManager(mockCmd, dir)
+	rs := &pkgpf.Ruleset{
+		Tables: []pkgpf.Table{{Name: "jails", Persist: true, Addresses: []string{"10.0.0.2"}}},
+		Rules:  []pkgpf.Rule{{Action: pkgpf.ActionPass, Direction: "in", Proto: "tcp", To: "<jails>", ToPort: "22"}},
+	}
+
+	path, err := manager.Apply("fcom/web", rs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if path != filepath.Join(dir, "web.conf") {
+		t.Errorf("unexpected path %s", path)
+	}
+	expected := []string{"pfctl -a fcom/web -nf TMP", "pfctl -a fcom/web -f " + path}
+	if commands := applyCommands(mockCmd); !reflect.DeepEqual(commands, expected) {
+		t.Errorf("expected commands %v, got %v", expected, commands)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(string(data), pkgpf.Render(rs)) {
+		t.Errorf("unexpected anchor file:\n%s", data)
+	}
+}
+
+func TestManager_Apply_Rejected(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "web.conf")
+	if err := os.WriteFile(path, []byte("pass all\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	mockCmd := &rejectingExecutor{MockCommandExecutor: bareos.NewMockCommandExecutor()}
+	manager := NewManager(mockCmd, dir)
+	rs := &pkgpf.Ruleset{Rules: []pkgpf.Rule{{Action: pkgpf.ActionPass, To: "<missing>"}}}
+	if _, err := manager.Apply("fcom/web", rs); err == nil {
+		t.Fatal("expected error but got none")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "pass all\n" {
+		t.Errorf("anchor file changed to:\n%s", data)
+	}
+	if commands := applyCommands(mockCmd.MockCommandExecutor); !reflect.DeepEqual(commands, []string{"pfctl -a fcom/web -nf TMP"}) {
+		t.Errorf("expected only the check, got %v", commands)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("temporary file left behind: %v", entries)
+	}
+
+	if _, err := manager.Apply("other", &pkgpf.Ruleset{}); err == nil {
+		t.Error("expected error for an anchor fcom does not own")
+	}
+	if _, err := manager.Apply("fcom/web", &pkgpf.Ruleset{Rules: []pkgpf.Rule{{Action: "allow"}}}); err == nil {
+		t.Error("expected error for an invalid ruleset")
+	}
+}
+
+// rejectingExecutor fails "pfctl -nf" like pfctl does for a syntax error
+type rejectingExecutor struct {
+	*bareos.MockCommandExecutor
+}
+
+func (r *rejectingExecutor) Execute(name string, args ...string) (string, error) {
+	output, err := r.MockCommandExecutor.Execute(name, args...)
+	if len(args) > 2 && args[2] == "-nf" {
+		return args[3] + ":1: table <missing> is not defined\n", errors.New("exit status 1")
+	}
+	return output, err
+}
+
+func TestManager_List(t *testing.T) {
+	mockCmd := bareos.NewMockCommandExecutor()
+	mockCmd.SetOutput("pfctl -a fcom/web -vvsr", `@0 pass in proto tcp from any to <jails> port = ssh flags S/SA keep state
+  [ Evaluations: 10        Packets: 4         Bytes: 240         States: 1     ]
+  [ Inserted: uid 0 pid 4120 State Creations: 1     ]
+`)
+	mockCmd.SetOutput("pfctl -a fcom/web -sn", "nat on em0 inet from <jails> to any -> (em0)\n")
+	mockCmd.SetOutput("pfctl -a fcom/web -s Tables", "jails\n")
+	mockCmd.SetOutput("pfctl -a fcom/web -t jails -T show", "   10.0.0.2\n")
+	manager := NewManager(mockCmd, t.TempDir())
+
+	rules, err := manager.Rules("fcom/web")
+	if err != nil || len(rules) != 1 || rules[0].Stats == nil || rules[0].Stats.Packets != 4 {
+		t.Errorf("unexpected rules %+v, %v", rules, err)
+	}
+	translations, err := manager.Translations("fcom/web")
+	if err != nil || len(translations) != 1 || translations[0].Target != "(em0)" {
+		t.Errorf("unexpected translations %+v, %v", translations, err)
+	}
+	tables, err := manager.Tables("fcom/web")
+	if err != nil || !reflect.DeepEqual(tables, []pkgpf.Table{{Name: "jails"}}) {
+		t.Errorf("unexpected tables %+v, %v", tables, err)
+	}
+	addrs, err := manager.TableAddresses("fcom/web", "jails")
+	if err != nil || !reflect.DeepEqual(addrs, []string{"10.0.0.2"}) {
+		t.Errorf("unexpected addresses %v, %v", addrs, err)
+	}
+}
+
+func TestManager_TableAddresses(t *testing.T) {
+	mockCmd := bareos.NewMockCommandExecutor()
+	manager := NewManager(mockCmd, t.TempDir())
+
+	if err := manager.AddTableAddresses("fcom/web", "jails", []string{"10.0.0.2", "10.0.1.0/24"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := manager.DeleteTableAddresses("fcom/web", "jails", []string{"10.0.0.2"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := manager.KillTable("fcom/web", "jails"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{
+		"pfctl -a fcom/web -t jails -T add 10.0.0.2 10.0.1.0/24",
+		"pfctl -a fcom/web -t jails -T delete 10.0.0.2",
+		"pfctl -a fcom/web -t jails -T kill",
+	}
+	if commands := mockCmd.GetCommands(); !reflect.DeepEqual(commands, expected) {
+		t.Errorf("expected commands %v, got %v", expected, commands)
+	}
+
+	if err := manager.AddTableAddresses("fcom/web", "jails", []string{"-f /etc/passwd"}); err == nil {
+		t.Error("expected error for an invalid address")
+	}
+	if err := manager.AddTableAddresses("fcom/web", "jails", nil); err == nil {
+		t.Error("expected error without addresses")
+	}
+	if err := manager.KillTable("fcom/web", "a b"); err == nil {
+		t.Error("expected error for an invalid table name")
+	}
+}
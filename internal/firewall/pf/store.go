@@ -0,0 +1,53 @@
+package pf
+
+import (
+	"FreeBSD-Command-manager/internal/jsonstore"
+	pkgpf "FreeBSD-Command-manager/pkg/pf"
+	"sort"
+)
+
+const (
+	// DefaultStateFile keeps the rulesets of the fcom anchors between runs
+	DefaultStateFile = "/var/db/fcom/pf.json"
+	// FilePermissions is the mode of the anchor files
+	FilePermissions = 0o644
+	// DirectoryPermissions is the mode used when creating the anchor directory
+	DirectoryPermissions = 0o755
+)
+
+// State holds the configured ruleset of each fcom anchor
+type State struct {
+	Anchors map[string]*pkgpf.Ruleset `json:"anchors"`
+}
+
+// Ruleset returns the ruleset of anchor, creating an empty one if needed
+func (s *State) Ruleset(anchor string) *pkgpf.Ruleset {
+	if s.Anchors == nil {
+		s.Anchors = make(map[string]*pkgpf.Ruleset)
+	}
+	rs, ok := s.Anchors[anchor]
+	if !ok {
+		rs = &pkgpf.Ruleset{}
+		s.Anchors[anchor] = rs
+	}
+	return rs
+}
+
+// AnchorNames returns the configured anchors, sorted
+func (s *State) AnchorNames() []string {
+	names := make([]string, 0, len(s.Anchors))
+	for name := range s.Anchors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Store reads and writes the state file. Its lock keeps concurrent fcom
+// invocations from losing each other's rules.
+type Store = jsonstore.Store[State]
+
+// NewStore returns a store for the state file at path.
+func NewStore(path string) *Store {
+	return jsonstore.New[State](path)
+}
@@ -0,0 +1,48 @@
+package pf
+
+import (
+	pkgpf "FreeBSD-Command-manager/pkg/pf"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "db", "pf.json"))
+
+	state, err := store.Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(state.AnchorNames()) != 0 {
+		t.Errorf("expected empty state, got %+v", state)
+	}
+
+	rule := pkgpf.Rule{Action: pkgpf.ActionPass, Proto: "tcp", ToPort: "22"}
+	if err := store.Update(func(s *State) error {
+		rs := s.Ruleset("fcom/web")
+		rs.Rules = append(rs.Rules, rule)
+		s.Ruleset("fcom/db")
+		return nil
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := store.Update(func(s *State) error {
+		s.Ruleset("fcom/web").Rules = nil
+		return errors.New("rejected")
+	}); err == nil {
+		t.Fatal("expected error but got none")
+	}
+
+	state, err = store.Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if names := state.AnchorNames(); !reflect.DeepEqual(names, []string{"fcom/db", "fcom/web"}) {
+		t.Errorf("unexpected anchors %v", names)
+	}
+	if rules := state.Ruleset("fcom/web").Rules; !reflect.DeepEqual(rules, []pkgpf.Rule{rule}) {
+		t.Errorf("unexpected rules %+v", rules)
+	}
+}
@@ -0,0 +1,295 @@
+// Package ipam provides local IP address management: named address pools
+// with gateways, excluded ranges and reservations, and the allocations made
+// from them to jails and interfaces.
+package ipam
+
+import (
+	"fmt"
+	"net/netip"
+	"sort"
+	"strings"
+)
+
+// Pool is a named range of addresses that can be allocated to owners
+type Pool struct {
+	Name         string        `json:"name"`
+	CIDR         string        `json:"cidr"`
+	Gateway      string        `json:"gateway,omitempty"`
+	Exclude      []string      `json:"exclude,omitempty"` // single addresses or "first-last" ranges
+	Reservations []Reservation `json:"reservations,omitempty"`
+	Allocations  []Allocation  `json:"allocations,omitempty"`
+}
+
+// Reservation pins an address to an owner; it is handed out only to that owner
+type Reservation struct {
+	Owner   string `json:"owner"`
+	Address string `json:"address"`
+}
+
+// Allocation records an address given to an owner (a jail or interface name)
+type Allocation struct {
+	Owner     string `json:"owner"`
+	Address   string `json:"address"`
+	Interface string `json:"interface,omitempty"`
+}
+
+// State is the content of the IPAM state file
+type State struct {
+	Pools []Pool `json:"pools"`
+}
+
+// addrRange is an inclusive range of addresses
+type addrRange struct {
+	first, last netip.Addr
+}
+
+func (r addrRange) contains(addr netip.Addr) bool {
+	return r.first.Compare(addr) <= 0 && addr.Compare(r.last) <= 0
+}
+
+// Prefix returns the parsed CIDR of the pool.
+func (p *Pool) Prefix() (netip.Prefix, error) {
+	prefix, err := netip.ParsePrefix(p.CIDR)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid CIDR %q: %v", p.CIDR, err)
+	}
+	return prefix.Masked(), nil
+}
+
+// Find returns the allocation of owner, or nil.
+func (p *Pool) Find(owner string) *Allocation {
+	for i := range p.Allocations {
+		if p.Allocations[i].Owner == owner {
+			return &p.Allocations[i]
+		}
+	}
+	return nil
+}
+
+// Pool returns the named pool, or nil.
+func (s *State) Pool(name string) *Pool {
+	for i := range s.Pools {
+		if s.Pools[i].Name == name {
+			return &s.Pools[i]
+		}
+	}
+	return nil
+}
+
+// CreatePool validates and adds a new pool. Pools may not overlap.
+func (s *State) CreatePool(p Pool) error {
+	if p.Name == "" {
+		return fmt.Errorf("pool name is required")
+	}
+	if s.Pool(p.Name) != nil {
+		return fmt.Errorf("pool %s already exists", p.Name)
+	}
+	prefix, err := p.Prefix()
+	if err != nil {
+		return err
+	}
+	p.CIDR = prefix.String()
+	for _, other := range s.Pools {
+		if op, err := other.Prefix(); err == nil && op.Overlaps(prefix) {
+			return fmt.Errorf("pool %s overlaps pool %s (%s)", p.Name, other.Name, other.CIDR)
+		}
+	}
+	if p.Gateway != "" {
+		gw, err := netip.ParseAddr(p.Gateway)
+		if err != nil || !prefix.Contains(gw) {
+			return fmt.Errorf("gateway %s is not an address in %s", p.Gateway, p.CIDR)
+		}
+		p.Gateway = gw.String()
+	}
+	excluded, err := parseRanges(prefix, p.Exclude)
+	if err != nil {
+		return err
+	}
+	owners := make(map[string]bool)
+	for i, r := range p.Reservations {
+		addr, err := netip.ParseAddr(r.Address)
+		switch {
+		case r.Owner == "":
+			return fmt.Errorf("reservation of %s has no owner", r.Address)
+		case err != nil || !prefix.Contains(addr):
+			return fmt.Errorf("reservation %s is not an address in %s", r.Address, p.CIDR)
+		case owners[r.Owner]:
+			return fmt.Errorf("owner %s has more than one reservation", r.Owner)
+		case inRanges(excluded, addr) || addr.String() == p.Gateway:
+			return fmt.Errorf("reservation %s is excluded from pool %s", r.Address, p.Name)
+		}
+		owners[r.Owner] = true
+		p.Reservations[i].Address = addr.String()
+	}
+	p.Allocations = nil
+	s.Pools = append(s.Pools, p)
+	return nil
+}
+
+// DeletePool removes a pool that has no allocations left.
+func (s *State) DeletePool(name string) error {
+	for i, p := range s.Pools {
+		if p.Name != name {
+			continue
+		}
+		if len(p.Allocations) > 0 {
+			return fmt.Errorf("pool %s still has %d allocations", name, len(p.Allocations))
+		}
+		s.Pools = append(s.Pools[:i], s.Pools[i+1:]...)
+		return nil
+	}
+	return fmt.Errorf("pool %s not found", name)
+}
+
+// Allocate hands out an address of the pool to owner. An owner that already
+// holds an address gets the same one back; owners with a reservation get the
+// reserved address, everyone else the lowest free address.
+func (s *State) Allocate(pool, owner, iface string) (Allocation, error) {
+	if owner == "" {
+		return Allocation{}, fmt.Errorf("owner is required")
+	}
+	p := s.Pool(pool)
+	if p == nil {
+		return Allocation{}, fmt.Errorf("pool %s not found", pool)
+	}
+	if a := p.Find(owner); a != nil {
+		return *a, nil
+	}
+
+	var addr netip.Addr
+	for _, r := range p.Reservations {
+		if r.Owner == owner {
+			addr = netip.MustParseAddr(r.Address)
+		}
+	}
+	if !addr.IsValid() {
+		next, err := p.next()
+		if err != nil {
+			return Allocation{}, err
+		}
+		addr = next
+	}
+
+	a := Allocation{Owner: owner, Address: addr.String(), Interface: iface}
+	p.Allocations = append(p.Allocations, a)
+	sort.Slice(p.Allocations, func(i, j int) bool {
+		return netip.MustParseAddr(p.Allocations[i].Address).Less(netip.MustParseAddr(p.Allocations[j].Address))
+	})
+	return a, nil
+}
+
+// Release returns the address held by owner, or the given address, to the pool.
+func (s *State) Release(pool, owner, address string) (Allocation, error) {
+	p := s.Pool(pool)
+	if p == nil {
+		return Allocation{}, fmt.Errorf("pool %s not found", pool)
+	}
+	if owner == "" && address == "" {
+		return Allocation{}, fmt.Errorf("owner or address is required")
+	}
+	for i, a := range p.Allocations {
+		if (owner == "" || a.Owner == owner) && (address == "" || a.Address == canonical(address)) {
+			p.Allocations = append(p.Allocations[:i], p.Allocations[i+1:]...)
+			return a, nil
+		}
+	}
+	return Allocation{}, fmt.Errorf("no allocation in pool %s matches", pool)
+}
+
+// Next returns the address the next allocation without a reservation would
+// get, or an invalid address when the pool is exhausted.
+func (p *Pool) Next() netip.Addr {
+	addr, _ := p.next()
+	return addr
+}
+
+func (p *Pool) next() (netip.Addr, error) {
+	prefix, err := p.Prefix()
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	skip, err := parseRanges(prefix, p.Exclude)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	used := make(map[netip.Addr]bool)
+	for _, a := range p.Allocations {
+		used[netip.MustParseAddr(a.Address)] = true
+	}
+	for _, r := range p.Reservations {
+		used[netip.MustParseAddr(r.Address)] = true
+	}
+	if p.Gateway != "" {
+		used[netip.MustParseAddr(p.Gateway)] = true
+	}
+
+	first, last := prefix.Addr(), lastAddr(prefix)
+	// The network address is never assigned; neither is the IPv4 broadcast
+	// address unless the prefix is a point-to-point /31 or a /32.
+	if prefix.Bits() < prefix.Addr().BitLen()-1 {
+		first = first.Next()
+		if first.Is4() {
+			last = last.Prev()
+		}
+	}
+	for addr := first; addr.IsValid() && addr.Compare(last) <= 0; addr = addr.Next() {
+		if r, ok := rangeOf(skip, addr); ok {
+			addr = r.last
+			continue
+		}
+		if !used[addr] {
+			return addr, nil
+		}
+	}
+	return netip.Addr{}, fmt.Errorf("pool %s is exhausted", p.Name)
+}
+
+// parseRanges parses excluded addresses and "first-last" ranges inside prefix.
+func parseRanges(prefix netip.Prefix, specs []string) ([]addrRange, error) {
+	var ranges []addrRange
+	for _, spec := range specs {
+		firstStr, lastStr, isRange := strings.Cut(spec, "-")
+		if !isRange {
+			lastStr = firstStr
+		}
+		first, err1 := netip.ParseAddr(strings.TrimSpace(firstStr))
+		last, err2 := netip.ParseAddr(strings.TrimSpace(lastStr))
+		if err1 != nil || err2 != nil || !prefix.Contains(first) || !prefix.Contains(last) || last.Less(first) {
+			return nil, fmt.Errorf("invalid excluded range %q for %s", spec, prefix)
+		}
+		ranges = append(ranges, addrRange{first: first, last: last})
+	}
+	return ranges, nil
+}
+
+func rangeOf(ranges []addrRange, addr netip.Addr) (addrRange, bool) {
+	for _, r := range ranges {
+		if r.contains(addr) {
+			return r, true
+		}
+	}
+	return addrRange{}, false
+}
+
+func inRanges(ranges []addrRange, addr netip.Addr) bool {
+	_, ok := rangeOf(ranges, addr)
+	return ok
+}
+
+// lastAddr returns the highest address of prefix.
+func lastAddr(prefix netip.Prefix) netip.Addr {
+	b := prefix.Addr().AsSlice()
+	for i := prefix.Bits(); i < len(b)*8; i++ {
+		b[i/8] |= 0x80 >> (i % 8)
+	}
+	addr, _ := netip.AddrFromSlice(b)
+	return addr
+}
+
+// canonical returns the canonical text form of an address, or s unchanged.
+func canonical(s string) string {
+	if addr, err := netip.ParseAddr(s); err == nil {
+		return addr.String()
+	}
+	return s
+}
@@ -0,0 +1,172 @@
+package ipam
+
+import (
+	"FreeBSD-Command-manager/pkg/ifconfig"
+	"path/filepath"
+	"testing"
+)
+
+func testState(t *testing.T) *State {
+	t.Helper()
+	s := &State{}
+	err := s.CreatePool(Pool{
+		Name:         "jails",
+		CIDR:         "10.0.10.0/24",
+		Gateway:      "10.0.10.1",
+		Exclude:      []string{"10.0.10.2-10.0.10.9", "10.0.10.11"},
+		Reservations: []Reservation{{Owner: "db", Address: "10.0.10.10"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return s
+}
+
+func TestCreatePool_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		pool Pool
+	}{
+		{name: "missing name", pool: Pool{CIDR: "10.1.0.0/24"}},
+		{name: "duplicate name", pool: Pool{Name: "jails", CIDR: "10.1.0.0/24"}},
+		{name: "invalid cidr", pool: Pool{Name: "p", CIDR: "10.1.0.0/33"}},
+		{name: "overlapping", pool: Pool{Name: "p", CIDR: "10.0.0.0/16"}},
+		{name: "gateway outside", pool: Pool{Name: "p", CIDR: "10.1.0.0/24", Gateway: "10.2.0.1"}},
+		{name: "exclude outside", pool: Pool{Name: "p", CIDR: "10.1.0.0/24", Exclude: []string{"10.1.0.200-10.1.1.5"}}},
+		{name: "exclude reversed", pool: Pool{Name: "p", CIDR: "10.1.0.0/24", Exclude: []string{"10.1.0.20-10.1.0.10"}}},
+		{name: "reservation excluded", pool: Pool{Name: "p", CIDR: "10.1.0.0/24", Exclude: []string{"10.1.0.5"}, Reservations: []Reservation{{Owner: "a", Address: "10.1.0.5"}}}},
+		{name: "reservation without owner", pool: Pool{Name: "p", CIDR: "10.1.0.0/24", Reservations: []Reservation{{Address: "10.1.0.5"}}}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := testState(t).CreatePool(tc.pool); err == nil {
+				t.Error("expected error but got none")
+			}
+		})
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	s := testState(t)
+	tests := []struct {
+		owner    string
+		expected string
+	}{
+		{owner: "web", expected: "10.0.10.12"}, // .1 gateway, .2-.9 and .11 excluded, .10 reserved
+		{owner: "db", expected: "10.0.10.10"},  // reservation
+		{owner: "mail", expected: "10.0.10.13"},
+		{owner: "web", expected: "10.0.10.12"}, // same owner, same address
+	}
+	for _, tc := range tests {
+		a, err := s.Allocate("jails", tc.owner, "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if a.Address != tc.expected {
+			t.Errorf("Allocate(%s) = %s, want %s", tc.owner, a.Address, tc.expected)
+		}
+	}
+	if n := len(s.Pool("jails").Allocations); n != 3 {
+		t.Errorf("expected 3 allocations, got %d", n)
+	}
+
+	if _, err := s.Release("jails", "web", ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a, _ := s.Allocate("jails", "cache", "epair0b"); a.Address != "10.0.10.12" || a.Interface != "epair0b" {
+		t.Errorf("expected released address to be reused, got %+v", a)
+	}
+	if _, err := s.Release("jails", "", "10.0.10.99"); err == nil {
+		t.Error("expected error for unknown address")
+	}
+	if _, err := s.Allocate("missing", "web", ""); err == nil {
+		t.Error("expected error for unknown pool")
+	}
+}
+
+func TestAllocate_Exhausted(t *testing.T) {
+	s := &State{}
+	if err := s.CreatePool(Pool{Name: "p2p", CIDR: "192.0.2.0/30", Gateway: "192.0.2.1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a, err := s.Allocate("p2p", "a", ""); err != nil || a.Address != "192.0.2.2" {
+		t.Fatalf("unexpected allocation %+v, %v", a, err)
+	}
+	if _, err := s.Allocate("p2p", "b", ""); err == nil {
+		t.Error("expected pool to be exhausted")
+	}
+	if err := s.DeletePool("p2p"); err == nil {
+		t.Error("expected error deleting a pool with allocations")
+	}
+}
+
+func TestAllocate_IPv6(t *testing.T) {
+	s := &State{}
+	if err := s.CreatePool(Pool{Name: "v6", CIDR: "2001:db8:10::/64", Exclude: []string{"2001:db8:10::1-2001:db8:10::ff"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a, err := s.Allocate("v6", "web", ""); err != nil || a.Address != "2001:db8:10::100" {
+		t.Errorf("unexpected allocation %+v, %v", a, err)
+	}
+}
+
+func TestStore(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "db", "ipam.json"))
+	state, err := store.Load()
+	if err != nil || len(state.Pools) != 0 {
+		t.Fatalf("expected empty state, got %+v, %v", state, err)
+	}
+	err = store.Update(func(s *State) error {
+		if err := s.CreatePool(Pool{Name: "lan", CIDR: "192.168.1.0/24"}); err != nil {
+			return err
+		}
+		_, err := s.Allocate("lan", "em1", "em1")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// A failing update must not be written
+	_ = store.Update(func(s *State) error {
+		_ = s.DeletePool("lan")
+		return s.CreatePool(Pool{Name: "lan"})
+	})
+
+	state, err = store.Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p := state.Pool("lan")
+	if p == nil || len(p.Allocations) != 1 || p.Allocations[0].Address != "192.168.1.1" {
+		t.Errorf("unexpected state: %+v", state)
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	s := testState(t)
+	for _, owner := range []string{"web", "db"} {
+		if _, err := s.Allocate("jails", owner, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	infos := ifconfig.ParseIfconfig(`bridge0: flags=8843<UP,BROADCAST,RUNNING,SIMPLEX,MULTICAST> metric 0 mtu 1500
+	inet 10.0.10.1 netmask 0xffffff00 broadcast 10.0.10.255
+	inet 10.0.10.12 netmask 0xffffffff broadcast 10.0.10.12
+	inet 10.0.10.50 netmask 0xffffffff broadcast 10.0.10.50
+	inet 192.168.1.1 netmask 0xffffff00 broadcast 192.168.1.255
+`)
+
+	findings := Reconcile(s, infos)
+	expected := []Finding{
+		{Pool: "jails", Address: "10.0.10.10", Owner: "db", Problem: NotConfigured},
+		{Pool: "jails", Address: "10.0.10.50", Interface: "bridge0", Problem: NotAllocated},
+	}
+	if len(findings) != len(expected) {
+		t.Fatalf("expected %d findings, got %+v", len(expected), findings)
+	}
+	for i, f := range expected {
+		if findings[i] != f {
+			t.Errorf("expected finding %+v, got %+v", f, findings[i])
+		}
+	}
+}
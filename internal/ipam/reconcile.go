@@ -0,0 +1,66 @@
+package ipam
+
+import (
+	"FreeBSD-Command-manager/pkg/ifconfig"
+	"net/netip"
+)
+
+// Reconcile problems.
+const (
+	// NotConfigured is an allocation whose address is on no interface
+	NotConfigured = "not_configured"
+	// NotAllocated is a configured address inside a pool without an allocation
+	NotAllocated = "not_allocated"
+)
+
+// Finding is a mismatch between the IPAM state and the configured addresses
+type Finding struct {
+	Pool      string `json:"pool"`
+	Address   string `json:"address"`
+	Owner     string `json:"owner,omitempty"`
+	Interface string `json:"interface,omitempty"`
+	Problem   string `json:"problem"`
+}
+
+// Reconcile compares the allocations of all pools with the addresses
+// configured on infos. Pool gateways are expected to be configured locally
+// and are not reported.
+func Reconcile(state *State, infos []ifconfig.Info) []Finding {
+	configured := make(map[netip.Addr]string)
+	for _, info := range infos {
+		for _, a := range info.Addresses {
+			if addr, err := netip.ParseAddr(a.Address); err == nil {
+				configured[addr] = info.Name
+			}
+		}
+	}
+
+	var findings []Finding
+	for _, p := range state.Pools {
+		prefix, err := p.Prefix()
+		if err != nil {
+			continue
+		}
+		allocated := make(map[netip.Addr]bool)
+		for _, a := range p.Allocations {
+			addr, err := netip.ParseAddr(a.Address)
+			if err != nil {
+				continue
+			}
+			allocated[addr] = true
+			if _, ok := configured[addr]; !ok {
+				findings = append(findings, Finding{Pool: p.Name, Address: a.Address, Owner: a.Owner, Interface: a.Interface, Problem: NotConfigured})
+			}
+		}
+		for _, info := range infos {
+			for _, a := range info.Addresses {
+				addr, err := netip.ParseAddr(a.Address)
+				if err != nil || !prefix.Contains(addr) || allocated[addr] || a.Address == p.Gateway {
+					continue
+				}
+				findings = append(findings, Finding{Pool: p.Name, Address: a.Address, Interface: info.Name, Problem: NotAllocated})
+			}
+		}
+	}
+	return findings
+}
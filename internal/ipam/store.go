@@ -0,0 +1,15 @@
+package ipam
+
+import "FreeBSD-Command-manager/internal/jsonstore"
+
+// DefaultStateFile is where the IPAM state is kept
+const DefaultStateFile = "/var/db/fcom/ipam.json"
+
+// Store reads and writes the IPAM state file. Its lock keeps concurrent fcom
+// invocations from handing out the same address twice.
+type Store = jsonstore.Store[State]
+
+// NewStore returns a store for the state file at path.
+func NewStore(path string) *Store {
+	return jsonstore.New[State](path)
+}
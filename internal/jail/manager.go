@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"strconv"
 )
 
 const (
@@ -20,6 +21,7 @@ type Config struct {
 	Path  string
 	IP    string
 	Mount string
+	FIB   int // exec.fib, 0 uses the default FIB
 }
 
 // Manager defines the interface for jail operations
@@ -64,6 +66,9 @@ func (j *FreeBSDJailManager) Create(cfg Config) error {
 	if cfg.Name == "" || cfg.Path == "" || cfg.IP == "" {
 		return errors.New("missing required parameters (name, path, ip)")
 	}
+	if cfg.FIB < 0 {
+		return fmt.Errorf("invalid FIB: %d", cfg.FIB)
+	}
 
 	// Ensure jail path exists
 	if err := j.fsManager.EnsurePath(cfg.Path); err != nil {
@@ -78,12 +83,16 @@ func (j *FreeBSDJailManager) Create(cfg Config) error {
 	}
 
 	// Create jail
-	_, err := j.cmdExec.Execute("jail", "-c",
-		"name="+cfg.Name,
-		"path="+cfg.Path,
-		"host.hostname="+cfg.Name,
-		"ip4.addr="+cfg.IP,
-		"command=/bin/sh")
+	args := []string{"-c",
+		"name=" + cfg.Name,
+		"path=" + cfg.Path,
+		"host.hostname=" + cfg.Name,
+		"ip4.addr=" + cfg.IP}
+	if cfg.FIB > 0 {
+		args = append(args, "exec.fib="+strconv.Itoa(cfg.FIB))
+	}
+	args = append(args, "command=/bin/sh")
+	_, err := j.cmdExec.Execute("jail", args...)
 	if err != nil {
 		return fmt.Errorf("failed to create jail: %v", err)
 	}
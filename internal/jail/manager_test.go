@@ -257,3 +257,31 @@ func TestNewTestManager(t *testing.T) {
 		t.Errorf("NewTestManager Destroy failed: %v", err)
 	}
 }
+
+func TestFreeBSDJailManager_CreateFIB(t *testing.T) {
+	mockCmd := &MockCommandExecutor{}
+	manager := NewFreeBSDJailManager(&MockFileSystemManager{}, mockCmd)
+
+	err := manager.Create(Config{Name: "mgmt", Path: "/jails/mgmt", IP: "10.1.0.5", FIB: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	found := false
+	for _, arg := range mockCmd.ExecuteArgs {
+		if arg == "exec.fib=1" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected exec.fib=1 in args, got %v", mockCmd.ExecuteArgs)
+	}
+
+	mockFS := &MockFileSystemManager{}
+	manager = NewFreeBSDJailManager(mockFS, mockCmd)
+	if err := manager.Create(Config{Name: "mgmt", Path: "/jails/mgmt", IP: "10.1.0.5", FIB: -1}); err == nil {
+		t.Error("expected error for negative FIB")
+	}
+	if mockFS.EnsurePathCalled {
+		t.Error("expected no jail path to be created for an invalid FIB")
+	}
+}
@@ -0,0 +1,102 @@
+// Package jsonstore keeps state in a JSON file shared by concurrent fcom
+// invocations. Every access holds a flock(2) on "<path>.lock": readers share
+// it, and updates hold it exclusively from reading the state until the new
+// state has replaced the file.
+package jsonstore
+
+import (
+	"FreeBSD-Command-manager/pkg/atomicfile"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+const (
+	// FilePermissions is the mode of the state and lock files
+	FilePermissions = 0o644
+	// DirectoryPermissions is the mode used when creating the state directory
+	DirectoryPermissions = 0o755
+)
+
+// Store reads and writes a state of type T in the file at its path
+type Store[T any] struct {
+	path string
+}
+
+// New returns a store for the state file at path
+func New[T any](path string) *Store[T] {
+	return &Store[T]{path: path}
+}
+
+// Path returns the path of the state file
+func (s *Store[T]) Path() string {
+	return s.path
+}
+
+// Load returns the current state. A missing state file is a zero state.
+func (s *Store[T]) Load() (*T, error) {
+	unlock, err := s.lock(syscall.LOCK_SH)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	return s.read()
+}
+
+// Update loads the state, passes it to fn and writes it back if fn succeeds,
+// all under an exclusive lock.
+func (s *Store[T]) Update(fn func(*T) error) error {
+	unlock, err := s.lock(syscall.LOCK_EX)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	state, err := s.read()
+	if err != nil {
+		return err
+	}
+	if err := fn(state); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+	return atomicfile.WriteFile(s.path, append(data, '\n'), FilePermissions)
+}
+
+func (s *Store[T]) lock(how int) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(s.path), DirectoryPermissions); err != nil {
+		return nil, fmt.Errorf("failed to create state directory: %w", err)
+	}
+	f, err := os.OpenFile(s.path+".lock", os.O_RDWR|os.O_CREATE, FilePermissions) //nolint:gosec // path is provided by the operator
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	if err := syscall.Flock(int(f.Fd()), how); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("failed to lock %s: %w", s.path, err)
+	}
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		_ = f.Close()
+	}, nil
+}
+
+func (s *Store[T]) read() (*T, error) {
+	state := new(T)
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", s.path, err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", s.path, err)
+	}
+	return state, nil
+}
@@ -0,0 +1,49 @@
+package jsonstore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type counter struct {
+	Count int `json:"count"`
+}
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db", "state.json")
+	store := New[counter](path)
+
+	state, err := store.Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if state.Count != 0 {
+		t.Errorf("expected zero state, got %+v", state)
+	}
+
+	if err := store.Update(func(c *counter) error {
+		c.Count++
+		return nil
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := store.Update(func(c *counter) error {
+		c.Count = 10
+		return errors.New("rejected")
+	}); err == nil {
+		t.Fatal("expected error but got none")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "{\n  \"count\": 1\n}\n" {
+		t.Errorf("unexpected state file %q", data)
+	}
+	if _, err := os.Stat(path + ".lock"); err != nil {
+		t.Errorf("expected lock file: %v", err)
+	}
+}
@@ -0,0 +1,198 @@
+// Package backend puts the native network stack, VALE, Open vSwitch and VPP
+// behind one interface so the generic network commands can target any of
+// them. Each backend implements the operations its tool supports and reports
+// them through Capabilities; the others fail with an UnsupportedError.
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"sort"
+	"strings"
+)
+
+// Names of the built-in backends
+const (
+	Bareos  = "bareos"
+	VALE    = "vale"
+	OVS     = "ovs"
+	VPP     = "vpp"
+	Default = Bareos
+)
+
+// Operation names an operation a backend may support
+type Operation string
+
+// Operations of the Backend interface
+const (
+	OpCreateInterface Operation = "create-interface"
+	OpDeleteInterface Operation = "delete-interface"
+	OpCreateBridge    Operation = "create-bridge"
+	OpDeleteBridge    Operation = "delete-bridge"
+	OpAddMember       Operation = "add-interface-to-bridge"
+	OpRemoveMember    Operation = "remove-interface-from-bridge"
+	OpList            Operation = "list"
+	OpSetState        Operation = "set-state"
+	OpSetMTU          Operation = "set-mtu"
+	OpAddIP           Operation = "add-ip"
+	OpDeleteIP        Operation = "delete-ip"
+)
+
+// Operations without a Backend method. Only the native backend has them, and
+// the commands using them call the bareos manager directly once the
+// selected backend reports them.
+const (
+	OpVLAN          Operation = "vlan"
+	OpTunnel        Operation = "tunnel" // gre, gif and vxlan
+	OpSetAttributes Operation = "set-attributes"
+	OpIPOptions     Operation = "ip-options" // aliases and IPv6 address flags
+
+	OpLAGG             Operation = "lagg"
+	OpEpair            Operation = "epair"
+	OpTap              Operation = "tap"
+	OpBridgeAttributes Operation = "bridge-attributes" // STP, learning and span ports
+	OpCARP             Operation = "carp"
+	OpWireGuard        Operation = "wireguard"
+	OpNeighbors        Operation = "neighbors" // ARP and NDP tables
+	OpRoutes           Operation = "routes"
+	OpStats            Operation = "stats"
+	OpDHCP             Operation = "dhcp" // dhclient and router advertisements
+	OpPersist          Operation = "persist"
+	OpTopology         Operation = "topology"
+)
+
+// ErrUnsupported is matched by every UnsupportedError
+var ErrUnsupported = errors.New("operation not supported")
+
+// UnsupportedError is returned for an operation the backend does not support
+type UnsupportedError struct {
+	Backend   string
+	Operation Operation
+	Hint      string // what to do instead, if anything
+}
+
+func (e *UnsupportedError) Error() string {
+	msg := fmt.Sprintf("backend %s does not support %s", e.Backend, e.Operation)
+	if e.Hint != "" {
+		msg += ": " + e.Hint
+	}
+	return msg
+}
+
+// Is makes errors.Is(err, ErrUnsupported) match
+func (e *UnsupportedError) Is(target error) bool {
+	return target == ErrUnsupported
+}
+
+// Interface is the backend neutral view of an interface, bridge or switch
+type Interface struct {
+	Name      string   `json:"name"`
+	Bridge    bool     `json:"bridge,omitempty"` // bridge, switch or bridge domain
+	State     string   `json:"state,omitempty"`  // up or down
+	MTU       int      `json:"mtu,omitempty"`
+	Addresses []string `json:"addresses,omitempty"`
+	Members   []string `json:"members,omitempty"` // bridge members
+}
+
+// Backend is the subset of network operations shared by the backends
+type Backend interface {
+	Name() string
+	Capabilities() []Operation
+	CreateInterface(name string) error
+	DeleteInterface(name string) error
+	CreateBridge(name string) error
+	DeleteBridge(name string) error
+	AddInterfaceToBridge(bridgeName, interfaceName string) error
+	RemoveInterfaceFromBridge(bridgeName, interfaceName string) error
+	List() ([]Interface, error)
+	SetState(name string, up bool) error
+	SetMTU(name string, mtu int) error
+	AddIP(name string, prefix netip.Prefix) error
+	DeleteIP(name string, prefix netip.Prefix) error
+}
+
+// Factory creates a backend
+type Factory func() Backend
+
+var registry = map[string]Factory{ //nolint:gochecknoglobals
+	Bareos: func() Backend { return DefaultBareos() },
+	VALE:   func() Backend { return DefaultVALE() },
+	OVS:    func() Backend { return DefaultOVS() },
+	VPP:    func() Backend { return DefaultVPP() },
+}
+
+// Register adds a backend, replacing any registered under the same name
+func Register(name string, f Factory) {
+	registry[name] = f
+}
+
+// Names returns the registered backend names, sorted
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New creates the backend registered under name, or the default backend if
+// name is empty.
+func New(name string) (Backend, error) {
+	if name == "" {
+		name = Default
+	}
+	f, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown network backend %q: must be one of %s", name, strings.Join(Names(), ", "))
+	}
+	return f(), nil
+}
+
+// Supports reports whether b supports op
+func Supports(b Backend, op Operation) bool {
+	for _, c := range b.Capabilities() {
+		if c == op {
+			return true
+		}
+	}
+	return false
+}
+
+// Require returns an UnsupportedError if b does not support op
+func Require(b Backend, op Operation) error {
+	if Supports(b, op) {
+		return nil
+	}
+	return &UnsupportedError{Backend: b.Name(), Operation: op}
+}
+
+// unsupported implements every Backend operation by failing. Backends embed
+// it and override the operations they support.
+type unsupported struct {
+	name  string
+	hints map[Operation]string
+}
+
+func (u unsupported) Name() string { return u.name }
+
+func (u unsupported) fail(op Operation) error {
+	return &UnsupportedError{Backend: u.name, Operation: op, Hint: u.hints[op]}
+}
+
+func (u unsupported) CreateInterface(string) error { return u.fail(OpCreateInterface) }
+func (u unsupported) DeleteInterface(string) error { return u.fail(OpDeleteInterface) }
+func (u unsupported) CreateBridge(string) error    { return u.fail(OpCreateBridge) }
+func (u unsupported) DeleteBridge(string) error    { return u.fail(OpDeleteBridge) }
+func (u unsupported) AddInterfaceToBridge(string, string) error {
+	return u.fail(OpAddMember)
+}
+func (u unsupported) RemoveInterfaceFromBridge(string, string) error {
+	return u.fail(OpRemoveMember)
+}
+func (u unsupported) List() ([]Interface, error)          { return nil, u.fail(OpList) }
+func (u unsupported) SetState(string, bool) error         { return u.fail(OpSetState) }
+func (u unsupported) SetMTU(string, int) error            { return u.fail(OpSetMTU) }
+func (u unsupported) AddIP(string, netip.Prefix) error    { return u.fail(OpAddIP) }
+func (u unsupported) DeleteIP(string, netip.Prefix) error { return u.fail(OpDeleteIP) }
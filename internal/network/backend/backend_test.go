@@ -0,0 +1,178 @@
+package backend
+
+import (
+	"FreeBSD-Command-manager/internal/network/bareos"
+	"FreeBSD-Command-manager/internal/network/ovs"
+	"FreeBSD-Command-manager/internal/network/vale"
+	"FreeBSD-Command-manager/internal/network/vpp"
+	"errors"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func mockBackends(mockCmd *bareos.MockCommandExecutor) []Backend {
+	return []Backend{
+		NewBareos(bareos.NewManager(mockCmd)),
+		NewVALE(vale.NewManager(mockCmd)),
+		NewOVS(ovs.NewManager(mockCmd)),
+		NewVPP(vpp.NewManager(mockCmd)),
+	}
+}
+
+func TestNew(t *testing.T) {
+	if names := Names(); !reflect.DeepEqual(names, []string{Bareos, OVS, VALE, VPP}) {
+		t.Errorf("unexpected backends: %v", names)
+	}
+	b, err := New("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b.Name() != Default {
+		t.Errorf("expected default backend %s, got %s", Default, b.Name())
+	}
+	if _, err := New("netgraph"); err == nil {
+		t.Error("expected error for an unknown backend")
+	}
+}
+
+// TestCapabilities checks that exactly the operations a backend does not
+// report fail with an UnsupportedError.
+func TestCapabilities(t *testing.T) {
+	prefix := netip.MustParsePrefix("10.0.0.1/24")
+	for _, b := range mockBackends(bareos.NewMockCommandExecutor()) {
+		calls := map[Operation]func() error{
+			OpCreateInterface: func() error { return b.CreateInterface("tap0") },
+			OpDeleteInterface: func() error { return b.DeleteInterface("tap0") },
+			OpCreateBridge:    func() error { return b.CreateBridge("10") },
+			OpDeleteBridge:    func() error { return b.DeleteBridge("10") },
+			OpAddMember:       func() error { return b.AddInterfaceToBridge("10", "tap0") },
+			OpRemoveMember:    func() error { return b.RemoveInterfaceFromBridge("10", "tap0") },
+			OpList:            func() error { _, err := b.List(); return err },
+			OpSetState:        func() error { return b.SetState("tap0", true) },
+			OpSetMTU:          func() error { return b.SetMTU("tap0", 1500) },
+			OpAddIP:           func() error { return b.AddIP("tap0", prefix) },
+			OpDeleteIP:        func() error { return b.DeleteIP("tap0", prefix) },
+		}
+		for op, call := range calls {
+			err := call()
+			var unsupported *UnsupportedError
+			if got := errors.As(err, &unsupported); got == Supports(b, op) {
+				t.Errorf("%s %s: supported %v, got error %v", b.Name(), op, Supports(b, op), err)
+				continue
+			}
+			if unsupported != nil && (unsupported.Backend != b.Name() || unsupported.Operation != op || !errors.Is(err, ErrUnsupported)) {
+				t.Errorf("%s %s: unexpected error %#v", b.Name(), op, unsupported)
+			}
+		}
+	}
+}
+
+func TestRequire(t *testing.T) {
+	b := NewOVS(ovs.NewManager(bareos.NewMockCommandExecutor()))
+	if err := Require(b, OpCreateBridge); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	err := Require(b, OpVLAN)
+	if !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("expected unsupported error, got %v", err)
+	}
+	if err.Error() != "backend ovs does not support vlan" {
+		t.Errorf("unexpected message: %s", err)
+	}
+	err = b.CreateInterface("vnet0")
+	if want := `backend ovs does not support create-interface: add it to a bridge with "fcom network ovs port add"`; err == nil || err.Error() != want {
+		t.Errorf("expected %q, got %v", want, err)
+	}
+}
+
+func TestVPP_CreateInterface(t *testing.T) {
+	mockCmd := bareos.NewMockCommandExecutor()
+	mockCmd.SetOutput("vppctl create host-interface name eth0", "host-eth0\n")
+	mockCmd.SetOutput("vppctl create tap id 3", "tap3\n")
+	mockCmd.SetOutput("vppctl create interface memif id 0 socket-id 1 slave", "memif1/0\n")
+	b := NewVPP(vpp.NewManager(mockCmd))
+
+	for _, name := range []string{"host-eth0", "tap3", "memif1/0"} {
+		if err := b.CreateInterface(name); err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		}
+	}
+	expected := []string{
+		"vppctl create host-interface name eth0",
+		"vppctl create tap id 3",
+		"vppctl create interface memif id 0 socket-id 1 slave",
+	}
+	if commands := mockCmd.GetCommands(); !reflect.DeepEqual(commands, expected) {
+		t.Errorf("expected commands %v, got %v", expected, commands)
+	}
+	if err := b.CreateInterface("GigabitEthernet0/8/0"); err == nil {
+		t.Error("expected error for an interface VPP cannot create")
+	}
+	if err := b.CreateBridge("br0"); err == nil {
+		t.Error("expected error for a non-numeric bridge domain")
+	}
+}
+
+func TestList(t *testing.T) {
+	mockCmd := bareos.NewMockCommandExecutor()
+	mockCmd.SetOutput("ifconfig", `bridge0: flags=1008843<UP,BROADCAST,RUNNING,SIMPLEX,MULTICAST,LOWER_UP> metric 0 mtu 1500
+	ether 58:9c:fc:10:ff:b6
+	inet 10.0.0.1 netmask 0xffffff00 broadcast 10.0.0.255
+	id 00:00:00:00:00:00 priority 32768 hellotime 2 fwddelay 15
+	maxage 20 holdcnt 6 proto rstp maxaddr 2000 timeout 1200
+	root id 00:00:00:00:00:00 priority 32768 ifcost 0 port 0
+	member: tap0 flags=143<LEARNING,DISCOVER,AUTOEDGE,AUTOPTP>
+	        ifmaxaddr 0 port 4 priority 128 path cost 2000000
+`)
+	mockCmd.SetOutput("valectl", "vale0:vp0 bridge:0 port:0\nvale0:em0 bridge:0 port:1\nvale0:em0^ bridge:0 port:2\n")
+
+	ifaces, err := NewBareos(bareos.NewManager(mockCmd)).List()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []Interface{{Name: "bridge0", Bridge: true, State: "up", MTU: 1500, Addresses: []string{"10.0.0.1/24"}, Members: []string{"tap0"}}}
+	if !reflect.DeepEqual(ifaces, expected) {
+		t.Errorf("expected %+v, got %+v", expected, ifaces)
+	}
+
+	ifaces, err = NewVALE(vale.NewManager(mockCmd)).List()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected = []Interface{{Name: "vale0", Bridge: true, Members: []string{"vp0", "em0", "em0^"}}}
+	if !reflect.DeepEqual(ifaces, expected) {
+		t.Errorf("expected %+v, got %+v", expected, ifaces)
+	}
+}
+
+func TestVPP_RemoveInterfaceFromBridge(t *testing.T) {
+	mockCmd := bareos.NewMockCommandExecutor()
+	header := "  BD-ID   Index   BSN  Age(min)  Learning  U-Forwrd   UU-Flood   Flooding  ARP-Term  arp-ufwd Learn-co Learn-li   BVI-Intf\n"
+	domain := "   10       1      0     off        on        on       flood        on       off       off        1    16777216      N/A\n"
+	mockCmd.SetOutput("vppctl show bridge-domain", header+domain)
+	mockCmd.SetOutput("vppctl show bridge-domain 10 detail", header+domain+"\n"+
+		"           Interface           If-idx  ISN  SHG  BVI  TxFlood        VLAN-Tag-Rewrite\n"+
+		"             tap0                1      1    0    -      *                 none\n")
+	b := NewVPP(vpp.NewManager(mockCmd))
+
+	if err := b.RemoveInterfaceFromBridge("10", "tap0"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if commands := mockCmd.GetCommands(); commands[len(commands)-1] != "vppctl set interface l3 tap0" {
+		t.Errorf("expected tap0 to be set to L3 mode, got %v", commands)
+	}
+
+	mockCmd.ClearCommands()
+	for _, tc := range []struct{ bridge, iface string }{{"10", "tap1"}, {"20", "tap0"}} {
+		if err := b.RemoveInterfaceFromBridge(tc.bridge, tc.iface); err == nil {
+			t.Errorf("%s in %s: expected error but got none", tc.iface, tc.bridge)
+		}
+	}
+	for _, command := range mockCmd.GetCommands() {
+		if strings.HasPrefix(command, "vppctl set") {
+			t.Errorf("unexpected command %s", command)
+		}
+	}
+}
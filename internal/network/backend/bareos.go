@@ -0,0 +1,112 @@
+package backend
+
+import (
+	"FreeBSD-Command-manager/internal/network/bareos"
+	"net/netip"
+)
+
+// native drives the FreeBSD network stack with ifconfig
+type native struct {
+	unsupported
+	m bareos.ManagerInterface
+}
+
+// NewBareos returns the native backend using m
+func NewBareos(m bareos.ManagerInterface) Backend {
+	return &native{unsupported: unsupported{name: Bareos}, m: m}
+}
+
+// DefaultBareos returns the native backend using the system commands
+func DefaultBareos() Backend {
+	return NewBareos(bareos.DefaultManager())
+}
+
+// Capabilities returns every operation: the native backend is the reference
+func (n *native) Capabilities() []Operation {
+	return []Operation{
+		OpCreateInterface, OpDeleteInterface, OpCreateBridge, OpDeleteBridge,
+		OpAddMember, OpRemoveMember, OpList, OpSetState, OpSetMTU, OpAddIP, OpDeleteIP,
+		OpVLAN, OpTunnel, OpSetAttributes, OpIPOptions,
+		OpLAGG, OpEpair, OpTap, OpBridgeAttributes, OpCARP, OpWireGuard,
+		OpNeighbors, OpRoutes, OpStats, OpDHCP, OpPersist, OpTopology,
+	}
+}
+
+// CreateInterface creates a cloned interface
+func (n *native) CreateInterface(name string) error {
+	return n.m.CreateInterface(name)
+}
+
+// DeleteInterface destroys a cloned interface
+func (n *native) DeleteInterface(name string) error {
+	return n.m.DeleteInterface(name)
+}
+
+// CreateBridge creates an if_bridge interface
+func (n *native) CreateBridge(name string) error {
+	return n.m.CreateBridge(name)
+}
+
+// DeleteBridge destroys an if_bridge interface
+func (n *native) DeleteBridge(name string) error {
+	return n.m.DeleteBridge(name)
+}
+
+// AddInterfaceToBridge adds a member to a bridge
+func (n *native) AddInterfaceToBridge(bridgeName, interfaceName string) error {
+	return n.m.AddInterfaceToBridge(bridgeName, interfaceName)
+}
+
+// RemoveInterfaceFromBridge removes a member from a bridge
+func (n *native) RemoveInterfaceFromBridge(bridgeName, interfaceName string) error {
+	return n.m.RemoveInterfaceFromBridge(bridgeName, interfaceName)
+}
+
+// List returns the interfaces reported by ifconfig
+func (n *native) List() ([]Interface, error) {
+	infos, err := n.m.List()
+	if err != nil {
+		return nil, err
+	}
+	ifaces := make([]Interface, 0, len(infos))
+	for _, info := range infos {
+		iface := Interface{Name: info.Name, State: info.Status, MTU: info.MTU}
+		for _, a := range info.Addresses {
+			if a.Prefix != "" {
+				iface.Addresses = append(iface.Addresses, a.Prefix)
+			} else {
+				iface.Addresses = append(iface.Addresses, a.Address)
+			}
+		}
+		if info.Bridge != nil {
+			iface.Bridge = true
+			for _, m := range info.Bridge.Members {
+				iface.Members = append(iface.Members, m.Name)
+			}
+		}
+		ifaces = append(ifaces, iface)
+	}
+	return ifaces, nil
+}
+
+// SetState brings an interface up or down
+func (n *native) SetState(name string, up bool) error {
+	_, err := n.m.SetInterface(name, bareos.InterfaceAttrs{Up: &up})
+	return err
+}
+
+// SetMTU sets the MTU of an interface
+func (n *native) SetMTU(name string, mtu int) error {
+	_, err := n.m.SetInterface(name, bareos.InterfaceAttrs{MTU: &mtu})
+	return err
+}
+
+// AddIP adds an address to an interface
+func (n *native) AddIP(name string, prefix netip.Prefix) error {
+	return n.m.AddIP(name, prefix, bareos.IPOptions{})
+}
+
+// DeleteIP removes an address from an interface
+func (n *native) DeleteIP(name string, prefix netip.Prefix) error {
+	return n.m.DeleteIP(name, prefix.Addr())
+}
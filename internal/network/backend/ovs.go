@@ -0,0 +1,68 @@
+package backend
+
+import "FreeBSD-Command-manager/internal/network/ovs"
+
+// ovsBackend drives Open vSwitch with ovs-vsctl. Bridges are OVS bridges and
+// their members are ports.
+type ovsBackend struct {
+	unsupported
+	m ovs.ManagerInterface
+}
+
+// NewOVS returns the Open vSwitch backend using m
+func NewOVS(m ovs.ManagerInterface) Backend {
+	return &ovsBackend{
+		unsupported: unsupported{name: OVS, hints: map[Operation]string{
+			OpCreateInterface: "add it to a bridge with \"fcom network ovs port add\"",
+			OpDeleteInterface: "remove its port with \"fcom network ovs port del\"",
+		}},
+		m: m,
+	}
+}
+
+// DefaultOVS returns the Open vSwitch backend using the system commands
+func DefaultOVS() Backend {
+	return NewOVS(ovs.DefaultManager())
+}
+
+// Capabilities returns the operations ovs-vsctl supports
+func (o *ovsBackend) Capabilities() []Operation {
+	return []Operation{OpCreateBridge, OpDeleteBridge, OpAddMember, OpRemoveMember, OpList}
+}
+
+// CreateBridge adds a bridge
+func (o *ovsBackend) CreateBridge(name string) error {
+	return o.m.AddBridge(name)
+}
+
+// DeleteBridge deletes a bridge and its ports
+func (o *ovsBackend) DeleteBridge(name string) error {
+	return o.m.DeleteBridge(name)
+}
+
+// AddInterfaceToBridge adds an interface to a bridge as a port
+func (o *ovsBackend) AddInterfaceToBridge(bridgeName, interfaceName string) error {
+	return o.m.AddPort(bridgeName, interfaceName, ovs.PortOptions{})
+}
+
+// RemoveInterfaceFromBridge deletes the port of an interface
+func (o *ovsBackend) RemoveInterfaceFromBridge(bridgeName, interfaceName string) error {
+	return o.m.DeletePort(bridgeName, interfaceName)
+}
+
+// List returns the bridges with their ports as members
+func (o *ovsBackend) List() ([]Interface, error) {
+	bridges, err := o.m.Show()
+	if err != nil {
+		return nil, err
+	}
+	ifaces := make([]Interface, 0, len(bridges))
+	for _, b := range bridges {
+		iface := Interface{Name: b.Name, Bridge: true}
+		for _, p := range b.Ports {
+			iface.Members = append(iface.Members, p.Name)
+		}
+		ifaces = append(ifaces, iface)
+	}
+	return ifaces, nil
+}
@@ -0,0 +1,81 @@
+package backend
+
+import (
+	"FreeBSD-Command-manager/internal/network/vale"
+	pkgvale "FreeBSD-Command-manager/pkg/vale"
+)
+
+// valeBackend drives netmap VALE switches with valectl. Interfaces are
+// persistent VALE ports and bridges are switches.
+type valeBackend struct {
+	unsupported
+	m vale.ManagerInterface
+}
+
+// NewVALE returns the VALE backend using m
+func NewVALE(m vale.ManagerInterface) Backend {
+	return &valeBackend{
+		unsupported: unsupported{name: VALE, hints: map[Operation]string{
+			OpCreateBridge: "a VALE switch is created by adding its first interface",
+		}},
+		m: m,
+	}
+}
+
+// DefaultVALE returns the VALE backend using the system commands
+func DefaultVALE() Backend {
+	return NewVALE(vale.DefaultManager())
+}
+
+// Capabilities returns the operations valectl supports
+func (v *valeBackend) Capabilities() []Operation {
+	return []Operation{OpCreateInterface, OpDeleteInterface, OpDeleteBridge, OpAddMember, OpRemoveMember, OpList}
+}
+
+// CreateInterface creates a persistent VALE port
+func (v *valeBackend) CreateInterface(name string) error {
+	return v.m.CreatePort(name)
+}
+
+// DeleteInterface detaches and removes a persistent VALE port
+func (v *valeBackend) DeleteInterface(name string) error {
+	return v.m.DestroyPort(name)
+}
+
+// DeleteBridge detaches every port of a switch, which removes it
+func (v *valeBackend) DeleteBridge(name string) error {
+	return v.m.DestroySwitch(name)
+}
+
+// AddInterfaceToBridge attaches an interface to a switch, creating the
+// switch if needed
+func (v *valeBackend) AddInterfaceToBridge(bridgeName, interfaceName string) error {
+	return v.m.Attach(bridgeName, interfaceName, false)
+}
+
+// RemoveInterfaceFromBridge detaches an interface from a switch
+func (v *valeBackend) RemoveInterfaceFromBridge(bridgeName, interfaceName string) error {
+	return v.m.Detach(bridgeName, interfaceName)
+}
+
+// List returns the switches with their ports as members. Host stack ports
+// carry the "^" suffix.
+func (v *valeBackend) List() ([]Interface, error) {
+	switches, err := v.m.List()
+	if err != nil {
+		return nil, err
+	}
+	ifaces := make([]Interface, 0, len(switches))
+	for _, sw := range switches {
+		iface := Interface{Name: sw.Name, Bridge: true}
+		for _, p := range sw.Ports {
+			name := p.Name
+			if p.Host {
+				name += pkgvale.HostSuffix
+			}
+			iface.Members = append(iface.Members, name)
+		}
+		ifaces = append(ifaces, iface)
+	}
+	return ifaces, nil
+}
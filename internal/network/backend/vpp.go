@@ -0,0 +1,175 @@
+package backend
+
+import (
+	"FreeBSD-Command-manager/internal/network/vpp"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// vppInterfaceName matches the interface names VPP assigns to tap and memif
+// interfaces, from which CreateInterface derives what to create.
+var vppInterfaceName = regexp.MustCompile(`^(?:tap([0-9]+)|memif([0-9]+)/([0-9]+))$`)
+
+// vppBackend drives VPP with vppctl. Bridges are bridge domains, named by
+// their numeric ID.
+type vppBackend struct {
+	unsupported
+	m vpp.ManagerInterface
+}
+
+// NewVPP returns the VPP backend using m
+func NewVPP(m vpp.ManagerInterface) Backend {
+	return &vppBackend{unsupported: unsupported{name: VPP}, m: m}
+}
+
+// DefaultVPP returns the VPP backend using the system commands
+func DefaultVPP() Backend {
+	return NewVPP(vpp.DefaultManager())
+}
+
+// Capabilities returns the operations vppctl supports
+func (v *vppBackend) Capabilities() []Operation {
+	return []Operation{
+		OpCreateInterface, OpDeleteInterface, OpCreateBridge, OpDeleteBridge,
+		OpAddMember, OpRemoveMember, OpList, OpSetState, OpSetMTU, OpAddIP, OpDeleteIP,
+	}
+}
+
+// CreateInterface creates the interface VPP will give name: "host-<if>"
+// binds host interface <if>, "tap<N>" creates tap N and "memif<S>/<N>" a
+// slave memif N on socket S. Other interfaces need "fcom network vpp iface".
+func (v *vppBackend) CreateInterface(name string) error {
+	var spec vpp.InterfaceSpec
+	m := vppInterfaceName.FindStringSubmatch(name)
+	switch {
+	case strings.HasPrefix(name, "host-"):
+		spec = vpp.InterfaceSpec{Type: vpp.TypeHost, HostIf: strings.TrimPrefix(name, "host-")}
+	case m == nil:
+		return fmt.Errorf("cannot tell the type of VPP interface %q: use host-<if>, tap<N> or memif<S>/<N>", name)
+	case m[1] != "":
+		spec = vpp.InterfaceSpec{Type: vpp.TypeTap, ID: atoi(m[1])}
+	default:
+		spec = vpp.InterfaceSpec{Type: vpp.TypeMemif, SocketID: atoi(m[2]), ID: atoi(m[3])}
+	}
+	_, err := v.m.CreateInterface(spec)
+	return err
+}
+
+// DeleteInterface deletes a host, tap, memif or loopback interface
+func (v *vppBackend) DeleteInterface(name string) error {
+	return v.m.DeleteInterface(name)
+}
+
+// CreateBridge creates a bridge domain with the VPP default options
+func (v *vppBackend) CreateBridge(name string) error {
+	id, err := bridgeID(name)
+	if err != nil {
+		return err
+	}
+	return v.m.CreateBridgeDomain(id, vpp.DefaultBridgeDomainOptions())
+}
+
+// DeleteBridge deletes a bridge domain
+func (v *vppBackend) DeleteBridge(name string) error {
+	id, err := bridgeID(name)
+	if err != nil {
+		return err
+	}
+	return v.m.DeleteBridgeDomain(id)
+}
+
+// AddInterfaceToBridge puts an interface in L2 mode in a bridge domain
+func (v *vppBackend) AddInterfaceToBridge(bridgeName, interfaceName string) error {
+	id, err := bridgeID(bridgeName)
+	if err != nil {
+		return err
+	}
+	return v.m.AddBridgeMember(id, interfaceName, false)
+}
+
+// RemoveInterfaceFromBridge returns an interface to L3 mode, which takes it
+// out of its bridge domain. The interface must be a member of bridgeName, as
+// L3 mode would otherwise take it out of another domain or cross-connect.
+func (v *vppBackend) RemoveInterfaceFromBridge(bridgeName, interfaceName string) error {
+	id, err := bridgeID(bridgeName)
+	if err != nil {
+		return err
+	}
+	domains, err := v.m.BridgeDomains()
+	if err != nil {
+		return err
+	}
+	for _, d := range domains {
+		if d.ID != id {
+			continue
+		}
+		for _, m := range d.Members {
+			if m.Name == interfaceName {
+				return v.m.SetL3(interfaceName)
+			}
+		}
+	}
+	return fmt.Errorf("interface %s is not a member of bridge domain %d", interfaceName, id)
+}
+
+// List returns the interfaces followed by the bridge domains
+func (v *vppBackend) List() ([]Interface, error) {
+	interfaces, err := v.m.List()
+	if err != nil {
+		return nil, err
+	}
+	domains, err := v.m.BridgeDomains()
+	if err != nil {
+		return nil, err
+	}
+	ifaces := make([]Interface, 0, len(interfaces)+len(domains))
+	for _, i := range interfaces {
+		ifaces = append(ifaces, Interface{Name: i.Name, State: i.State, MTU: i.MTU, Addresses: i.Addresses})
+	}
+	for _, d := range domains {
+		iface := Interface{Name: strconv.Itoa(d.ID), Bridge: true}
+		for _, m := range d.Members {
+			iface.Members = append(iface.Members, m.Name)
+		}
+		ifaces = append(ifaces, iface)
+	}
+	return ifaces, nil
+}
+
+// SetState brings an interface up or down
+func (v *vppBackend) SetState(name string, up bool) error {
+	return v.m.SetState(name, up)
+}
+
+// SetMTU sets the packet MTU of an interface
+func (v *vppBackend) SetMTU(name string, mtu int) error {
+	return v.m.SetMTU(name, mtu)
+}
+
+// AddIP adds an address to an interface
+func (v *vppBackend) AddIP(name string, prefix netip.Prefix) error {
+	return v.m.AddAddress(name, prefix)
+}
+
+// DeleteIP removes an address from an interface
+func (v *vppBackend) DeleteIP(name string, prefix netip.Prefix) error {
+	return v.m.DeleteAddress(name, prefix)
+}
+
+// bridgeID parses the name of a bridge domain
+func bridgeID(name string) (int, error) {
+	id, err := strconv.Atoi(name)
+	if err != nil {
+		return 0, fmt.Errorf("invalid VPP bridge domain %q: must be a numeric ID", name)
+	}
+	return id, nil
+}
+
+// atoi converts digits matched by a regular expression
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
@@ -0,0 +1,167 @@
+package bareos
+
+import (
+	"FreeBSD-Command-manager/pkg/ifconfig"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// InterfaceAttrs describes the attributes to change on an existing interface.
+// Nil and empty fields are left untouched.
+type InterfaceAttrs struct {
+	MTU          *int
+	Description  *string
+	Up           *bool
+	Ether        string
+	FIB          *int
+	Group        string
+	Capabilities []string // e.g. "-tso", "+lro"
+}
+
+// AttrChange reports the value of an attribute before and after SetInterface.
+type AttrChange struct {
+	Attribute string `json:"attribute"`
+	Before    string `json:"before"`
+	After     string `json:"after"`
+}
+
+// capabilityFlags maps ifconfig capability arguments to the names shown in "options=<...>".
+var capabilityFlags = map[string]string{ //nolint:gochecknoglobals
+	"rxcsum":       "RXCSUM",
+	"txcsum":       "TXCSUM",
+	"rxcsum6":      "RXCSUM_IPV6",
+	"txcsum6":      "TXCSUM_IPV6",
+	"tso":          "TSO4",
+	"tso4":         "TSO4",
+	"tso6":         "TSO6",
+	"lro":          "LRO",
+	"vlanmtu":      "VLAN_MTU",
+	"vlanhwtag":    "VLAN_HWTAGGING",
+	"vlanhwcsum":   "VLAN_HWCSUM",
+	"vlanhwfilter": "VLAN_HWFILTER",
+	"vlanhwtso":    "VLAN_HWTSO",
+	"wol":          "WOL_MAGIC",
+	"polling":      "POLLING",
+}
+
+// SetInterface applies the given attributes to an interface, skipping those
+// that already match the current state, and returns the changes made.
+func (n *Manager) SetInterface(name string, attrs InterfaceAttrs) ([]AttrChange, error) {
+	info, err := n.GetInfo(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []AttrChange
+	apply := func(attr, before, after string, args ...string) error {
+		if before == after {
+			return nil
+		}
+		if _, err := n.cmdExec.Execute("ifconfig", append([]string{name}, args...)...); err != nil {
+			return fmt.Errorf("failed to set %s on interface %s: %v", attr, name, err)
+		}
+		changes = append(changes, AttrChange{Attribute: attr, Before: before, After: after})
+		return nil
+	}
+
+	if attrs.MTU != nil {
+		if *attrs.MTU <= 0 {
+			return nil, fmt.Errorf("invalid MTU: %d", *attrs.MTU)
+		}
+		mtu := strconv.Itoa(*attrs.MTU)
+		if err := apply("mtu", strconv.Itoa(info.MTU), mtu, "mtu", mtu); err != nil {
+			return changes, err
+		}
+	}
+	if attrs.Description != nil {
+		args := []string{"description", *attrs.Description}
+		if *attrs.Description == "" {
+			args = []string{"-description"}
+		}
+		if err := apply("description", info.Description, *attrs.Description, args...); err != nil {
+			return changes, err
+		}
+	}
+	if attrs.Ether != "" {
+		mac, err := net.ParseMAC(attrs.Ether)
+		if err != nil {
+			return changes, fmt.Errorf("invalid MAC address: %s", attrs.Ether)
+		}
+		if err := apply("ether", info.MAC, mac.String(), "ether", mac.String()); err != nil {
+			return changes, err
+		}
+	}
+	if attrs.FIB != nil {
+		if *attrs.FIB < 0 {
+			return changes, fmt.Errorf("invalid FIB: %d", *attrs.FIB)
+		}
+		fib := strconv.Itoa(*attrs.FIB)
+		if err := apply("fib", strconv.Itoa(info.FIB), fib, "fib", fib); err != nil {
+			return changes, err
+		}
+	}
+	if attrs.Group != "" && !contains(info.Groups, attrs.Group) {
+		before := strings.Join(info.Groups, " ")
+		after := strings.TrimSpace(before + " " + attrs.Group)
+		if err := apply("group", before, after, "group", attrs.Group); err != nil {
+			return changes, err
+		}
+	}
+	for _, c := range attrs.Capabilities {
+		if err := setCapability(info, c, apply); err != nil {
+			return changes, err
+		}
+	}
+	if attrs.Up != nil {
+		before, after := stateString(contains(info.Flags, "UP")), stateString(*attrs.Up)
+		if err := apply("state", before, after, after); err != nil {
+			return changes, err
+		}
+	}
+
+	return changes, nil
+}
+
+// setCapability enables ("+lro", "lro") or disables ("-lro") a single capability.
+func setCapability(info *ifconfig.Info, capability string, apply func(attr, before, after string, args ...string) error) error {
+	enable := !strings.HasPrefix(capability, "-")
+	capName := strings.ToLower(strings.TrimLeft(capability, "+-"))
+	if capName == "" {
+		return fmt.Errorf("invalid capability: %q", capability)
+	}
+	flag, ok := capabilityFlags[capName]
+	if !ok {
+		flag = strings.ToUpper(capName)
+	}
+	arg := capName
+	if !enable {
+		arg = "-" + capName
+	}
+	return apply("capability "+capName,
+		enabledString(contains(info.Capabilities, flag)), enabledString(enable), arg)
+}
+
+func enabledString(enabled bool) string {
+	if enabled {
+		return "enabled"
+	}
+	return "disabled"
+}
+
+func stateString(up bool) string {
+	if up {
+		return "up"
+	}
+	return "down"
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
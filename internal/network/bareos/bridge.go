@@ -1,7 +1,10 @@
 package bareos
 
 import (
+	"FreeBSD-Command-manager/pkg/ifconfig"
 	"fmt"
+	"strconv"
+	"strings"
 )
 
 // CreateBridge creates a bridge interface
@@ -73,3 +76,156 @@ func (n *Manager) RemoveInterfaceFromBridge(bridgeName, interfaceName string) er
 
 	return nil
 }
+
+// BridgeAttrs describes bridge-wide settings. Nil and empty fields are left untouched.
+type BridgeAttrs struct {
+	Proto    string   // "stp" or "rstp"
+	Priority *int     // 0-61440 in steps of 4096
+	MaxAddr  *int     // size of the address cache
+	Timeout  *int     // address cache expiry in seconds
+	Span     []string // span ports to add, "-em2" removes
+}
+
+// BridgeMemberAttrs describes per-member bridge settings. Nil fields are left untouched.
+type BridgeMemberAttrs struct {
+	STP      *bool
+	Learn    *bool
+	Discover *bool
+	Sticky   *bool
+	Private  *bool
+	Priority *int // 0-240 in steps of 16
+	PathCost *int // 0 selects the cost automatically
+}
+
+const (
+	maxBridgePriority    = 61440
+	bridgePriorityStep   = 4096
+	maxBridgeIfPriority  = 240
+	bridgeIfPriorityStep = 16
+	maxBridgePathCost    = 200000000
+	bridgeProtoSTP       = "stp"
+	bridgeProtoRSTP      = "rstp"
+	bridgeRemovePrefix   = "-"
+)
+
+// SetBridge applies bridge-wide STP, address cache and span settings
+func (n *Manager) SetBridge(name string, attrs BridgeAttrs) error {
+	if name == "" {
+		return fmt.Errorf("bridge name is required")
+	}
+
+	args := []string{name}
+	if attrs.Proto != "" {
+		if attrs.Proto != bridgeProtoSTP && attrs.Proto != bridgeProtoRSTP {
+			return fmt.Errorf("unsupported bridge protocol %q (want stp or rstp)", attrs.Proto)
+		}
+		args = append(args, "proto", attrs.Proto)
+	}
+	if attrs.Priority != nil {
+		p := *attrs.Priority
+		if p < 0 || p > maxBridgePriority || p%bridgePriorityStep != 0 {
+			return fmt.Errorf("bridge priority must be between 0 and %d in steps of %d", maxBridgePriority, bridgePriorityStep)
+		}
+		args = append(args, "priority", strconv.Itoa(p))
+	}
+	if attrs.MaxAddr != nil {
+		if *attrs.MaxAddr < 0 {
+			return fmt.Errorf("invalid maxaddr: %d", *attrs.MaxAddr)
+		}
+		args = append(args, "maxaddr", strconv.Itoa(*attrs.MaxAddr))
+	}
+	if attrs.Timeout != nil {
+		if *attrs.Timeout < 0 {
+			return fmt.Errorf("invalid timeout: %d", *attrs.Timeout)
+		}
+		args = append(args, "timeout", strconv.Itoa(*attrs.Timeout))
+	}
+	for _, span := range attrs.Span {
+		iface := strings.TrimPrefix(span, bridgeRemovePrefix)
+		if iface == "" {
+			return fmt.Errorf("invalid span port: %q", span)
+		}
+		if strings.HasPrefix(span, bridgeRemovePrefix) {
+			args = append(args, "-span", iface)
+		} else {
+			args = append(args, "span", iface)
+		}
+	}
+	if len(args) == 1 {
+		return fmt.Errorf("no bridge attributes to set")
+	}
+
+	_, err := n.cmdExec.Execute("ifconfig", args...)
+	if err != nil {
+		return fmt.Errorf("failed to configure bridge %s: %v", name, err)
+	}
+
+	return nil
+}
+
+// SetBridgeMember applies STP, learning and forwarding settings to a bridge member
+func (n *Manager) SetBridgeMember(bridgeName, interfaceName string, attrs BridgeMemberAttrs) error {
+	if bridgeName == "" {
+		return fmt.Errorf("bridge name is required")
+	}
+	if interfaceName == "" {
+		return fmt.Errorf("interface name is required")
+	}
+
+	args := []string{bridgeName}
+	toggles := []struct {
+		keyword string
+		value   *bool
+	}{
+		{"stp", attrs.STP},
+		{"learn", attrs.Learn},
+		{"discover", attrs.Discover},
+		{"sticky", attrs.Sticky},
+		{"private", attrs.Private},
+	}
+	for _, t := range toggles {
+		if t.value == nil {
+			continue
+		}
+		keyword := t.keyword
+		if !*t.value {
+			keyword = bridgeRemovePrefix + keyword
+		}
+		args = append(args, keyword, interfaceName)
+	}
+	if attrs.Priority != nil {
+		p := *attrs.Priority
+		if p < 0 || p > maxBridgeIfPriority || p%bridgeIfPriorityStep != 0 {
+			return fmt.Errorf("member priority must be between 0 and %d in steps of %d", maxBridgeIfPriority, bridgeIfPriorityStep)
+		}
+		args = append(args, "ifpriority", interfaceName, strconv.Itoa(p))
+	}
+	if attrs.PathCost != nil {
+		if *attrs.PathCost < 0 || *attrs.PathCost > maxBridgePathCost {
+			return fmt.Errorf("path cost must be between 0 and %d", maxBridgePathCost)
+		}
+		args = append(args, "ifpathcost", interfaceName, strconv.Itoa(*attrs.PathCost))
+	}
+	if len(args) == 1 {
+		return fmt.Errorf("no bridge member attributes to set")
+	}
+
+	_, err := n.cmdExec.Execute("ifconfig", args...)
+	if err != nil {
+		return fmt.Errorf("failed to configure member %s of bridge %s: %v", interfaceName, bridgeName, err)
+	}
+
+	return nil
+}
+
+// BridgeAddresses returns the learned and static entries of a bridge address cache
+func (n *Manager) BridgeAddresses(name string) ([]ifconfig.BridgeAddress, error) {
+	if name == "" {
+		return nil, fmt.Errorf("bridge name is required")
+	}
+	output, err := n.cmdExec.Execute("ifconfig", name, "addr")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get address cache of bridge %s: %v", name, err)
+	}
+	return ifconfig.ParseBridgeAddr(output), nil
+}
@@ -0,0 +1,143 @@
+package bareos
+
+import (
+	"FreeBSD-Command-manager/pkg/ifconfig"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxCARPVHID    = 255
+	maxCARPAdvSkew = 254
+	maxCARPPassLen = 20
+	carpMaster     = "master"
+	carpBackup     = "backup"
+)
+
+// AddCARP adds a CARP virtual address with the given vhid to an interface
+func (n *Manager) AddCARP(iface string, vhid, advskew int, pass, addr string) error {
+	if iface == "" {
+		return fmt.Errorf("interface name is required")
+	}
+	if err := validateVHID(vhid); err != nil {
+		return err
+	}
+	if advskew < 0 || advskew > maxCARPAdvSkew {
+		return fmt.Errorf("advskew must be between 0 and %d", maxCARPAdvSkew)
+	}
+	if len(pass) > maxCARPPassLen {
+		return fmt.Errorf("CARP pass phrase must be at most %d characters", maxCARPPassLen)
+	}
+	fam, cidr, err := carpAddress(addr)
+	if err != nil {
+		return err
+	}
+
+	args := []string{iface, "vhid", strconv.Itoa(vhid), "advskew", strconv.Itoa(advskew)}
+	if pass != "" {
+		args = append(args, "pass", pass)
+	}
+	args = append(args, fam, cidr, "alias")
+	_, err = n.cmdExec.Execute("ifconfig", args...)
+	if err != nil {
+		// Do not include the arguments, they contain the pass phrase
+		return fmt.Errorf("failed to add CARP vhid %d to %s: %v", vhid, iface, err)
+	}
+
+	return nil
+}
+
+// RemoveCARP removes a CARP virtual address from an interface
+func (n *Manager) RemoveCARP(iface, addr string) error {
+	if iface == "" {
+		return fmt.Errorf("interface name is required")
+	}
+	fam, cidr, err := carpAddress(addr)
+	if err != nil {
+		return err
+	}
+	ip := strings.SplitN(cidr, "/", 2)[0]
+
+	_, err = n.cmdExec.Execute("ifconfig", iface, fam, ip, "-alias")
+	if err != nil {
+		return fmt.Errorf("failed to remove CARP address %s from %s: %v", ip, iface, err)
+	}
+
+	return nil
+}
+
+// SetCARPState forces a vhid on an interface into the master or backup state
+func (n *Manager) SetCARPState(iface string, vhid int, state string) error {
+	if iface == "" {
+		return fmt.Errorf("interface name is required")
+	}
+	if err := validateVHID(vhid); err != nil {
+		return err
+	}
+	state = strings.ToLower(state)
+	if state != carpMaster && state != carpBackup {
+		return fmt.Errorf("CARP state must be master or backup")
+	}
+
+	_, err := n.cmdExec.Execute("ifconfig", iface, "vhid", strconv.Itoa(vhid), "state", state)
+	if err != nil {
+		return fmt.Errorf("failed to set CARP vhid %d on %s to %s: %v", vhid, iface, state, err)
+	}
+
+	return nil
+}
+
+// CARPStatus returns the CARP vhids of one interface, or of all interfaces when iface is empty
+func (n *Manager) CARPStatus(iface string) (map[string][]ifconfig.CARPInfo, error) {
+	var infos []ifconfig.Info
+	if iface != "" {
+		info, err := n.GetInfo(iface)
+		if err != nil {
+			return nil, err
+		}
+		infos = []ifconfig.Info{*info}
+	} else {
+		all, err := n.List()
+		if err != nil {
+			return nil, err
+		}
+		infos = all
+	}
+
+	status := make(map[string][]ifconfig.CARPInfo)
+	for _, info := range infos {
+		if len(info.CARP) > 0 {
+			status[info.Name] = info.CARP
+		}
+	}
+	return status, nil
+}
+
+func validateVHID(vhid int) error {
+	if vhid < 1 || vhid > maxCARPVHID {
+		return fmt.Errorf("vhid must be between 1 and %d", maxCARPVHID)
+	}
+	return nil
+}
+
+// carpAddress returns the family and CIDR form of a CARP address; a bare
+// address is treated as a host address.
+func carpAddress(addr string) (family, cidr string, err error) {
+	if addr == "" {
+		return "", "", fmt.Errorf("address is required")
+	}
+	prefix, err := netip.ParsePrefix(addr)
+	if err != nil {
+		ip, ipErr := netip.ParseAddr(addr)
+		if ipErr != nil {
+			return "", "", fmt.Errorf("invalid address: %s", addr)
+		}
+		prefix = netip.PrefixFrom(ip, ip.BitLen())
+	}
+	if prefix.Addr().Is4() {
+		return inetFamily, prefix.String(), nil
+	}
+	return inet6Family, prefix.String(), nil
+}
@@ -0,0 +1,81 @@
+package bareos
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EpairPair holds the names of both ends of an epair interface
+type EpairPair struct {
+	A string `json:"a"`
+	B string `json:"b"`
+}
+
+// CreateEpair creates a virtual ethernet pair and brings both ends up.
+// When name is set the ends are renamed to <name>a and <name>b.
+func (n *Manager) CreateEpair(name string) (EpairPair, error) {
+	// Create epair, ifconfig prints the name of the "a" side
+	out, err := n.cmdExec.Execute("ifconfig", "epair", "create")
+	if err != nil {
+		return EpairPair{}, fmt.Errorf("failed to create epair interface: %v", err)
+	}
+	a := strings.TrimSpace(out)
+	if !strings.HasSuffix(a, "a") {
+		return EpairPair{}, fmt.Errorf("unexpected epair name %q", a)
+	}
+	pair := EpairPair{A: a, B: strings.TrimSuffix(a, "a") + "b"}
+
+	if name != "" {
+		// Rename both ends to desired name
+		renamed := EpairPair{A: name + "a", B: name + "b"}
+		if _, err = n.cmdExec.Execute("ifconfig", pair.A, "name", renamed.A); err != nil {
+			return pair, fmt.Errorf("failed to rename epair to %s: %v", renamed.A, err)
+		}
+		pair.A = renamed.A
+		if _, err = n.cmdExec.Execute("ifconfig", pair.B, "name", renamed.B); err != nil {
+			return pair, fmt.Errorf("failed to rename epair to %s: %v", renamed.B, err)
+		}
+		pair.B = renamed.B
+	}
+
+	// Bring up both ends
+	for _, side := range []string{pair.A, pair.B} {
+		if _, err = n.cmdExec.Execute("ifconfig", side, "up"); err != nil {
+			return pair, fmt.Errorf("failed to bring up epair %s: %v", side, err)
+		}
+	}
+
+	return pair, nil
+}
+
+// DeleteEpair deletes an epair. Either end, or the pair name without the
+// a/b suffix, may be given; destroying one end removes the whole pair.
+func (n *Manager) DeleteEpair(name string) (EpairPair, error) {
+	if name == "" {
+		return EpairPair{}, fmt.Errorf("epair name is required")
+	}
+
+	side := name
+	info, err := n.GetInfo(side)
+	if err != nil {
+		// Not an interface itself, try it as the pair name
+		side = name + "a"
+		if info, err = n.GetInfo(side); err != nil {
+			return EpairPair{}, fmt.Errorf("epair %s not found", name)
+		}
+	}
+	if !contains(info.Groups, "epair") {
+		return EpairPair{}, fmt.Errorf("interface %s is not an epair", side)
+	}
+
+	if _, err := n.cmdExec.Execute("ifconfig", side, "destroy"); err != nil {
+		return EpairPair{}, fmt.Errorf("failed to delete epair %s: %v", side, err)
+	}
+
+	if !strings.HasSuffix(side, "a") && !strings.HasSuffix(side, "b") {
+		// Renamed without the a/b convention, the peer name is unknown
+		return EpairPair{A: side}, nil
+	}
+	base := side[:len(side)-1]
+	return EpairPair{A: base + "a", B: base + "b"}, nil
+}
@@ -0,0 +1,57 @@
+package bareos
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CreateGIF creates a gif tunnel interface (IPv4/IPv6 in IPv4/IPv6)
+func (n *Manager) CreateGIF(name, remote, local string, opts TunnelOptions) error {
+	outerFam, err := tunnelFamily(local, remote)
+	if err != nil {
+		return err
+	}
+	innerFam, err := innerFamily(opts)
+	if err != nil {
+		return err
+	}
+	if opts.Key != 0 {
+		return fmt.Errorf("gif tunnels do not support keys")
+	}
+
+	// Create gif interface
+	out, err := n.cmdExec.Execute("ifconfig", "gif", "create")
+	if err != nil {
+		return fmt.Errorf("failed to create gif interface: %v", err)
+	}
+	gifName := strings.TrimSpace(out)
+
+	if err := n.configureTunnel(gifName, outerFam, innerFam, local, remote, opts); err != nil {
+		return err
+	}
+
+	// Bring up the interface
+	_, err = n.cmdExec.Execute("ifconfig", gifName, "up")
+	if err != nil {
+		return fmt.Errorf("failed to bring up gif interface %s: %v", name, err)
+	}
+
+	if name != "" {
+		// Rename to desired name
+		_, err = n.cmdExec.Execute("ifconfig", gifName, "name", name)
+		if err != nil {
+			return fmt.Errorf("failed to rename gif to %s: %v", name, err)
+		}
+	}
+
+	return nil
+}
+
+// DeleteGIF deletes a gif tunnel interface
+func (n *Manager) DeleteGIF(name string) error {
+	if name == "" {
+		return fmt.Errorf("gif tunnel name is required")
+	}
+
+	return n.DeleteInterface(name)
+}
@@ -2,27 +2,38 @@ package bareos
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 )
 
 // CreateGRE creates a GRE tunnel interface
-func (n *Manager) CreateGRE(name, remote, local string) error {
-	if remote == "" {
-		return fmt.Errorf("remote address is required")
+func (n *Manager) CreateGRE(name, remote, local string, opts TunnelOptions) error {
+	outerFam, err := tunnelFamily(local, remote)
+	if err != nil {
+		return err
 	}
-	if local == "" {
-		return fmt.Errorf("local address is required")
+	innerFam, err := innerFamily(opts)
+	if err != nil {
+		return err
 	}
 
 	// Create GRE interface
-	greName, err := n.cmdExec.Execute("ifconfig", "gre", "create")
+	out, err := n.cmdExec.Execute("ifconfig", "gre", "create")
 	if err != nil {
 		return fmt.Errorf("failed to create GRE interface: %v", err)
 	}
+	greName := strings.TrimSpace(out)
 
 	// Configure GRE tunnel
-	_, err = n.cmdExec.Execute("ifconfig", greName, "tunnel", local, remote)
-	if err != nil {
-		return fmt.Errorf("failed to configure GRE tunnel %s: %v", name, err)
+	if err := n.configureTunnel(greName, outerFam, innerFam, local, remote, opts); err != nil {
+		return err
+	}
+
+	if opts.Key != 0 {
+		_, err = n.cmdExec.Execute("ifconfig", greName, "grekey", strconv.FormatUint(uint64(opts.Key), 10))
+		if err != nil {
+			return fmt.Errorf("failed to set GRE key on %s: %v", name, err)
+		}
 	}
 
 	// Bring up the interface
@@ -1,8 +1,11 @@
 package bareos
 
 import (
+	"FreeBSD-Command-manager/pkg/ifconfig"
 	"fmt"
-	"os/exec"
+	"net/netip"
+	"strconv"
+	"strings"
 )
 
 const (
@@ -10,56 +13,188 @@ const (
 	inet6Family = "inet6"
 )
 
-// AddIP adds an IP address to an interface (IPv4 or IPv6).
-func AddIP(iface, ip string, mask int, family string) error {
-	if iface == "" || ip == "" || mask == 0 {
-		return fmt.Errorf("iface, ip, and mask are required")
-	}
-	fam := family
-	if fam == "" {
-		fam = inetFamily
-	}
-	addr := fmt.Sprintf("%s/%d", ip, mask)
-	execCmd := exec.Command("ifconfig", iface, fam, addr, "add") //nolint:gosec
-	output, err := execCmd.CombinedOutput()
+// IPOptions holds the optional IPv6 address flags of AddIP and AliasIP.
+// Lifetimes are in seconds; zero leaves the kernel default (infinite).
+type IPOptions struct {
+	Anycast    bool
+	Tentative  bool
+	Deprecated bool
+	AutoConf   bool
+	VLTime     int
+	PLTime     int
+}
+
+// ParsePrefix parses an address in CIDR notation, or a bare address combined
+// with mask. A bare address without mask becomes a host prefix (/32 or /128).
+func ParsePrefix(addr string, mask int) (netip.Prefix, error) {
+	if addr == "" {
+		return netip.Prefix{}, fmt.Errorf("IP address is required")
+	}
+	if strings.Contains(addr, "/") {
+		prefix, err := netip.ParsePrefix(addr)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid IP prefix %q: %v", addr, err)
+		}
+		if prefix.Bits() == 0 {
+			return netip.Prefix{}, fmt.Errorf("invalid IP prefix %q: prefix length must not be 0", addr)
+		}
+		if mask != 0 && mask != prefix.Bits() {
+			return netip.Prefix{}, fmt.Errorf("mask %d conflicts with prefix %s", mask, addr)
+		}
+		return prefix, nil
+	}
+	ip, err := netip.ParseAddr(addr)
 	if err != nil {
-		return fmt.Errorf("ifconfig error: %v, output: %s", err, string(output))
+		return netip.Prefix{}, fmt.Errorf("invalid IP address %q: %v", addr, err)
 	}
-	return nil
+	if ip.Zone() != "" {
+		return netip.Prefix{}, fmt.Errorf("IP address %q must not have a scope zone, use the interface instead", addr)
+	}
+	if mask == 0 {
+		mask = ip.BitLen()
+	}
+	if mask < 0 || mask > ip.BitLen() {
+		return netip.Prefix{}, fmt.Errorf("invalid mask %d for %s", mask, addr)
+	}
+	return netip.PrefixFrom(ip, mask), nil
+}
+
+// AddIP adds an IP address to an interface, the family follows the address.
+func (n *Manager) AddIP(iface string, prefix netip.Prefix, opts IPOptions) error {
+	return n.addIP(iface, prefix, opts, "add")
+}
+
+// AliasIP adds an alias IP address to an interface, the family follows the address.
+func (n *Manager) AliasIP(iface string, prefix netip.Prefix, opts IPOptions) error {
+	return n.addIP(iface, prefix, opts, "alias")
 }
 
-// AliasIP adds an alias IP address to an interface (IPv4 or IPv6).
-func AliasIP(iface, ip string, mask int, family string) error {
-	if iface == "" || ip == "" || mask == 0 {
-		return fmt.Errorf("iface, ip, and mask are required")
-	}
-	fam := family
-	if fam == "" {
-		fam = inetFamily
-	}
-	addr := fmt.Sprintf("%s/%d", ip, mask)
-	execCmd := exec.Command("ifconfig", iface, fam, addr, "alias") //nolint:gosec
-	output, err := execCmd.CombinedOutput()
+// DeleteIP deletes an IP address from an interface.
+func (n *Manager) DeleteIP(iface string, addr netip.Addr) error {
+	if iface == "" || !addr.IsValid() {
+		return fmt.Errorf("iface and ip are required")
+	}
+	output, err := n.cmdExec.Execute("ifconfig", iface, ipFamily(addr), addr.String(), "-alias")
 	if err != nil {
-		return fmt.Errorf("ifconfig error: %v, output: %s", err, string(output))
+		return fmt.Errorf("ifconfig error: %v, output: %s", err, output)
 	}
 	return nil
 }
 
-// DeleteIP deletes an IP address from an interface (IPv4 or IPv6).
-func DeleteIP(iface, ip string, mask int, family string) error {
-	if iface == "" || ip == "" || mask == 0 {
-		return fmt.Errorf("iface, ip, and mask are required")
-	}
-	fam := family
-	if fam == "" {
-		fam = inetFamily
-	}
-	addr := fmt.Sprintf("%s/%d", ip, mask)
-	execCmd := exec.Command("ifconfig", iface, fam, addr, "delete") //nolint:gosec
-	output, err := execCmd.CombinedOutput()
+// ListIPs returns the addresses of an interface, or of all interfaces when
+// iface is empty, keyed by interface name.
+func (n *Manager) ListIPs(iface string) (map[string][]ifconfig.Address, error) {
+	infos, err := n.interfaces(iface)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string][]ifconfig.Address)
+	for _, info := range infos {
+		result[info.Name] = info.Addresses
+	}
+	return result, nil
+}
+
+// addIP runs "ifconfig <iface> <family> <ip>/<len> [flags] <action>" after
+// checking that the address is not already in use.
+func (n *Manager) addIP(iface string, prefix netip.Prefix, opts IPOptions, action string) error {
+	if iface == "" || !prefix.IsValid() {
+		return fmt.Errorf("iface and ip are required")
+	}
+	addr := prefix.Addr()
+	flags, err := ipFlags(addr, opts)
+	if err != nil {
+		return err
+	}
+	if owner, err := n.ipOwner(iface, addr); err != nil {
+		return err
+	} else if owner != "" {
+		return fmt.Errorf("IP address %s is already configured on %s", addr, owner)
+	}
+
+	args := append([]string{iface, ipFamily(addr), prefix.String()}, flags...)
+	args = append(args, action)
+	output, err := n.cmdExec.Execute("ifconfig", args...)
 	if err != nil {
-		return fmt.Errorf("ifconfig error: %v, output: %s", err, string(output))
+		return fmt.Errorf("ifconfig error: %v, output: %s", err, output)
 	}
 	return nil
 }
+
+// ipOwner returns the interface that already has addr, or "". Link-local
+// addresses are only unique per link, so they are checked on iface alone.
+func (n *Manager) ipOwner(iface string, addr netip.Addr) (string, error) {
+	scope := ""
+	if addr.IsLinkLocalUnicast() {
+		scope = iface
+	}
+	infos, err := n.interfaces(scope)
+	if err != nil {
+		return "", err
+	}
+	for _, info := range infos {
+		for _, a := range info.Addresses {
+			if existing, err := netip.ParseAddr(a.Address); err == nil && existing == addr {
+				return info.Name, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// interfaces returns the named interface, or all interfaces when name is empty.
+func (n *Manager) interfaces(name string) ([]ifconfig.Info, error) {
+	if name == "" {
+		return n.List()
+	}
+	info, err := n.GetInfo(name)
+	if err != nil {
+		return nil, err
+	}
+	return []ifconfig.Info{*info}, nil
+}
+
+// ipFlags returns the ifconfig keywords for opts.
+func ipFlags(addr netip.Addr, opts IPOptions) ([]string, error) {
+	if opts == (IPOptions{}) {
+		return nil, nil
+	}
+	if addr.Is4() {
+		return nil, fmt.Errorf("address flags and lifetimes are only supported for IPv6 addresses")
+	}
+	if opts.VLTime < 0 || opts.PLTime < 0 {
+		return nil, fmt.Errorf("address lifetimes must not be negative")
+	}
+	if opts.VLTime > 0 && opts.PLTime > opts.VLTime {
+		return nil, fmt.Errorf("preferred lifetime must not exceed valid lifetime")
+	}
+
+	var flags []string
+	for _, f := range []struct {
+		set  bool
+		name string
+	}{
+		{opts.Anycast, "anycast"},
+		{opts.Tentative, "tentative"},
+		{opts.Deprecated, "deprecated"},
+		{opts.AutoConf, "autoconf"},
+	} {
+		if f.set {
+			flags = append(flags, f.name)
+		}
+	}
+	if opts.PLTime > 0 {
+		flags = append(flags, "pltime", strconv.Itoa(opts.PLTime))
+	}
+	if opts.VLTime > 0 {
+		flags = append(flags, "vltime", strconv.Itoa(opts.VLTime))
+	}
+	return flags, nil
+}
+
+func ipFamily(addr netip.Addr) string {
+	if addr.Is4() {
+		return inetFamily
+	}
+	return inet6Family
+}
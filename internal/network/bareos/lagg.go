@@ -0,0 +1,133 @@
+package bareos
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Supported link aggregation protocols.
+const (
+	LAGGFailover    = "failover"
+	LAGGLACP        = "lacp"
+	LAGGLoadBalance = "loadbalance"
+	LAGGRoundRobin  = "roundrobin"
+)
+
+// CreateLAGG creates a link aggregation interface with the given protocol and
+// member ports and returns its name
+func (n *Manager) CreateLAGG(name, proto string, ports []string) (string, error) {
+	switch proto {
+	case LAGGFailover, LAGGLACP, LAGGLoadBalance, LAGGRoundRobin:
+	default:
+		return "", fmt.Errorf("unsupported lagg protocol %q (want failover, lacp, loadbalance or roundrobin)", proto)
+	}
+	if len(ports) == 0 {
+		return "", fmt.Errorf("at least one lagg port is required")
+	}
+	for _, port := range ports {
+		if port == "" {
+			return "", fmt.Errorf("lagg port name must not be empty")
+		}
+	}
+
+	// Create lagg interface
+	out, err := n.cmdExec.Execute("ifconfig", "lagg", "create")
+	if err != nil {
+		return "", fmt.Errorf("failed to create lagg interface: %v", err)
+	}
+	laggName := strings.TrimSpace(out)
+
+	// Configure protocol and ports
+	args := []string{laggName, "laggproto", proto}
+	for _, port := range ports {
+		args = append(args, "laggport", port)
+	}
+	_, err = n.cmdExec.Execute("ifconfig", args...)
+	if err != nil {
+		return laggName, fmt.Errorf("failed to configure lagg %s: %v", laggName, err)
+	}
+
+	// Bring up the interface
+	_, err = n.cmdExec.Execute("ifconfig", laggName, "up")
+	if err != nil {
+		return laggName, fmt.Errorf("failed to bring up lagg %s: %v", laggName, err)
+	}
+
+	if name != "" {
+		// Rename to desired name
+		_, err = n.cmdExec.Execute("ifconfig", laggName, "name", name)
+		if err != nil {
+			return laggName, fmt.Errorf("failed to rename lagg to %s: %v", name, err)
+		}
+		laggName = name
+	}
+
+	return laggName, nil
+}
+
+// SetLAGGHash sets the hash layers (l2, l3, l4) used by lacp and loadbalance
+func (n *Manager) SetLAGGHash(name string, hash []string) error {
+	if name == "" {
+		return fmt.Errorf("lagg name is required")
+	}
+	if len(hash) == 0 {
+		return fmt.Errorf("at least one lagghash option is required")
+	}
+	for _, h := range hash {
+		switch h {
+		case "l2", "l3", "l4":
+		default:
+			return fmt.Errorf("unsupported lagghash option %q (want l2, l3 or l4)", h)
+		}
+	}
+
+	_, err := n.cmdExec.Execute("ifconfig", name, "lagghash", strings.Join(hash, ","))
+	if err != nil {
+		return fmt.Errorf("failed to set lagghash on %s: %v", name, err)
+	}
+
+	return nil
+}
+
+// AddLAGGPort adds a member port to a lagg interface
+func (n *Manager) AddLAGGPort(name, port string) error {
+	if name == "" {
+		return fmt.Errorf("lagg name is required")
+	}
+	if port == "" {
+		return fmt.Errorf("port name is required")
+	}
+
+	_, err := n.cmdExec.Execute("ifconfig", name, "laggport", port)
+	if err != nil {
+		return fmt.Errorf("failed to add port %s to lagg %s: %v", port, name, err)
+	}
+
+	return nil
+}
+
+// RemoveLAGGPort removes a member port from a lagg interface
+func (n *Manager) RemoveLAGGPort(name, port string) error {
+	if name == "" {
+		return fmt.Errorf("lagg name is required")
+	}
+	if port == "" {
+		return fmt.Errorf("port name is required")
+	}
+
+	_, err := n.cmdExec.Execute("ifconfig", name, "-laggport", port)
+	if err != nil {
+		return fmt.Errorf("failed to remove port %s from lagg %s: %v", port, name, err)
+	}
+
+	return nil
+}
+
+// DeleteLAGG deletes a lagg interface
+func (n *Manager) DeleteLAGG(name string) error {
+	if name == "" {
+		return fmt.Errorf("lagg name is required")
+	}
+
+	return n.DeleteInterface(name)
+}
@@ -2,8 +2,15 @@ package bareos
 
 import (
 	"FreeBSD-Command-manager/pkg/ifconfig"
+	"FreeBSD-Command-manager/pkg/neighbor"
+	"FreeBSD-Command-manager/pkg/netstat"
+	"bytes"
+	"context"
 	"fmt"
+	"io"
+	"net/netip"
 	"os/exec"
+	"strings"
 )
 
 // Config represents network interface configuration
@@ -24,14 +31,55 @@ type ManagerInterface interface {
 	DeleteBridge(name string) error
 	AddInterfaceToBridge(bridgeName, interfaceName string) error
 	RemoveInterfaceFromBridge(bridgeName, interfaceName string) error
+	SetBridge(name string, attrs BridgeAttrs) error
+	SetBridgeMember(bridgeName, interfaceName string, attrs BridgeMemberAttrs) error
+	BridgeAddresses(name string) ([]ifconfig.BridgeAddress, error)
 	CreateVLAN(name, parent string, vlanID int) error
 	DeleteVLAN(name string) error
-	CreateGRE(name, remote, local string) error
+	CreateGRE(name, remote, local string, opts TunnelOptions) error
 	DeleteGRE(name string) error
+	CreateGIF(name, remote, local string, opts TunnelOptions) error
+	DeleteGIF(name string) error
 	CreateVXLAN(name, local, remote, group, dev string, vxlanID int) error
 	DeleteVXLAN(name string) error
+	CreateLAGG(name, proto string, ports []string) (string, error)
+	SetLAGGHash(name string, hash []string) error
+	AddLAGGPort(name, port string) error
+	RemoveLAGGPort(name, port string) error
+	DeleteLAGG(name string) error
+	CreateEpair(name string) (EpairPair, error)
+	DeleteEpair(name string) (EpairPair, error)
+	CreateTap(name string, opts TapOptions) (string, error)
+	DeleteTap(name string) error
+	AddCARP(iface string, vhid, advskew int, pass, addr string) error
+	RemoveCARP(iface, addr string) error
+	SetCARPState(iface string, vhid int, state string) error
+	CARPStatus(iface string) (map[string][]ifconfig.CARPInfo, error)
+	AddIP(iface string, prefix netip.Prefix, opts IPOptions) error
+	AliasIP(iface string, prefix netip.Prefix, opts IPOptions) error
+	DeleteIP(iface string, addr netip.Addr) error
+	ListIPs(iface string) (map[string][]ifconfig.Address, error)
+	ListNeighbors(iface, family string) ([]neighbor.Entry, error)
+	AddNeighbor(spec NeighborSpec) error
+	DeleteNeighbor(iface, ip string) error
+	FlushNeighbors(iface, family string) error
+	ND6(iface string) (*neighbor.ND6Info, error)
+	SetAcceptRtadv(iface string, on bool) (*neighbor.ND6Info, error)
+	SolicitRouter(iface string) error
+	InterfaceStats(name string) ([]netstat.InterfaceStats, error)
+	InterfaceSamples(name string, wait, count int) ([]netstat.Counters, error)
+	AddRoute(spec RouteSpec) error
+	ChangeRoute(spec RouteSpec) error
+	GetRoute(family, destination string, fib int) (*netstat.RouteLookup, error)
+	DelRoute(family, network, gw string, fib int) error
+	ListRoutes(family string, fib int) ([]netstat.Route, error)
+	ListAllFIBRoutes(family string) ([]netstat.Route, error)
+	WatchRoutes(ctx context.Context, family, destination string, fn func(netstat.RouteMessage)) error
+	FIBs() (int, error)
 	List() ([]ifconfig.Info, error)
+	JailInterfaces(jail string) ([]ifconfig.Info, error)
 	GetInfo(name string) (*ifconfig.Info, error)
+	SetInterface(name string, attrs InterfaceAttrs) ([]AttrChange, error)
 }
 
 // CommandExecutor defines the interface for executing system commands
@@ -39,6 +87,13 @@ type CommandExecutor interface {
 	Execute(name string, args ...string) (string, error)
 }
 
+// CommandStreamer is implemented by executors that can run a long-lived
+// command, writing its output to w as it is produced until the command exits
+// or ctx is cancelled. Cancellation is not an error.
+type CommandStreamer interface {
+	Stream(ctx context.Context, w io.Writer, name string, args ...string) error
+}
+
 // Manager implements Manager for BareOS network operations
 type Manager struct {
 	cmdExec CommandExecutor
@@ -60,6 +115,18 @@ func (n *Manager) List() ([]ifconfig.Info, error) {
 	return ifconfig.ParseIfconfig(output), nil
 }
 
+// JailInterfaces returns the network interfaces seen inside a jail
+func (n *Manager) JailInterfaces(jail string) ([]ifconfig.Info, error) {
+	if jail == "" {
+		return nil, fmt.Errorf("jail name is required")
+	}
+	output, err := n.cmdExec.Execute("jexec", jail, "ifconfig")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list interfaces of jail %s: %v", jail, err)
+	}
+	return ifconfig.ParseIfconfig(output), nil
+}
+
 // GetInfo returns information about a specific network interface
 func (n *Manager) GetInfo(name string) (*ifconfig.Info, error) {
 	if name == "" {
@@ -91,6 +158,18 @@ func (r *RealCommandExecutor) Execute(name string, args ...string) (string, erro
 	return string(output), err
 }
 
+// Stream runs a system command, writing its standard output to w
+func (r *RealCommandExecutor) Stream(ctx context.Context, w io.Writer, name string, args ...string) error {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = w
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
 // DefaultManager returns the default network manager instance
 func DefaultManager() ManagerInterface {
 	cmdExec := NewRealCommandExecutor()
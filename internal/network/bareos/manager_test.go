@@ -2,8 +2,10 @@ package bareos
 
 import (
 	"FreeBSD-Command-manager/pkg/ifconfig"
+	"FreeBSD-Command-manager/pkg/netstat"
+	"context"
 	"errors"
-	"os/exec"
+	"net/netip"
 	"strings"
 	"testing"
 )
@@ -254,7 +256,7 @@ func TestBareOSManager_CreateGRE(t *testing.T) {
 
 			manager := NewManager(mockCmd)
 
-			err := manager.CreateGRE(tc.greName, tc.remote, tc.local)
+			err := manager.CreateGRE(tc.greName, tc.remote, tc.local, TunnelOptions{})
 
 			if tc.shouldError {
 				if err == nil {
@@ -725,168 +727,264 @@ func TestBareOSManager_RemoveInterfaceFromBridge(t *testing.T) {
 	}
 }
 
+func TestParsePrefix(t *testing.T) {
+	tests := []struct {
+		name        string
+		addr        string
+		mask        int
+		expected    string
+		shouldError bool
+	}{
+		{name: "address and mask", addr: "192.168.1.10", mask: 24, expected: "192.168.1.10/24"},
+		{name: "cidr", addr: "2001:db8::1/64", expected: "2001:db8::1/64"},
+		{name: "cidr with matching mask", addr: "10.0.0.1/8", mask: 8, expected: "10.0.0.1/8"},
+		{name: "bare IPv4 address", addr: "192.168.1.10", expected: "192.168.1.10/32"},
+		{name: "bare IPv6 address", addr: "2001:db8::1", expected: "2001:db8::1/128"},
+		{name: "empty", addr: "", shouldError: true},
+		{name: "invalid address", addr: "192.168.1.300", mask: 24, shouldError: true},
+		{name: "mask too long", addr: "192.168.1.10", mask: 33, shouldError: true},
+		{name: "negative mask", addr: "192.168.1.10", mask: -1, shouldError: true},
+		{name: "conflicting mask", addr: "10.0.0.1/8", mask: 24, shouldError: true},
+		{name: "zero prefix length", addr: "10.0.0.1/0", shouldError: true},
+		{name: "scope zone", addr: "fe80::1%em0", mask: 64, shouldError: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			prefix, err := ParsePrefix(tc.addr, tc.mask)
+			if tc.shouldError {
+				if err == nil {
+					t.Errorf("expected error but got none (%s)", prefix)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if prefix.String() != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, prefix)
+			}
+		})
+	}
+}
+
 func TestAddIP_InvalidInput(t *testing.T) {
+	manager := NewManager(NewMockCommandExecutor())
 	t.Run("empty iface", func(t *testing.T) {
-		err := AddIP("", "192.168.1.10", 24, inetFamily)
+		err := manager.AddIP("", netip.MustParsePrefix("192.168.1.10/24"), IPOptions{})
 		if err == nil {
 			t.Error("expected error for empty iface")
 		}
 	})
 	t.Run("empty ip", func(t *testing.T) {
-		err := AddIP("em0", "", 24, inetFamily)
+		err := manager.AddIP("em0", netip.Prefix{}, IPOptions{})
 		if err == nil {
 			t.Error("expected error for empty ip")
 		}
 	})
-	t.Run("zero mask", func(t *testing.T) {
-		err := AddIP("em0", "192.168.1.10", 0, inetFamily)
+	t.Run("IPv6 flags on IPv4 address", func(t *testing.T) {
+		err := manager.AddIP("em0", netip.MustParsePrefix("192.168.1.10/24"), IPOptions{Anycast: true})
+		if err == nil {
+			t.Error("expected error for IPv6 flags on an IPv4 address")
+		}
+	})
+	t.Run("pltime exceeds vltime", func(t *testing.T) {
+		err := manager.AddIP("em0", netip.MustParsePrefix("2001:db8::1/64"), IPOptions{PLTime: 7200, VLTime: 3600})
 		if err == nil {
-			t.Error("expected error for zero mask")
+			t.Error("expected error for pltime exceeding vltime")
 		}
 	})
 }
 
 func TestAliasIP_InvalidInput(t *testing.T) {
+	manager := NewManager(NewMockCommandExecutor())
 	t.Run("empty iface", func(t *testing.T) {
-		err := AliasIP("", "192.168.1.20", 24, inetFamily)
+		err := manager.AliasIP("", netip.MustParsePrefix("192.168.1.20/24"), IPOptions{})
 		if err == nil {
 			t.Error("expected error for empty iface")
 		}
 	})
 	t.Run("empty ip", func(t *testing.T) {
-		err := AliasIP("em0", "", 24, inetFamily)
+		err := manager.AliasIP("em0", netip.Prefix{}, IPOptions{})
 		if err == nil {
 			t.Error("expected error for empty ip")
 		}
 	})
-	t.Run("zero mask", func(t *testing.T) {
-		err := AliasIP("em0", "192.168.1.20", 0, inetFamily)
+	t.Run("negative lifetime", func(t *testing.T) {
+		err := manager.AliasIP("em0", netip.MustParsePrefix("2001:db8::1/64"), IPOptions{VLTime: -1})
 		if err == nil {
-			t.Error("expected error for zero mask")
+			t.Error("expected error for negative lifetime")
 		}
 	})
 }
 
 func TestDeleteIP_InvalidInput(t *testing.T) {
+	manager := NewManager(NewMockCommandExecutor())
 	t.Run("empty iface", func(t *testing.T) {
-		err := DeleteIP("", "192.168.1.10", 24, inetFamily)
+		err := manager.DeleteIP("", netip.MustParseAddr("192.168.1.10"))
 		if err == nil {
 			t.Error("expected error for empty iface")
 		}
 	})
 	t.Run("empty ip", func(t *testing.T) {
-		err := DeleteIP("em0", "", 24, inetFamily)
+		err := manager.DeleteIP("em0", netip.Addr{})
 		if err == nil {
 			t.Error("expected error for empty ip")
 		}
 	})
-	t.Run("zero mask", func(t *testing.T) {
-		err := DeleteIP("em0", "192.168.1.10", 0, inetFamily)
-		if err == nil {
-			t.Error("expected error for zero mask")
+}
+
+func TestManager_IPCommands(t *testing.T) {
+	mockCmd := NewMockCommandExecutor()
+	manager := NewManager(mockCmd)
+	if err := manager.AddIP("em0", netip.MustParsePrefix("192.168.1.10/24"), IPOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	opts := IPOptions{Anycast: true, AutoConf: true, PLTime: 3600, VLTime: 7200}
+	if err := manager.AliasIP("em0", netip.MustParsePrefix("2001:db8::1/64"), opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := manager.DeleteIP("em0", netip.MustParseAddr("192.168.1.10")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{
+		"ifconfig",
+		"ifconfig em0 inet 192.168.1.10/24 add",
+		"ifconfig",
+		"ifconfig em0 inet6 2001:db8::1/64 anycast autoconf pltime 3600 vltime 7200 alias",
+		"ifconfig em0 inet 192.168.1.10 -alias",
+	}
+	commands := mockCmd.GetCommands()
+	if len(commands) != len(expected) {
+		t.Fatalf("expected %d commands, got %v", len(expected), commands)
+	}
+	for i, c := range expected {
+		if commands[i] != c {
+			t.Errorf("expected command %q, got %q", c, commands[i])
 		}
-	})
+	}
 }
 
-func TestAddRoute(t *testing.T) {
-	oldExecCommand := execCommand
-	defer func() { execCommand = oldExecCommand }()
+func TestAddIP_Duplicate(t *testing.T) {
+	const ifconfigOutput = `em0: flags=8843<UP,BROADCAST,RUNNING,SIMPLEX,MULTICAST> metric 0 mtu 1500
+	inet 192.168.1.10 netmask 0xffffff00 broadcast 192.168.1.255
+	inet6 fe80::1%em0 prefixlen 64 scopeid 0x1
+em1: flags=8843<UP,BROADCAST,RUNNING,SIMPLEX,MULTICAST> metric 0 mtu 1500
+	inet6 2001:db8:0::1 prefixlen 64
+`
+	mockCmd := NewMockCommandExecutor()
+	mockCmd.SetOutput("ifconfig", ifconfigOutput)
+	mockCmd.SetOutput("ifconfig em1", "em1: flags=8843<UP> metric 0 mtu 1500\n")
+	manager := NewManager(mockCmd)
 
-	var calledArgs []string
-	execCommand = func(name string, args ...string) *exec.Cmd {
-		calledArgs = append([]string{name}, args...)
-		return exec.Command("echo") // always succeeds
+	err := manager.AddIP("em1", netip.MustParsePrefix("192.168.1.10/24"), IPOptions{})
+	if err == nil || !strings.Contains(err.Error(), "already configured on em0") {
+		t.Errorf("expected duplicate error, got %v", err)
 	}
+	err = manager.AliasIP("em0", netip.MustParsePrefix("2001:db8::1/64"), IPOptions{})
+	if err == nil || !strings.Contains(err.Error(), "already configured on em1") {
+		t.Errorf("expected duplicate error, got %v", err)
+	}
+	// Link-local addresses only have to be unique on their own link
+	if err := manager.AliasIP("em1", netip.MustParsePrefix("fe80::1/64"), IPOptions{}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	for _, c := range mockCmd.GetCommands() {
+		if strings.HasSuffix(c, " add") || strings.Contains(c, "2001:db8::1/64 alias") {
+			t.Errorf("unexpected command %q", c)
+		}
+	}
+}
 
-	err := AddRoute(inetFamily, "10.0.0.0/24", "10.0.0.1")
+func TestListIPs(t *testing.T) {
+	mockCmd := NewMockCommandExecutor()
+	mockCmd.SetOutput("ifconfig em0", `em0: flags=8843<UP,BROADCAST,RUNNING,SIMPLEX,MULTICAST> metric 0 mtu 1500
+	inet 192.168.1.10 netmask 0xffffff00 broadcast 192.168.1.255
+	inet6 2001:db8::1 prefixlen 64 deprecated
+`)
+	ips, err := NewManager(mockCmd).ListIPs("em0")
 	if err != nil {
-		t.Errorf("expected no error, got %v", err)
+		t.Fatalf("unexpected error: %v", err)
+	}
+	addrs := ips["em0"]
+	if len(addrs) != 2 {
+		t.Fatalf("expected 2 addresses, got %+v", addrs)
+	}
+	if addrs[0].Prefix != "192.168.1.10/24" || addrs[0].Broadcast != "192.168.1.255" {
+		t.Errorf("unexpected IPv4 address: %+v", addrs[0])
 	}
-	if len(calledArgs) == 0 || calledArgs[0] != "route" {
-		t.Errorf("expected route command, got %v", calledArgs)
+	if addrs[1].Family != "inet6" || len(addrs[1].Flags) != 1 || addrs[1].Flags[0] != "deprecated" {
+		t.Errorf("unexpected IPv6 address: %+v", addrs[1])
 	}
 }
 
-func TestAddRoute_Error(t *testing.T) {
-	oldExecCommand := execCommand
-	defer func() { execCommand = oldExecCommand }()
+func TestAddRoute(t *testing.T) {
+	mockCmd := NewMockCommandExecutor()
+	manager := NewManager(mockCmd)
 
-	execCommand = func(_ string, _ ...string) *exec.Cmd {
-		return exec.Command("false") // always fails
+	err := manager.AddRoute(RouteSpec{Family: inetFamily, Network: "10.0.0.0/24", Gateway: "10.0.0.1", FIB: CurrentFIB})
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	err = manager.AddRoute(RouteSpec{Family: inet6Family, Network: "2001:db8::/64", Gateway: "fe80::1", Iface: "em0", FIB: 1})
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	commands := mockCmd.GetCommands()
+	if len(commands) != 2 || commands[0] != "route -n add -inet 10.0.0.0/24 10.0.0.1" ||
+		commands[1] != "route -n add -fib 1 -inet6 2001:db8::/64 fe80::1 -ifp em0" {
+		t.Errorf("unexpected commands: %v", commands)
 	}
+}
+
+func TestAddRoute_Error(t *testing.T) {
+	mockCmd := NewMockCommandExecutor()
+	mockCmd.SetError("route -n add -inet 10.0.0.0/24 10.0.0.1", errors.New("exit status 1"))
 
-	err := AddRoute(inetFamily, "10.0.0.0/24", "10.0.0.1")
+	err := NewManager(mockCmd).AddRoute(RouteSpec{Family: inetFamily, Network: "10.0.0.0/24", Gateway: "10.0.0.1", FIB: CurrentFIB})
 	if err == nil || !strings.Contains(err.Error(), "route add error") {
 		t.Errorf("expected route add error, got %v", err)
 	}
 }
 
 func TestDelRoute_LastDefault(t *testing.T) {
-	oldListRoutes := listRoutes
-	oldExecCommand := execCommand
-	defer func() { listRoutes = oldListRoutes; execCommand = oldExecCommand }()
-
-	listRoutes = func(family string) (string, error) {
-		if family == inetFamily {
-			return "default 10.0.0.1\n", nil // only one default
-		}
-		if family == inet6Family {
-			return "default fe80::1\n2001:db8::/64 fe80::1\n", nil
-		}
-		return "", nil
-	}
+	mockCmd := NewMockCommandExecutor()
+	mockCmd.SetOutput("netstat -rnW -f inet", "default 10.0.0.1\n") // only one default
+	mockCmd.SetOutput("netstat -rnW -f inet6", "default fe80::1\n2001:db8::/64 fe80::1\n")
 
-	err := DelRoute(inetFamily, "default")
+	err := NewManager(mockCmd).DelRoute(inetFamily, "default", "", CurrentFIB)
 	if err == nil || !strings.Contains(err.Error(), "cannot delete the last default route") {
 		t.Errorf("expected last default route error, got %v", err)
 	}
 }
 
 func TestDelRoute_Success(t *testing.T) {
-	oldListRoutes := listRoutes
-	oldExecCommand := execCommand
-	defer func() { listRoutes = oldListRoutes; execCommand = oldExecCommand }()
-
-	listRoutes = func(family string) (string, error) {
-		if family == inetFamily {
-			return "default 10.0.0.1\ndefault 10.0.0.2\n", nil // two defaults
-		}
-		if family == inet6Family {
-			return "default fe80::1\ndefault fe80::2\n", nil // two defaults
-		}
-		return "", nil
-	}
-	execCommand = func(_ string, _ ...string) *exec.Cmd {
-		return exec.Command("echo", "") // always succeeds
-	}
+	mockCmd := NewMockCommandExecutor()
+	mockCmd.SetOutput("netstat -rnW -f inet", "default 10.0.0.1\ndefault 10.0.0.2\n") // two defaults
 
-	err := DelRoute(inetFamily, "default")
+	err := NewManager(mockCmd).DelRoute(inetFamily, "default", "", CurrentFIB)
 	if err != nil {
 		t.Errorf("expected no error, got %v", err)
 	}
+	commands := mockCmd.GetCommands()
+	if commands[len(commands)-1] != "route -n delete -inet default" {
+		t.Errorf("unexpected commands: %v", commands)
+	}
 }
 
-func TestListAllRoutes(t *testing.T) {
-	oldListRoutes := listRoutes
-	defer func() { listRoutes = oldListRoutes }()
-
-	listRoutes = func(family string) (string, error) {
-		if family == inetFamily {
-			return `Destination        Gateway            Flags     Netif
+func TestListRoutes(t *testing.T) {
+	mockCmd := NewMockCommandExecutor()
+	mockCmd.SetOutput("netstat -rnW -f inet", `Destination        Gateway            Flags     Netif
 10.0.0.0/24        10.0.0.1           UGS      em0
 default             10.0.0.1           UGS      em0
-`, nil
-		}
-		if family == inet6Family {
-			return `Destination        Gateway            Flags     Netif
+`)
+	mockCmd.SetOutput("netstat -rnW -f inet6", `Destination        Gateway            Flags     Netif
 2001:db8::/64      fe80::1            UGS      em1
 default            fe80::1            UGS      em1
-`, nil
-		}
-		return "", nil
-	}
+`)
+
+	mockCmd.SetOutput("sysctl -n net.my_fibnum", "0\n")
 
-	routes, err := ListAllRoutes(inetFamily)
+	routes, err := NewManager(mockCmd).ListRoutes(inetFamily, CurrentFIB)
 	if err != nil {
 		t.Errorf("expected no error, got %v", err)
 	}
@@ -898,15 +996,11 @@ default            fe80::1            UGS      em1
 	}
 }
 
-func TestListAllRoutes_Error(t *testing.T) {
-	oldListRoutes := listRoutes
-	defer func() { listRoutes = oldListRoutes }()
-
-	listRoutes = func(_ string) (string, error) {
-		return "", errors.New("fail")
-	}
+func TestListRoutes_Error(t *testing.T) {
+	mockCmd := NewMockCommandExecutor()
+	mockCmd.SetError("netstat -rnW -f inet -F 0", errors.New("fail"))
 
-	_, err := ListAllRoutes(inetFamily)
+	_, err := NewManager(mockCmd).ListRoutes(inetFamily, 0)
 	if err == nil || !strings.Contains(err.Error(), "fail") {
 		t.Errorf("expected error, got %v", err)
 	}
@@ -969,6 +1063,29 @@ func TestManager_GetInfo_IPs(t *testing.T) {
 	}
 }
 
+func TestManager_JailInterfaces(t *testing.T) {
+	mockCmd := NewMockCommandExecutor()
+	mockCmd.SetOutput("jexec web ifconfig", `epair0b: flags=1008843<UP,BROADCAST,RUNNING,SIMPLEX,MULTICAST,LOWER_UP> metric 0 mtu 1500
+    inet 10.20.0.10 netmask 0xffffff00 broadcast 10.20.0.255
+`)
+	manager := NewManager(mockCmd)
+	infos, err := manager.JailInterfaces("web")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(infos) != 1 || infos[0].Name != "epair0b" {
+		t.Errorf("unexpected interfaces: %+v", infos)
+	}
+
+	if _, err := manager.JailInterfaces(""); err == nil {
+		t.Error("expected error but got none")
+	}
+	mockCmd.SetError("jexec down ifconfig", errors.New("jail not found"))
+	if _, err := manager.JailInterfaces("down"); err == nil {
+		t.Error("expected error but got none")
+	}
+}
+
 func findInfo(infos []ifconfig.Info, name string) *ifconfig.Info {
 	for i := range infos {
 		if infos[i].Name == name {
@@ -977,3 +1094,992 @@ func findInfo(infos []ifconfig.Info, name string) *ifconfig.Info {
 	}
 	return nil
 }
+
+func TestBareOSManager_SetInterface(t *testing.T) {
+	mockCmd := NewMockCommandExecutor()
+	mockCmd.SetOutput("ifconfig em0", `em0: flags=1008843<UP,BROADCAST,RUNNING,SIMPLEX,MULTICAST,LOWER_UP> metric 0 mtu 1500
+	description: uplink
+	options=48505bb<RXCSUM,TXCSUM,TSO4,LRO>
+	ether 08:00:27:20:af:31
+	groups: jails
+`)
+	manager := NewManager(mockCmd)
+
+	mtu, fib, down := 9000, 0, false
+	description := "uplink"
+	changes, err := manager.SetInterface("em0", InterfaceAttrs{
+		MTU:          &mtu,
+		Description:  &description,
+		FIB:          &fib,
+		Up:           &down,
+		Ether:        "08:00:27:20:AF:31",
+		Group:        "jails",
+		Capabilities: []string{"-tso", "+lro", "-rxcsum6"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"ifconfig em0",
+		"ifconfig em0 mtu 9000",
+		"ifconfig em0 -tso",
+		"ifconfig em0 down",
+	}
+	commands := mockCmd.GetCommands()
+	if strings.Join(commands, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected commands %v, got %v", expected, commands)
+	}
+	if len(changes) != 3 {
+		t.Fatalf("expected 3 changes, got %+v", changes)
+	}
+	if changes[0] != (AttrChange{Attribute: "mtu", Before: "1500", After: "9000"}) {
+		t.Errorf("unexpected mtu change: %+v", changes[0])
+	}
+	if changes[2] != (AttrChange{Attribute: "state", Before: "up", After: "down"}) {
+		t.Errorf("unexpected state change: %+v", changes[2])
+	}
+}
+
+func TestBareOSManager_SetInterface_Invalid(t *testing.T) {
+	mockCmd := NewMockCommandExecutor()
+	mockCmd.SetOutput("ifconfig em0", "em0: flags=8843<UP,BROADCAST,RUNNING,SIMPLEX,MULTICAST> metric 0 mtu 1500\n")
+	manager := NewManager(mockCmd)
+
+	if _, err := manager.SetInterface("", InterfaceAttrs{}); err == nil {
+		t.Error("expected error for empty interface name")
+	}
+	if _, err := manager.SetInterface("em0", InterfaceAttrs{Ether: "not-a-mac"}); err == nil {
+		t.Error("expected error for invalid MAC")
+	}
+	mtu := -1
+	if _, err := manager.SetInterface("em0", InterfaceAttrs{MTU: &mtu}); err == nil {
+		t.Error("expected error for invalid MTU")
+	}
+}
+
+func TestBareOSManager_CreateLAGG(t *testing.T) {
+	tests := []struct {
+		name        string
+		laggName    string
+		proto       string
+		ports       []string
+		expected    []string
+		created     string
+		shouldError bool
+	}{
+		{
+			name:     "successful lacp creation",
+			laggName: "uplink0",
+			proto:    LAGGLACP,
+			ports:    []string{"em0", "em1"},
+			expected: []string{
+				"ifconfig lagg create",
+				"ifconfig lagg0 laggproto lacp laggport em0 laggport em1",
+				"ifconfig lagg0 up",
+				"ifconfig lagg0 name uplink0",
+			},
+			created: "uplink0",
+		},
+		{
+			name:     "failover with default name",
+			proto:    LAGGFailover,
+			ports:    []string{"em0"},
+			expected: []string{"ifconfig lagg create", "ifconfig lagg0 laggproto failover laggport em0", "ifconfig lagg0 up"},
+			created:  "lagg0",
+		},
+		{name: "unsupported protocol", proto: "bonding", ports: []string{"em0"}, shouldError: true},
+		{name: "no ports", proto: LAGGRoundRobin, shouldError: true},
+		{name: "empty port", proto: LAGGLACP, ports: []string{"em0", ""}, shouldError: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCmd := NewMockCommandExecutor()
+			mockCmd.SetOutput("ifconfig lagg create", "lagg0\n")
+			manager := NewManager(mockCmd)
+
+			created, err := manager.CreateLAGG(tc.laggName, tc.proto, tc.ports)
+			if tc.shouldError {
+				if err == nil {
+					t.Error("expected error but got none")
+				}
+				if got := mockCmd.GetCommands(); len(got) != 0 {
+					t.Errorf("expected no commands, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if created != tc.created {
+				t.Errorf("expected lagg %s, got %s", tc.created, created)
+			}
+			if got := mockCmd.GetCommands(); strings.Join(got, "\n") != strings.Join(tc.expected, "\n") {
+				t.Errorf("expected commands %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestBareOSManager_LAGGPorts(t *testing.T) {
+	mockCmd := NewMockCommandExecutor()
+	manager := NewManager(mockCmd)
+	runBridgeMemberTest(t, mockCmd, manager.AddLAGGPort, "lagg0", "em2", "ifconfig lagg0 laggport em2", false)
+
+	mockCmd.ClearCommands()
+	runBridgeMemberTest(t, mockCmd, manager.RemoveLAGGPort, "lagg0", "em2", "ifconfig lagg0 -laggport em2", false)
+	runBridgeMemberTest(t, mockCmd, manager.RemoveLAGGPort, "", "em2", "", true)
+
+	mockCmd.ClearCommands()
+	runDeleteTest(t, mockCmd, manager.DeleteLAGG, "lagg0", "ifconfig lagg0 destroy", false)
+}
+
+func TestBareOSManager_SetLAGGHash(t *testing.T) {
+	mockCmd := NewMockCommandExecutor()
+	manager := NewManager(mockCmd)
+
+	if err := manager.SetLAGGHash("lagg0", []string{"l3", "l4"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cmds := mockCmd.GetCommands(); len(cmds) != 1 || cmds[0] != "ifconfig lagg0 lagghash l3,l4" {
+		t.Errorf("unexpected commands: %v", cmds)
+	}
+	if err := manager.SetLAGGHash("lagg0", []string{"l7"}); err == nil {
+		t.Error("expected error for invalid hash layer")
+	}
+}
+
+func TestBareOSManager_CreateEpair(t *testing.T) {
+	mockCmd := NewMockCommandExecutor()
+	mockCmd.SetOutput("ifconfig epair create", "epair3a\n")
+	manager := NewManager(mockCmd)
+
+	pair, err := manager.CreateEpair("web")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pair != (EpairPair{A: "weba", B: "webb"}) {
+		t.Errorf("unexpected pair: %+v", pair)
+	}
+	expected := []string{
+		"ifconfig epair create",
+		"ifconfig epair3a name weba",
+		"ifconfig epair3b name webb",
+		"ifconfig weba up",
+		"ifconfig webb up",
+	}
+	if got := mockCmd.GetCommands(); strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected commands %v, got %v", expected, got)
+	}
+
+	mockCmd = NewMockCommandExecutor()
+	mockCmd.SetOutput("ifconfig epair create", "epair0a\n")
+	pair, err = NewManager(mockCmd).CreateEpair("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pair != (EpairPair{A: "epair0a", B: "epair0b"}) {
+		t.Errorf("unexpected pair: %+v", pair)
+	}
+}
+
+func TestBareOSManager_DeleteEpair(t *testing.T) {
+	epairOutput := func(name string) string {
+		return name + `: flags=8843<UP,BROADCAST,RUNNING,SIMPLEX,MULTICAST> metric 0 mtu 1500
+	ether 02:5d:a4:5e:11:0b
+	groups: epair
+`
+	}
+	tests := []struct {
+		name        string
+		arg         string
+		outputs     map[string]string
+		expected    EpairPair
+		destroyCmd  string
+		shouldError bool
+	}{
+		{
+			name:       "delete by b side",
+			arg:        "epair0b",
+			outputs:    map[string]string{"ifconfig epair0b": epairOutput("epair0b")},
+			expected:   EpairPair{A: "epair0a", B: "epair0b"},
+			destroyCmd: "ifconfig epair0b destroy",
+		},
+		{
+			name:       "delete by pair name",
+			arg:        "web",
+			outputs:    map[string]string{"ifconfig weba": epairOutput("weba")},
+			expected:   EpairPair{A: "weba", B: "webb"},
+			destroyCmd: "ifconfig weba destroy",
+		},
+		{
+			name:        "not an epair",
+			arg:         "em0",
+			outputs:     map[string]string{"ifconfig em0": "em0: flags=8843<UP> metric 0 mtu 1500\n"},
+			shouldError: true,
+		},
+		{name: "missing", arg: "epair9", shouldError: true},
+		{name: "empty name", arg: "", shouldError: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCmd := NewMockCommandExecutor()
+			for c, out := range tc.outputs {
+				mockCmd.SetOutput(c, out)
+			}
+			pair, err := NewManager(mockCmd).DeleteEpair(tc.arg)
+			if tc.shouldError {
+				if err == nil {
+					t.Error("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if pair != tc.expected {
+				t.Errorf("expected pair %+v, got %+v", tc.expected, pair)
+			}
+			commands := mockCmd.GetCommands()
+			if commands[len(commands)-1] != tc.destroyCmd {
+				t.Errorf("expected last command %s, got %v", tc.destroyCmd, commands)
+			}
+		})
+	}
+}
+
+func TestBareOSManager_CreateTap(t *testing.T) {
+	mockCmd := NewMockCommandExecutor()
+	mockCmd.SetOutput("ifconfig tap create", "tap0\n")
+	manager := NewManager(mockCmd)
+
+	name, err := manager.CreateTap("vm0", TapOptions{Owner: "bhyve:bhyve", Persist: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if name != "vm0" {
+		t.Errorf("expected name vm0, got %s", name)
+	}
+	expected := []string{
+		"ifconfig tap create",
+		"ifconfig tap0 name vm0",
+		"ifconfig vm0 link0",
+		"chown bhyve:bhyve /dev/vm0",
+		"ifconfig vm0 up",
+	}
+	if got := mockCmd.GetCommands(); strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected commands %v, got %v", expected, got)
+	}
+
+	mockCmd.ClearCommands()
+	runDeleteTest(t, mockCmd, manager.DeleteTap, "vm0", "ifconfig vm0 destroy", false)
+	runDeleteTest(t, mockCmd, manager.DeleteTap, "", "", true)
+}
+
+func TestBareOSManager_CreateTunnelOptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		create      func(m *Manager) error
+		expected    []string
+		shouldError bool
+	}{
+		{
+			name: "6in4 gif tunnel",
+			create: func(m *Manager) error {
+				return m.CreateGIF("he-ipv6", "198.51.100.1", "192.0.2.1",
+					TunnelOptions{InnerLocal: "2001:db8:1::2", InnerRemote: "2001:db8:1::1"})
+			},
+			expected: []string{
+				"ifconfig gif create",
+				"ifconfig gif0 tunnel 192.0.2.1 198.51.100.1",
+				"ifconfig gif0 inet6 2001:db8:1::2 2001:db8:1::1 prefixlen 128",
+				"ifconfig gif0 up",
+				"ifconfig gif0 name he-ipv6",
+			},
+		},
+		{
+			name: "GRE over IPv6 with key and inner IPv4",
+			create: func(m *Manager) error {
+				return m.CreateGRE("", "2001:db8::2", "2001:db8::1",
+					TunnelOptions{InnerLocal: "10.0.0.1", InnerRemote: "10.0.0.2", Key: 42})
+			},
+			expected: []string{
+				"ifconfig gre create",
+				"ifconfig gre0 inet6 tunnel 2001:db8::1 2001:db8::2",
+				"ifconfig gre0 inet 10.0.0.1 10.0.0.2 netmask 255.255.255.255",
+				"ifconfig gre0 grekey 42",
+				"ifconfig gre0 up",
+			},
+		},
+		{
+			name: "mismatched outer families",
+			create: func(m *Manager) error {
+				return m.CreateGIF("gif0", "2001:db8::2", "192.0.2.1", TunnelOptions{})
+			},
+			shouldError: true,
+		},
+		{
+			name: "mismatched inner families",
+			create: func(m *Manager) error {
+				return m.CreateGRE("gre0", "198.51.100.1", "192.0.2.1", TunnelOptions{InnerLocal: "10.0.0.1", InnerRemote: "fd00::2"})
+			},
+			shouldError: true,
+		},
+		{
+			name: "missing inner remote",
+			create: func(m *Manager) error {
+				return m.CreateGIF("gif0", "198.51.100.1", "192.0.2.1", TunnelOptions{InnerLocal: "10.0.0.1"})
+			},
+			shouldError: true,
+		},
+		{
+			name: "invalid outer address",
+			create: func(m *Manager) error {
+				return m.CreateGRE("gre0", "remote.example", "192.0.2.1", TunnelOptions{})
+			},
+			shouldError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCmd := NewMockCommandExecutor()
+			mockCmd.SetOutput("ifconfig gif create", "gif0\n")
+			mockCmd.SetOutput("ifconfig gre create", "gre0\n")
+			err := tc.create(NewManager(mockCmd))
+			if tc.shouldError {
+				if err == nil {
+					t.Error("expected error but got none")
+				}
+				if len(mockCmd.GetCommands()) != 0 {
+					t.Errorf("expected no commands before validation, got %v", mockCmd.GetCommands())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := mockCmd.GetCommands(); strings.Join(got, "\n") != strings.Join(tc.expected, "\n") {
+				t.Errorf("expected commands %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestBareOSManager_DeleteGIF(t *testing.T) {
+	mockCmd := NewMockCommandExecutor()
+	manager := NewManager(mockCmd)
+	runDeleteTest(t, mockCmd, manager.DeleteGIF, "gif0", "ifconfig gif0 destroy", false)
+	runDeleteTest(t, mockCmd, manager.DeleteGIF, "", "", true)
+}
+
+func TestBareOSManager_SetBridge(t *testing.T) {
+	priority, maxAddr, timeout := 4096, 2000, 1200
+	badPriority := 100
+	tests := []struct {
+		name        string
+		bridgeName  string
+		attrs       BridgeAttrs
+		expected    string
+		shouldError bool
+	}{
+		{
+			name:       "all attributes",
+			bridgeName: "bridge0",
+			attrs:      BridgeAttrs{Proto: "rstp", Priority: &priority, MaxAddr: &maxAddr, Timeout: &timeout, Span: []string{"em2", "-em3"}},
+			expected:   "ifconfig bridge0 proto rstp priority 4096 maxaddr 2000 timeout 1200 span em2 -span em3",
+		},
+		{name: "empty bridge name", attrs: BridgeAttrs{Proto: "stp"}, shouldError: true},
+		{name: "no attributes", bridgeName: "bridge0", shouldError: true},
+		{name: "invalid proto", bridgeName: "bridge0", attrs: BridgeAttrs{Proto: "mstp"}, shouldError: true},
+		{name: "invalid priority", bridgeName: "bridge0", attrs: BridgeAttrs{Priority: &badPriority}, shouldError: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCmd := NewMockCommandExecutor()
+			err := NewManager(mockCmd).SetBridge(tc.bridgeName, tc.attrs)
+			if tc.shouldError {
+				if err == nil {
+					t.Error("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cmds := mockCmd.GetCommands(); len(cmds) != 1 || cmds[0] != tc.expected {
+				t.Errorf("expected command %s, got %v", tc.expected, cmds)
+			}
+		})
+	}
+}
+
+func TestBareOSManager_SetBridgeMember(t *testing.T) {
+	on, off := true, false
+	priority, cost, badPriority := 64, 20000, 100
+	mockCmd := NewMockCommandExecutor()
+	manager := NewManager(mockCmd)
+
+	err := manager.SetBridgeMember("bridge0", "em0", BridgeMemberAttrs{
+		STP: &on, Learn: &off, Discover: &on, Sticky: &on, Private: &off, Priority: &priority, PathCost: &cost,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "ifconfig bridge0 stp em0 -learn em0 discover em0 sticky em0 -private em0 ifpriority em0 64 ifpathcost em0 20000"
+	if cmds := mockCmd.GetCommands(); len(cmds) != 1 || cmds[0] != expected {
+		t.Errorf("expected command %s, got %v", expected, cmds)
+	}
+
+	if err := manager.SetBridgeMember("bridge0", "em0", BridgeMemberAttrs{}); err == nil {
+		t.Error("expected error for no attributes")
+	}
+	if err := manager.SetBridgeMember("bridge0", "", BridgeMemberAttrs{STP: &on}); err == nil {
+		t.Error("expected error for empty interface")
+	}
+	if err := manager.SetBridgeMember("bridge0", "em0", BridgeMemberAttrs{Priority: &badPriority}); err == nil {
+		t.Error("expected error for invalid priority")
+	}
+}
+
+func TestBareOSManager_BridgeAddresses(t *testing.T) {
+	mockCmd := NewMockCommandExecutor()
+	mockCmd.SetOutput("ifconfig bridge0 addr", "58:9c:fc:00:44:29 Vlan1 tap0 1187 flags=0<>\n")
+	addrs, err := NewManager(mockCmd).BridgeAddresses("bridge0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(addrs) != 1 || addrs[0].Interface != "tap0" {
+		t.Errorf("unexpected addresses: %+v", addrs)
+	}
+}
+
+func TestBareOSManager_AddCARP(t *testing.T) {
+	tests := []struct {
+		name        string
+		vhid        int
+		advskew     int
+		pass        string
+		addr        string
+		expected    string
+		shouldError bool
+	}{
+		{name: "IPv4 with pass", vhid: 1, advskew: 100, pass: "secret", addr: "192.0.2.10/24",
+			expected: "ifconfig em0 vhid 1 advskew 100 pass secret inet 192.0.2.10/24 alias"},
+		{name: "bare IPv6 address", vhid: 2, addr: "2001:db8::10",
+			expected: "ifconfig em0 vhid 2 advskew 0 inet6 2001:db8::10/128 alias"},
+		{name: "vhid out of range", vhid: 256, addr: "192.0.2.10", shouldError: true},
+		{name: "advskew out of range", vhid: 1, advskew: 255, addr: "192.0.2.10", shouldError: true},
+		{name: "pass too long", vhid: 1, pass: "012345678901234567890", addr: "192.0.2.10", shouldError: true},
+		{name: "invalid address", vhid: 1, addr: "192.0.2", shouldError: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCmd := NewMockCommandExecutor()
+			err := NewManager(mockCmd).AddCARP("em0", tc.vhid, tc.advskew, tc.pass, tc.addr)
+			if tc.shouldError {
+				if err == nil {
+					t.Error("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			commands := mockCmd.GetCommands()
+			if len(commands) != 1 || commands[0] != tc.expected {
+				t.Errorf("expected %q, got %v", tc.expected, commands)
+			}
+		})
+	}
+}
+
+func TestBareOSManager_AddCARP_ErrorHidesPass(t *testing.T) {
+	mockCmd := NewMockCommandExecutor()
+	mockCmd.SetError("ifconfig em0 vhid 1 advskew 0 pass secret inet 192.0.2.10/32 alias", errors.New("exit status 1"))
+	err := NewManager(mockCmd).AddCARP("em0", 1, 0, "secret", "192.0.2.10")
+	if err == nil {
+		t.Fatal("expected error but got none")
+	}
+	if strings.Contains(err.Error(), "secret") {
+		t.Errorf("error leaks pass phrase: %v", err)
+	}
+}
+
+func TestBareOSManager_RemoveCARP(t *testing.T) {
+	mockCmd := NewMockCommandExecutor()
+	if err := NewManager(mockCmd).RemoveCARP("em0", "192.0.2.10/24"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	commands := mockCmd.GetCommands()
+	if len(commands) != 1 || commands[0] != "ifconfig em0 inet 192.0.2.10 -alias" {
+		t.Errorf("unexpected commands: %v", commands)
+	}
+}
+
+func TestBareOSManager_SetCARPState(t *testing.T) {
+	mockCmd := NewMockCommandExecutor()
+	manager := NewManager(mockCmd)
+	if err := manager.SetCARPState("em0", 1, "BACKUP"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	commands := mockCmd.GetCommands()
+	if len(commands) != 1 || commands[0] != "ifconfig em0 vhid 1 state backup" {
+		t.Errorf("unexpected commands: %v", commands)
+	}
+	if err := manager.SetCARPState("em0", 1, "init"); err == nil {
+		t.Error("expected error for invalid state")
+	}
+	if err := manager.SetCARPState("em0", 0, "master"); err == nil {
+		t.Error("expected error for invalid vhid")
+	}
+}
+
+func TestBareOSManager_CARPStatus(t *testing.T) {
+	mockCmd := NewMockCommandExecutor()
+	mockCmd.SetOutput("ifconfig", `em0: flags=8843<UP,BROADCAST,RUNNING,SIMPLEX,MULTICAST> metric 0 mtu 1500
+	inet 192.0.2.2 netmask 0xffffff00 broadcast 192.0.2.255
+	inet 192.0.2.10 netmask 0xffffff00 broadcast 192.0.2.255 vhid 1
+	carp: MASTER vhid 1 advbase 1 advskew 0
+lo0: flags=8049<UP,LOOPBACK,RUNNING,MULTICAST> metric 0 mtu 16384
+	inet 127.0.0.1 netmask 0xff000000`)
+	status, err := NewManager(mockCmd).CARPStatus("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(status) != 1 || len(status["em0"]) != 1 || status["em0"][0].State != "MASTER" {
+		t.Errorf("unexpected status: %+v", status)
+	}
+}
+
+func TestDelRoute_FIB(t *testing.T) {
+	mockCmd := NewMockCommandExecutor()
+	mockCmd.SetOutput("netstat -rnW -f inet -F 1", "default 10.1.0.1\n")
+
+	err := NewManager(mockCmd).DelRoute(inetFamily, "default", "", 1)
+	if err == nil || !strings.Contains(err.Error(), "cannot delete the last default route") {
+		t.Errorf("expected last default route error, got %v", err)
+	}
+	if err := NewManager(mockCmd).DelRoute(inetFamily, "10.0.0.0/24", "", 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	commands := mockCmd.GetCommands()
+	if commands[len(commands)-1] != "route -n delete -fib 1 -inet 10.0.0.0/24" {
+		t.Errorf("unexpected commands: %v", commands)
+	}
+}
+
+func TestListAllFIBRoutes(t *testing.T) {
+	mockCmd := NewMockCommandExecutor()
+	mockCmd.SetOutput("sysctl -n net.fibs", "2\n")
+	mockCmd.SetOutput("netstat -rnW -F 0", `Destination        Gateway            Flags     Netif
+default            192.0.2.1          UGS       em0
+`)
+	mockCmd.SetOutput("netstat -rnW -F 1", `Destination        Gateway            Flags     Netif
+default            10.1.0.1           UGS       em1
+10.1.0.0/24        link#2             U         em1
+`)
+
+	routes, err := NewManager(mockCmd).ListAllFIBRoutes("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(routes) != 3 {
+		t.Fatalf("expected 3 routes, got %+v", routes)
+	}
+	if routes[0].FIB != 0 || routes[1].FIB != 1 || routes[2].FIB != 1 {
+		t.Errorf("unexpected FIB tags: %+v", routes)
+	}
+	if routes[1].Gateway != "10.1.0.1" {
+		t.Errorf("unexpected route: %+v", routes[1])
+	}
+}
+
+func TestWatchRoutes(t *testing.T) {
+	mockCmd := NewMockCommandExecutor()
+	mockCmd.SetOutput("route -n monitor", `got message of size 200 on Mon Oct 19 12:00:00 2026
+RTM_ADD: Add Route: len 200, pid: 1234, seq 1, errno 0, flags:<UP,GATEWAY,DONE,STATIC>
+locks:  inits: 
+sockaddrs: <DST,GATEWAY,NETMASK>
+ 10.0.0.0 192.168.1.1 255.255.255.0
+
+got message of size 192 on Mon Oct 19 12:00:05 2026
+RTM_DELETE: Delete Route: len 192, pid: 1240, seq 2, errno 0, flags:<GATEWAY,DONE,STATIC>
+locks:  inits: 
+sockaddrs: <DST,GATEWAY,NETMASK>
+ default 192.168.1.1 default
+`)
+	manager := NewManager(mockCmd)
+
+	var got []netstat.RouteMessage
+	err := manager.WatchRoutes(context.Background(), inetFamily, testDefaultRoute, func(m netstat.RouteMessage) {
+		got = append(got, m)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 1 || got[0].Type != netstat.RTMDelete || got[0].Gateway != "192.168.1.1" {
+		t.Errorf("unexpected messages: %+v", got)
+	}
+
+	for _, tc := range []struct{ family, destination string }{{"ipx", ""}, {"", "10.0.0.0/33"}, {"", "gateway"}} {
+		if err := manager.WatchRoutes(context.Background(), tc.family, tc.destination, func(netstat.RouteMessage) {}); err == nil {
+			t.Errorf("expected error for family %q destination %q but got none", tc.family, tc.destination)
+		}
+	}
+
+	mockCmd.SetError("route -n monitor", errors.New("permission denied"))
+	if err := manager.WatchRoutes(context.Background(), "", "", func(netstat.RouteMessage) {}); err == nil {
+		t.Error("expected error but got none")
+	}
+}
+
+func TestListRoutes_InvalidFIB(t *testing.T) {
+	mockCmd := NewMockCommandExecutor()
+	mockCmd.SetOutput("sysctl -n net.fibs", "")
+	if _, err := NewManager(mockCmd).ListAllFIBRoutes(""); err == nil {
+		t.Error("expected error for invalid net.fibs value")
+	}
+	if _, err := NewManager(mockCmd).ListRoutes("", -2); err == nil {
+		t.Error("expected error for invalid FIB")
+	}
+}
+
+func TestAddRoute_Options(t *testing.T) {
+	tests := []struct {
+		name        string
+		spec        RouteSpec
+		expected    string
+		shouldError bool
+	}{
+		{
+			name:     "blackhole without gateway",
+			spec:     RouteSpec{Network: "10.9.0.0/16", Blackhole: true, FIB: CurrentFIB},
+			expected: "route -n add -inet 10.9.0.0/16 127.0.0.1 -blackhole",
+		},
+		{
+			name:     "IPv6 reject",
+			spec:     RouteSpec{Family: inet6Family, Network: "2001:db8:dead::/48", Reject: true, FIB: CurrentFIB},
+			expected: "route -n add -inet6 2001:db8:dead::/48 ::1 -reject",
+		},
+		{
+			name:     "on-link with mtu and expire",
+			spec:     RouteSpec{Network: "192.0.2.0/24", Gateway: "em1", OnLink: true, MTU: 1400, Expire: 300, FIB: CurrentFIB},
+			expected: "route -n add -inet 192.0.2.0/24 em1 -interface -mtu 1400 -expire +300",
+		},
+		{
+			name:     "multipath weight",
+			spec:     RouteSpec{Network: "default", Gateway: "10.0.0.2", Weight: 10, FIB: 0},
+			expected: "route -n add -fib 0 -inet default 10.0.0.2 -weight 10",
+		},
+		{name: "missing gateway", spec: RouteSpec{Network: "10.0.0.0/8", FIB: CurrentFIB}, shouldError: true},
+		{name: "blackhole and reject", spec: RouteSpec{Network: "10.0.0.0/8", Blackhole: true, Reject: true, FIB: CurrentFIB}, shouldError: true},
+		{name: "negative mtu", spec: RouteSpec{Network: "10.0.0.0/8", Gateway: "10.0.0.1", MTU: -1, FIB: CurrentFIB}, shouldError: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCmd := NewMockCommandExecutor()
+			err := NewManager(mockCmd).AddRoute(tc.spec)
+			if tc.shouldError {
+				if err == nil {
+					t.Error("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			commands := mockCmd.GetCommands()
+			if len(commands) != 1 || commands[0] != tc.expected {
+				t.Errorf("expected %q, got %v", tc.expected, commands)
+			}
+		})
+	}
+}
+
+func TestChangeRoute(t *testing.T) {
+	mockCmd := NewMockCommandExecutor()
+	err := NewManager(mockCmd).ChangeRoute(RouteSpec{Network: "default", Gateway: "10.0.0.254", FIB: CurrentFIB})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	commands := mockCmd.GetCommands()
+	if len(commands) != 1 || commands[0] != "route -n change -inet default 10.0.0.254" {
+		t.Errorf("unexpected commands: %v", commands)
+	}
+}
+
+func TestDelRoute_MultipathGateway(t *testing.T) {
+	mockCmd := NewMockCommandExecutor()
+	mockCmd.SetOutput("netstat -rnW -f inet", "default 10.0.0.1\ndefault 10.0.0.2\n")
+	if err := NewManager(mockCmd).DelRoute(inetFamily, "default", "10.0.0.2", CurrentFIB); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	commands := mockCmd.GetCommands()
+	if commands[len(commands)-1] != "route -n delete -inet default 10.0.0.2" {
+		t.Errorf("unexpected commands: %v", commands)
+	}
+}
+
+func TestGetRoute(t *testing.T) {
+	mockCmd := NewMockCommandExecutor()
+	mockCmd.SetOutput("route -n get -fib 1 -inet 10.1.0.77", `   route to: 10.1.0.77
+destination: 10.1.0.0
+       mask: 255.255.255.0
+        fib: 1
+  interface: em1
+      flags: <UP,DONE,PINNED>
+ recvpipe  sendpipe  ssthresh  rtt,msec    mtu        weight    expire
+       0         0         0         0      1500         1         0
+`)
+	lookup, err := NewManager(mockCmd).GetRoute("", "10.1.0.77", 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lookup.Interface != "em1" || lookup.FIB != 1 || lookup.MTU != 1500 {
+		t.Errorf("unexpected lookup: %+v", lookup)
+	}
+	if _, err := NewManager(mockCmd).GetRoute("", "", CurrentFIB); err == nil {
+		t.Error("expected error for empty destination")
+	}
+}
+
+func TestListNeighbors(t *testing.T) {
+	mockCmd := NewMockCommandExecutor()
+	mockCmd.SetOutput("arp -an", "? (192.168.1.1) at 00:11:22:33:44:55 on em0 expires in 1193 seconds [ethernet]\n"+
+		"? (10.0.0.5) at 00:aa:bb:cc:dd:ee on em1 permanent [ethernet]\n")
+	mockCmd.SetOutput("ndp -an", "Neighbor                             Linklayer Address  Netif Expire    1s 5s\n"+
+		"fe80::1%em0                          00:11:22:33:44:55    em0 23h59m58s S R\n")
+	manager := NewManager(mockCmd)
+
+	tests := []struct {
+		name     string
+		iface    string
+		family   string
+		expected []string
+	}{
+		{name: "all", expected: []string{"192.168.1.1", "10.0.0.5", "fe80::1"}},
+		{name: "interface", iface: "em0", expected: []string{"192.168.1.1", "fe80::1"}},
+		{name: "inet", family: inetFamily, expected: []string{"192.168.1.1", "10.0.0.5"}},
+		{name: "inet6 on em1", iface: "em1", family: inet6Family, expected: nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			entries, err := manager.ListNeighbors(tc.iface, tc.family)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(entries) != len(tc.expected) {
+				t.Fatalf("expected %v, got %+v", tc.expected, entries)
+			}
+			for i, ip := range tc.expected {
+				if entries[i].IP != ip {
+					t.Errorf("expected %s, got %s", ip, entries[i].IP)
+				}
+			}
+		})
+	}
+	if _, err := manager.ListNeighbors("", "link"); err == nil {
+		t.Error("expected error for invalid family")
+	}
+}
+
+func TestNeighborCommands(t *testing.T) {
+	tests := []struct {
+		name        string
+		run         func(m *Manager) error
+		expected    string
+		shouldError bool
+	}{
+		{
+			name: "static ARP entry",
+			run: func(m *Manager) error {
+				return m.AddNeighbor(NeighborSpec{IP: "10.0.0.5", MAC: "00:AA:BB:CC:DD:EE", Static: true})
+			},
+			expected: "arp -s 10.0.0.5 00:aa:bb:cc:dd:ee",
+		},
+		{
+			name: "temporary published ARP entry",
+			run: func(m *Manager) error {
+				return m.AddNeighbor(NeighborSpec{IP: "10.0.0.5", MAC: "00:aa:bb:cc:dd:ee", Publish: true})
+			},
+			expected: "arp -s 10.0.0.5 00:aa:bb:cc:dd:ee temp pub",
+		},
+		{
+			name: "link-local NDP entry",
+			run: func(m *Manager) error {
+				return m.AddNeighbor(NeighborSpec{Interface: "em0", IP: "fe80::1", MAC: "00:11:22:33:44:55", Static: true})
+			},
+			expected: "ndp -s fe80::1%em0 00:11:22:33:44:55",
+		},
+		{
+			name: "proxy NDP entry",
+			run: func(m *Manager) error {
+				return m.AddNeighbor(NeighborSpec{IP: "2001:db8::5", MAC: "00:11:22:33:44:55", Publish: true, Static: true})
+			},
+			expected: "ndp -s 2001:db8::5 00:11:22:33:44:55 proxy",
+		},
+		{
+			name:     "delete ARP entry",
+			run:      func(m *Manager) error { return m.DeleteNeighbor("", "10.0.0.5") },
+			expected: "arp -d 10.0.0.5",
+		},
+		{
+			name:     "delete scoped NDP entry",
+			run:      func(m *Manager) error { return m.DeleteNeighbor("em0", "fe80::1%em0") },
+			expected: "ndp -d fe80::1%em0",
+		},
+		{
+			name:     "flush inet6",
+			run:      func(m *Manager) error { return m.FlushNeighbors("", inet6Family) },
+			expected: "ndp -c",
+		},
+		{
+			name:        "link-local without interface",
+			run:         func(m *Manager) error { return m.DeleteNeighbor("", "fe80::1") },
+			shouldError: true,
+		},
+		{
+			name:        "zone mismatch",
+			run:         func(m *Manager) error { return m.DeleteNeighbor("em1", "fe80::1%em0") },
+			shouldError: true,
+		},
+		{
+			name:        "invalid MAC",
+			run:         func(m *Manager) error { return m.AddNeighbor(NeighborSpec{IP: "10.0.0.5", MAC: "zz"}) },
+			shouldError: true,
+		},
+		{
+			name:        "invalid IP",
+			run:         func(m *Manager) error { return m.DeleteNeighbor("", "10.0.0") },
+			shouldError: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCmd := NewMockCommandExecutor()
+			err := tc.run(NewManager(mockCmd))
+			if tc.shouldError {
+				if err == nil {
+					t.Error("expected error but got none")
+				}
+				if len(mockCmd.GetCommands()) != 0 {
+					t.Errorf("expected no commands, got %v", mockCmd.GetCommands())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			commands := mockCmd.GetCommands()
+			if len(commands) != 1 || commands[0] != tc.expected {
+				t.Errorf("expected command %q, got %v", tc.expected, commands)
+			}
+		})
+	}
+}
+
+func TestFlushNeighbors_Interface(t *testing.T) {
+	mockCmd := NewMockCommandExecutor()
+	mockCmd.SetOutput("arp -an", "? (192.168.1.1) at 00:11:22:33:44:55 on em0 expires in 1193 seconds [ethernet]\n"+
+		"? (10.0.0.5) at 00:aa:bb:cc:dd:ee on em1 permanent [ethernet]\n")
+	mockCmd.SetOutput("ndp -an", "fe80::1%em0                          00:11:22:33:44:55    em0 23h59m58s S R\n")
+	if err := NewManager(mockCmd).FlushNeighbors("em0", ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{"arp -an", "ndp -an", "arp -d 192.168.1.1", "ndp -d fe80::1%em0"}
+	commands := mockCmd.GetCommands()
+	if len(commands) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, commands)
+	}
+	for i, c := range expected {
+		if commands[i] != c {
+			t.Errorf("expected command %q, got %q", c, commands[i])
+		}
+	}
+}
+
+func TestND6(t *testing.T) {
+	const settings = "linkmtu=0, maxmtu=1500, curhlim=64, basereachable=30s0ms, reachable=36s, retrans=1s0ms\n"
+	mockCmd := NewMockCommandExecutor()
+	mockCmd.SetOutput("ndp -i vlan20", settings+"Flags: nud auto_linklocal\n")
+	mockCmd.SetOutput("ndp -i vlan20 -- accept_rtadv -disabled", settings+"Flags: nud accept_rtadv auto_linklocal\n")
+	mockCmd.SetOutput("ndp -i vlan20 -- -accept_rtadv", settings+"Flags: nud auto_linklocal\n")
+	manager := NewManager(mockCmd)
+
+	info, err := manager.ND6("vlan20")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.AcceptRtadv || info.MaxMTU != 1500 {
+		t.Errorf("unexpected settings %+v", info)
+	}
+	if info, err = manager.SetAcceptRtadv("vlan20", true); err != nil || !info.AcceptRtadv {
+		t.Errorf("expected accept_rtadv, got %+v, %v", info, err)
+	}
+	if info, err = manager.SetAcceptRtadv("vlan20", false); err != nil || info.AcceptRtadv {
+		t.Errorf("expected no accept_rtadv, got %+v, %v", info, err)
+	}
+	if err := manager.SolicitRouter("vlan20"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{"ndp -i vlan20", "ndp -i vlan20 -- accept_rtadv -disabled", "ndp -i vlan20 -- -accept_rtadv", "rtsol vlan20"}
+	if commands := mockCmd.GetCommands(); strings.Join(commands, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected %v, got %v", expected, commands)
+	}
+
+	mockCmd.SetError("rtsol em0", errors.New("no such interface"))
+	if err := manager.SolicitRouter("em0"); err == nil {
+		t.Error("expected error but got none")
+	}
+	if _, err := manager.SetAcceptRtadv("", true); err == nil {
+		t.Error("expected error but got none")
+	}
+}
+
+func TestInterfaceStats(t *testing.T) {
+	mockCmd := NewMockCommandExecutor()
+	mockCmd.SetOutput("netstat -i -b -n -W -I em0", `Name      Mtu Network            Address                     Ipkts Ierrs Idrop     Ibytes    Opkts Oerrs     Obytes  Coll
+em0      1500 <Link#1>           00:11:22:33:44:55             120     1     0     150000       80     0      64000     0
+em0         - 192.168.1.0/24     192.168.1.10                   12     -     -       1200        8     -        640     -
+`)
+	stats, err := NewManager(mockCmd).InterfaceStats("em0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stats) != 1 || stats[0].Name != "em0" || stats[0].InPackets != 120 || stats[0].InErrors != 1 || stats[0].OutBytes != 64000 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	mockCmd.SetError("netstat -i -b -n -W", errors.New("netstat failed"))
+	if _, err := NewManager(mockCmd).InterfaceStats(""); err == nil {
+		t.Error("expected error but got none")
+	}
+}
+
+func TestInterfaceSamples(t *testing.T) {
+	mockCmd := NewMockCommandExecutor()
+	mockCmd.SetOutput("netstat -I em0 -b -n -w 5 -q 2", `            input            em0           output
+   packets  errs idrops      bytes    packets  errs      bytes colls
+        12     0     0       1560         10     0       1400     0
+         8     0     0        980          7     0        800     0
+`)
+	samples, err := NewManager(mockCmd).InterfaceSamples("em0", 5, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(samples) != 2 || samples[1].InBytes != 980 {
+		t.Errorf("unexpected samples: %+v", samples)
+	}
+	if _, err := NewManager(mockCmd).InterfaceSamples("", 5, 2); err == nil {
+		t.Error("expected error for empty name")
+	}
+	if _, err := NewManager(mockCmd).InterfaceSamples("em0", 0, 2); err == nil {
+		t.Error("expected error for zero wait")
+	}
+}
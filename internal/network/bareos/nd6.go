@@ -0,0 +1,49 @@
+package bareos
+
+import (
+	"FreeBSD-Command-manager/pkg/neighbor"
+	"fmt"
+)
+
+// ND6 returns the IPv6 neighbor discovery settings of an interface
+func (n *Manager) ND6(iface string) (*neighbor.ND6Info, error) {
+	if iface == "" {
+		return nil, fmt.Errorf("interface name is required")
+	}
+	output, err := n.cmdExec.Execute("ndp", "-i", iface)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get neighbor discovery settings of %s: %v, output: %s", iface, err, output)
+	}
+	return neighbor.ParseND6(output)
+}
+
+// SetAcceptRtadv enables or disables the configuration of addresses and the
+// default route from router advertisements (SLAAC) on an interface. Enabling
+// also clears the disabled flag, without which the interface ignores IPv6.
+func (n *Manager) SetAcceptRtadv(iface string, on bool) (*neighbor.ND6Info, error) {
+	if iface == "" {
+		return nil, fmt.Errorf("interface name is required")
+	}
+	flags := []string{"-" + neighbor.FlagAcceptRtadv}
+	if on {
+		flags = []string{neighbor.FlagAcceptRtadv, "-" + neighbor.FlagDisabled}
+	}
+	output, err := n.cmdExec.Execute("ndp", append([]string{"-i", iface, "--"}, flags...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set %s on %s: %v, output: %s", neighbor.FlagAcceptRtadv, iface, err, output)
+	}
+	return neighbor.ParseND6(output)
+}
+
+// SolicitRouter sends a router solicitation on an interface, so routers
+// answer with an advertisement right away instead of at their next interval
+func (n *Manager) SolicitRouter(iface string) error {
+	if iface == "" {
+		return fmt.Errorf("interface name is required")
+	}
+	output, err := n.cmdExec.Execute("rtsol", iface)
+	if err != nil {
+		return fmt.Errorf("failed to solicit a router on %s: %v, output: %s", iface, err, output)
+	}
+	return nil
+}
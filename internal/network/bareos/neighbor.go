@@ -0,0 +1,164 @@
+package bareos
+
+import (
+	"FreeBSD-Command-manager/pkg/neighbor"
+	"fmt"
+	"net"
+	"net/netip"
+)
+
+// NeighborSpec describes a neighbor (ARP or NDP) entry to add
+type NeighborSpec struct {
+	Interface string // required for IPv6 link-local addresses
+	IP        string
+	MAC       string
+	Static    bool // never expires; otherwise the entry times out like a learned one
+	Publish   bool // answer requests for IP on behalf of MAC (proxy ARP/NDP)
+}
+
+// ListNeighbors returns the ARP and NDP cache entries, limited to an
+// interface and family ("inet" or "inet6") when given.
+func (n *Manager) ListNeighbors(iface, family string) ([]neighbor.Entry, error) {
+	if err := validateFamily(family); err != nil {
+		return nil, err
+	}
+	var entries []neighbor.Entry
+	if family != inet6Family {
+		output, err := n.cmdExec.Execute("arp", "-an")
+		if err != nil {
+			return nil, fmt.Errorf("failed to list ARP entries: %v", err)
+		}
+		entries = append(entries, neighbor.ParseARP(output)...)
+	}
+	if family != inetFamily {
+		output, err := n.cmdExec.Execute("ndp", "-an")
+		if err != nil {
+			return nil, fmt.Errorf("failed to list NDP entries: %v", err)
+		}
+		entries = append(entries, neighbor.ParseNDP(output)...)
+	}
+	if iface == "" {
+		return entries, nil
+	}
+	var filtered []neighbor.Entry
+	for _, e := range entries {
+		if e.Interface == iface {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered, nil
+}
+
+// AddNeighbor adds an ARP entry for an IPv4 address or an NDP entry for an
+// IPv6 address.
+func (n *Manager) AddNeighbor(spec NeighborSpec) error {
+	addr, err := neighborAddr(spec.Interface, spec.IP)
+	if err != nil {
+		return err
+	}
+	mac, err := net.ParseMAC(spec.MAC)
+	if err != nil {
+		return fmt.Errorf("invalid MAC address %q: %v", spec.MAC, err)
+	}
+
+	cmd, args := "arp", []string{"-s", addr, mac.String()}
+	if !spec.Static {
+		args = append(args, "temp")
+	}
+	if netip.MustParseAddr(addr).Is4() {
+		if spec.Publish {
+			args = append(args, "pub")
+		}
+	} else {
+		cmd = "ndp"
+		if spec.Publish {
+			args = append(args, "proxy")
+		}
+	}
+	output, err := n.cmdExec.Execute(cmd, args...)
+	if err != nil {
+		return fmt.Errorf("failed to add neighbor %s: %v, output: %s", spec.IP, err, output)
+	}
+	return nil
+}
+
+// DeleteNeighbor deletes the ARP or NDP entry of an address.
+func (n *Manager) DeleteNeighbor(iface, ip string) error {
+	addr, err := neighborAddr(iface, ip)
+	if err != nil {
+		return err
+	}
+	cmd := "ndp"
+	if netip.MustParseAddr(addr).Is4() {
+		cmd = "arp"
+	}
+	output, err := n.cmdExec.Execute(cmd, "-d", addr)
+	if err != nil {
+		return fmt.Errorf("failed to delete neighbor %s: %v, output: %s", ip, err, output)
+	}
+	return nil
+}
+
+// FlushNeighbors deletes all ARP and NDP entries, or only those of an
+// interface and family when given.
+func (n *Manager) FlushNeighbors(iface, family string) error {
+	if err := validateFamily(family); err != nil {
+		return err
+	}
+	if iface != "" {
+		entries, err := n.ListNeighbors(iface, family)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			ip := e.IP
+			if e.Zone != "" {
+				ip += "%" + e.Zone
+			}
+			if err := n.DeleteNeighbor("", ip); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if family != inet6Family {
+		if output, err := n.cmdExec.Execute("arp", "-d", "-a"); err != nil {
+			return fmt.Errorf("failed to flush ARP entries: %v, output: %s", err, output)
+		}
+	}
+	if family != inetFamily {
+		if output, err := n.cmdExec.Execute("ndp", "-c"); err != nil {
+			return fmt.Errorf("failed to flush NDP entries: %v, output: %s", err, output)
+		}
+	}
+	return nil
+}
+
+// neighborAddr validates ip and returns it in the form arp/ndp expect;
+// IPv6 link-local addresses get the interface as scope zone.
+func neighborAddr(iface, ip string) (string, error) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return "", fmt.Errorf("invalid IP address %q: %v", ip, err)
+	}
+	if addr.Is4() || !addr.IsLinkLocalUnicast() {
+		return addr.WithZone("").String(), nil
+	}
+	switch {
+	case addr.Zone() == "" && iface == "":
+		return "", fmt.Errorf("interface is required for link-local address %s", ip)
+	case addr.Zone() != "" && iface != "" && addr.Zone() != iface:
+		return "", fmt.Errorf("address %s is not scoped to %s", ip, iface)
+	case addr.Zone() == "":
+		addr = addr.WithZone(iface)
+	}
+	return addr.String(), nil
+}
+
+func validateFamily(family string) error {
+	if family != "" && family != inetFamily && family != inet6Family {
+		return fmt.Errorf("invalid family %q: must be inet or inet6", family)
+	}
+	return nil
+}
@@ -3,69 +3,179 @@ package bareos
 import (
 	"FreeBSD-Command-manager/pkg/netstat"
 	"fmt"
-	"os/exec"
+	"strconv"
 	"strings"
 )
 
-var execCommand = exec.Command
+// CurrentFIB selects the FIB of the calling process, i.e. no -fib/-F argument is passed.
+const CurrentFIB = -1
 
-func realListRoutes(family string) (string, error) {
-	var cmd *exec.Cmd
-	if family == "" {
-		cmd = execCommand("netstat", "-rn")
-	} else {
-		cmd = execCommand("netstat", "-rn", "-f", family)
+// fibArgs returns the flag selecting a FIB, or nothing for CurrentFIB.
+func fibArgs(flag string, fib int) []string {
+	if fib == CurrentFIB {
+		return nil
 	}
-	output, err := cmd.CombinedOutput()
+	return []string{flag, strconv.Itoa(fib)}
+}
+
+func validateFIB(fib int) error {
+	if fib < CurrentFIB {
+		return fmt.Errorf("invalid FIB: %d", fib)
+	}
+	return nil
+}
+
+// routeTable returns the raw "netstat -rnW" output, optionally limited to one family and FIB.
+func (n *Manager) routeTable(family string, fib int) (string, error) {
+	args := []string{"-rnW"}
+	if family != "" {
+		args = append(args, "-f", family)
+	}
+	args = append(args, fibArgs("-F", fib)...)
+	output, err := n.cmdExec.Execute("netstat", args...)
 	if err != nil {
-		return "", fmt.Errorf("route list error: %v, output: %s", err, string(output))
+		return "", fmt.Errorf("route list error: %v, output: %s", err, output)
 	}
-	return string(output), nil
+	return output, nil
 }
 
-var listRoutes = realListRoutes
+// RouteSpec describes a route to add or change.
+type RouteSpec struct {
+	Family    string // inet (default) or inet6
+	Network   string // destination prefix, host or "default"
+	Gateway   string // next hop; an interface name when OnLink is set
+	Iface     string // outgoing interface (-ifp)
+	OnLink    bool   // destination is directly reachable through Gateway (-interface)
+	Blackhole bool   // silently discard matching packets
+	Reject    bool   // discard matching packets with an unreachable error
+	MTU       int    // 0 keeps the interface MTU
+	Expire    int    // lifetime in seconds, 0 never expires
+	Weight    int    // multipath (ECMP) weight, 0 uses the kernel default
+	FIB       int    // CurrentFIB or a FIB number
+}
 
-// AddRoute adds a route for the given network and gateway (IPv4 or IPv6).
-func AddRoute(family, network, gw string) error {
-	fam := family
+// routeArgs validates a RouteSpec and builds the route(8) arguments for command.
+func routeArgs(command string, spec RouteSpec) ([]string, error) {
+	if err := validateFIB(spec.FIB); err != nil {
+		return nil, err
+	}
+	if spec.Network == "" {
+		return nil, fmt.Errorf("route destination is required")
+	}
+	if spec.Blackhole && spec.Reject {
+		return nil, fmt.Errorf("a route cannot be both blackhole and reject")
+	}
+	if spec.MTU < 0 || spec.Expire < 0 || spec.Weight < 0 {
+		return nil, fmt.Errorf("route mtu, expire and weight must not be negative")
+	}
+	fam := spec.Family
 	if fam == "" {
 		fam = inetFamily
 	}
-	cmd := execCommand("route", "-n", "add", "-"+fam, network, gw)
-	output, err := cmd.CombinedOutput()
+	gw := spec.Gateway
+	if gw == "" {
+		switch {
+		case spec.Blackhole || spec.Reject:
+			// Discard routes point at the loopback interface
+			gw = "127.0.0.1"
+			if fam == inet6Family {
+				gw = "::1"
+			}
+		default:
+			return nil, fmt.Errorf("route gateway is required")
+		}
+	}
+
+	args := []string{"-n", command}
+	args = append(args, fibArgs("-fib", spec.FIB)...)
+	args = append(args, "-"+fam, spec.Network, gw)
+	if spec.Iface != "" {
+		args = append(args, "-ifp", spec.Iface)
+	}
+	if spec.OnLink {
+		args = append(args, "-interface")
+	}
+	if spec.Blackhole {
+		args = append(args, "-blackhole")
+	}
+	if spec.Reject {
+		args = append(args, "-reject")
+	}
+	if spec.MTU > 0 {
+		args = append(args, "-mtu", strconv.Itoa(spec.MTU))
+	}
+	if spec.Expire > 0 {
+		// A leading "+" makes the expiry relative to now
+		args = append(args, "-expire", "+"+strconv.Itoa(spec.Expire))
+	}
+	if spec.Weight > 0 {
+		args = append(args, "-weight", strconv.Itoa(spec.Weight))
+	}
+	return args, nil
+}
+
+// AddRoute adds a route. Adding a second route to the same destination with a
+// different gateway creates a multipath (ECMP) route.
+func (n *Manager) AddRoute(spec RouteSpec) error {
+	args, err := routeArgs("add", spec)
+	if err != nil {
+		return err
+	}
+	output, err := n.cmdExec.Execute("route", args...)
 	if err != nil {
-		return fmt.Errorf("route add error: %v, output: %s", err, string(output))
+		return fmt.Errorf("route add error: %v, output: %s", err, output)
 	}
 	return nil
 }
 
-// AddRouteWithIface adds a route for the given network, gateway, and optional interface (IPv4 or IPv6).
-func AddRouteWithIface(family, network, gw, iface string) error {
+// ChangeRoute changes the gateway or attributes of an existing route.
+func (n *Manager) ChangeRoute(spec RouteSpec) error {
+	args, err := routeArgs("change", spec)
+	if err != nil {
+		return err
+	}
+	output, err := n.cmdExec.Execute("route", args...)
+	if err != nil {
+		return fmt.Errorf("route change error: %v, output: %s", err, output)
+	}
+	return nil
+}
+
+// GetRoute looks up the route used to reach a destination.
+func (n *Manager) GetRoute(family, destination string, fib int) (*netstat.RouteLookup, error) {
+	if err := validateFIB(fib); err != nil {
+		return nil, err
+	}
+	if destination == "" {
+		return nil, fmt.Errorf("route destination is required")
+	}
 	fam := family
 	if fam == "" {
 		fam = inetFamily
 	}
-	args := []string{"-n", "add", "-" + fam, network, gw}
-	if iface != "" {
-		args = append(args, "-ifp", iface)
-	}
-	cmd := execCommand("route", args...)
-	output, err := cmd.CombinedOutput()
+	args := []string{"-n", "get"}
+	args = append(args, fibArgs("-fib", fib)...)
+	args = append(args, "-"+fam, destination)
+	output, err := n.cmdExec.Execute("route", args...)
 	if err != nil {
-		return fmt.Errorf("route add error: %v, output: %s", err, string(output))
+		return nil, fmt.Errorf("route get error: %v, output: %s", err, output)
 	}
-	return nil
+	return netstat.ParseRouteGet(output), nil
 }
 
-// DelRoute deletes a route for the given network (IPv4 or IPv6).
+// DelRoute deletes a route for the given network (IPv4 or IPv6) from the given FIB.
+// A non-empty gateway removes only that path of a multipath route.
 // For default route, prevents deleting the last default route.
-func DelRoute(family, network string) error {
+func (n *Manager) DelRoute(family, network, gw string, fib int) error {
+	if err := validateFIB(fib); err != nil {
+		return err
+	}
 	fam := family
 	if fam == "" {
 		fam = inetFamily
 	}
 	if network == "default" {
-		count, err := countDefaultRoutes(fam)
+		count, err := n.countDefaultRoutes(fam, fib)
 		if err != nil {
 			return err
 		}
@@ -73,32 +183,87 @@ func DelRoute(family, network string) error {
 			return fmt.Errorf("cannot delete the last default route")
 		}
 	}
-	cmd := execCommand("route", "-n", "delete", "-"+fam, network)
-	output, err := cmd.CombinedOutput()
+	args := []string{"-n", "delete"}
+	args = append(args, fibArgs("-fib", fib)...)
+	args = append(args, "-"+fam, network)
+	if gw != "" {
+		args = append(args, gw)
+	}
+	output, err := n.cmdExec.Execute("route", args...)
 	if err != nil {
-		return fmt.Errorf("route delete error: %v, output: %s", err, string(output))
+		return fmt.Errorf("route delete error: %v, output: %s", err, output)
 	}
 	return nil
 }
 
-// ListAllRoutes lists both IPv4 and IPv6 routes as a JSON string using the netstat parser.
-func ListAllRoutes(family string) ([]netstat.Route, error) {
-	output, err := listRoutes(family)
+// ListRoutes lists IPv4 and/or IPv6 routes of one FIB using the netstat parser.
+// Each route is tagged with the FIB it was read from.
+func (n *Manager) ListRoutes(family string, fib int) ([]netstat.Route, error) {
+	if err := validateFIB(fib); err != nil {
+		return nil, err
+	}
+	tag := fib
+	if fib == CurrentFIB {
+		current, err := n.sysctlInt("net.my_fibnum")
+		if err != nil {
+			return nil, err
+		}
+		tag = current
+	}
 
+	output, err := n.routeTable(family, fib)
 	if err != nil {
-		return nil, fmt.Errorf("route list error: %w, output: %s", err, output)
+		return nil, err
 	}
 
 	routes, err := netstat.ParseNetstat(output)
 	if err != nil {
 		return nil, fmt.Errorf("parse netstat error: %w", err)
 	}
+	for i := range routes {
+		routes[i].FIB = tag
+	}
+	return routes, nil
+}
+
+// ListAllFIBRoutes lists the routes of every FIB reported by "sysctl net.fibs".
+func (n *Manager) ListAllFIBRoutes(family string) ([]netstat.Route, error) {
+	fibs, err := n.FIBs()
+	if err != nil {
+		return nil, err
+	}
+	var routes []netstat.Route
+	for fib := 0; fib < fibs; fib++ {
+		r, err := n.ListRoutes(family, fib)
+		if err != nil {
+			return nil, err
+		}
+		routes = append(routes, r...)
+	}
 	return routes, nil
 }
 
-// countDefaultRoutes returns the number of default routes for the given family.
-func countDefaultRoutes(family string) (int, error) {
-	out, err := listRoutes(family)
+// FIBs returns the number of FIBs configured in the kernel.
+func (n *Manager) FIBs() (int, error) {
+	return n.sysctlInt("net.fibs")
+}
+
+// sysctlInt reads an integer sysctl value.
+func (n *Manager) sysctlInt(name string) (int, error) {
+	output, err := n.cmdExec.Execute("sysctl", "-n", name)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %v", name, err)
+	}
+	value, err := strconv.Atoi(strings.TrimSpace(output))
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s value %q", name, strings.TrimSpace(output))
+	}
+	return value, nil
+}
+
+// countDefaultRoutes returns the number of default routes for the given family and FIB.
+func (n *Manager) countDefaultRoutes(family string, fib int) (int, error) {
+	out, err := n.routeTable(family, fib)
 	if err != nil {
 		return 0, err
 	}
@@ -0,0 +1,50 @@
+package bareos
+
+import (
+	"FreeBSD-Command-manager/pkg/netstat"
+	"context"
+	"fmt"
+	"io"
+	"net/netip"
+)
+
+// WatchRoutes runs "route -n monitor" and calls fn for every routing
+// message of family ("inet", "inet6" or "" for both) concerning destination
+// ("default", an address, a prefix, or "" for all), until ctx is cancelled.
+func (n *Manager) WatchRoutes(ctx context.Context, family, destination string, fn func(netstat.RouteMessage)) error {
+	if err := validateFamily(family); err != nil {
+		return err
+	}
+	if destination != "" && destination != "default" {
+		if _, err := netip.ParsePrefix(destination); err != nil {
+			if _, err := netip.ParseAddr(destination); err != nil {
+				return fmt.Errorf("invalid destination %q: must be default, an address or a prefix", destination)
+			}
+		}
+	}
+	streamer, ok := n.cmdExec.(CommandStreamer)
+	if !ok {
+		return fmt.Errorf("route monitoring requires a command executor that can stream output")
+	}
+
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := netstat.ScanRouteMessages(pr, func(m netstat.RouteMessage) {
+			if m.Matches(family, destination) {
+				fn(m)
+			}
+		})
+		// Unblock the command if scanning stopped early
+		pr.CloseWithError(io.ErrClosedPipe)
+		done <- err
+	}()
+
+	err := streamer.Stream(ctx, pw, "route", "-n", "monitor")
+	pw.Close()
+	scanErr := <-done
+	if err != nil {
+		return fmt.Errorf("route monitor error: %v", err)
+	}
+	return scanErr
+}
@@ -0,0 +1,37 @@
+package bareos
+
+import (
+	"FreeBSD-Command-manager/pkg/netstat"
+	"fmt"
+	"strconv"
+)
+
+// InterfaceStats returns the traffic and error counters of all interfaces,
+// or of the named one, from "netstat -i -b -n -W".
+func (n *Manager) InterfaceStats(name string) ([]netstat.InterfaceStats, error) {
+	args := []string{"-i", "-b", "-n", "-W"}
+	if name != "" {
+		args = append(args, "-I", name)
+	}
+	output, err := n.cmdExec.Execute("netstat", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get interface statistics: %v", err)
+	}
+	return netstat.ParseInterfaceStats(output)
+}
+
+// InterfaceSamples returns count samples of the counter increments of an
+// interface over wait seconds each, from "netstat -I <name> -w <wait>".
+func (n *Manager) InterfaceSamples(name string, wait, count int) ([]netstat.Counters, error) {
+	if name == "" {
+		return nil, fmt.Errorf("interface name is required")
+	}
+	if wait < 1 || count < 1 {
+		return nil, fmt.Errorf("wait and count must be positive")
+	}
+	output, err := n.cmdExec.Execute("netstat", "-I", name, "-b", "-n", "-w", strconv.Itoa(wait), "-q", strconv.Itoa(count))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sample interface %s: %v", name, err)
+	}
+	return netstat.ParseInterfaceSamples(output)
+}
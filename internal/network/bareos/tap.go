@@ -0,0 +1,63 @@
+package bareos
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TapOptions holds optional settings for a tap interface
+type TapOptions struct {
+	Owner   string // user or user:group owning /dev/<tap>
+	Persist bool   // keep the interface up and configured when its device is closed
+}
+
+// CreateTap creates a tap interface and returns its name
+func (n *Manager) CreateTap(name string, opts TapOptions) (string, error) {
+	// Create tap interface
+	out, err := n.cmdExec.Execute("ifconfig", "tap", "create")
+	if err != nil {
+		return "", fmt.Errorf("failed to create tap interface: %v", err)
+	}
+	tapName := strings.TrimSpace(out)
+
+	if name != "" {
+		// Rename to desired name
+		_, err = n.cmdExec.Execute("ifconfig", tapName, "name", name)
+		if err != nil {
+			return tapName, fmt.Errorf("failed to rename tap to %s: %v", name, err)
+		}
+		tapName = name
+	}
+
+	if opts.Persist {
+		// link0 stops the interface from being brought down on last close
+		_, err = n.cmdExec.Execute("ifconfig", tapName, "link0")
+		if err != nil {
+			return tapName, fmt.Errorf("failed to set persist on tap %s: %v", tapName, err)
+		}
+	}
+
+	if opts.Owner != "" {
+		_, err = n.cmdExec.Execute("chown", opts.Owner, "/dev/"+tapName)
+		if err != nil {
+			return tapName, fmt.Errorf("failed to set owner of tap %s: %v", tapName, err)
+		}
+	}
+
+	// Bring up the interface
+	_, err = n.cmdExec.Execute("ifconfig", tapName, "up")
+	if err != nil {
+		return tapName, fmt.Errorf("failed to bring up tap %s: %v", tapName, err)
+	}
+
+	return tapName, nil
+}
+
+// DeleteTap deletes a tap interface
+func (n *Manager) DeleteTap(name string) error {
+	if name == "" {
+		return fmt.Errorf("tap name is required")
+	}
+
+	return n.DeleteInterface(name)
+}
@@ -1,5 +1,10 @@
 package bareos
 
+import (
+	"context"
+	"io"
+)
+
 // MockCommandExecutor implements CommandExecutor for testing
 type MockCommandExecutor struct {
 	commands []string
@@ -38,6 +43,16 @@ func (m *MockCommandExecutor) Execute(name string, args ...string) (string, erro
 	return "", nil
 }
 
+// Stream simulates a long-lived command by writing its predefined output to w
+func (m *MockCommandExecutor) Stream(_ context.Context, w io.Writer, name string, args ...string) error {
+	output, err := m.Execute(name, args...)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, output)
+	return err
+}
+
 // SetOutput sets the output for a specific command
 func (m *MockCommandExecutor) SetOutput(command, output string) {
 	m.outputs[command] = output
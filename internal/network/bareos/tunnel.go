@@ -0,0 +1,78 @@
+package bareos
+
+import (
+	"fmt"
+	"net/netip"
+)
+
+// TunnelOptions holds optional settings for GRE and gif tunnels
+type TunnelOptions struct {
+	InnerLocal  string // local point-to-point address inside the tunnel
+	InnerRemote string // remote point-to-point address inside the tunnel
+	Key         uint32 // GRE key, 0 disables
+}
+
+// tunnelFamily validates the outer endpoints and returns their address family.
+func tunnelFamily(local, remote string) (string, error) {
+	if local == "" {
+		return "", fmt.Errorf("local address is required")
+	}
+	if remote == "" {
+		return "", fmt.Errorf("remote address is required")
+	}
+	return addrPairFamily("tunnel", local, remote)
+}
+
+// addrPairFamily parses two addresses and checks they belong to the same family.
+func addrPairFamily(what, local, remote string) (string, error) {
+	l, err := netip.ParseAddr(local)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s local address: %s", what, local)
+	}
+	r, err := netip.ParseAddr(remote)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s remote address: %s", what, remote)
+	}
+	if l.Is4() != r.Is4() {
+		return "", fmt.Errorf("%s address families do not match: %s and %s", what, local, remote)
+	}
+	if l.Is4() {
+		return inetFamily, nil
+	}
+	return inet6Family, nil
+}
+
+// innerFamily validates the optional inner point-to-point addresses.
+func innerFamily(opts TunnelOptions) (string, error) {
+	if opts.InnerLocal == "" && opts.InnerRemote == "" {
+		return "", nil
+	}
+	if opts.InnerLocal == "" || opts.InnerRemote == "" {
+		return "", fmt.Errorf("both inner local and inner remote addresses are required")
+	}
+	return addrPairFamily("inner", opts.InnerLocal, opts.InnerRemote)
+}
+
+// configureTunnel sets outer endpoints and inner addresses on a created tunnel interface.
+func (n *Manager) configureTunnel(ifName, outerFam, innerFam, local, remote string, opts TunnelOptions) error {
+	args := []string{ifName}
+	if outerFam == inet6Family {
+		args = append(args, inet6Family)
+	}
+	_, err := n.cmdExec.Execute("ifconfig", append(args, "tunnel", local, remote)...)
+	if err != nil {
+		return fmt.Errorf("failed to configure tunnel %s: %v", ifName, err)
+	}
+
+	switch innerFam {
+	case inetFamily:
+		_, err = n.cmdExec.Execute("ifconfig", ifName, inetFamily, opts.InnerLocal, opts.InnerRemote, "netmask", "255.255.255.255")
+	case inet6Family:
+		_, err = n.cmdExec.Execute("ifconfig", ifName, inet6Family, opts.InnerLocal, opts.InnerRemote, "prefixlen", "128")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set inner addresses on tunnel %s: %v", ifName, err)
+	}
+
+	return nil
+}
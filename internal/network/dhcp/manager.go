@@ -0,0 +1,162 @@
+// Package dhcp runs dhclient(8) on interfaces and reports the leases it
+// obtained.
+package dhcp
+
+import (
+	"FreeBSD-Command-manager/internal/network/bareos"
+	pkgdhcp "FreeBSD-Command-manager/pkg/dhcp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+const (
+	// DefaultLeaseDir holds the dhclient.leases.<interface> files
+	DefaultLeaseDir = "/var/db"
+	// DefaultPIDDir holds the dhclient.<interface>.pid files
+	DefaultPIDDir = "/var/run/dhclient"
+)
+
+// ifaceRegex matches the interface names accepted by dhclient
+var ifaceRegex = regexp.MustCompile(`^[A-Za-z0-9_.]+$`)
+
+// ManagerInterface defines the interface for DHCP client operations
+type ManagerInterface interface {
+	Start(iface string) (*pkgdhcp.Lease, error)
+	Release(iface string) (*pkgdhcp.Lease, error)
+	Lease(iface string) (*pkgdhcp.Lease, error)
+	Running(iface string) (bool, error)
+}
+
+// CommandExecutor defines the interface for executing system commands
+type CommandExecutor interface {
+	Execute(name string, args ...string) (string, error)
+}
+
+// Manager implements ManagerInterface using dhclient
+type Manager struct {
+	cmdExec  CommandExecutor
+	leaseDir string
+	pidDir   string
+	now      func() time.Time
+}
+
+// NewManager creates a new DHCP client manager reading leases from leaseDir
+// and dhclient pid files from pidDir
+func NewManager(cmdExec CommandExecutor, leaseDir, pidDir string) *Manager {
+	return &Manager{
+		cmdExec:  cmdExec,
+		leaseDir: leaseDir,
+		pidDir:   pidDir,
+		now:      time.Now,
+	}
+}
+
+// DefaultManager returns the default DHCP client manager instance
+func DefaultManager() ManagerInterface {
+	return NewManager(bareos.NewRealCommandExecutor(), DefaultLeaseDir, DefaultPIDDir)
+}
+
+// Start runs dhclient on an interface and returns the lease it obtained.
+// dhclient stays in the background to renew the lease.
+func (m *Manager) Start(iface string) (*pkgdhcp.Lease, error) {
+	if err := validateInterface(iface); err != nil {
+		return nil, err
+	}
+	output, err := m.cmdExec.Execute("dhclient", iface)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run dhclient on %s: %v, output: %s", iface, err, output)
+	}
+	lease, err := m.Lease(iface)
+	if err != nil {
+		return nil, err
+	}
+	if lease == nil {
+		return nil, fmt.Errorf("dhclient obtained no lease on %s", iface)
+	}
+	return lease, nil
+}
+
+// Release stops the dhclient of an interface and removes the address of its
+// active lease, which is returned. FreeBSD's dhclient cannot send a
+// DHCPRELEASE, so the server keeps the lease until it expires.
+func (m *Manager) Release(iface string) (*pkgdhcp.Lease, error) {
+	if err := validateInterface(iface); err != nil {
+		return nil, err
+	}
+	lease, err := m.Lease(iface)
+	if err != nil {
+		return nil, err
+	}
+
+	pidFile := m.pidFile(iface)
+	if _, err := os.Stat(pidFile); err == nil {
+		if output, err := m.cmdExec.Execute("pkill", "-F", pidFile); err != nil {
+			return nil, fmt.Errorf("failed to stop dhclient on %s: %v, output: %s", iface, err, output)
+		}
+		// dhclient leaves its pid file behind, which Running relies on
+		if err := os.Remove(pidFile); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to remove dhclient pid file: %w", err)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read dhclient pid file: %w", err)
+	}
+
+	if lease != nil && lease.Address != "" {
+		if output, err := m.cmdExec.Execute("ifconfig", iface, "inet", lease.Address, "-alias"); err != nil {
+			return nil, fmt.Errorf("failed to remove %s from %s: %v, output: %s", lease.Address, iface, err, output)
+		}
+	}
+	return lease, nil
+}
+
+// Lease returns the most recent active lease of an interface, or nil when
+// it has none
+func (m *Manager) Lease(iface string) (*pkgdhcp.Lease, error) {
+	if err := validateInterface(iface); err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(filepath.Join(m.leaseDir, "dhclient.leases."+iface))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read leases of %s: %w", iface, err)
+	}
+	leases, err := pkgdhcp.ParseLeases(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse leases of %s: %w", iface, err)
+	}
+	return pkgdhcp.Latest(leases, m.now()), nil
+}
+
+// Running reports whether dhclient runs on an interface, that is whether
+// its pid file exists. Names dhclient does not accept are never running.
+func (m *Manager) Running(iface string) (bool, error) {
+	if validateInterface(iface) != nil {
+		return false, nil
+	}
+	_, err := os.Stat(m.pidFile(iface))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read dhclient pid file: %w", err)
+	}
+	return true, nil
+}
+
+func (m *Manager) pidFile(iface string) string {
+	return filepath.Join(m.pidDir, "dhclient."+iface+".pid")
+}
+
+func validateInterface(iface string) error {
+	if !ifaceRegex.MatchString(iface) {
+		return fmt.Errorf("invalid interface name %q", iface)
+	}
+	return nil
+}
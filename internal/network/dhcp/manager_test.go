@@ -0,0 +1,121 @@
+package dhcp
+
+import (
+	"FreeBSD-Command-manager/internal/network/bareos"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testLeases = `lease {
+  interface "vlan20";
+  fixed-address 192.168.20.105;
+  option subnet-mask 255.255.255.0;
+  option routers 192.168.20.1;
+  renew 1 2026/10/19 08:30:00;
+  rebind 1 2026/10/19 08:52:30;
+  expire 1 2026/10/19 09:00:00;
+}
+`
+
+func newTestManager(t *testing.T, leases string) (*Manager, *bareos.MockCommandExecutor, string) {
+	t.Helper()
+	dir := t.TempDir()
+	if leases != "" {
+		if err := os.WriteFile(filepath.Join(dir, "dhclient.leases.vlan20"), []byte(leases), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mockCmd := bareos.NewMockCommandExecutor()
+	m := NewManager(mockCmd, dir, dir)
+	m.now = func() time.Time { return time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC) }
+	return m, mockCmd, dir
+}
+
+func TestManager_Start(t *testing.T) {
+	m, mockCmd, _ := newTestManager(t, testLeases)
+	lease, err := m.Start("vlan20")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lease.Prefix != "192.168.20.105/24" {
+		t.Errorf("expected 192.168.20.105/24, got %+v", lease)
+	}
+	if commands := mockCmd.GetCommands(); !reflect.DeepEqual(commands, []string{"dhclient vlan20"}) {
+		t.Errorf("unexpected commands %v", commands)
+	}
+
+	m.now = func() time.Time { return time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC) }
+	if _, err := m.Start("vlan20"); err == nil {
+		t.Error("expected error for an expired lease but got none")
+	}
+
+	m, mockCmd, _ = newTestManager(t, "")
+	mockCmd.SetError("dhclient vlan20", errors.New("exit status 1"))
+	if _, err := m.Start("vlan20"); err == nil {
+		t.Error("expected error but got none")
+	}
+	if _, err := m.Start("vlan20; reboot"); err == nil {
+		t.Error("expected error for invalid interface name")
+	}
+}
+
+func TestManager_Release(t *testing.T) {
+	m, mockCmd, dir := newTestManager(t, testLeases)
+	pidFile := filepath.Join(dir, "dhclient.vlan20.pid")
+	if err := os.WriteFile(pidFile, []byte("1234\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	lease, err := m.Release("vlan20")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lease == nil || lease.Address != "192.168.20.105" {
+		t.Errorf("expected the released lease, got %+v", lease)
+	}
+	expected := []string{"pkill -F " + pidFile, "ifconfig vlan20 inet 192.168.20.105 -alias"}
+	if commands := mockCmd.GetCommands(); !reflect.DeepEqual(commands, expected) {
+		t.Errorf("expected %v, got %v", expected, commands)
+	}
+	if running, err := m.Running("vlan20"); err != nil || running {
+		t.Errorf("expected dhclient not to run after release, got %v, %v", running, err)
+	}
+
+	// neither running nor leased
+	m, mockCmd, _ = newTestManager(t, "")
+	if lease, err := m.Release("vlan20"); err != nil || lease != nil {
+		t.Errorf("expected no lease, got %+v, %v", lease, err)
+	}
+	if commands := mockCmd.GetCommands(); len(commands) != 0 {
+		t.Errorf("expected no commands, got %v", commands)
+	}
+}
+
+func TestManager_Running(t *testing.T) {
+	m, _, dir := newTestManager(t, "")
+	if running, err := m.Running("vlan20"); err != nil || running {
+		t.Errorf("expected dhclient not to run without a pid file, got %v, %v", running, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "dhclient.vlan20.pid"), []byte("1234\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if running, err := m.Running("vlan20"); err != nil || !running {
+		t.Errorf("expected dhclient to run, got %v, %v", running, err)
+	}
+	if running, err := m.Running("br-lan"); err != nil || running {
+		t.Errorf("expected no dhclient on an invalid name, got %v, %v", running, err)
+	}
+}
+
+func TestManager_Lease(t *testing.T) {
+	m, _, _ := newTestManager(t, "lease {\n  fixed-address 10.0.0.5\n}\n")
+	if _, err := m.Lease("vlan20"); err == nil {
+		t.Error("expected error for an invalid lease file")
+	}
+	if lease, err := m.Lease("em0"); err != nil || lease != nil {
+		t.Errorf("expected no lease without a lease file, got %+v, %v", lease, err)
+	}
+}
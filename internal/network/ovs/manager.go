@@ -0,0 +1,316 @@
+// Package ovs provides Open vSwitch bridge, port and interface management
+// using ovs-vsctl(8).
+package ovs
+
+import (
+	"FreeBSD-Command-manager/internal/network/bareos"
+	pkgovs "FreeBSD-Command-manager/pkg/ovs"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	vsctl   = "ovs-vsctl"
+	maxVLAN = 4095
+	// TypeVXLAN and TypeGRE are the tunnel interface types
+	TypeVXLAN = "vxlan"
+	TypeGRE   = "gre"
+	// TypeInternal is a port on the host stack of the bridge
+	TypeInternal = "internal"
+)
+
+var (
+	// Interface names are limited to IFNAMSIZ-1 characters
+	ovsName = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,15}$`)
+	// Option keys and values are passed as options:key=value, so they are
+	// restricted to characters that need no OVSDB quoting
+	optionKey   = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+	optionValue = regexp.MustCompile(`^[A-Za-z0-9_.:/-]+$`)
+)
+
+// PortOptions configures a port added to a bridge
+type PortOptions struct {
+	Tag     int               // access VLAN, 0 for none
+	Trunks  []int             // VLANs carried by a trunk port
+	Type    string            // interface type: "" (system), internal, vxlan or gre
+	Options map[string]string // interface options, e.g. remote_ip, local_ip, key, dst_port
+}
+
+// ManagerInterface defines the interface for Open vSwitch operations
+type ManagerInterface interface {
+	AddBridge(name string) error
+	DeleteBridge(name string) error
+	ListBridges() ([]string, error)
+	AddPort(bridge, port string, opts PortOptions) error
+	DeletePort(bridge, port string) error
+	ListPorts(bridge string) ([]string, error)
+	SetInterface(name, typ string, options map[string]string) error
+	Bridges() ([]pkgovs.Bridge, error)
+	Ports() ([]pkgovs.Port, error)
+	Interfaces() ([]pkgovs.Interface, error)
+	Show() ([]pkgovs.BridgeState, error)
+}
+
+// CommandExecutor defines the interface for executing system commands
+type CommandExecutor interface {
+	Execute(name string, args ...string) (string, error)
+}
+
+// Manager implements ManagerInterface using ovs-vsctl
+type Manager struct {
+	cmdExec CommandExecutor
+}
+
+// NewManager creates a new Open vSwitch manager
+func NewManager(cmdExec CommandExecutor) *Manager {
+	return &Manager{
+		cmdExec: cmdExec,
+	}
+}
+
+// DefaultManager returns the default Open vSwitch manager instance
+func DefaultManager() ManagerInterface {
+	return NewManager(bareos.NewRealCommandExecutor())
+}
+
+// AddBridge creates a bridge
+func (m *Manager) AddBridge(name string) error {
+	if err := validateName("bridge", name); err != nil {
+		return err
+	}
+	if output, err := m.cmdExec.Execute(vsctl, "add-br", name); err != nil {
+		return fmt.Errorf("failed to add bridge %s: %v, output: %s", name, err, output)
+	}
+	return nil
+}
+
+// DeleteBridge deletes a bridge and all of its ports
+func (m *Manager) DeleteBridge(name string) error {
+	if err := validateName("bridge", name); err != nil {
+		return err
+	}
+	if output, err := m.cmdExec.Execute(vsctl, "del-br", name); err != nil {
+		return fmt.Errorf("failed to delete bridge %s: %v, output: %s", name, err, output)
+	}
+	return nil
+}
+
+// ListBridges returns the bridge names
+func (m *Manager) ListBridges() ([]string, error) {
+	output, err := m.cmdExec.Execute(vsctl, "list-br")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bridges: %v", err)
+	}
+	return strings.Fields(output), nil
+}
+
+// AddPort adds a port to a bridge. The port's interface type and options
+// are set in the same transaction, so a tunnel port never exists
+// unconfigured.
+func (m *Manager) AddPort(bridge, port string, opts PortOptions) error {
+	if err := validateName("bridge", bridge); err != nil {
+		return err
+	}
+	if err := validateName("port", port); err != nil {
+		return err
+	}
+	if opts.Tag != 0 && len(opts.Trunks) > 0 {
+		return fmt.Errorf("a port is either an access port (tag) or a trunk port (trunks)")
+	}
+
+	args := []string{"add-port", bridge, port}
+	if opts.Tag != 0 {
+		if err := validateVLAN(opts.Tag); err != nil {
+			return err
+		}
+		args = append(args, "tag="+strconv.Itoa(opts.Tag))
+	}
+	if len(opts.Trunks) > 0 {
+		trunks := make([]string, 0, len(opts.Trunks))
+		for _, vlan := range opts.Trunks {
+			if err := validateVLAN(vlan); err != nil {
+				return err
+			}
+			trunks = append(trunks, strconv.Itoa(vlan))
+		}
+		args = append(args, "trunks="+strings.Join(trunks, ","))
+	}
+	if opts.Type != "" || len(opts.Options) > 0 {
+		settings, err := interfaceSettings(opts.Type, opts.Options)
+		if err != nil {
+			return err
+		}
+		args = append(append(args, "--", "set", "interface", port), settings...)
+	}
+
+	if output, err := m.cmdExec.Execute(vsctl, args...); err != nil {
+		return fmt.Errorf("failed to add port %s to %s: %v, output: %s", port, bridge, err, output)
+	}
+	return nil
+}
+
+// DeletePort removes a port from a bridge; with an empty bridge the port is
+// removed from whichever bridge has it.
+func (m *Manager) DeletePort(bridge, port string) error {
+	if err := validateName("port", port); err != nil {
+		return err
+	}
+	args := []string{"del-port", port}
+	if bridge != "" {
+		if err := validateName("bridge", bridge); err != nil {
+			return err
+		}
+		args = []string{"del-port", bridge, port}
+	}
+	if output, err := m.cmdExec.Execute(vsctl, args...); err != nil {
+		return fmt.Errorf("failed to delete port %s: %v, output: %s", port, err, output)
+	}
+	return nil
+}
+
+// ListPorts returns the port names of a bridge
+func (m *Manager) ListPorts(bridge string) ([]string, error) {
+	if err := validateName("bridge", bridge); err != nil {
+		return nil, err
+	}
+	output, err := m.cmdExec.Execute(vsctl, "list-ports", bridge)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ports of %s: %v", bridge, err)
+	}
+	return strings.Fields(output), nil
+}
+
+// SetInterface sets the type and options of an existing interface, e.g. to
+// turn it into a VXLAN or GRE tunnel.
+func (m *Manager) SetInterface(name, typ string, options map[string]string) error {
+	if err := validateName("interface", name); err != nil {
+		return err
+	}
+	if typ == "" {
+		return fmt.Errorf("interface type is required")
+	}
+	settings, err := interfaceSettings(typ, options)
+	if err != nil {
+		return err
+	}
+	args := append([]string{"set", "interface", name}, settings...)
+	if output, err := m.cmdExec.Execute(vsctl, args...); err != nil {
+		return fmt.Errorf("failed to set interface %s: %v, output: %s", name, err, output)
+	}
+	return nil
+}
+
+// Bridges returns the rows of the Bridge table
+func (m *Manager) Bridges() ([]pkgovs.Bridge, error) {
+	output, err := m.list("Bridge")
+	if err != nil {
+		return nil, err
+	}
+	rows, err := pkgovs.ParseBridges(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Bridge table: %v", err)
+	}
+	return rows, nil
+}
+
+// Ports returns the rows of the Port table
+func (m *Manager) Ports() ([]pkgovs.Port, error) {
+	output, err := m.list("Port")
+	if err != nil {
+		return nil, err
+	}
+	rows, err := pkgovs.ParsePorts(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Port table: %v", err)
+	}
+	return rows, nil
+}
+
+// Interfaces returns the rows of the Interface table
+func (m *Manager) Interfaces() ([]pkgovs.Interface, error) {
+	output, err := m.list("Interface")
+	if err != nil {
+		return nil, err
+	}
+	rows, err := pkgovs.ParseInterfaces(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Interface table: %v", err)
+	}
+	return rows, nil
+}
+
+// Show returns every bridge with its ports and interfaces
+func (m *Manager) Show() ([]pkgovs.BridgeState, error) {
+	bridges, err := m.Bridges()
+	if err != nil {
+		return nil, err
+	}
+	ports, err := m.Ports()
+	if err != nil {
+		return nil, err
+	}
+	ifaces, err := m.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	return pkgovs.Resolve(bridges, ports, ifaces), nil
+}
+
+func (m *Manager) list(table string) (string, error) {
+	output, err := m.cmdExec.Execute(vsctl, "--format=json", "list", table)
+	if err != nil {
+		return "", fmt.Errorf("failed to list %s table: %v", table, err)
+	}
+	return output, nil
+}
+
+// interfaceSettings returns the ovs-vsctl column settings for an interface
+// type and options. Tunnels need a remote_ip option.
+func interfaceSettings(typ string, options map[string]string) ([]string, error) {
+	switch typ {
+	case "", TypeInternal:
+		if len(options) > 0 {
+			return nil, fmt.Errorf("options are only supported for %s and %s interfaces", TypeVXLAN, TypeGRE)
+		}
+	case TypeVXLAN, TypeGRE:
+		if options["remote_ip"] == "" {
+			return nil, fmt.Errorf("%s interfaces require the remote_ip option", typ)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported interface type %q: must be %s, %s or %s", typ, TypeInternal, TypeVXLAN, TypeGRE)
+	}
+
+	var settings []string
+	if typ != "" {
+		settings = append(settings, "type="+typ)
+	}
+	keys := make([]string, 0, len(options))
+	for k := range options {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if !optionKey.MatchString(k) || !optionValue.MatchString(options[k]) {
+			return nil, fmt.Errorf("invalid option %s=%q", k, options[k])
+		}
+		settings = append(settings, "options:"+k+"="+options[k])
+	}
+	return settings, nil
+}
+
+func validateName(kind, name string) error {
+	if !ovsName.MatchString(name) {
+		return fmt.Errorf("invalid %s name %q", kind, name)
+	}
+	return nil
+}
+
+func validateVLAN(vlan int) error {
+	if vlan < 1 || vlan > maxVLAN {
+		return fmt.Errorf("invalid VLAN %d: must be between 1 and %d", vlan, maxVLAN)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package ovs
+
+import (
+	"FreeBSD-Command-manager/internal/network/bareos"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestManager_Bridges(t *testing.T) {
+	mockCmd := bareos.NewMockCommandExecutor()
+	mockCmd.SetOutput("ovs-vsctl list-br", "br0\nbr1\n")
+	mockCmd.SetOutput("ovs-vsctl list-ports br0", "em1\nvx0\n")
+	manager := NewManager(mockCmd)
+
+	if err := manager.AddBridge("br0"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := manager.DeleteBridge("br1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	bridges, err := manager.ListBridges()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(bridges, []string{"br0", "br1"}) {
+		t.Errorf("unexpected bridges: %v", bridges)
+	}
+	ports, err := manager.ListPorts("br0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(ports, []string{"em1", "vx0"}) {
+		t.Errorf("unexpected ports: %v", ports)
+	}
+
+	expected := []string{"ovs-vsctl add-br br0", "ovs-vsctl del-br br1", "ovs-vsctl list-br", "ovs-vsctl list-ports br0"}
+	if commands := mockCmd.GetCommands(); !reflect.DeepEqual(commands, expected) {
+		t.Errorf("expected commands %v, got %v", expected, commands)
+	}
+}
+
+func TestManager_AddPort(t *testing.T) {
+	tests := []struct {
+		name     string
+		port     string
+		opts     PortOptions
+		expected string
+	}{
+		{name: "plain", port: "em1", expected: "ovs-vsctl add-port br0 em1"},
+		{name: "access", port: "em1", opts: PortOptions{Tag: 10}, expected: "ovs-vsctl add-port br0 em1 tag=10"},
+		{name: "trunk", port: "em2", opts: PortOptions{Trunks: []int{20, 30}}, expected: "ovs-vsctl add-port br0 em2 trunks=20,30"},
+		{name: "internal", port: "mgmt0", opts: PortOptions{Tag: 5, Type: TypeInternal},
+			expected: "ovs-vsctl add-port br0 mgmt0 tag=5 -- set interface mgmt0 type=internal"},
+		{name: "vxlan", port: "vx0", opts: PortOptions{Type: TypeVXLAN, Options: map[string]string{"remote_ip": "192.0.2.2", "key": "100"}},
+			expected: "ovs-vsctl add-port br0 vx0 -- set interface vx0 type=vxlan options:key=100 options:remote_ip=192.0.2.2"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCmd := bareos.NewMockCommandExecutor()
+			if err := NewManager(mockCmd).AddPort("br0", tc.port, tc.opts); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if commands := mockCmd.GetCommands(); len(commands) != 1 || commands[0] != tc.expected {
+				t.Errorf("expected %q, got %v", tc.expected, commands)
+			}
+		})
+	}
+}
+
+func TestManager_AddPort_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		bridge string
+		port   string
+		opts   PortOptions
+	}{
+		{name: "empty bridge", port: "em1"},
+		{name: "long port name", bridge: "br0", port: "averyveryverylongport"},
+		{name: "tag and trunks", bridge: "br0", port: "em1", opts: PortOptions{Tag: 10, Trunks: []int{20}}},
+		{name: "tag out of range", bridge: "br0", port: "em1", opts: PortOptions{Tag: 4096}},
+		{name: "trunk out of range", bridge: "br0", port: "em1", opts: PortOptions{Trunks: []int{0}}},
+		{name: "unknown type", bridge: "br0", port: "em1", opts: PortOptions{Type: "patch"}},
+		{name: "tunnel without remote", bridge: "br0", port: "gre0", opts: PortOptions{Type: TypeGRE}},
+		{name: "options without type", bridge: "br0", port: "em1", opts: PortOptions{Options: map[string]string{"key": "1"}}},
+		{name: "option needs quoting", bridge: "br0", port: "vx0",
+			opts: PortOptions{Type: TypeVXLAN, Options: map[string]string{"remote_ip": "192.0.2.2", "key": "1 2"}}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCmd := bareos.NewMockCommandExecutor()
+			if err := NewManager(mockCmd).AddPort(tc.bridge, tc.port, tc.opts); err == nil {
+				t.Error("expected error but got none")
+			}
+			if commands := mockCmd.GetCommands(); len(commands) != 0 {
+				t.Errorf("expected no commands, got %v", commands)
+			}
+		})
+	}
+}
+
+func TestManager_DeletePort(t *testing.T) {
+	mockCmd := bareos.NewMockCommandExecutor()
+	manager := NewManager(mockCmd)
+	if err := manager.DeletePort("br0", "em1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := manager.DeletePort("", "vx0"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{"ovs-vsctl del-port br0 em1", "ovs-vsctl del-port vx0"}
+	if commands := mockCmd.GetCommands(); !reflect.DeepEqual(commands, expected) {
+		t.Errorf("expected commands %v, got %v", expected, commands)
+	}
+}
+
+func TestManager_SetInterface(t *testing.T) {
+	mockCmd := bareos.NewMockCommandExecutor()
+	manager := NewManager(mockCmd)
+	err := manager.SetInterface("gre0", TypeGRE, map[string]string{"remote_ip": "198.51.100.1", "local_ip": "203.0.113.10"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "ovs-vsctl set interface gre0 type=gre options:local_ip=203.0.113.10 options:remote_ip=198.51.100.1"
+	if commands := mockCmd.GetCommands(); len(commands) != 1 || commands[0] != expected {
+		t.Errorf("expected %q, got %v", expected, commands)
+	}
+	if err := manager.SetInterface("gre0", "", nil); err == nil {
+		t.Error("expected error but got none")
+	}
+}
+
+func TestManager_Show(t *testing.T) {
+	mockCmd := bareos.NewMockCommandExecutor()
+	mockCmd.SetOutput("ovs-vsctl --format=json list Bridge",
+		`{"data":[[["uuid","b1"],"br0",["set",[["uuid","p1"],["uuid","p2"]]]]],"headings":["_uuid","name","ports"]}`)
+	mockCmd.SetOutput("ovs-vsctl --format=json list Port",
+		`{"data":[[["uuid","p1"],["uuid","i1"],"vx0",["set",[]]],[["uuid","p2"],["uuid","i2"],"em1",10]],"headings":["_uuid","interfaces","name","tag"]}`)
+	mockCmd.SetOutput("ovs-vsctl --format=json list Interface",
+		`{"data":[[["uuid","i1"],"vx0",1,"vxlan"],[["uuid","i2"],"em1",2,""]],"headings":["_uuid","name","ofport","type"]}`)
+
+	bridges, err := NewManager(mockCmd).Show()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(bridges) != 1 || len(bridges[0].Ports) != 2 {
+		t.Fatalf("unexpected bridges: %+v", bridges)
+	}
+	em1 := bridges[0].Ports[0]
+	if em1.Name != "em1" || em1.Tag != 10 || len(em1.Interfaces) != 1 || em1.Interfaces[0].OFPort != 2 {
+		t.Errorf("unexpected port: %+v", em1)
+	}
+
+	mockCmd.SetError("ovs-vsctl --format=json list Port", errors.New("database connection failed"))
+	if _, err := NewManager(mockCmd).Show(); err == nil {
+		t.Error("expected error but got none")
+	}
+}
@@ -0,0 +1,611 @@
+// Package persist translates the running network configuration into rc.conf
+// variables so that interfaces and routes survive a reboot.
+package persist
+
+import (
+	"FreeBSD-Command-manager/pkg/ifconfig"
+	"FreeBSD-Command-manager/pkg/netstat"
+	"FreeBSD-Command-manager/pkg/rcconf"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultRCConf is the rc.conf file written by default
+	DefaultRCConf = "/etc/rc.conf"
+	// routePrefix names the static routes owned by fcom (route_fcom0, ...)
+	routePrefix = "fcom"
+)
+
+// cloners maps persisted interface types to their if_clone driver name.
+var cloners = map[string]string{ //nolint:gochecknoglobals
+	ifconfig.Bridge:    "bridge",
+	ifconfig.LAGG:      "lagg",
+	ifconfig.GRE:       "gre",
+	ifconfig.GIF:       "gif",
+	ifconfig.VXLAN:     "vxlan",
+	ifconfig.Tap:       "tap",
+	ifconfig.Epair:     "epair",
+	ifconfig.WireGuard: "wg",
+}
+
+// managedClone matches cloned_interfaces entries created by the cloners above.
+var managedClone = regexp.MustCompile(`^(bridge|lagg|gre|gif|vxlan|tap|epair|wg)[0-9]+$`)
+
+// Dynamic describes interfaces that obtain their addresses at run time. Their
+// leased addresses and routes are not persisted as static configuration.
+type Dynamic struct {
+	// DHCP maps interfaces to whether they are configured by DHCP: true
+	// writes ifconfig_<if>="DHCP", false replaces a DHCP configuration.
+	// Other interfaces are configured by DHCP when rc.conf says so.
+	DHCP map[string]bool
+	// Rtadv maps interfaces to whether they accept router advertisements,
+	// kept as accept_rtadv in ifconfig_<if>_ipv6. Other interfaces keep
+	// what rc.conf says.
+	Rtadv map[string]bool
+}
+
+// dhcp reports whether an interface is configured by DHCP.
+func (d Dynamic) dhcp(f *rcconf.File, iface string) bool {
+	if on, ok := d.DHCP[iface]; ok {
+		return on
+	}
+	return isDHCP(f, iface)
+}
+
+// rtadv reports whether an interface accepts router advertisements.
+func (d Dynamic) rtadv(f *rcconf.File, iface string) bool {
+	if on, ok := d.Rtadv[iface]; ok {
+		return on
+	}
+	value, _ := f.Get("ifconfig_" + rcconf.Name(iface) + "_ipv6")
+	return contains(strings.Fields(value), "accept_rtadv")
+}
+
+// changes reports whether dyn changes the configuration of an interface.
+func (d Dynamic) changes(iface string) bool {
+	_, dhcp := d.DHCP[iface]
+	_, rtadv := d.Rtadv[iface]
+	return dhcp || rtadv
+}
+
+// plan collects the desired value of each rc.conf variable; nil deletes it.
+type plan struct {
+	file *rcconf.File
+	keys []string
+	vars map[string]*string
+}
+
+func (p *plan) set(key, value string) {
+	if _, ok := p.vars[key]; !ok {
+		p.keys = append(p.keys, key)
+	}
+	p.vars[key] = &value
+}
+
+func (p *plan) del(key string) {
+	if _, ok := p.vars[key]; ok {
+		return
+	}
+	if _, exists := p.file.Get(key); exists {
+		p.keys = append(p.keys, key)
+		p.vars[key] = nil
+	}
+}
+
+// Save plans the changes to the rc.conf file at path and, unless dryRun is
+// set, writes them.
+func Save(path string, infos []ifconfig.Info, routes []netstat.Route, dyn Dynamic, dryRun bool) ([]rcconf.Change, error) {
+	f, err := rcconf.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	changes := Plan(f, infos, routes, dyn)
+	if dryRun || len(changes) == 0 {
+		return changes, nil
+	}
+	if err := f.WriteFile(path); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// Plan edits f so that it recreates the given interfaces and routes at boot
+// and returns the changes made. Variables not managed by fcom are preserved,
+// as are interfaces configured by DHCP.
+func Plan(f *rcconf.File, infos []ifconfig.Info, routes []netstat.Route, dyn Dynamic) []rcconf.Change {
+	p := &plan{file: f, vars: make(map[string]*string)}
+
+	clones := p.planClones(infos)
+	vlans := p.planVLANs(infos)
+
+	// Interfaces referenced by clones must be brought up at boot
+	needed := make(map[string]bool)
+	for i := range infos {
+		info := &infos[i]
+		if _, ok := cloners[info.Type]; ok || info.Type == ifconfig.VLAN {
+			needed[info.Name] = true
+		}
+		if info.VLANParent != "" {
+			needed[info.VLANParent] = true
+		}
+		for _, port := range info.LaggPorts {
+			needed[port.Name] = true
+		}
+		if info.Bridge != nil {
+			for _, m := range info.Bridge.Members {
+				needed[m.Name] = true
+			}
+		}
+	}
+	dhcp := make(map[string]bool)
+	for i := range infos {
+		info := &infos[i]
+		if info.Type == ifconfig.Loopback {
+			continue
+		}
+		if dyn.dhcp(f, info.Name) {
+			dhcp[info.Name] = true
+			p.planDHCP(info, dyn)
+			continue
+		}
+		v4, v6 := staticAddresses(info)
+		if needed[info.Name] || len(v4) > 0 || len(v6) > 0 || dyn.changes(info.Name) {
+			p.planInterface(info, dyn.rtadv(f, info.Name))
+		}
+	}
+
+	p.planStaleClones(clones, vlans)
+	p.planRoutes(routes, dhcp)
+
+	return f.Apply(p.keys, p.vars)
+}
+
+// planClones assigns a clone unit to every cloned interface and sets
+// cloned_interfaces, create_args_<unit> and ifconfig_<unit>_name.
+func (p *plan) planClones(infos []ifconfig.Info) map[string]bool {
+	used := make(map[string]bool)
+	for _, info := range infos {
+		used[info.Name] = true
+	}
+
+	var cloned []string
+	units := make(map[string]bool)
+	if old, ok := p.file.Get("cloned_interfaces"); ok {
+		// Keep entries fcom does not manage, such as lo1
+		for _, name := range strings.Fields(old) {
+			if !managedClone.MatchString(name) {
+				cloned = append(cloned, name)
+			}
+		}
+	}
+	for i := range infos {
+		info := &infos[i]
+		driver, ok := cloners[info.Type]
+		if !ok {
+			continue
+		}
+		unit := info.Name
+		switch {
+		case info.Type == ifconfig.Epair:
+			// epairN creates both ends; renamed ends cannot be matched to
+			// their peer and are not recreated
+			base, ok := epairUnit(info.Name)
+			if !ok || units[base] {
+				continue
+			}
+			unit = base
+		case !isUnit(unit, driver):
+			unit = freeUnit(driver, used)
+			p.rename(unit, info.Name)
+		}
+		cloned = append(cloned, unit)
+		units[unit] = true
+		if args := createArgs(info); args != "" {
+			p.set("create_args_"+rcconf.Name(unit), args)
+		} else {
+			p.del("create_args_" + rcconf.Name(unit))
+		}
+	}
+	if len(cloned) > 0 {
+		p.set("cloned_interfaces", strings.Join(cloned, " "))
+	} else {
+		p.del("cloned_interfaces")
+	}
+	return units
+}
+
+// rename sets ifconfig_<unit>_name, dropping the configuration of the
+// interface the unit was previously renamed to.
+func (p *plan) rename(unit, name string) {
+	key := "ifconfig_" + rcconf.Name(unit) + "_name"
+	if old, ok := p.file.Get(key); ok && old != name {
+		p.delInterface(rcconf.Name(old))
+	}
+	p.set(key, name)
+}
+
+// planVLANs sets vlans_<parent> and create_args_<vlan> for every VLAN.
+func (p *plan) planVLANs(infos []ifconfig.Info) map[string]bool {
+	used := make(map[string]bool)
+	for _, info := range infos {
+		used[info.Name] = true
+	}
+
+	units := make(map[string]bool)
+	parents := make(map[string][]string)
+	var order []string
+	for i := range infos {
+		info := &infos[i]
+		if info.Type != ifconfig.VLAN || info.VLANParent == "" {
+			continue
+		}
+		unit := info.Name
+		if !isUnit(unit, "vlan") {
+			unit = freeUnit("vlan", used)
+			p.rename(unit, info.Name)
+		}
+		if _, ok := parents[info.VLANParent]; !ok {
+			order = append(order, info.VLANParent)
+		}
+		parents[info.VLANParent] = append(parents[info.VLANParent], unit)
+		units[unit] = true
+		p.set("create_args_"+rcconf.Name(unit), "vlan "+strconv.Itoa(info.VLANID))
+	}
+	for _, parent := range order {
+		p.set("vlans_"+rcconf.Name(parent), strings.Join(parents[parent], " "))
+	}
+	for _, key := range p.file.Keys() {
+		if strings.HasPrefix(key, "vlans_") {
+			p.del(key)
+		}
+	}
+	return units
+}
+
+// planDHCP configures an interface with DHCP. Existing DHCP configurations
+// such as "SYNCDHCP" are kept as they are.
+func (p *plan) planDHCP(info *ifconfig.Info, dyn Dynamic) {
+	name := rcconf.Name(info.Name)
+	if !isDHCP(p.file, info.Name) {
+		p.set("ifconfig_"+name, "DHCP")
+	}
+	if _, ok := dyn.Rtadv[info.Name]; ok {
+		_, v6 := staticAddresses(info)
+		p.planIPv6(name, v6, dyn.Rtadv[info.Name])
+	}
+}
+
+// planInterface sets ifconfig_<if>, ifconfig_<if>_ipv6 and ifconfig_<if>_aliasN.
+func (p *plan) planInterface(info *ifconfig.Info, rtadv bool) {
+	name := rcconf.Name(info.Name)
+	var args []string
+	switch {
+	case info.Bridge != nil:
+		for _, m := range info.Bridge.Members {
+			args = append(args, "addm", m.Name)
+		}
+	case info.Type == ifconfig.LAGG:
+		if info.LaggProto != "" {
+			args = append(args, "laggproto", info.LaggProto)
+		}
+		for _, port := range info.LaggPorts {
+			args = append(args, "laggport", port.Name)
+		}
+		if len(info.LaggHash) > 0 {
+			args = append(args, "lagghash", strings.Join(info.LaggHash, ","))
+		}
+	}
+
+	v4, v6 := staticAddresses(info)
+	var aliases []string
+	for i, addr := range v4 {
+		if i == 0 {
+			args = append(args, "inet", addr)
+			continue
+		}
+		aliases = append(aliases, "inet "+addr)
+	}
+	p.planIPv6(name, v6, rtadv)
+	for i, addr := range v6 {
+		if i > 0 {
+			aliases = append(aliases, "inet6 "+addr)
+		}
+	}
+	if contains(info.Flags, "UP") {
+		args = append(args, "up")
+	}
+	if len(args) > 0 {
+		p.set("ifconfig_"+name, strings.Join(args, " "))
+	} else {
+		p.del("ifconfig_" + name)
+	}
+
+	// CARP aliases written by hand ("inet vhid 1 pass ... alias ...") keep
+	// their slot, as CARP addresses are not persisted from the running state
+	next := 0
+	for _, alias := range aliases {
+		for p.isCARPAlias(name, next) {
+			next++
+		}
+		p.set(fmt.Sprintf("ifconfig_%s_alias%d", name, next), alias)
+		next++
+	}
+	for _, key := range p.file.Keys() {
+		if n, ok := strings.CutPrefix(key, "ifconfig_"+name+"_alias"); ok {
+			if idx, err := strconv.Atoi(n); err == nil && idx >= next && !p.isCARPAlias(name, idx) {
+				p.del(key)
+			}
+		}
+	}
+}
+
+// planIPv6 sets ifconfig_<if>_ipv6 to the first static IPv6 address and
+// whether the interface accepts router advertisements.
+func (p *plan) planIPv6(name string, v6 []string, rtadv bool) {
+	key := "ifconfig_" + name + "_ipv6"
+	args := []string{"inet6"}
+	if len(v6) > 0 {
+		args = append(args, v6[0])
+	}
+	if rtadv {
+		args = append(args, "accept_rtadv")
+	}
+	value, _ := p.file.Get(key)
+	switch {
+	case len(args) > 1:
+		p.set(key, strings.Join(args, " "))
+	case contains(strings.Fields(value), "accept_rtadv"):
+		p.del(key)
+	}
+}
+
+// isCARPAlias reports whether ifconfig_<name>_alias<idx> configures a CARP address
+func (p *plan) isCARPAlias(name string, idx int) bool {
+	value, ok := p.file.Get(fmt.Sprintf("ifconfig_%s_alias%d", name, idx))
+	return ok && contains(strings.Fields(value), "vhid")
+}
+
+// planStaleClones deletes the variables of clones and VLANs that no longer exist.
+func (p *plan) planStaleClones(clones, vlans map[string]bool) {
+	var stale []string
+	if old, ok := p.file.Get("cloned_interfaces"); ok {
+		for _, unit := range strings.Fields(old) {
+			if managedClone.MatchString(unit) && !clones[unit] {
+				stale = append(stale, unit)
+			}
+		}
+	}
+	for _, key := range p.file.Keys() {
+		if strings.HasPrefix(key, "vlans_") {
+			old, _ := p.file.Get(key)
+			for _, unit := range strings.Fields(old) {
+				if !vlans[unit] {
+					stale = append(stale, unit)
+				}
+			}
+		}
+	}
+	for _, unit := range stale {
+		name := rcconf.Name(unit)
+		if renamed, ok := p.file.Get("ifconfig_" + name + "_name"); ok {
+			p.delInterface(rcconf.Name(renamed))
+		}
+		p.del("ifconfig_" + name + "_name")
+		p.del("create_args_" + name)
+		p.delInterface(name)
+		if isUnit(unit, "epair") {
+			p.delInterface(name + "a")
+			p.delInterface(name + "b")
+		}
+	}
+}
+
+// delInterface deletes the address configuration of an interface.
+func (p *plan) delInterface(name string) {
+	p.del("ifconfig_" + name)
+	p.del("ifconfig_" + name + "_ipv6")
+	for _, key := range p.file.Keys() {
+		if strings.HasPrefix(key, "ifconfig_"+name+"_alias") {
+			p.del(key)
+		}
+	}
+}
+
+// planRoutes sets the default routers and the fcom owned static routes.
+func (p *plan) planRoutes(routes []netstat.Route, dhcp map[string]bool) {
+	var v4, v6 []string
+	for _, r := range routes {
+		if !persistable(r) || dhcp[r.Interface] {
+			continue
+		}
+		inet6 := strings.Contains(r.Destination, ":") || strings.Contains(r.Gateway, ":")
+		if r.Destination == "default" && !contains(r.Flags, "BLACKHOLE") && !contains(r.Flags, "REJECT") {
+			key := "defaultrouter"
+			if inet6 {
+				key = "ipv6_defaultrouter"
+			}
+			if r.FIB > 0 {
+				key += "_fib" + strconv.Itoa(r.FIB)
+			}
+			p.set(key, r.Gateway)
+			continue
+		}
+
+		args := []string{"-net", r.Destination, r.Gateway}
+		if contains(r.Flags, "HOST") {
+			args[0] = "-host"
+		}
+		if r.FIB > 0 {
+			args = append(args, "-fib", strconv.Itoa(r.FIB))
+		}
+		if contains(r.Flags, "BLACKHOLE") {
+			args = append(args, "-blackhole")
+		}
+		if contains(r.Flags, "REJECT") {
+			args = append(args, "-reject")
+		}
+		if inet6 {
+			v6 = append(v6, strings.Join(args, " "))
+		} else {
+			v4 = append(v4, strings.Join(args, " "))
+		}
+	}
+	p.planRouteList("static_routes", "route_", v4)
+	p.planRouteList("ipv6_static_routes", "ipv6_route_", v6)
+}
+
+// planRouteList replaces the fcom owned entries of a static route list.
+func (p *plan) planRouteList(listKey, prefix string, routes []string) {
+	var names []string
+	if old, ok := p.file.Get(listKey); ok {
+		for _, name := range strings.Fields(old) {
+			if !strings.HasPrefix(name, routePrefix) {
+				names = append(names, name)
+			}
+		}
+	}
+	owned := make(map[string]bool)
+	for i, route := range routes {
+		name := routePrefix + strconv.Itoa(i)
+		names = append(names, name)
+		owned[prefix+name] = true
+		p.set(prefix+name, route)
+	}
+	for _, key := range p.file.Keys() {
+		if strings.HasPrefix(key, prefix+routePrefix) && !owned[key] {
+			p.del(key)
+		}
+	}
+	if len(names) > 0 {
+		p.set(listKey, strings.Join(names, " "))
+	} else {
+		p.del(listKey)
+	}
+}
+
+// persistable reports whether a route was added statically and can be
+// recreated from rc.conf.
+func persistable(r netstat.Route) bool {
+	if !contains(r.Flags, "STATIC") {
+		return false
+	}
+	if !contains(r.Flags, "GATEWAY") && !contains(r.Flags, "BLACKHOLE") && !contains(r.Flags, "REJECT") {
+		return false
+	}
+	if strings.HasPrefix(r.Gateway, "link#") || strings.Contains(r.Destination, "%") {
+		return false
+	}
+	return !strings.HasPrefix(strings.ToLower(r.Destination), "fe80:")
+}
+
+// createArgs returns the arguments passed to "ifconfig <unit> create".
+func createArgs(info *ifconfig.Info) string {
+	var args []string
+	switch info.Type {
+	case ifconfig.GRE, ifconfig.GIF:
+		if info.TunnelLocal != "" && info.TunnelRemote != "" {
+			if strings.Contains(info.TunnelLocal, ":") {
+				args = append(args, "inet6")
+			}
+			args = append(args, "tunnel", info.TunnelLocal, info.TunnelRemote)
+		}
+		if info.GREKey != 0 {
+			args = append(args, "grekey", strconv.FormatUint(uint64(info.GREKey), 10))
+		}
+	case ifconfig.VXLAN:
+		if info.VXLANID != 0 {
+			args = append(args, "vxlanid", strconv.Itoa(info.VXLANID))
+		}
+		if info.VXLANLocal != "" {
+			args = append(args, "vxlanlocal", info.VXLANLocal)
+		}
+		if info.VXLANRemote != "" {
+			args = append(args, "vxlanremote", info.VXLANRemote)
+		}
+		if info.VXLANGroup != "" {
+			args = append(args, "vxlangroup", info.VXLANGroup)
+		}
+	}
+	return strings.Join(args, " ")
+}
+
+// isDHCP reports whether rc.conf configures an interface with DHCP.
+func isDHCP(f *rcconf.File, iface string) bool {
+	value, _ := f.Get("ifconfig_" + rcconf.Name(iface))
+	return strings.Contains(strings.ToUpper(value), "DHCP")
+}
+
+// staticAddresses returns the ifconfig arguments of the IPv4 and IPv6
+// addresses of an interface that are configured at boot. Link-local
+// addresses are configured by the kernel, and CARP addresses are left out:
+// brought up as plain aliases on a backup node they would conflict with the
+// master. Autoconfigured addresses come from router advertisements again.
+// Point-to-point addresses keep their destination.
+func staticAddresses(info *ifconfig.Info) (v4, v6 []string) {
+	for _, a := range info.Addresses {
+		if a.VHID != 0 || a.Scope == ifconfig.ScopeLink || contains(a.Flags, "autoconf") {
+			continue
+		}
+		if a.Family == ifconfig.FamilyInet6 {
+			v6 = append(v6, addressArgs(a))
+		} else {
+			v4 = append(v4, addressArgs(a))
+		}
+	}
+	return v4, v6
+}
+
+// addressArgs formats an address the way ifconfig_<if> expects it, e.g.
+// "10.0.0.1/24" or "172.16.0.1 172.16.0.2 netmask 0xffffffff".
+func addressArgs(a ifconfig.Address) string {
+	if a.Destination == "" {
+		return a.Prefix
+	}
+	if a.Family == ifconfig.FamilyInet6 {
+		return fmt.Sprintf("%s %s prefixlen %d", a.Address, a.Destination, a.PrefixLen)
+	}
+	mask := uint32(0xffffffff) << (32 - a.PrefixLen)
+	return fmt.Sprintf("%s %s netmask 0x%08x", a.Address, a.Destination, mask)
+}
+
+// isUnit reports whether name is a plain clone unit such as bridge0.
+func isUnit(name, driver string) bool {
+	n, ok := strings.CutPrefix(name, driver)
+	if !ok || n == "" {
+		return false
+	}
+	_, err := strconv.Atoi(n)
+	return err == nil
+}
+
+// epairUnit returns the epairN clone an epairNa or epairNb end belongs to.
+func epairUnit(name string) (string, bool) {
+	base, ok := strings.CutSuffix(name, "a")
+	if !ok {
+		base, ok = strings.CutSuffix(name, "b")
+	}
+	return base, ok && isUnit(base, "epair")
+}
+
+// freeUnit returns the first unused <driver>N name and marks it used.
+func freeUnit(driver string, used map[string]bool) string {
+	for i := 0; ; i++ {
+		name := driver + strconv.Itoa(i)
+		if !used[name] {
+			used[name] = true
+			return name
+		}
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
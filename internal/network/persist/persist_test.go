@@ -0,0 +1,312 @@
+package persist
+
+import (
+	"FreeBSD-Command-manager/pkg/ifconfig"
+	"FreeBSD-Command-manager/pkg/netstat"
+	"FreeBSD-Command-manager/pkg/rcconf"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testIfconfig = `em0: flags=1008843<UP,BROADCAST,RUNNING,SIMPLEX,MULTICAST,LOWER_UP> metric 0 mtu 1500
+	ether 00:11:22:33:44:55
+	inet 203.0.113.10 netmask 0xffffff00 broadcast 203.0.113.255
+	media: Ethernet autoselect (1000baseT <full-duplex>)
+	status: active
+em1: flags=1008843<UP,BROADCAST,RUNNING,SIMPLEX,MULTICAST,LOWER_UP> metric 0 mtu 1500
+	ether 00:11:22:33:44:56
+	inet 10.0.0.1 netmask 0xffffff00 broadcast 10.0.0.255
+	inet 10.0.0.2 netmask 0xffffffff broadcast 10.0.0.2
+	inet6 fe80::1%em1 prefixlen 64 scopeid 0x2
+	inet6 2001:db8::1 prefixlen 64
+	media: Ethernet autoselect (1000baseT <full-duplex>)
+	status: active
+em2: flags=8802<BROADCAST,SIMPLEX,MULTICAST> metric 0 mtu 1500
+	ether 00:11:22:33:44:57
+	media: Ethernet autoselect
+	status: no carrier
+lo0: flags=1008049<UP,LOOPBACK,RUNNING,MULTICAST,LOWER_UP> metric 0 mtu 16384
+	inet 127.0.0.1 netmask 0xff000000
+	groups: lo
+vlan20: flags=1008843<UP,BROADCAST,RUNNING,SIMPLEX,MULTICAST,LOWER_UP> metric 0 mtu 1500
+	ether 00:11:22:33:44:56
+	inet 10.20.0.1 netmask 0xffffff00 broadcast 10.20.0.255
+	groups: vlan
+	vlan: 20 vlanproto: 802.1q vlanpcp: 0 parent interface: em1
+	media: Ethernet autoselect (1000baseT <full-duplex>)
+	status: active
+br-lan: flags=1008843<UP,BROADCAST,RUNNING,SIMPLEX,MULTICAST,LOWER_UP> metric 0 mtu 1500
+	ether 58:9c:fc:10:ff:01
+	inet 192.168.50.1 netmask 0xffffff00 broadcast 192.168.50.255
+	id 00:00:00:00:00:00 priority 32768 hellotime 2 fwddelay 15
+	maxage 20 holdcnt 6 proto rstp maxaddr 2000 timeout 1200
+	root id 00:00:00:00:00:00 priority 32768 ifcost 0 port 0
+	groups: bridge
+	member: vlan20 flags=143<LEARNING,DISCOVER,AUTOEDGE,AUTOPTP>
+	        ifmaxaddr 0 port 5 priority 128 path cost 20000
+	nd6 options=9<PERFORMNUD,IFDISABLED>
+gre0: flags=1008051<UP,POINTOPOINT,RUNNING,MULTICAST,LOWER_UP> metric 0 mtu 1476
+	tunnel inet 203.0.113.10 --> 198.51.100.1
+	inet 172.16.0.1 --> 172.16.0.2 netmask 0xffffffff
+	groups: gre
+	grekey: 0x2a (42)
+`
+
+const testRoutes = `Routing tables
+
+Internet:
+Destination        Gateway            Flags     Netif Expire
+default            203.0.113.1        UGS         em0
+10.50.0.0/16       10.0.0.254         UGS         em1
+10.9.0.0/16        127.0.0.1          UGSB        lo0
+10.0.0.0/24        link#2             U           em1
+`
+
+const testRCConf = `hostname="fw1"
+sshd_enable="YES"
+cloned_interfaces="lo1 bridge3"
+ifconfig_bridge3="addm em2 up"
+ifconfig_em1="inet 10.0.0.1/24"
+ifconfig_em1_alias0="inet 10.0.0.2/32"
+ifconfig_em1_alias1="inet 10.0.0.3/32"
+static_routes="office fcom0 fcom1 fcom2"
+route_office="-net 172.20.0.0/16 10.0.0.250"
+route_fcom2="-net 10.99.0.0/16 10.0.0.254"
+`
+
+const wantRCConf = `hostname="fw1"
+sshd_enable="YES"
+cloned_interfaces="lo1 bridge0 gre0"
+ifconfig_em1="inet 10.0.0.1/24 up"
+ifconfig_em1_alias0="inet 10.0.0.2/32"
+static_routes="office fcom0 fcom1"
+route_office="-net 172.20.0.0/16 10.0.0.250"
+ifconfig_bridge0_name="br-lan"
+create_args_gre0="tunnel 203.0.113.10 198.51.100.1 grekey 42"
+create_args_vlan20="vlan 20"
+vlans_em1="vlan20"
+ifconfig_em0="inet 203.0.113.10/24 up"
+ifconfig_em1_ipv6="inet6 2001:db8::1/64"
+ifconfig_vlan20="inet 10.20.0.1/24 up"
+ifconfig_br_lan="addm vlan20 inet 192.168.50.1/24 up"
+ifconfig_gre0="inet 172.16.0.1 172.16.0.2 netmask 0xffffffff up"
+defaultrouter="203.0.113.1"
+route_fcom0="-net 10.50.0.0/16 10.0.0.254"
+route_fcom1="-net 10.9.0.0/16 127.0.0.1 -blackhole"
+`
+
+func testState(t *testing.T) ([]ifconfig.Info, []netstat.Route) {
+	t.Helper()
+	routes, err := netstat.ParseNetstat(testRoutes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return ifconfig.ParseIfconfig(testIfconfig), routes
+}
+
+func TestPlan(t *testing.T) {
+	infos, routes := testState(t)
+	f := rcconf.Parse(testRCConf)
+
+	changes := Plan(f, infos, routes, Dynamic{})
+	if got := f.String(); got != wantRCConf {
+		t.Errorf("unexpected rc.conf:\n%s\nwant:\n%s", got, wantRCConf)
+	}
+
+	deleted := map[string]bool{}
+	for _, c := range changes {
+		if c.Action == "delete" {
+			deleted[c.Key] = true
+		}
+	}
+	for _, key := range []string{"ifconfig_bridge3", "ifconfig_em1_alias1", "route_fcom2"} {
+		if !deleted[key] {
+			t.Errorf("expected %s to be deleted, changes: %+v", key, changes)
+		}
+	}
+
+	// A second run against the result is a no-op
+	if again := Plan(f, infos, routes, Dynamic{}); len(again) != 0 {
+		t.Errorf("expected no changes on second run, got %+v", again)
+	}
+}
+
+func TestPlan_KeepsDHCP(t *testing.T) {
+	infos, routes := testState(t)
+	f := rcconf.Parse("ifconfig_em0=\"SYNCDHCP\"\n")
+
+	Plan(f, infos, routes, Dynamic{})
+	if v, _ := f.Get("ifconfig_em0"); v != "SYNCDHCP" {
+		t.Errorf("expected DHCP configuration to be kept, got %q", v)
+	}
+	if _, ok := f.Get("defaultrouter"); ok {
+		t.Error("expected DHCP default route not to be persisted")
+	}
+}
+
+func TestSave(t *testing.T) {
+	infos, routes := testState(t)
+	path := filepath.Join(t.TempDir(), "rc.conf")
+	if err := os.WriteFile(path, []byte(testRCConf), 0o644); err != nil { //nolint:gosec
+		t.Fatal(err)
+	}
+
+	changes, err := Save(path, infos, routes, Dynamic{}, true)
+	if err != nil || len(changes) == 0 {
+		t.Fatalf("expected planned changes, got %v, %v", changes, err)
+	}
+	data, _ := os.ReadFile(path) //nolint:gosec
+	if string(data) != testRCConf {
+		t.Error("dry run must not modify the file")
+	}
+
+	if _, err := Save(path, infos, routes, Dynamic{}, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ = os.ReadFile(path) //nolint:gosec
+	if string(data) != wantRCConf {
+		t.Errorf("unexpected rc.conf:\n%s", data)
+	}
+}
+
+const testCARPIfconfig = `em0: flags=1008843<UP,BROADCAST,RUNNING,SIMPLEX,MULTICAST,LOWER_UP> metric 0 mtu 1500
+	ether 00:11:22:33:44:55
+	inet 10.0.0.2 netmask 0xffffff00 broadcast 10.0.0.255
+	inet 10.0.0.100 netmask 0xffffffff broadcast 10.0.0.100 vhid 1
+	inet 10.0.0.3 netmask 0xffffffff broadcast 10.0.0.3
+	inet6 2001:db8::100 prefixlen 128 vhid 2
+	carp: BACKUP vhid 1 advbase 1 advskew 100
+	carp: BACKUP vhid 2 advbase 1 advskew 100
+	media: Ethernet autoselect (1000baseT <full-duplex>)
+	status: active
+`
+
+func TestPlan_SkipsCARP(t *testing.T) {
+	f := rcconf.Parse("ifconfig_em0_alias0=\"inet vhid 1 advskew 100 pass secret alias 10.0.0.100/32\"\n" +
+		"ifconfig_em0_alias1=\"inet 10.0.0.9/32\"\n")
+	Plan(f, ifconfig.ParseIfconfig(testCARPIfconfig), nil, Dynamic{})
+
+	want := `ifconfig_em0_alias0="inet vhid 1 advskew 100 pass secret alias 10.0.0.100/32"
+ifconfig_em0_alias1="inet 10.0.0.3/32"
+ifconfig_em0="inet 10.0.0.2/24 up"
+`
+	if got := f.String(); got != want {
+		t.Errorf("unexpected rc.conf:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+const testEpairWGIfconfig = `epair0a: flags=1008843<UP,BROADCAST,RUNNING,SIMPLEX,MULTICAST,LOWER_UP> metric 0 mtu 1500
+	ether 02:5d:a4:5e:11:0a
+	inet 10.30.0.1 netmask 0xffffff00 broadcast 10.30.0.255
+	groups: epair
+	media: Ethernet 10Gbase-T (10Gbase-T <full-duplex>)
+	status: active
+epair0b: flags=1008843<UP,BROADCAST,RUNNING,SIMPLEX,MULTICAST,LOWER_UP> metric 0 mtu 1500
+	ether 02:5d:a4:5e:11:0b
+	groups: epair
+	media: Ethernet 10Gbase-T (10Gbase-T <full-duplex>)
+	status: active
+wg-site: flags=10080c1<UP,RUNNING,NOARP,MULTICAST,LOWER_UP> metric 0 mtu 1420
+	inet 10.10.0.1 netmask 0xffffff00
+	groups: wg
+`
+
+func TestPlan_EpairWireGuard(t *testing.T) {
+	f := rcconf.Parse("cloned_interfaces=\"lo1 epair3\"\nifconfig_epair3a=\"up\"\n")
+	Plan(f, ifconfig.ParseIfconfig(testEpairWGIfconfig), nil, Dynamic{})
+
+	want := `cloned_interfaces="lo1 epair0 wg0"
+ifconfig_wg0_name="wg-site"
+ifconfig_epair0a="inet 10.30.0.1/24 up"
+ifconfig_epair0b="up"
+ifconfig_wg_site="inet 10.10.0.1/24 up"
+`
+	if got := f.String(); got != want {
+		t.Errorf("unexpected rc.conf:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+const testTunnelIfconfig = `gif0: flags=1008051<UP,POINTOPOINT,RUNNING,MULTICAST,LOWER_UP> metric 0 mtu 1280
+	options=80000<LINKSTATE>
+	tunnel inet 203.0.113.10 --> 198.51.100.1
+	inet 10.0.0.1 --> 10.0.0.2 netmask 0xffffffff
+	inet6 fe80::1%gif0 prefixlen 64 scopeid 0x5
+	inet6 2001:db8:1::2 --> 2001:db8:1::1 prefixlen 128
+	groups: gif
+	nd6 options=21<PERFORMNUD,AUTO_LINKLOCAL>
+gif1: flags=1008051<UP,POINTOPOINT,RUNNING,MULTICAST,LOWER_UP> metric 0 mtu 1280
+	tunnel inet6 2001:db8::10 --> 2001:db8::20
+	inet 10.0.1.1 --> 10.0.1.2 netmask 0xffffffff
+	groups: gif
+`
+
+func TestPlan_Tunnel(t *testing.T) {
+	f := rcconf.Parse("")
+	Plan(f, ifconfig.ParseIfconfig(testTunnelIfconfig), nil, Dynamic{})
+
+	want := `create_args_gif0="tunnel 203.0.113.10 198.51.100.1"
+create_args_gif1="inet6 tunnel 2001:db8::10 2001:db8::20"
+cloned_interfaces="gif0 gif1"
+ifconfig_gif0_ipv6="inet6 2001:db8:1::2 2001:db8:1::1 prefixlen 128"
+ifconfig_gif0="inet 10.0.0.1 10.0.0.2 netmask 0xffffffff up"
+ifconfig_gif1="inet 10.0.1.1 10.0.1.2 netmask 0xffffffff up"
+`
+	if got := f.String(); got != want {
+		t.Errorf("unexpected rc.conf:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+const testDynamicIfconfig = `vlan20: flags=1008843<UP,BROADCAST,RUNNING,SIMPLEX,MULTICAST,LOWER_UP> metric 0 mtu 1500
+	ether 00:11:22:33:44:56
+	inet 192.168.20.105 netmask 0xffffff00 broadcast 192.168.20.255
+	inet6 fe80::211:22ff:fe33:4456%vlan20 prefixlen 64 scopeid 0x4
+	inet6 2001:db8:20::211:22ff:fe33:4456 prefixlen 64 autoconf pltime 604800 vltime 2592000
+	groups: vlan
+	vlan: 20 vlanproto: 802.1q vlanpcp: 0 parent interface: em1
+	nd6 options=23<PERFORMNUD,ACCEPT_RTADV,AUTO_LINKLOCAL>
+`
+
+const testDynamicRoutes = `Routing tables
+
+Internet:
+Destination        Gateway            Flags     Netif Expire
+default            192.168.20.1       UGS         vlan20
+`
+
+func TestPlan_Dynamic(t *testing.T) {
+	infos := ifconfig.ParseIfconfig(testDynamicIfconfig)
+	routes, err := netstat.ParseNetstat(testDynamicRoutes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// a running dhclient is persisted as DHCP, not as its lease
+	f := rcconf.Parse("")
+	Plan(f, infos, routes, Dynamic{DHCP: map[string]bool{"vlan20": true}})
+	want := `create_args_vlan20="vlan 20"
+vlans_em1="vlan20"
+ifconfig_vlan20="DHCP"
+`
+	if got := f.String(); got != want {
+		t.Errorf("unexpected rc.conf:\n%s\nwant:\n%s", got, want)
+	}
+
+	// accepting router advertisements is kept, autoconfigured addresses are not
+	Plan(f, infos, routes, Dynamic{Rtadv: map[string]bool{"vlan20": true}})
+	if v, _ := f.Get("ifconfig_vlan20_ipv6"); v != "inet6 accept_rtadv" {
+		t.Errorf("expected inet6 accept_rtadv, got %q", v)
+	}
+	if v, _ := f.Get("ifconfig_vlan20"); v != "DHCP" {
+		t.Errorf("expected DHCP configuration to be kept, got %q", v)
+	}
+
+	// releasing the lease replaces the DHCP configuration
+	Plan(f, infos, nil, Dynamic{DHCP: map[string]bool{"vlan20": false}, Rtadv: map[string]bool{"vlan20": false}})
+	if v, _ := f.Get("ifconfig_vlan20"); v != "inet 192.168.20.105/24 up" {
+		t.Errorf("expected static configuration, got %q", v)
+	}
+	if v, ok := f.Get("ifconfig_vlan20_ipv6"); ok {
+		t.Errorf("expected ifconfig_vlan20_ipv6 to be deleted, got %q", v)
+	}
+}
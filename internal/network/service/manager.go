@@ -0,0 +1,239 @@
+// Package service runs the local DNS and DHCP service of the jail networks:
+// it renders dnsmasq(8) configuration from the running jails into a
+// directory dnsmasq.conf includes with
+//
+//	conf-dir=/usr/local/etc/dnsmasq.d/,*.conf
+package service
+
+import (
+	"FreeBSD-Command-manager/internal/jail"
+	"FreeBSD-Command-manager/internal/network/bareos"
+	"FreeBSD-Command-manager/pkg/atomicfile"
+	"FreeBSD-Command-manager/pkg/dnsmasq"
+	"FreeBSD-Command-manager/pkg/ifconfig"
+	pkgjail "FreeBSD-Command-manager/pkg/jail"
+	"bytes"
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// DefaultConfigDir is the dnsmasq conf-dir the files are written to
+	DefaultConfigDir = "/usr/local/etc/dnsmasq.d"
+	// DNSFile and DHCPFile are the files written to the conf-dir
+	DNSFile  = "fcom-dns.conf"
+	DHCPFile = "fcom-dhcp.conf"
+	// FilePermissions is the mode of the written files
+	FilePermissions = 0o644
+	// DirectoryPermissions is the mode of a created conf-dir
+	DirectoryPermissions = 0o755
+	// Service names
+	ServiceDNS  = "dns"
+	ServiceDHCP = "dhcp"
+	dnsmasqCmd  = "dnsmasq"
+	jailRunning = "running"
+)
+
+// ManagerInterface defines the interface for the local DNS and DHCP service
+type ManagerInterface interface {
+	Hosts() ([]dnsmasq.Host, error)
+	DNS(opts DNSOptions) (*Result, error)
+	DHCP(opts DHCPOptions) (*Result, error)
+}
+
+// CommandExecutor defines the interface for executing system commands
+type CommandExecutor interface {
+	Execute(name string, args ...string) (string, error)
+}
+
+// DNSOptions selects what the DNS service answers
+type DNSOptions struct {
+	Interfaces []string // listen on these only; all interfaces when empty
+	Domain     string
+	DryRun     bool // render only
+}
+
+// DHCPOptions selects the interfaces the DHCP service serves
+type DHCPOptions struct {
+	Interfaces []string // required
+	Range      string   // start-end, with a single interface only; default: upper half of the subnet
+	LeaseTime  string   // default: dnsmasq.DefaultLeaseTime
+	DryRun     bool     // render only
+}
+
+// Result reports a rendered configuration
+type Result struct {
+	Service  string          `json:"service"`
+	Path     string          `json:"path"`
+	Hosts    []dnsmasq.Host  `json:"hosts"`
+	Ranges   []dnsmasq.Range `json:"ranges,omitempty"`
+	Config   string          `json:"config,omitempty"` // set on dry runs
+	Changed  bool            `json:"changed"`
+	Reloaded bool            `json:"reloaded"`
+}
+
+// Manager implements ManagerInterface using dnsmasq
+type Manager struct {
+	cmdExec CommandExecutor
+	jails   jail.Manager
+	network bareos.ManagerInterface
+	dir     string
+}
+
+// NewManager creates a new service manager listing jails with jails and
+// interfaces with network, and writing to dir
+func NewManager(cmdExec CommandExecutor, jails jail.Manager, network bareos.ManagerInterface, dir string) *Manager {
+	return &Manager{
+		cmdExec: cmdExec,
+		jails:   jails,
+		network: network,
+		dir:     dir,
+	}
+}
+
+// DefaultManager returns the default service manager instance
+func DefaultManager() ManagerInterface {
+	cmdExec := bareos.NewRealCommandExecutor()
+	return NewManager(cmdExec, jail.DefaultManager(), bareos.NewManager(cmdExec), DefaultConfigDir)
+}
+
+// Hosts returns the hosts of the running jails. Jails that cannot be
+// entered only contribute the addresses of the jail list; the host
+// interfaces tell VNET interfaces from shared ones.
+func (m *Manager) Hosts() ([]dnsmasq.Host, error) {
+	list, err := m.jails.List()
+	if err != nil {
+		return nil, err
+	}
+	host, err := m.network.List()
+	if err != nil {
+		return nil, err
+	}
+	var jails []pkgjail.Info
+	ifaces := make(map[string][]ifconfig.Info)
+	for _, j := range list {
+		if j.Status != "" && j.Status != jailRunning {
+			continue
+		}
+		jails = append(jails, j)
+		if infos, err := m.network.JailInterfaces(j.Name); err == nil {
+			ifaces[j.Name] = infos
+		}
+	}
+	return dnsmasq.JailHosts(jails, ifaces, host), nil
+}
+
+// DNS renders host records for the running jails and applies them
+func (m *Manager) DNS(opts DNSOptions) (*Result, error) {
+	if err := dnsmasq.ValidateDomain(opts.Domain); err != nil {
+		return nil, err
+	}
+	for _, iface := range opts.Interfaces {
+		if _, err := m.network.GetInfo(iface); err != nil {
+			return nil, err
+		}
+	}
+	hosts, err := m.Hosts()
+	if err != nil {
+		return nil, err
+	}
+	conf := dnsmasq.RenderDNS(dnsmasq.DNSConfig{Interfaces: opts.Interfaces, Domain: opts.Domain, Hosts: hosts})
+	res := &Result{Service: ServiceDNS, Hosts: hosts}
+	return res, m.apply(res, DNSFile, conf, opts.DryRun)
+}
+
+// DHCP renders DHCP ranges on the subnets of the interfaces, with the MAC
+// addresses of the running jails bound to their names, and applies them
+func (m *Manager) DHCP(opts DHCPOptions) (*Result, error) {
+	if len(opts.Interfaces) == 0 {
+		return nil, fmt.Errorf("at least one interface is required")
+	}
+	if opts.Range != "" && len(opts.Interfaces) > 1 {
+		return nil, fmt.Errorf("a range can only be given for a single interface")
+	}
+	var ranges []dnsmasq.Range
+	for _, iface := range opts.Interfaces {
+		r, err := m.dhcpRange(iface, opts.Range)
+		if err != nil {
+			return nil, err
+		}
+		if opts.LeaseTime != "" {
+			r.LeaseTime = opts.LeaseTime
+		}
+		if err := r.Validate(); err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, r)
+	}
+	hosts, err := m.Hosts()
+	if err != nil {
+		return nil, err
+	}
+	conf := dnsmasq.RenderDHCP(dnsmasq.DHCPConfig{Ranges: ranges, Hosts: hosts})
+	res := &Result{Service: ServiceDHCP, Hosts: hosts, Ranges: ranges}
+	return res, m.apply(res, DHCPFile, conf, opts.DryRun)
+}
+
+// dhcpRange returns the range of an interface, on the subnet of its first
+// global IPv4 address
+func (m *Manager) dhcpRange(iface, bounds string) (dnsmasq.Range, error) {
+	info, err := m.network.GetInfo(iface)
+	if err != nil {
+		return dnsmasq.Range{}, err
+	}
+	for _, a := range info.Addresses {
+		if a.Family != ifconfig.FamilyInet || a.Scope != ifconfig.ScopeGlobal || a.VHID != 0 {
+			continue
+		}
+		prefix, err := netip.ParsePrefix(a.Prefix)
+		if err != nil {
+			continue
+		}
+		if bounds == "" {
+			return dnsmasq.DefaultRange(iface, prefix)
+		}
+		start, end, ok := strings.Cut(bounds, "-")
+		if !ok {
+			return dnsmasq.Range{}, fmt.Errorf("invalid range %q: expected start-end", bounds)
+		}
+		return dnsmasq.NewRange(iface, prefix, start, end)
+	}
+	return dnsmasq.Range{}, fmt.Errorf("interface %s has no IPv4 address to serve DHCP on", iface)
+}
+
+// apply writes conf to the file called name unless it is unchanged, checks
+// it with "dnsmasq --test" and restarts dnsmasq, which only rereads its
+// configuration files on start. A configuration dnsmasq rejects leaves the
+// previous file in place.
+func (m *Manager) apply(res *Result, name, conf string, dryRun bool) error {
+	res.Path = filepath.Join(m.dir, name)
+	if dryRun {
+		res.Config = conf
+		return nil
+	}
+	if current, err := os.ReadFile(res.Path); err == nil && bytes.Equal(current, []byte(conf)) {
+		return nil
+	}
+	if err := os.MkdirAll(m.dir, DirectoryPermissions); err != nil {
+		return fmt.Errorf("failed to create %s: %w", m.dir, err)
+	}
+
+	check := func(tmp string) error {
+		if output, err := m.cmdExec.Execute(dnsmasqCmd, "--test", "--conf-file="+tmp); err != nil {
+			return fmt.Errorf("dnsmasq rejected the %s configuration: %v, output: %s", res.Service, err, output)
+		}
+		return nil
+	}
+	if err := atomicfile.WriteFileChecked(res.Path, []byte(conf), FilePermissions, check); err != nil {
+		return err
+	}
+	res.Changed = true
+	if output, err := m.cmdExec.Execute("service", dnsmasqCmd, "restart"); err != nil {
+		return fmt.Errorf("failed to restart dnsmasq: %v, output: %s", err, output)
+	}
+	res.Reloaded = true
+	return nil
+}
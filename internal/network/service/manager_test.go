@@ -0,0 +1,199 @@
+package service
+
+import (
+	"FreeBSD-Command-manager/internal/jail"
+	"FreeBSD-Command-manager/internal/network/bareos"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testJails = `name  state    ip4.addr   ip6.addr  path
+web   running  -          -         /jails/web
+db    running  10.0.20.5  -         /jails/db
+www   running  10.0.20.6  -         /jails/www
+old   stopped  10.0.20.9  -         /jails/old
+`
+
+const testBridge = `bridge0: flags=1008843<UP,BROADCAST,RUNNING,SIMPLEX,MULTICAST,LOWER_UP> metric 0 mtu 1500
+	ether 58:9c:fc:10:ff:d6
+	inet 10.0.10.1 netmask 0xffffff00 broadcast 10.0.10.255
+	groups: bridge
+`
+
+const testWebIfconfig = `lo0: flags=1008049<UP,LOOPBACK,RUNNING,MULTICAST,LOWER_UP> metric 0 mtu 16384
+	inet 127.0.0.1 netmask 0xff000000
+epair0b: flags=1008843<UP,BROADCAST,RUNNING,SIMPLEX,MULTICAST,LOWER_UP> metric 0 mtu 1500
+	ether 02:a8:3b:5e:0f:0b
+	inet 10.0.10.5 netmask 0xffffff00 broadcast 10.0.10.255
+`
+
+const testHostEm0 = `em0: flags=1008843<UP,BROADCAST,RUNNING,SIMPLEX,MULTICAST,LOWER_UP> metric 0 mtu 1500
+	ether 58:9c:fc:10:ff:aa
+	inet 10.0.20.1 netmask 0xffffff00 broadcast 10.0.20.255
+`
+
+// testWWWIfconfig is what a non-VNET jail sees: the host's em0 with the
+// jail address only
+const testWWWIfconfig = `em0: flags=1008843<UP,BROADCAST,RUNNING,SIMPLEX,MULTICAST,LOWER_UP> metric 0 mtu 1500
+	ether 58:9c:fc:10:ff:aa
+	inet 10.0.20.6 netmask 0xffffffff broadcast 10.0.20.6
+`
+
+func newTestManager(t *testing.T) (*Manager, *bareos.MockCommandExecutor, string) {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "dnsmasq.d")
+	mockCmd := bareos.NewMockCommandExecutor()
+	mockCmd.SetOutput("jail -l", testJails)
+	mockCmd.SetOutput("jexec web ifconfig", testWebIfconfig)
+	mockCmd.SetError("jexec db ifconfig", errors.New("jexec: not permitted"))
+	mockCmd.SetOutput("jexec www ifconfig", testWWWIfconfig)
+	mockCmd.SetOutput("ifconfig bridge0", testBridge)
+	mockCmd.SetOutput("ifconfig", testHostEm0+testBridge)
+	m := NewManager(mockCmd, jail.NewFreeBSDJailManager(&jail.MockFileSystemManager{}, mockCmd), bareos.NewManager(mockCmd), dir)
+	return m, mockCmd, dir
+}
+
+func TestManager_DNS(t *testing.T) {
+	m, mockCmd, dir := newTestManager(t)
+	res, err := m.DNS(DNSOptions{Interfaces: []string{"bridge0"}, Domain: "jail.lan"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !res.Changed || !res.Reloaded || len(res.Hosts) != 3 {
+		t.Errorf("unexpected result %+v", res)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, DNSFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"interface=bridge0", "host-record=db,db.jail.lan,10.0.20.5",
+		"host-record=web,web.jail.lan,10.0.10.5", "host-record=www,www.jail.lan,10.0.20.6"} {
+		if !strings.Contains(string(content), line+"\n") {
+			t.Errorf("expected %q in\n%s", line, content)
+		}
+	}
+	if strings.Contains(string(content), "old") {
+		t.Errorf("expected no stopped jail in\n%s", content)
+	}
+	commands := mockCmd.GetCommands()
+	if last := commands[len(commands)-1]; last != "service dnsmasq restart" {
+		t.Errorf("expected dnsmasq restart, got %v", commands)
+	}
+	if !strings.HasPrefix(commands[len(commands)-2], "dnsmasq --test --conf-file="+dir+"/.fcom-dns.conf.") {
+		t.Errorf("expected the configuration to be tested, got %v", commands)
+	}
+
+	// unchanged configuration: nothing to restart
+	res, err = m.DNS(DNSOptions{Interfaces: []string{"bridge0"}, Domain: "jail.lan"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Changed || res.Reloaded {
+		t.Errorf("expected no change, got %+v", res)
+	}
+
+	if _, err := m.DNS(DNSOptions{Domain: "jail..lan"}); err == nil {
+		t.Error("expected error for invalid domain")
+	}
+}
+
+func TestManager_DHCP(t *testing.T) {
+	m, mockCmd, dir := newTestManager(t)
+	res, err := m.DHCP(DHCPOptions{Interfaces: []string{"bridge0"}, LeaseTime: "1h", DryRun: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "# Generated by fcom from the running jails; changes will be overwritten\n" +
+		"dhcp-range=set:bridge0,10.0.10.128,10.0.10.254,255.255.255.0,1h\n" +
+		"dhcp-host=02:a8:3b:5e:0f:0b,web\n"
+	if res.Config != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, res.Config)
+	}
+	if res.Path != filepath.Join(dir, DHCPFile) || res.Changed {
+		t.Errorf("unexpected result %+v", res)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("expected nothing written on a dry run, got %v", err)
+	}
+	for _, c := range mockCmd.GetCommands() {
+		if strings.HasPrefix(c, "service") || strings.HasPrefix(c, "dnsmasq") {
+			t.Errorf("unexpected command %q on a dry run", c)
+		}
+	}
+
+	res, err = m.DHCP(DHCPOptions{Interfaces: []string{"bridge0"}, Range: "10.0.10.50-10.0.10.60", DryRun: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r := res.Ranges[0]; r.Start != "10.0.10.50" || r.End != "10.0.10.60" || r.LeaseTime != "12h" {
+		t.Errorf("unexpected range %+v", r)
+	}
+
+	tests := []DHCPOptions{
+		{},
+		{Interfaces: []string{"bridge0", "bridge1"}, Range: "10.0.10.50-10.0.10.60"},
+		{Interfaces: []string{"bridge0"}, Range: "10.0.11.50-10.0.11.60"},
+		{Interfaces: []string{"bridge0"}, Range: "10.0.10.50"},
+		{Interfaces: []string{"bridge0"}, LeaseTime: "forever"},
+		{Interfaces: []string{"lo0"}},
+	}
+	for _, opts := range tests {
+		if _, err := m.DHCP(opts); err == nil {
+			t.Errorf("expected error for %+v but got none", opts)
+		}
+	}
+}
+
+func TestManager_DHCPRejected(t *testing.T) {
+	m, mockCmd, dir := newTestManager(t)
+	path := filepath.Join(dir, DHCPFile)
+	if err := os.MkdirAll(dir, DirectoryPermissions); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("# previous\n"), FilePermissions); err != nil {
+		t.Fatal(err)
+	}
+	m.cmdExec = rejectingExecutor{mockCmd}
+	if _, err := m.DHCP(DHCPOptions{Interfaces: []string{"bridge0"}}); err == nil {
+		t.Fatal("expected error but got none")
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "# previous\n" {
+		t.Errorf("expected the previous file to be kept, got\n%s", content)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := dirNames(entries); !reflect.DeepEqual(names, []string{DHCPFile}) {
+		t.Errorf("expected no temporary files, got %v", names)
+	}
+}
+
+// rejectingExecutor fails every configuration test, whose command line
+// contains a temporary file name the mock cannot be keyed on
+type rejectingExecutor struct {
+	*bareos.MockCommandExecutor
+}
+
+func (r rejectingExecutor) Execute(name string, args ...string) (string, error) {
+	if name == dnsmasqCmd {
+		return "dnsmasq: bad option at line 3", errors.New("exit status 1")
+	}
+	return r.MockCommandExecutor.Execute(name, args...)
+}
+
+func dirNames(entries []os.DirEntry) []string {
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
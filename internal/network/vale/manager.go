@@ -0,0 +1,198 @@
+// Package vale provides VALE (netmap software switch) management for FreeBSD
+// using valectl(8).
+package vale
+
+import (
+	"FreeBSD-Command-manager/internal/network/bareos"
+	pkgvale "FreeBSD-Command-manager/pkg/vale"
+	"fmt"
+	"regexp"
+)
+
+const valectl = "valectl"
+
+// Switch names must start with "vale"; netmap treats any other name as a
+// regular interface
+var (
+	switchName = regexp.MustCompile(`^vale[A-Za-z0-9_]*$`)
+	portName   = regexp.MustCompile(`^[A-Za-z0-9_.]+$`)
+)
+
+// ManagerInterface defines the interface for VALE switch operations
+type ManagerInterface interface {
+	CreateSwitch(name string, ports []string) error
+	DestroySwitch(name string) error
+	CreatePort(name string) error
+	DestroyPort(name string) error
+	Attach(sw, iface string, host bool) error
+	Detach(sw, iface string) error
+	List() ([]pkgvale.Switch, error)
+}
+
+// CommandExecutor defines the interface for executing system commands
+type CommandExecutor interface {
+	Execute(name string, args ...string) (string, error)
+}
+
+// Manager implements ManagerInterface using valectl(8)
+type Manager struct {
+	cmdExec CommandExecutor
+}
+
+// NewManager creates a new VALE manager
+func NewManager(cmdExec CommandExecutor) *Manager {
+	return &Manager{
+		cmdExec: cmdExec,
+	}
+}
+
+// DefaultManager returns the default VALE manager instance
+func DefaultManager() ManagerInterface {
+	return NewManager(bareos.NewRealCommandExecutor())
+}
+
+// CreateSwitch creates a persistent VALE port for each name and attaches it
+// to the switch. A VALE switch only exists while it has ports, so at least
+// one is required.
+func (m *Manager) CreateSwitch(name string, ports []string) error {
+	if err := validateSwitch(name); err != nil {
+		return err
+	}
+	if len(ports) == 0 {
+		return fmt.Errorf("at least one port is required to create switch %s", name)
+	}
+	for _, port := range ports {
+		if err := m.CreatePort(port); err != nil {
+			return err
+		}
+		if err := m.Attach(name, port, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DestroySwitch detaches every port of a switch, which removes the switch.
+// Persistent VALE ports are detached but not removed.
+func (m *Manager) DestroySwitch(name string) error {
+	if err := validateSwitch(name); err != nil {
+		return err
+	}
+	switches, err := m.List()
+	if err != nil {
+		return err
+	}
+	for _, sw := range switches {
+		if sw.Name != name {
+			continue
+		}
+		for _, p := range sw.Ports {
+			// Detaching an interface also detaches its host stack port
+			if p.Host {
+				continue
+			}
+			if err := m.Detach(name, p.Name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("switch %s not found", name)
+}
+
+// CreatePort creates a persistent VALE port, a network interface that can be
+// attached to a switch and used by bhyve or ifconfig.
+func (m *Manager) CreatePort(name string) error {
+	if err := validatePort(name); err != nil {
+		return err
+	}
+	if output, err := m.cmdExec.Execute(valectl, "-n", name); err != nil {
+		return fmt.Errorf("failed to create VALE port %s: %v, output: %s", name, err, output)
+	}
+	return nil
+}
+
+// DestroyPort removes a persistent VALE port, detaching it first if needed.
+func (m *Manager) DestroyPort(name string) error {
+	if err := validatePort(name); err != nil {
+		return err
+	}
+	switches, err := m.List()
+	if err != nil {
+		return err
+	}
+	for _, sw := range switches {
+		for _, p := range sw.Ports {
+			if p.Name == name && !p.Host {
+				if err := m.Detach(sw.Name, name); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if output, err := m.cmdExec.Execute(valectl, "-r", name); err != nil {
+		return fmt.Errorf("failed to remove VALE port %s: %v, output: %s", name, err, output)
+	}
+	return nil
+}
+
+// Attach attaches an interface (a NIC or a persistent VALE port) to a
+// switch, creating the switch if needed. With host the host stack of the
+// interface is attached as well.
+func (m *Manager) Attach(sw, iface string, host bool) error {
+	if err := validateSwitch(sw); err != nil {
+		return err
+	}
+	if err := validatePort(iface); err != nil {
+		return err
+	}
+	flag := "-a"
+	if host {
+		flag = "-h"
+	}
+	if output, err := m.cmdExec.Execute(valectl, flag, sw+":"+iface); err != nil {
+		return fmt.Errorf("failed to attach %s to %s: %v, output: %s", iface, sw, err, output)
+	}
+	return nil
+}
+
+// Detach detaches an interface from a switch.
+func (m *Manager) Detach(sw, iface string) error {
+	if err := validateSwitch(sw); err != nil {
+		return err
+	}
+	if err := validatePort(iface); err != nil {
+		return err
+	}
+	if output, err := m.cmdExec.Execute(valectl, "-d", sw+":"+iface); err != nil {
+		return fmt.Errorf("failed to detach %s from %s: %v, output: %s", iface, sw, err, output)
+	}
+	return nil
+}
+
+// List returns all VALE switches and their ports
+func (m *Manager) List() ([]pkgvale.Switch, error) {
+	output, err := m.cmdExec.Execute(valectl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list VALE switches: %v", err)
+	}
+	switches, err := pkgvale.ParseList(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse valectl output: %v", err)
+	}
+	return switches, nil
+}
+
+func validateSwitch(name string) error {
+	if !switchName.MatchString(name) {
+		return fmt.Errorf("invalid switch name %q: must start with \"vale\" followed by letters, digits or _", name)
+	}
+	return nil
+}
+
+func validatePort(name string) error {
+	if !portName.MatchString(name) {
+		return fmt.Errorf("invalid port name %q", name)
+	}
+	return nil
+}
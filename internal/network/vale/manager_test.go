@@ -0,0 +1,106 @@
+package vale
+
+import (
+	"FreeBSD-Command-manager/internal/network/bareos"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const testList = `vale0:em0 bridge:0 port:0
+vale0:em0^ bridge:0 port:1
+vale0:vp0 bridge:0 port:2
+`
+
+func TestManager_CreateSwitch(t *testing.T) {
+	mockCmd := bareos.NewMockCommandExecutor()
+	manager := NewManager(mockCmd)
+
+	if err := manager.CreateSwitch("vale1", []string{"vp1", "vp2"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{"valectl -n vp1", "valectl -a vale1:vp1", "valectl -n vp2", "valectl -a vale1:vp2"}
+	if commands := mockCmd.GetCommands(); !reflect.DeepEqual(commands, expected) {
+		t.Errorf("expected commands %v, got %v", expected, commands)
+	}
+
+	if err := manager.CreateSwitch("vale1", nil); err == nil {
+		t.Error("expected error for switch without ports")
+	}
+}
+
+func TestManager_DestroySwitch(t *testing.T) {
+	mockCmd := bareos.NewMockCommandExecutor()
+	mockCmd.SetOutput("valectl", testList)
+	manager := NewManager(mockCmd)
+
+	if err := manager.DestroySwitch("vale0"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{"valectl", "valectl -d vale0:em0", "valectl -d vale0:vp0"}
+	if commands := mockCmd.GetCommands(); !reflect.DeepEqual(commands, expected) {
+		t.Errorf("expected commands %v, got %v", expected, commands)
+	}
+
+	if err := manager.DestroySwitch("vale9"); err == nil {
+		t.Error("expected error for unknown switch")
+	}
+}
+
+func TestManager_Ports(t *testing.T) {
+	mockCmd := bareos.NewMockCommandExecutor()
+	mockCmd.SetOutput("valectl", testList)
+	manager := NewManager(mockCmd)
+
+	if err := manager.Attach("vale0", "em1", true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := manager.Detach("vale0", "em1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := manager.DestroyPort("vp0"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{"valectl -h vale0:em1", "valectl -d vale0:em1", "valectl", "valectl -d vale0:vp0", "valectl -r vp0"}
+	if commands := mockCmd.GetCommands(); !reflect.DeepEqual(commands, expected) {
+		t.Errorf("expected commands %v, got %v", expected, commands)
+	}
+}
+
+func TestManager_InvalidNames(t *testing.T) {
+	manager := NewManager(bareos.NewMockCommandExecutor())
+	tests := []struct {
+		name string
+		fn   func() error
+	}{
+		{"switch without vale prefix", func() error { return manager.Attach("br0", "em0", false) }},
+		{"port with colon", func() error { return manager.Attach("vale0", "em0:1", false) }},
+		{"empty port", func() error { return manager.Detach("vale0", "") }},
+		{"port with host suffix", func() error { return manager.CreatePort("vp0^") }},
+		{"empty switch", func() error { return manager.DestroySwitch("") }},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.fn(); err == nil {
+				t.Error("expected error but got none")
+			}
+		})
+	}
+}
+
+func TestManager_List(t *testing.T) {
+	mockCmd := bareos.NewMockCommandExecutor()
+	mockCmd.SetOutput("valectl", testList)
+	switches, err := NewManager(mockCmd).List()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(switches) != 1 || len(switches[0].Ports) != 3 {
+		t.Errorf("unexpected switches: %+v", switches)
+	}
+
+	mockCmd.SetError("valectl", errors.New("netmap not loaded"))
+	if _, err := NewManager(mockCmd).List(); err == nil {
+		t.Error("expected error but got none")
+	}
+}
@@ -0,0 +1,411 @@
+// Package vpp provides VPP (fd.io Vector Packet Processing) interface, bridge
+// domain and cross-connect management using vppctl.
+package vpp
+
+import (
+	"FreeBSD-Command-manager/internal/network/bareos"
+	pkgvpp "FreeBSD-Command-manager/pkg/vpp"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	vppctl = "vppctl"
+	// TypeHost, TypeTap, TypeMemif and TypeLoopback are the interface types
+	// that can be created
+	TypeHost     = "host"
+	TypeTap      = "tap"
+	TypeMemif    = "memif"
+	TypeLoopback = "loopback"
+	maxBridgeID  = 16777215 // bridge domain 0 is reserved
+	maxMTU       = 9216
+	hostPrefix   = "host-"
+)
+
+var ifName = regexp.MustCompile(`^[A-Za-z0-9_./-]+$`)
+
+// InterfaceSpec describes an interface to create
+type InterfaceSpec struct {
+	Type     string // host, tap, memif or loopback
+	HostIf   string // host: host interface to bind (required); tap: host side name (optional)
+	ID       int    // tap and memif ID
+	SocketID int    // memif socket ID, 0 for the default socket
+	Socket   string // memif socket file registered under SocketID (optional)
+	Master   bool   // memif role, slave otherwise
+}
+
+// BridgeDomainOptions sets the forwarding behavior of a bridge domain
+type BridgeDomainOptions struct {
+	Learn   bool // learn MAC addresses
+	Forward bool // forward known unicast
+	Flood   bool // flood broadcast and multicast
+	UUFlood bool // flood unknown unicast
+	ARPTerm bool // answer ARP requests in VPP
+}
+
+// DefaultBridgeDomainOptions returns the VPP defaults: everything on except
+// ARP termination.
+func DefaultBridgeDomainOptions() BridgeDomainOptions {
+	return BridgeDomainOptions{Learn: true, Forward: true, Flood: true, UUFlood: true}
+}
+
+// ManagerInterface defines the interface for VPP operations
+type ManagerInterface interface {
+	CreateInterface(spec InterfaceSpec) (string, error)
+	DeleteInterface(name string) error
+	SetState(name string, up bool) error
+	SetMTU(name string, mtu int) error
+	AddAddress(name string, prefix netip.Prefix) error
+	DeleteAddress(name string, prefix netip.Prefix) error
+	CreateBridgeDomain(id int, opts BridgeDomainOptions) error
+	DeleteBridgeDomain(id int) error
+	AddBridgeMember(id int, iface string, bvi bool) error
+	AddXConnect(a, b string) error
+	SetL3(iface string) error
+	List() ([]pkgvpp.Interface, error)
+	BridgeDomains() ([]pkgvpp.BridgeDomain, error)
+	Modes() ([]pkgvpp.Mode, error)
+}
+
+// CommandExecutor defines the interface for executing system commands
+type CommandExecutor interface {
+	Execute(name string, args ...string) (string, error)
+}
+
+// Manager implements ManagerInterface using vppctl
+type Manager struct {
+	cmdExec CommandExecutor
+}
+
+// NewManager creates a new VPP manager
+func NewManager(cmdExec CommandExecutor) *Manager {
+	return &Manager{
+		cmdExec: cmdExec,
+	}
+}
+
+// DefaultManager returns the default VPP manager instance
+func DefaultManager() ManagerInterface {
+	return NewManager(bareos.NewRealCommandExecutor())
+}
+
+// exec runs a vppctl command that prints nothing on success. vppctl exits
+// with status 0 when VPP rejects a command, so any output is an error.
+func (m *Manager) exec(args ...string) error {
+	return m.execEcho("", args...)
+}
+
+// execEcho runs a vppctl command that prints echo on success, such as
+// "create bridge-domain 10" printing "bridge-domain 10". Any other output is
+// an error.
+func (m *Manager) execEcho(echo string, args ...string) error {
+	output, err := m.cmdExec.Execute(vppctl, args...)
+	if err != nil {
+		return fmt.Errorf("%v, output: %s", err, strings.TrimSpace(output))
+	}
+	if output = strings.TrimSpace(output); output != "" && output != echo {
+		return fmt.Errorf("%s", output)
+	}
+	return nil
+}
+
+// show runs a vppctl show command and returns its output
+func (m *Manager) show(args ...string) (string, error) {
+	output, err := m.cmdExec.Execute(vppctl, append([]string{"show"}, args...)...)
+	if err != nil {
+		return "", fmt.Errorf("failed to show %s: %v, output: %s", strings.Join(args, " "), err, output)
+	}
+	return output, nil
+}
+
+// CreateInterface creates a host, tap, memif or loopback interface and
+// returns its VPP name, e.g. host-eth0, tap0, memif1/0 or loop0.
+func (m *Manager) CreateInterface(spec InterfaceSpec) (string, error) {
+	var args []string
+	switch spec.Type {
+	case TypeHost:
+		if err := validateName(spec.HostIf); err != nil {
+			return "", err
+		}
+		args = []string{"create", "host-interface", "name", spec.HostIf}
+	case TypeTap:
+		if spec.ID < 0 {
+			return "", fmt.Errorf("invalid tap ID %d", spec.ID)
+		}
+		args = []string{"create", "tap", "id", strconv.Itoa(spec.ID)}
+		if spec.HostIf != "" {
+			if err := validateName(spec.HostIf); err != nil {
+				return "", err
+			}
+			args = append(args, "host-if-name", spec.HostIf)
+		}
+	case TypeMemif:
+		if spec.ID < 0 || spec.SocketID < 0 {
+			return "", fmt.Errorf("invalid memif ID %d or socket ID %d", spec.ID, spec.SocketID)
+		}
+		if spec.Socket != "" {
+			if spec.SocketID == 0 {
+				return "", fmt.Errorf("the default memif socket (ID 0) cannot be changed")
+			}
+			if err := m.exec("create", "memif", "socket", "id", strconv.Itoa(spec.SocketID), "filename", spec.Socket); err != nil {
+				return "", fmt.Errorf("failed to create memif socket %s: %v", spec.Socket, err)
+			}
+		}
+		role := "slave"
+		if spec.Master {
+			role = "master"
+		}
+		args = []string{"create", "interface", "memif", "id", strconv.Itoa(spec.ID), "socket-id", strconv.Itoa(spec.SocketID), role}
+	case TypeLoopback:
+		args = []string{"create", "loopback", "interface"}
+	default:
+		return "", fmt.Errorf("unsupported interface type %q: must be %s, %s, %s or %s", spec.Type, TypeHost, TypeTap, TypeMemif, TypeLoopback)
+	}
+
+	output, err := m.cmdExec.Execute(vppctl, args...)
+	name := strings.TrimSpace(output)
+	if err != nil || !ifName.MatchString(name) {
+		return "", fmt.Errorf("failed to create %s interface: %v, output: %s", spec.Type, err, name)
+	}
+	return name, nil
+}
+
+// DeleteInterface deletes an interface created with CreateInterface; the
+// type is derived from the name.
+func (m *Manager) DeleteInterface(name string) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+	var args []string
+	switch {
+	case strings.HasPrefix(name, hostPrefix):
+		args = []string{"delete", "host-interface", "name", strings.TrimPrefix(name, hostPrefix)}
+	case strings.HasPrefix(name, "tap"):
+		args = []string{"delete", "tap", name}
+	case strings.HasPrefix(name, "memif"):
+		args = []string{"delete", "interface", "memif", name}
+	case strings.HasPrefix(name, "loop"):
+		args = []string{"delete", "loopback", "interface", "intfc", name}
+	default:
+		return fmt.Errorf("cannot delete %s: only host, tap, memif and loopback interfaces can be deleted", name)
+	}
+	if err := m.exec(args...); err != nil {
+		return fmt.Errorf("failed to delete interface %s: %v", name, err)
+	}
+	return nil
+}
+
+// SetState brings an interface up or down
+func (m *Manager) SetState(name string, up bool) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+	state := "down"
+	if up {
+		state = "up"
+	}
+	if err := m.exec("set", "interface", "state", name, state); err != nil {
+		return fmt.Errorf("failed to set %s %s: %v", name, state, err)
+	}
+	return nil
+}
+
+// SetMTU sets the packet MTU of an interface
+func (m *Manager) SetMTU(name string, mtu int) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+	if mtu < 1 || mtu > maxMTU {
+		return fmt.Errorf("invalid MTU %d: must be between 1 and %d", mtu, maxMTU)
+	}
+	if err := m.exec("set", "interface", "mtu", "packet", strconv.Itoa(mtu), name); err != nil {
+		return fmt.Errorf("failed to set MTU of %s: %v", name, err)
+	}
+	return nil
+}
+
+// AddAddress adds an IPv4 or IPv6 address to an interface
+func (m *Manager) AddAddress(name string, prefix netip.Prefix) error {
+	if err := validateAddress(name, prefix); err != nil {
+		return err
+	}
+	if err := m.exec("set", "interface", "ip", "address", name, prefix.String()); err != nil {
+		return fmt.Errorf("failed to add %s to %s: %v", prefix, name, err)
+	}
+	return nil
+}
+
+// DeleteAddress removes an address from an interface
+func (m *Manager) DeleteAddress(name string, prefix netip.Prefix) error {
+	if err := validateAddress(name, prefix); err != nil {
+		return err
+	}
+	if err := m.exec("set", "interface", "ip", "address", "del", name, prefix.String()); err != nil {
+		return fmt.Errorf("failed to delete %s from %s: %v", prefix, name, err)
+	}
+	return nil
+}
+
+// CreateBridgeDomain creates an L2 bridge domain
+func (m *Manager) CreateBridgeDomain(id int, opts BridgeDomainOptions) error {
+	if err := validateBridgeID(id); err != nil {
+		return err
+	}
+	flag := func(on bool) string {
+		if on {
+			return "1"
+		}
+		return "0"
+	}
+	err := m.execEcho("bridge-domain "+strconv.Itoa(id), "create", "bridge-domain", strconv.Itoa(id),
+		"learn", flag(opts.Learn), "forward", flag(opts.Forward), "uu-flood", flag(opts.UUFlood),
+		"flood", flag(opts.Flood), "arp-term", flag(opts.ARPTerm))
+	if err != nil {
+		return fmt.Errorf("failed to create bridge domain %d: %v", id, err)
+	}
+	return nil
+}
+
+// DeleteBridgeDomain deletes a bridge domain; it must have no members
+func (m *Manager) DeleteBridgeDomain(id int) error {
+	if err := validateBridgeID(id); err != nil {
+		return err
+	}
+	if err := m.exec("create", "bridge-domain", strconv.Itoa(id), "del"); err != nil {
+		return fmt.Errorf("failed to delete bridge domain %d: %v", id, err)
+	}
+	return nil
+}
+
+// AddBridgeMember puts an interface in L2 bridge mode in a bridge domain. A
+// BVI (usually a loopback) gives the bridge domain an L3 address.
+func (m *Manager) AddBridgeMember(id int, iface string, bvi bool) error {
+	if err := validateBridgeID(id); err != nil {
+		return err
+	}
+	if err := validateName(iface); err != nil {
+		return err
+	}
+	args := []string{"set", "interface", "l2", "bridge", iface, strconv.Itoa(id)}
+	if bvi {
+		args = append(args, "bvi")
+	}
+	if err := m.exec(args...); err != nil {
+		return fmt.Errorf("failed to add %s to bridge domain %d: %v", iface, id, err)
+	}
+	return nil
+}
+
+// AddXConnect cross-connects two interfaces in both directions
+func (m *Manager) AddXConnect(a, b string) error {
+	if err := validateName(a); err != nil {
+		return err
+	}
+	if err := validateName(b); err != nil {
+		return err
+	}
+	if a == b {
+		return fmt.Errorf("cannot cross-connect %s to itself", a)
+	}
+	for _, pair := range [][2]string{{a, b}, {b, a}} {
+		if err := m.exec("set", "interface", "l2", "xconnect", pair[0], pair[1]); err != nil {
+			return fmt.Errorf("failed to cross-connect %s to %s: %v", pair[0], pair[1], err)
+		}
+	}
+	return nil
+}
+
+// SetL3 returns an interface to L3 mode, removing it from its bridge domain
+// or cross-connect
+func (m *Manager) SetL3(iface string) error {
+	if err := validateName(iface); err != nil {
+		return err
+	}
+	if err := m.exec("set", "interface", "l3", iface); err != nil {
+		return fmt.Errorf("failed to set %s to L3 mode: %v", iface, err)
+	}
+	return nil
+}
+
+// List returns all interfaces with their counters and addresses
+func (m *Manager) List() ([]pkgvpp.Interface, error) {
+	output, err := m.show("interface")
+	if err != nil {
+		return nil, err
+	}
+	ifaces, err := pkgvpp.ParseInterfaces(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse interfaces: %v", err)
+	}
+	output, err = m.show("interface", "addr")
+	if err != nil {
+		return nil, err
+	}
+	addrs := pkgvpp.ParseInterfaceAddresses(output)
+	for i := range ifaces {
+		ifaces[i].Addresses = addrs[ifaces[i].Name]
+	}
+	return ifaces, nil
+}
+
+// BridgeDomains returns all bridge domains with their members
+func (m *Manager) BridgeDomains() ([]pkgvpp.BridgeDomain, error) {
+	output, err := m.show("bridge-domain")
+	if err != nil {
+		return nil, err
+	}
+	domains, err := pkgvpp.ParseBridgeDomains(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse bridge domains: %v", err)
+	}
+	for i := range domains {
+		output, err := m.show("bridge-domain", strconv.Itoa(domains[i].ID), "detail")
+		if err != nil {
+			return nil, err
+		}
+		detail, err := pkgvpp.ParseBridgeDomains(output)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse bridge domain %d: %v", domains[i].ID, err)
+		}
+		if len(detail) == 1 {
+			domains[i].Members = detail[0].Members
+		}
+	}
+	return domains, nil
+}
+
+// Modes returns the L3, bridge or cross-connect mode of every interface
+func (m *Manager) Modes() ([]pkgvpp.Mode, error) {
+	output, err := m.show("mode")
+	if err != nil {
+		return nil, err
+	}
+	return pkgvpp.ParseModes(output), nil
+}
+
+func validateName(name string) error {
+	if !ifName.MatchString(name) {
+		return fmt.Errorf("invalid interface name %q", name)
+	}
+	return nil
+}
+
+func validateAddress(name string, prefix netip.Prefix) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+	if !prefix.IsValid() || prefix.Addr().Zone() != "" {
+		return fmt.Errorf("invalid address %s", prefix)
+	}
+	return nil
+}
+
+func validateBridgeID(id int) error {
+	if id < 1 || id > maxBridgeID {
+		return fmt.Errorf("invalid bridge domain ID %d: must be between 1 and %d", id, maxBridgeID)
+	}
+	return nil
+}
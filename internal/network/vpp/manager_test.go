@@ -0,0 +1,186 @@
+package vpp
+
+import (
+	"FreeBSD-Command-manager/internal/network/bareos"
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+func TestManager_CreateInterface(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     InterfaceSpec
+		command  string
+		output   string
+		expected []string
+	}{
+		{name: "host", spec: InterfaceSpec{Type: TypeHost, HostIf: "eth0"},
+			command: "vppctl create host-interface name eth0", output: "host-eth0\n",
+			expected: []string{"vppctl create host-interface name eth0"}},
+		{name: "tap", spec: InterfaceSpec{Type: TypeTap, ID: 1, HostIf: "vpp1"},
+			command: "vppctl create tap id 1 host-if-name vpp1", output: "tap1\n",
+			expected: []string{"vppctl create tap id 1 host-if-name vpp1"}},
+		{name: "memif", spec: InterfaceSpec{Type: TypeMemif, ID: 0, SocketID: 1, Socket: "/run/vpp/memif1.sock", Master: true},
+			command: "vppctl create interface memif id 0 socket-id 1 master", output: "memif1/0\n",
+			expected: []string{"vppctl create memif socket id 1 filename /run/vpp/memif1.sock", "vppctl create interface memif id 0 socket-id 1 master"}},
+		{name: "loopback", spec: InterfaceSpec{Type: TypeLoopback},
+			command: "vppctl create loopback interface", output: "loop0\n",
+			expected: []string{"vppctl create loopback interface"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCmd := bareos.NewMockCommandExecutor()
+			mockCmd.SetOutput(tc.command, tc.output)
+			name, err := NewManager(mockCmd).CreateInterface(tc.spec)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if want := tc.output[:len(tc.output)-1]; name != want {
+				t.Errorf("expected name %s, got %s", want, name)
+			}
+			if commands := mockCmd.GetCommands(); !reflect.DeepEqual(commands, tc.expected) {
+				t.Errorf("expected commands %v, got %v", tc.expected, commands)
+			}
+		})
+	}
+}
+
+func TestManager_CreateInterface_Errors(t *testing.T) {
+	mockCmd := bareos.NewMockCommandExecutor()
+	mockCmd.SetOutput("vppctl create host-interface name eth9", "create host-interface: Invalid interface name\n")
+	manager := NewManager(mockCmd)
+
+	for _, spec := range []InterfaceSpec{
+		{Type: "vxlan"},
+		{Type: TypeHost},
+		{Type: TypeHost, HostIf: "eth9"},
+		{Type: TypeTap, ID: -1},
+		{Type: TypeMemif, Socket: "/tmp/memif.sock"},
+	} {
+		if _, err := manager.CreateInterface(spec); err == nil {
+			t.Errorf("expected error for %+v but got none", spec)
+		}
+	}
+}
+
+func TestManager_DeleteInterface(t *testing.T) {
+	mockCmd := bareos.NewMockCommandExecutor()
+	manager := NewManager(mockCmd)
+	for _, name := range []string{"host-eth0", "tap1", "memif1/0", "loop0"} {
+		if err := manager.DeleteInterface(name); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	expected := []string{
+		"vppctl delete host-interface name eth0",
+		"vppctl delete tap tap1",
+		"vppctl delete interface memif memif1/0",
+		"vppctl delete loopback interface intfc loop0",
+	}
+	if commands := mockCmd.GetCommands(); !reflect.DeepEqual(commands, expected) {
+		t.Errorf("expected commands %v, got %v", expected, commands)
+	}
+	if err := manager.DeleteInterface("GigabitEthernet0/8/0"); err == nil {
+		t.Error("expected error for a hardware interface")
+	}
+}
+
+func TestManager_Configure(t *testing.T) {
+	mockCmd := bareos.NewMockCommandExecutor()
+	// a created bridge domain is echoed
+	mockCmd.SetOutput("vppctl create bridge-domain 10 learn 1 forward 1 uu-flood 1 flood 1 arp-term 0", "bridge-domain 10\n")
+	manager := NewManager(mockCmd)
+
+	steps := []func() error{
+		func() error { return manager.SetState("tap0", true) },
+		func() error { return manager.SetMTU("tap0", 1500) },
+		func() error { return manager.AddAddress("loop0", netip.MustParsePrefix("10.0.0.1/24")) },
+		func() error { return manager.DeleteAddress("loop0", netip.MustParsePrefix("2001:db8::1/64")) },
+		func() error { return manager.CreateBridgeDomain(10, DefaultBridgeDomainOptions()) },
+		func() error { return manager.AddBridgeMember(10, "loop0", true) },
+		func() error { return manager.AddBridgeMember(10, "tap0", false) },
+		func() error { return manager.AddXConnect("host-eth0", "tap1") },
+		func() error { return manager.SetL3("tap0") },
+		func() error { return manager.DeleteBridgeDomain(10) },
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("step %d: unexpected error: %v", i, err)
+		}
+	}
+	expected := []string{
+		"vppctl set interface state tap0 up",
+		"vppctl set interface mtu packet 1500 tap0",
+		"vppctl set interface ip address loop0 10.0.0.1/24",
+		"vppctl set interface ip address del loop0 2001:db8::1/64",
+		"vppctl create bridge-domain 10 learn 1 forward 1 uu-flood 1 flood 1 arp-term 0",
+		"vppctl set interface l2 bridge loop0 10 bvi",
+		"vppctl set interface l2 bridge tap0 10",
+		"vppctl set interface l2 xconnect host-eth0 tap1",
+		"vppctl set interface l2 xconnect tap1 host-eth0",
+		"vppctl set interface l3 tap0",
+		"vppctl create bridge-domain 10 del",
+	}
+	if commands := mockCmd.GetCommands(); !reflect.DeepEqual(commands, expected) {
+		t.Errorf("expected commands:\n%v\ngot:\n%v", expected, commands)
+	}
+}
+
+func TestManager_RejectedCommand(t *testing.T) {
+	mockCmd := bareos.NewMockCommandExecutor()
+	mockCmd.SetOutput("vppctl set interface state tap9 up", "set interface state: unknown input `tap9 up'\n")
+	manager := NewManager(mockCmd)
+
+	if err := manager.SetState("tap9", true); err == nil {
+		t.Error("expected error for output of a rejected command")
+	}
+	mockCmd.SetOutput("vppctl create bridge-domain 11 learn 1 forward 1 uu-flood 1 flood 1 arp-term 0", "bridge-domain 11 learn: unknown input\n")
+	if err := manager.CreateBridgeDomain(11, DefaultBridgeDomainOptions()); err == nil {
+		t.Error("expected error for output other than the bridge domain echo")
+	}
+	for _, err := range []error{
+		manager.CreateBridgeDomain(0, BridgeDomainOptions{}),
+		manager.AddXConnect("tap0", "tap0"),
+		manager.SetMTU("tap0", 0),
+		manager.AddAddress("tap0", netip.Prefix{}),
+	} {
+		if err == nil {
+			t.Error("expected error but got none")
+		}
+	}
+}
+
+func TestManager_List(t *testing.T) {
+	mockCmd := bareos.NewMockCommandExecutor()
+	mockCmd.SetOutput("vppctl show interface", `              Name               Idx    State  MTU (L3/IP4/IP6/MPLS)     Counter          Count
+local0                            0     down          0/0/0/0
+loop0                             1      up          9000/0/0/0
+`)
+	mockCmd.SetOutput("vppctl show interface addr", "local0 (dn):\nloop0 (up):\n  L3 10.0.0.1/24\n")
+	ifaces, err := NewManager(mockCmd).List()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ifaces) != 2 || !reflect.DeepEqual(ifaces[1].Addresses, []string{"10.0.0.1/24"}) {
+		t.Errorf("unexpected interfaces: %+v", ifaces)
+	}
+}
+
+func TestManager_BridgeDomains(t *testing.T) {
+	mockCmd := bareos.NewMockCommandExecutor()
+	header := "  BD-ID   Index   BSN  Age(min)  Learning  U-Forwrd   UU-Flood   Flooding  ARP-Term  arp-ufwd Learn-co Learn-li   BVI-Intf\n"
+	mockCmd.SetOutput("vppctl show bridge-domain", header+
+		"   10       1      0     off        on        on       flood        on       off       off        1    16777216     loop0\n")
+	mockCmd.SetOutput("vppctl show bridge-domain 10 detail", header+
+		"   10       1      0     off        on        on       flood        on       off       off        1    16777216     loop0\n\n"+
+		"           Interface           If-idx  ISN  SHG  BVI  TxFlood        VLAN-Tag-Rewrite\n"+
+		"             loop0               1      1    0    *      *                 none\n")
+	domains, err := NewManager(mockCmd).BridgeDomains()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(domains) != 1 || domains[0].BVI != "loop0" || len(domains[0].Members) != 1 || !domains[0].Members[0].BVI {
+		t.Errorf("unexpected bridge domains: %+v", domains)
+	}
+}
@@ -0,0 +1,312 @@
+// Package wg provides WireGuard (if_wg) interface and peer management for FreeBSD.
+package wg
+
+import (
+	"FreeBSD-Command-manager/internal/network/bareos"
+	pkgwg "FreeBSD-Command-manager/pkg/wg"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	// KeySize is the length in bytes of WireGuard keys
+	KeySize = 32
+	// SecretFilePermissions is the mode used for key files
+	SecretFilePermissions = 0o600
+	maxPort               = 65535
+	maxKeepalive          = 65535
+)
+
+// KeyPair holds a base64 encoded Curve25519 key pair
+type KeyPair struct {
+	PrivateKey string `json:"-"`
+	PublicKey  string `json:"public_key"`
+}
+
+// PeerConfig represents the configuration of a WireGuard peer
+type PeerConfig struct {
+	PublicKey           string
+	Endpoint            string   // host:port
+	AllowedIPs          []string // CIDR prefixes
+	PersistentKeepalive int      // seconds, 0 disables
+	PresharedKey        string   // base64, optional
+}
+
+// ManagerInterface defines the interface for WireGuard operations
+type ManagerInterface interface {
+	CreateInterface(name string, listenPort int, privateKey string) (string, error)
+	DeleteInterface(name string) error
+	AddPeer(iface string, peer PeerConfig) error
+	RemovePeer(iface, publicKey string) error
+	Show(iface string) ([]pkgwg.Interface, error)
+}
+
+// CommandExecutor defines the interface for executing system commands
+type CommandExecutor interface {
+	Execute(name string, args ...string) (string, error)
+}
+
+// Manager implements ManagerInterface using ifconfig and wg(8)
+type Manager struct {
+	cmdExec CommandExecutor
+}
+
+// NewManager creates a new WireGuard manager
+func NewManager(cmdExec CommandExecutor) *Manager {
+	return &Manager{
+		cmdExec: cmdExec,
+	}
+}
+
+// DefaultManager returns the default WireGuard manager instance
+func DefaultManager() ManagerInterface {
+	return NewManager(bareos.NewRealCommandExecutor())
+}
+
+// GenerateKeyPair generates a new Curve25519 key pair
+func GenerateKeyPair() (KeyPair, error) {
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return KeyPair{}, fmt.Errorf("failed to generate key: %w", err)
+	}
+	return KeyPair{
+		PrivateKey: base64.StdEncoding.EncodeToString(key.Bytes()),
+		PublicKey:  base64.StdEncoding.EncodeToString(key.PublicKey().Bytes()),
+	}, nil
+}
+
+// PublicKey derives the public key from a base64 encoded private key
+func PublicKey(privateKey string) (string, error) {
+	raw, err := decodeKey(privateKey)
+	if err != nil {
+		return "", err
+	}
+	key, err := ecdh.X25519().NewPrivateKey(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid private key: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(key.PublicKey().Bytes()), nil
+}
+
+// GeneratePresharedKey generates a random base64 encoded preshared key
+func GeneratePresharedKey() (string, error) {
+	buf := make([]byte, KeySize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate preshared key: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(buf), nil
+}
+
+// WritePrivateKey saves a private key to a new file only its owner can
+// read. An existing file is never overwritten, so a key in use is not lost.
+func WritePrivateKey(path, key string) error {
+	if _, err := decodeKey(key); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, SecretFilePermissions) //nolint:gosec // path is provided by the operator
+	if err != nil {
+		return fmt.Errorf("failed to create key file: %w", err)
+	}
+	if _, err := f.WriteString(key + "\n"); err != nil {
+		_ = f.Close()
+		_ = os.Remove(path)
+		return fmt.Errorf("failed to write key file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(path)
+		return fmt.Errorf("failed to write key file: %w", err)
+	}
+	return nil
+}
+
+// CreateInterface creates a wg interface, sets its private key and listen
+// port, brings it up and returns its name
+func (m *Manager) CreateInterface(name string, listenPort int, privateKey string) (string, error) {
+	if listenPort < 0 || listenPort > maxPort {
+		return "", fmt.Errorf("listen port must be between 0 and %d", maxPort)
+	}
+	if _, err := decodeKey(privateKey); err != nil {
+		return "", err
+	}
+
+	// Create wg interface
+	out, err := m.cmdExec.Execute("ifconfig", "wg", "create")
+	if err != nil {
+		return "", fmt.Errorf("failed to create wg interface: %v", err)
+	}
+	wgName := strings.TrimSpace(out)
+
+	if name != "" {
+		// Rename to desired name
+		_, err = m.cmdExec.Execute("ifconfig", wgName, "name", name)
+		if err != nil {
+			return wgName, fmt.Errorf("failed to rename wg interface to %s: %v", name, err)
+		}
+		wgName = name
+	}
+
+	// wg(8) reads keys from files so they never appear in the process list
+	keyFile, cleanup, err := writeSecret(privateKey)
+	if err != nil {
+		return wgName, err
+	}
+	defer cleanup()
+
+	args := []string{"set", wgName, "private-key", keyFile}
+	if listenPort > 0 {
+		args = append(args, "listen-port", strconv.Itoa(listenPort))
+	}
+	_, err = m.cmdExec.Execute("wg", args...)
+	if err != nil {
+		return wgName, fmt.Errorf("failed to configure wg interface %s: %v", wgName, err)
+	}
+
+	// Bring up the interface
+	_, err = m.cmdExec.Execute("ifconfig", wgName, "up")
+	if err != nil {
+		return wgName, fmt.Errorf("failed to bring up wg interface %s: %v", wgName, err)
+	}
+
+	return wgName, nil
+}
+
+// DeleteInterface deletes a wg interface
+func (m *Manager) DeleteInterface(name string) error {
+	if name == "" {
+		return fmt.Errorf("wg interface name is required")
+	}
+
+	_, err := m.cmdExec.Execute("ifconfig", name, "destroy")
+	if err != nil {
+		return fmt.Errorf("failed to delete wg interface %s: %v", name, err)
+	}
+
+	return nil
+}
+
+// AddPeer adds or updates a peer on a wg interface
+func (m *Manager) AddPeer(iface string, peer PeerConfig) error {
+	if iface == "" {
+		return fmt.Errorf("wg interface name is required")
+	}
+	if _, err := decodeKey(peer.PublicKey); err != nil {
+		return fmt.Errorf("peer public key: %w", err)
+	}
+
+	args := []string{"set", iface, "peer", peer.PublicKey}
+	if peer.Endpoint != "" {
+		if _, _, err := net.SplitHostPort(peer.Endpoint); err != nil {
+			return fmt.Errorf("invalid endpoint %q: %v", peer.Endpoint, err)
+		}
+		args = append(args, "endpoint", peer.Endpoint)
+	}
+	if len(peer.AllowedIPs) > 0 {
+		for _, ip := range peer.AllowedIPs {
+			if _, err := netip.ParsePrefix(ip); err != nil {
+				return fmt.Errorf("invalid allowed IP %q: %v", ip, err)
+			}
+		}
+		args = append(args, "allowed-ips", strings.Join(peer.AllowedIPs, ","))
+	}
+	if peer.PersistentKeepalive < 0 || peer.PersistentKeepalive > maxKeepalive {
+		return fmt.Errorf("persistent keepalive must be between 0 and %d", maxKeepalive)
+	}
+	if peer.PersistentKeepalive > 0 {
+		args = append(args, "persistent-keepalive", strconv.Itoa(peer.PersistentKeepalive))
+	}
+	if peer.PresharedKey != "" {
+		if _, err := decodeKey(peer.PresharedKey); err != nil {
+			return fmt.Errorf("preshared key: %w", err)
+		}
+		pskFile, cleanup, err := writeSecret(peer.PresharedKey)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		args = append(args, "preshared-key", pskFile)
+	}
+
+	_, err := m.cmdExec.Execute("wg", args...)
+	if err != nil {
+		return fmt.Errorf("failed to add peer to %s: %v", iface, err)
+	}
+
+	return nil
+}
+
+// RemovePeer removes a peer from a wg interface
+func (m *Manager) RemovePeer(iface, publicKey string) error {
+	if iface == "" {
+		return fmt.Errorf("wg interface name is required")
+	}
+	if _, err := decodeKey(publicKey); err != nil {
+		return fmt.Errorf("peer public key: %w", err)
+	}
+
+	_, err := m.cmdExec.Execute("wg", "set", iface, "peer", publicKey, "remove")
+	if err != nil {
+		return fmt.Errorf("failed to remove peer from %s: %v", iface, err)
+	}
+
+	return nil
+}
+
+// Show returns the state of one wg interface, or of all when iface is empty
+func (m *Manager) Show(iface string) ([]pkgwg.Interface, error) {
+	target := iface
+	if target == "" {
+		target = "all"
+	}
+	output, err := m.cmdExec.Execute("wg", "show", target, "dump")
+	if err != nil {
+		return nil, fmt.Errorf("failed to show wg %s: %v", target, err)
+	}
+	interfaces, err := pkgwg.ParseDump(output, iface)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse wg dump: %v", err)
+	}
+	return interfaces, nil
+}
+
+// decodeKey validates a base64 encoded 32 byte key
+func decodeKey(key string) ([]byte, error) {
+	if key == "" {
+		return nil, fmt.Errorf("key is required")
+	}
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil || len(raw) != KeySize {
+		return nil, fmt.Errorf("invalid key: must be %d bytes base64 encoded", KeySize)
+	}
+	return raw, nil
+}
+
+// writeSecret stores a key in a private temporary file and returns its path
+func writeSecret(secret string) (path string, cleanup func(), err error) {
+	f, err := os.CreateTemp("", "fcom-wg-*")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create key file: %w", err)
+	}
+	cleanup = func() { _ = os.Remove(f.Name()) }
+	if err := f.Chmod(SecretFilePermissions); err != nil {
+		_ = f.Close()
+		cleanup()
+		return "", nil, fmt.Errorf("failed to protect key file: %w", err)
+	}
+	if _, err := f.WriteString(secret + "\n"); err != nil {
+		_ = f.Close()
+		cleanup()
+		return "", nil, fmt.Errorf("failed to write key file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to write key file: %w", err)
+	}
+	return f.Name(), cleanup, nil
+}
@@ -0,0 +1,195 @@
+package wg
+
+import (
+	"FreeBSD-Command-manager/internal/network/bareos"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testPeerKey = "Sd8xGIpNkzj7g0pPg7/4RVw+E2d6Xr5kGQJ5dV3mCzE="
+
+func TestGenerateKeyPair(t *testing.T) {
+	kp, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pub, err := PublicKey(kp.PrivateKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pub != kp.PublicKey {
+		t.Errorf("derived public key %s does not match %s", pub, kp.PublicKey)
+	}
+	if _, err := PublicKey("short"); err == nil {
+		t.Error("expected error for invalid private key")
+	}
+
+	psk, err := GeneratePresharedKey()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := decodeKey(psk); err != nil {
+		t.Errorf("generated preshared key is invalid: %v", err)
+	}
+}
+
+func TestManager_CreateInterface(t *testing.T) {
+	kp, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	mockCmd := bareos.NewMockCommandExecutor()
+	mockCmd.SetOutput("ifconfig wg create", "wg0\n")
+	manager := NewManager(mockCmd)
+
+	name, err := manager.CreateInterface("wg-site", 51820, kp.PrivateKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if name != "wg-site" {
+		t.Errorf("expected wg-site, got %s", name)
+	}
+	commands := mockCmd.GetCommands()
+	if len(commands) != 4 {
+		t.Fatalf("expected 4 commands, got %v", commands)
+	}
+	if commands[1] != "ifconfig wg0 name wg-site" {
+		t.Errorf("unexpected rename command: %s", commands[1])
+	}
+	if !strings.HasPrefix(commands[2], "wg set wg-site private-key ") || !strings.HasSuffix(commands[2], " listen-port 51820") {
+		t.Errorf("unexpected wg set command: %s", commands[2])
+	}
+	if strings.Contains(strings.Join(commands, " "), kp.PrivateKey) {
+		t.Error("private key must not appear in command arguments")
+	}
+	if commands[3] != "ifconfig wg-site up" {
+		t.Errorf("unexpected up command: %s", commands[3])
+	}
+
+	if _, err := manager.CreateInterface("wg1", 70000, kp.PrivateKey); err == nil {
+		t.Error("expected error for invalid port")
+	}
+	if _, err := manager.CreateInterface("wg1", 51820, "bad"); err == nil {
+		t.Error("expected error for invalid key")
+	}
+}
+
+func TestWritePrivateKey(t *testing.T) {
+	kp, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "wg0.key")
+	if err := WritePrivateKey(path, kp.PrivateKey); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	st, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Mode().Perm() != SecretFilePermissions {
+		t.Errorf("expected mode %o, got %o", SecretFilePermissions, st.Mode().Perm())
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != kp.PrivateKey+"\n" {
+		t.Errorf("unexpected key file %q", data)
+	}
+
+	if err := WritePrivateKey(path, kp.PrivateKey); err == nil {
+		t.Error("expected error for an existing key file")
+	}
+	if err := WritePrivateKey(filepath.Join(t.TempDir(), "bad.key"), "bad"); err == nil {
+		t.Error("expected error for invalid key")
+	}
+}
+
+func TestManager_AddPeer(t *testing.T) {
+	tests := []struct {
+		name        string
+		peer        PeerConfig
+		expected    string
+		shouldError bool
+	}{
+		{
+			name: "full peer",
+			peer: PeerConfig{
+				PublicKey:           testPeerKey,
+				Endpoint:            "203.0.113.5:51820",
+				AllowedIPs:          []string{"10.10.0.2/32", "fd00::/64"},
+				PersistentKeepalive: 25,
+			},
+			expected: "wg set wg0 peer " + testPeerKey + " endpoint 203.0.113.5:51820 allowed-ips 10.10.0.2/32,fd00::/64 persistent-keepalive 25",
+		},
+		{
+			name:     "minimal peer",
+			peer:     PeerConfig{PublicKey: testPeerKey},
+			expected: "wg set wg0 peer " + testPeerKey,
+		},
+		{name: "invalid key", peer: PeerConfig{PublicKey: "abc"}, shouldError: true},
+		{name: "invalid endpoint", peer: PeerConfig{PublicKey: testPeerKey, Endpoint: "203.0.113.5"}, shouldError: true},
+		{name: "invalid allowed ip", peer: PeerConfig{PublicKey: testPeerKey, AllowedIPs: []string{"10.0.0.300/32"}}, shouldError: true},
+		{name: "invalid keepalive", peer: PeerConfig{PublicKey: testPeerKey, PersistentKeepalive: -1}, shouldError: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockCmd := bareos.NewMockCommandExecutor()
+			err := NewManager(mockCmd).AddPeer("wg0", tc.peer)
+			if tc.shouldError {
+				if err == nil {
+					t.Error("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cmds := mockCmd.GetCommands(); len(cmds) != 1 || cmds[0] != tc.expected {
+				t.Errorf("expected command %s, got %v", tc.expected, cmds)
+			}
+		})
+	}
+}
+
+func TestManager_AddPeer_PresharedKey(t *testing.T) {
+	psk, err := GeneratePresharedKey()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	mockCmd := bareos.NewMockCommandExecutor()
+	if err := NewManager(mockCmd).AddPeer("wg0", PeerConfig{PublicKey: testPeerKey, PresharedKey: psk}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cmd := mockCmd.GetCommands()[0]
+	if !strings.Contains(cmd, " preshared-key ") || strings.Contains(cmd, psk) {
+		t.Errorf("preshared key must be passed as a file: %s", cmd)
+	}
+}
+
+func TestManager_RemovePeerAndShow(t *testing.T) {
+	mockCmd := bareos.NewMockCommandExecutor()
+	mockCmd.SetOutput("wg show all dump",
+		"wg0\tcFHkX1FlJ0TTo1c0J2z7m7bN8Ah2SK5RGnb6Y9j2w2w=\tTQ0r3mDgKG5aF0FT0hGYh3U1f2f1E9EJ2b2Zf5X1yW8=\t51820\toff\n"+
+			"wg0\t"+testPeerKey+"\t(none)\t203.0.113.5:51820\t10.10.0.2/32\t0\t0\t0\t25\n")
+	manager := NewManager(mockCmd)
+
+	if err := manager.RemovePeer("wg0", testPeerKey); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cmd := mockCmd.GetCommands()[0]; cmd != "wg set wg0 peer "+testPeerKey+" remove" {
+		t.Errorf("unexpected command: %s", cmd)
+	}
+
+	interfaces, err := manager.Show("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(interfaces) != 1 || len(interfaces[0].Peers) != 1 || interfaces[0].Peers[0].Endpoint != "203.0.113.5:51820" {
+		t.Errorf("unexpected show result: %#v", interfaces)
+	}
+}
@@ -0,0 +1,47 @@
+// Package atomicfile replaces files atomically: the content is written to a
+// temporary file in the same directory, which is renamed over the target, so
+// readers see either the old or the new file and never a partial one.
+package atomicfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// WriteFile atomically replaces path with data, with mode perm
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	return WriteFileChecked(path, data, perm, nil)
+}
+
+// WriteFileChecked is WriteFile, calling check with the name of the complete
+// temporary file before it replaces path. An error from check leaves path
+// untouched and is returned as is.
+func WriteFileChecked(path string, data []byte, perm os.FileMode, check func(tmp string) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", tmp.Name(), err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to set mode on %s: %w", tmp.Name(), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", tmp.Name(), err)
+	}
+	if check != nil {
+		if err := check(tmp.Name()); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
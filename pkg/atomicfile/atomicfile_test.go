@@ -0,0 +1,76 @@
+package atomicfile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+	if err := WriteFile(path, []byte("one\n"), 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := WriteFile(path, []byte("two\n"), 0o640); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "two\n" {
+		t.Errorf("expected the new content, got %q", data)
+	}
+	st, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Mode().Perm() != 0o640 {
+		t.Errorf("expected mode 0640, got %v", st.Mode().Perm())
+	}
+
+	if err := WriteFile(filepath.Join(dir, "missing", "file"), nil, 0o600); err == nil {
+		t.Error("expected error for a missing directory but got none")
+	}
+}
+
+func TestWriteFileChecked(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pf.conf")
+	if err := os.WriteFile(path, []byte("old\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	rejected := errors.New("syntax error")
+	var checked string
+	err := WriteFileChecked(path, []byte("new\n"), 0o600, func(tmp string) error {
+		data, err := os.ReadFile(tmp)
+		if err != nil {
+			return err
+		}
+		checked = string(data)
+		return rejected
+	})
+	if !errors.Is(err, rejected) {
+		t.Fatalf("expected the check error, got %v", err)
+	}
+	if checked != "new\n" {
+		t.Errorf("expected the check to see the new content, got %q", checked)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "old\n" {
+		t.Errorf("expected the old content to be kept, got %q", data)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected no temporary files, got %v", entries)
+	}
+}
@@ -0,0 +1,198 @@
+// Package dhcp parses the lease files dhclient(8) keeps in
+// /var/db/dhclient.leases.<interface>.
+package dhcp
+
+import (
+	"bufio"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeLayout is the date of renew, rebind and expire after the weekday,
+// always in UTC
+const timeLayout = "2006/01/02 15:04:05"
+
+// Lease is a lease statement of a dhclient lease file
+type Lease struct {
+	Interface    string            `json:"interface"`
+	Address      string            `json:"address"`
+	Prefix       string            `json:"prefix,omitempty"` // address with the subnet mask length
+	Routers      []string          `json:"routers,omitempty"`
+	DNS          []string          `json:"dns,omitempty"`
+	DomainName   string            `json:"domain_name,omitempty"`
+	DomainSearch string            `json:"domain_search,omitempty"`
+	ServerID     string            `json:"server_id,omitempty"`
+	LeaseTime    int               `json:"lease_time,omitempty"` // seconds
+	Renew        *time.Time        `json:"renew,omitempty"`
+	Rebind       *time.Time        `json:"rebind,omitempty"`
+	Expire       *time.Time        `json:"expire,omitempty"` // nil for leases that never expire
+	Options      map[string]string `json:"options,omitempty"`
+}
+
+// Active reports whether the lease has not expired at now
+func (l *Lease) Active(now time.Time) bool {
+	return l.Expire == nil || l.Expire.After(now)
+}
+
+// ParseLeases parses a lease file. dhclient appends a statement for every
+// lease it obtains, so the last one is the most recent:
+//
+//	lease {
+//	  interface "vlan20";
+//	  fixed-address 192.168.20.105;
+//	  option subnet-mask 255.255.255.0;
+//	  option routers 192.168.20.1;
+//	  option domain-name-servers 192.168.20.1,9.9.9.9;
+//	  renew 3 2026/10/21 08:12:44;
+//	  rebind 3 2026/10/21 18:42:44;
+//	  expire 3 2026/10/21 21:42:44;
+//	}
+func ParseLeases(content string) ([]Lease, error) {
+	var leases []Lease
+	var current *Lease
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+		case line == "lease {":
+			if current != nil {
+				return nil, fmt.Errorf("line %d: nested lease", n)
+			}
+			current = &Lease{}
+		case line == "}":
+			if current == nil {
+				return nil, fmt.Errorf("line %d: unexpected }", n)
+			}
+			current.Prefix = prefix(current.Address, current.Options["subnet-mask"])
+			leases = append(leases, *current)
+			current = nil
+		case current == nil:
+			return nil, fmt.Errorf("line %d: statement outside a lease: %q", n, line)
+		default:
+			if !strings.HasSuffix(line, ";") {
+				return nil, fmt.Errorf("line %d: missing ;", n)
+			}
+			if err := parseStatement(current, strings.TrimSuffix(line, ";")); err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scanner error: %w", err)
+	}
+	if current != nil {
+		return nil, fmt.Errorf("unterminated lease")
+	}
+	return leases, nil
+}
+
+// Latest returns the most recent lease that has not expired at now, or nil
+func Latest(leases []Lease, now time.Time) *Lease {
+	for i := len(leases) - 1; i >= 0; i-- {
+		if leases[i].Active(now) {
+			return &leases[i]
+		}
+	}
+	return nil
+}
+
+func parseStatement(l *Lease, stmt string) error {
+	key, value, _ := strings.Cut(stmt, " ")
+	value = strings.TrimSpace(value)
+	switch key {
+	case "interface":
+		l.Interface = unquote(value)
+	case "fixed-address":
+		l.Address = value
+	case "option":
+		name, v, _ := strings.Cut(value, " ")
+		return parseOption(l, name, unquote(strings.TrimSpace(v)))
+	case "renew", "rebind", "expire":
+		t, err := parseTime(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s time: %w", key, err)
+		}
+		switch key {
+		case "renew":
+			l.Renew = t
+		case "rebind":
+			l.Rebind = t
+		default:
+			l.Expire = t
+		}
+	}
+	return nil
+}
+
+// parseOption sets an option and the field it maps to
+func parseOption(l *Lease, name, value string) error {
+	if l.Options == nil {
+		l.Options = make(map[string]string)
+	}
+	l.Options[name] = value
+	switch name {
+	case "routers":
+		l.Routers = strings.Split(value, ",")
+	case "domain-name-servers":
+		l.DNS = strings.Split(value, ",")
+	case "domain-name":
+		l.DomainName = value
+	case "domain-search":
+		l.DomainSearch = value
+	case "dhcp-server-identifier":
+		l.ServerID = value
+	case "dhcp-lease-time":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid lease time %q", value)
+		}
+		l.LeaseTime = n
+	}
+	return nil
+}
+
+// parseTime parses "<weekday> 2026/10/21 08:12:44" or "never"
+func parseTime(value string) (*time.Time, error) {
+	if value == "never" {
+		return nil, nil
+	}
+	_, date, ok := strings.Cut(value, " ")
+	if !ok {
+		return nil, fmt.Errorf("%q", value)
+	}
+	t, err := time.Parse(timeLayout, date)
+	if err != nil {
+		return nil, fmt.Errorf("%q: %w", value, err)
+	}
+	return &t, nil
+}
+
+// prefix returns the address in CIDR notation, or "" without a valid mask
+func prefix(addr, mask string) string {
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return ""
+	}
+	m, err := netip.ParseAddr(mask)
+	if err != nil || !m.Is4() {
+		return ""
+	}
+	bits := 0
+	for _, b := range m.As4() {
+		for ; b&0x80 != 0; b <<= 1 {
+			bits++
+		}
+	}
+	return netip.PrefixFrom(ip, bits).String()
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
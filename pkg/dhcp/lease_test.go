@@ -0,0 +1,113 @@
+package dhcp
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func readFixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func date(s string) *time.Time {
+	t, err := time.Parse(timeLayout, s)
+	if err != nil {
+		panic(err)
+	}
+	return &t
+}
+
+func TestParseLeases(t *testing.T) {
+	leases, err := ParseLeases(readFixture(t, "dhclient.leases.vlan20"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(leases) != 2 {
+		t.Fatalf("expected 2 leases, got %d", len(leases))
+	}
+	expected := Lease{
+		Interface:    "vlan20",
+		Address:      "192.168.20.105",
+		Prefix:       "192.168.20.105/24",
+		Routers:      []string{"192.168.20.1", "192.168.20.2"},
+		DNS:          []string{"192.168.20.1", "9.9.9.9"},
+		DomainName:   "lab.example",
+		DomainSearch: "lab.example. example.",
+		ServerID:     "192.168.20.1",
+		LeaseTime:    86400,
+		Renew:        date("2026/10/20 09:00:00"),
+		Rebind:       date("2026/10/20 18:00:00"),
+		Expire:       date("2026/10/20 21:00:00"),
+		Options: map[string]string{
+			"subnet-mask":            "255.255.255.0",
+			"routers":                "192.168.20.1,192.168.20.2",
+			"domain-name-servers":    "192.168.20.1,9.9.9.9",
+			"domain-name":            "lab.example",
+			"domain-search":          "lab.example. example.",
+			"host-name":              "web01",
+			"dhcp-lease-time":        "86400",
+			"dhcp-message-type":      "5",
+			"dhcp-server-identifier": "192.168.20.1",
+		},
+	}
+	if !reflect.DeepEqual(leases[1], expected) {
+		t.Errorf("expected:\n%+v\ngot:\n%+v", expected, leases[1])
+	}
+
+	// The renewed lease is the latest; once both expired there is none
+	if l := Latest(leases, *date("2026/10/19 10:00:00")); l == nil || l.LeaseTime != 86400 {
+		t.Errorf("expected the second lease, got %+v", l)
+	}
+	if l := Latest(leases[:1], *date("2026/10/19 08:00:00")); l == nil || l.LeaseTime != 3600 {
+		t.Errorf("expected the first lease, got %+v", l)
+	}
+	if l := Latest(leases, *date("2026/10/21 00:00:00")); l != nil {
+		t.Errorf("expected no active lease, got %+v", l)
+	}
+}
+
+func TestParseLeases_NeverExpires(t *testing.T) {
+	leases, err := ParseLeases(readFixture(t, "dhclient.leases.em0"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(leases) != 1 {
+		t.Fatalf("expected 1 lease, got %d", len(leases))
+	}
+	l := leases[0]
+	if l.Prefix != "10.1.0.20/22" || l.Expire != nil || l.DNS != nil {
+		t.Errorf("unexpected lease %+v", l)
+	}
+	if !l.Active(time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Error("expected a lease that never expires to stay active")
+	}
+}
+
+func TestParseLeases_Invalid(t *testing.T) {
+	if _, err := ParseLeases(readFixture(t, "dhclient.leases.broken")); err == nil {
+		t.Error("expected error for an invalid date but got none")
+	}
+	for _, content := range []string{
+		"lease {\n  interface \"em0\";\n",
+		"interface \"em0\";\n",
+		"lease {\nlease {\n",
+		"}\n",
+		"lease {\n  fixed-address 10.0.0.1\n}\n",
+		"lease {\n  option dhcp-lease-time forever;\n}\n",
+	} {
+		if _, err := ParseLeases(content); err == nil {
+			t.Errorf("expected error for %q but got none", content)
+		}
+	}
+	if leases, err := ParseLeases(""); err != nil || len(leases) != 0 {
+		t.Errorf("expected no leases, got %+v, %v", leases, err)
+	}
+}
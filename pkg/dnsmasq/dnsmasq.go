@@ -0,0 +1,249 @@
+// Package dnsmasq renders dnsmasq(8) configuration serving names and
+// addresses to jails. The functions are pure: the caller collects the jails
+// and interfaces and writes the result.
+package dnsmasq
+
+import (
+	"FreeBSD-Command-manager/pkg/ifconfig"
+	pkgjail "FreeBSD-Command-manager/pkg/jail"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// DefaultLeaseTime is the lease time of DHCP ranges without one
+const DefaultLeaseTime = "12h"
+
+// header starts every rendered file
+const header = "# Generated by fcom from the running jails; changes will be overwritten\n"
+
+var (
+	// invalidLabelRegex matches the characters not allowed in a host name label
+	invalidLabelRegex = regexp.MustCompile(`[^a-z0-9-]+`)
+	// domainRegex matches a DNS domain such as jail.lan
+	domainRegex = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)*$`)
+	// leaseTimeRegex matches a dnsmasq lease time such as 45m, 12h or infinite
+	leaseTimeRegex = regexp.MustCompile(`^([0-9]+[smhdw]?|infinite)$`)
+)
+
+// Host is a jail with the addresses and MAC addresses it is served under
+type Host struct {
+	Name string   `json:"name"` // jail name turned into a host name
+	Jail string   `json:"jail"`
+	IPv4 []string `json:"ipv4,omitempty"`
+	IPv6 []string `json:"ipv6,omitempty"`
+	MACs []string `json:"macs,omitempty"` // of the jail's own (vnet) interfaces
+}
+
+// Range is a DHCP address range on the subnet of a host interface
+type Range struct {
+	Interface string `json:"interface"`
+	Start     string `json:"start"`
+	End       string `json:"end"`
+	Netmask   string `json:"netmask"`
+	LeaseTime string `json:"lease_time"`
+}
+
+// DNSConfig is the input of RenderDNS
+type DNSConfig struct {
+	Interfaces []string // listen on these only; all interfaces when empty
+	Domain     string   // answered locally, e.g. jail.lan
+	Hosts      []Host
+}
+
+// DHCPConfig is the input of RenderDHCP
+type DHCPConfig struct {
+	Ranges []Range
+	Hosts  []Host
+}
+
+// JailHosts returns a host for every jail with an address or a MAC address,
+// sorted by name. Addresses come from the jail list (ip4.addr, ip6.addr) and
+// from the interfaces seen inside each jail, keyed by jail name; loopback and
+// link-local addresses are left out. MAC addresses are only taken from the
+// jail's VNET interfaces: interfaces also found among the host interfaces
+// are shared with the host, as in a non-VNET jail. Of jails whose names give
+// the same host name, the first one listed wins.
+func JailHosts(jails []pkgjail.Info, ifaces map[string][]ifconfig.Info, host []ifconfig.Info) []Host {
+	shared := make(map[string]bool)
+	for _, info := range host {
+		shared[info.Name] = true
+	}
+	var hosts []Host
+	seen := make(map[string]bool)
+	for _, j := range jails {
+		name := Hostname(j.Name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		h := Host{Name: name, Jail: j.Name}
+		for _, list := range []string{j.IPv4, j.IPv6} {
+			for _, a := range strings.Split(list, ",") {
+				h.addAddress(a)
+			}
+		}
+		for _, info := range ifaces[j.Name] {
+			if slices.Contains(info.Flags, "LOOPBACK") {
+				continue
+			}
+			for _, a := range info.Addresses {
+				if a.Scope == ifconfig.ScopeGlobal {
+					h.addAddress(a.Address)
+				}
+			}
+			if info.MAC != "" && !shared[info.Name] && !slices.Contains(h.MACs, info.MAC) {
+				h.MACs = append(h.MACs, info.MAC)
+			}
+		}
+		if len(h.IPv4)+len(h.IPv6)+len(h.MACs) > 0 {
+			hosts = append(hosts, h)
+		}
+	}
+	slices.SortStableFunc(hosts, func(a, b Host) int { return strings.Compare(a.Name, b.Name) })
+	return hosts
+}
+
+// addAddress adds a global address once, ignoring anything else
+func (h *Host) addAddress(s string) {
+	addr, err := netip.ParseAddr(strings.TrimSpace(s))
+	if err != nil || addr.IsLoopback() || addr.IsLinkLocalUnicast() || addr.IsUnspecified() {
+		return
+	}
+	addr = addr.WithZone("")
+	list := &h.IPv6
+	if addr.Is4() || addr.Is4In6() {
+		addr, list = addr.Unmap(), &h.IPv4
+	}
+	if !slices.Contains(*list, addr.String()) {
+		*list = append(*list, addr.String())
+	}
+}
+
+// Hostname turns a jail name into a host name label: lower case, with runs
+// of other characters than letters, digits and hyphens replaced by a hyphen.
+// It returns "" for names without any valid character.
+func Hostname(name string) string {
+	label := strings.Trim(invalidLabelRegex.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if len(label) > 63 {
+		label = strings.TrimRight(label[:63], "-")
+	}
+	return label
+}
+
+// ValidateDomain checks a local domain, which may be empty
+func ValidateDomain(domain string) error {
+	if domain != "" && (len(domain) > 253 || !domainRegex.MatchString(domain)) {
+		return fmt.Errorf("invalid domain %q", domain)
+	}
+	return nil
+}
+
+// DefaultRange returns a range of the upper half of the subnet of an
+// interface address, leaving the lower half for static addresses:
+// 10.0.10.1/24 gives 10.0.10.128-10.0.10.254.
+func DefaultRange(iface string, prefix netip.Prefix) (Range, error) {
+	if !prefix.Addr().Is4() || prefix.Bits() > 29 {
+		return Range{}, fmt.Errorf("%s on %s is not an IPv4 subnet with room for a DHCP range", prefix, iface)
+	}
+	network := prefix.Masked().Addr().As4()
+	base := uint32(network[0])<<24 | uint32(network[1])<<16 | uint32(network[2])<<8 | uint32(network[3])
+	size := uint32(1) << (32 - prefix.Bits())
+	return Range{
+		Interface: iface,
+		Start:     uint32Addr(base + size/2).String(),
+		End:       uint32Addr(base + size - 2).String(),
+		Netmask:   netmask(prefix.Bits()),
+		LeaseTime: DefaultLeaseTime,
+	}, nil
+}
+
+// NewRange returns the range start-end on the subnet of an interface address
+func NewRange(iface string, prefix netip.Prefix, start, end string) (Range, error) {
+	if !prefix.Addr().Is4() {
+		return Range{}, fmt.Errorf("%s on %s is not an IPv4 subnet", prefix, iface)
+	}
+	s, err := netip.ParseAddr(start)
+	if err != nil {
+		return Range{}, fmt.Errorf("invalid range start %q", start)
+	}
+	e, err := netip.ParseAddr(end)
+	if err != nil {
+		return Range{}, fmt.Errorf("invalid range end %q", end)
+	}
+	if !prefix.Masked().Contains(s) || !prefix.Masked().Contains(e) || e.Less(s) {
+		return Range{}, fmt.Errorf("range %s-%s is not within %s on %s", start, end, prefix.Masked(), iface)
+	}
+	return Range{Interface: iface, Start: s.String(), End: e.String(), Netmask: netmask(prefix.Bits()), LeaseTime: DefaultLeaseTime}, nil
+}
+
+// Validate checks a range before it is rendered
+func (r Range) Validate() error {
+	if r.Interface == "" {
+		return fmt.Errorf("range %s-%s has no interface", r.Start, r.End)
+	}
+	if !leaseTimeRegex.MatchString(r.LeaseTime) {
+		return fmt.Errorf("invalid lease time %q: use seconds, or a number with s, m, h, d or w, or infinite", r.LeaseTime)
+	}
+	return nil
+}
+
+// RenderDNS renders the host records of the jails, under the domain when
+// one is given:
+//
+//	domain=jail.lan
+//	local=/jail.lan/
+//	host-record=web,web.jail.lan,10.0.10.5
+func RenderDNS(c DNSConfig) string {
+	var b strings.Builder
+	b.WriteString(header)
+	for _, iface := range c.Interfaces {
+		fmt.Fprintf(&b, "interface=%s\n", iface)
+	}
+	if c.Domain != "" {
+		fmt.Fprintf(&b, "domain=%s\nlocal=/%s/\n", c.Domain, c.Domain)
+	}
+	for _, h := range c.Hosts {
+		names := h.Name
+		if c.Domain != "" {
+			names += "," + h.Name + "." + c.Domain
+		}
+		for _, addr := range slices.Concat(h.IPv4, h.IPv6) {
+			fmt.Fprintf(&b, "host-record=%s,%s\n", names, addr)
+		}
+	}
+	return b.String()
+}
+
+// RenderDHCP renders the DHCP ranges, and binds the MAC addresses of the
+// jails to their names so leases are registered under the jail name:
+//
+//	dhcp-range=set:bridge0,10.0.10.128,10.0.10.254,255.255.255.0,12h
+//	dhcp-host=02:a8:3b:5e:0f:0b,web
+//
+// dnsmasq serves a range on the interface holding its subnet. No
+// "interface=" lines are written: they would also restrict the DNS
+// configuration read from the same directory.
+func RenderDHCP(c DHCPConfig) string {
+	var b strings.Builder
+	b.WriteString(header)
+	for _, r := range c.Ranges {
+		fmt.Fprintf(&b, "dhcp-range=set:%s,%s,%s,%s,%s\n", r.Interface, r.Start, r.End, r.Netmask, r.LeaseTime)
+	}
+	for _, h := range c.Hosts {
+		for _, mac := range h.MACs {
+			fmt.Fprintf(&b, "dhcp-host=%s,%s\n", mac, h.Name)
+		}
+	}
+	return b.String()
+}
+
+func uint32Addr(n uint32) netip.Addr {
+	return netip.AddrFrom4([4]byte{byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)})
+}
+
+func netmask(bits int) string {
+	return uint32Addr(^uint32(0) << (32 - bits)).String()
+}
@@ -0,0 +1,156 @@
+package dnsmasq
+
+import (
+	"FreeBSD-Command-manager/pkg/ifconfig"
+	pkgjail "FreeBSD-Command-manager/pkg/jail"
+	"flag"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// checkGolden compares got with testdata/<name>, rewriting it with -update
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Errorf("%s mismatch, got:\n%s\nwant:\n%s", name, got, want)
+	}
+}
+
+func testHosts() []Host {
+	jails := []pkgjail.Info{
+		{Name: "web", Status: "running", IPv4: "-", IPv6: "-"},
+		{Name: "DB_1", Status: "running", IPv4: "10.0.20.5,127.0.1.1", IPv6: "2001:db8:20::5"},
+		{Name: "mail", Status: "running", IPv4: "-", IPv6: "-"},
+		{Name: "db-1", Status: "running", IPv4: "10.0.20.6", IPv6: "-"},
+		{Name: "---", Status: "running", IPv4: "10.0.20.7", IPv6: "-"},
+	}
+	ifaces := map[string][]ifconfig.Info{
+		"web": {
+			{Name: "lo0", Flags: []string{"UP", "LOOPBACK"}, Addresses: []ifconfig.Address{
+				{Family: ifconfig.FamilyInet, Address: "127.0.0.1", Scope: ifconfig.ScopeHost},
+			}},
+			{Name: "epair0b", MAC: "02:a8:3b:5e:0f:0b", Addresses: []ifconfig.Address{
+				{Family: ifconfig.FamilyInet, Address: "10.0.10.5", Scope: ifconfig.ScopeGlobal},
+				{Family: ifconfig.FamilyInet6, Address: "fe80::a8:3bff:fe5e:f0b", Zone: "epair0b", Scope: ifconfig.ScopeLink},
+				{Family: ifconfig.FamilyInet6, Address: "2001:db8:10::5", Scope: ifconfig.ScopeGlobal},
+			}},
+		},
+		// vnet jail waiting for a lease
+		"mail": {{Name: "epair1b", MAC: "02:a8:3b:5e:0f:1b"}},
+		// non-VNET jail: the host's em0 restricted to the jail address
+		"DB_1": {{Name: "em0", MAC: "58:9c:fc:10:ff:d6", Addresses: []ifconfig.Address{
+			{Family: ifconfig.FamilyInet, Address: "10.0.20.5", Scope: ifconfig.ScopeGlobal},
+		}}},
+	}
+	host := []ifconfig.Info{{Name: "em0", MAC: "58:9c:fc:10:ff:d6"}, {Name: "bridge0", MAC: "58:9c:fc:10:ff:01"}}
+	return JailHosts(jails, ifaces, host)
+}
+
+func TestJailHosts(t *testing.T) {
+	expected := []Host{
+		{Name: "db-1", Jail: "DB_1", IPv4: []string{"10.0.20.5"}, IPv6: []string{"2001:db8:20::5"}},
+		{Name: "mail", Jail: "mail", MACs: []string{"02:a8:3b:5e:0f:1b"}},
+		{Name: "web", Jail: "web", IPv4: []string{"10.0.10.5"}, IPv6: []string{"2001:db8:10::5"}, MACs: []string{"02:a8:3b:5e:0f:0b"}},
+	}
+	if hosts := testHosts(); !reflect.DeepEqual(hosts, expected) {
+		t.Errorf("expected %+v, got %+v", expected, hosts)
+	}
+}
+
+func TestRenderDNS(t *testing.T) {
+	checkGolden(t, "dns.conf", RenderDNS(DNSConfig{Interfaces: []string{"bridge0", "bridge1"}, Domain: "jail.lan", Hosts: testHosts()}))
+	checkGolden(t, "dns-nodomain.conf", RenderDNS(DNSConfig{Hosts: testHosts()}))
+}
+
+func TestRenderDHCP(t *testing.T) {
+	r, err := DefaultRange("bridge0", netip.MustParsePrefix("10.0.10.1/24"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r2, err := NewRange("bridge1", netip.MustParsePrefix("10.0.20.1/25"), "10.0.20.100", "10.0.20.120")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r2.LeaseTime = "1d"
+	checkGolden(t, "dhcp.conf", RenderDHCP(DHCPConfig{Ranges: []Range{r, r2}, Hosts: testHosts()}))
+
+	// dnsmasq reads both files from its conf-dir: DNS still answers on all
+	// interfaces when the DNS file names none
+	dhcp := RenderDHCP(DHCPConfig{Ranges: []Range{r}, Hosts: testHosts()})
+	checkGolden(t, "dns-dhcp.conf", RenderDNS(DNSConfig{Domain: "jail.lan", Hosts: testHosts()})+dhcp)
+}
+
+func TestDefaultRange(t *testing.T) {
+	tests := []struct {
+		prefix      string
+		start, end  string
+		netmask     string
+		shouldError bool
+	}{
+		{prefix: "10.0.10.1/24", start: "10.0.10.128", end: "10.0.10.254", netmask: "255.255.255.0"},
+		{prefix: "172.16.5.1/22", start: "172.16.6.0", end: "172.16.7.254", netmask: "255.255.252.0"},
+		{prefix: "192.168.1.9/29", start: "192.168.1.12", end: "192.168.1.14", netmask: "255.255.255.248"},
+		{prefix: "192.168.1.1/30", shouldError: true},
+		{prefix: "2001:db8::1/64", shouldError: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.prefix, func(t *testing.T) {
+			r, err := DefaultRange("bridge0", netip.MustParsePrefix(tc.prefix))
+			if tc.shouldError {
+				if err == nil {
+					t.Error("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if r.Start != tc.start || r.End != tc.end || r.Netmask != tc.netmask {
+				t.Errorf("expected %s-%s/%s, got %+v", tc.start, tc.end, tc.netmask, r)
+			}
+		})
+	}
+}
+
+func TestNewRange(t *testing.T) {
+	prefix := netip.MustParsePrefix("10.0.10.1/24")
+	for _, bounds := range [][2]string{{"10.0.11.5", "10.0.11.9"}, {"10.0.10.9", "10.0.10.5"}, {"10.0.10.5", "x"}} {
+		if _, err := NewRange("bridge0", prefix, bounds[0], bounds[1]); err == nil {
+			t.Errorf("expected error for %v but got none", bounds)
+		}
+	}
+	if err := (Range{Interface: "bridge0", LeaseTime: "12 hours"}).Validate(); err == nil {
+		t.Error("expected error for invalid lease time")
+	}
+}
+
+func TestHostname(t *testing.T) {
+	for name, expected := range map[string]string{"web": "web", "DB_1": "db-1", "a..b": "a-b", "-x-": "x", "___": ""} {
+		if got := Hostname(name); got != expected {
+			t.Errorf("Hostname(%q) = %q, expected %q", name, got, expected)
+		}
+	}
+	if err := ValidateDomain("jail.lan"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	for _, domain := range []string{"jail..lan", "-jail.lan", "jail lan", "Jail.LAN"} {
+		if err := ValidateDomain(domain); err == nil {
+			t.Errorf("expected error for %q but got none", domain)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package ifconfig
+
+import (
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// Address families as printed by ifconfig.
+const (
+	FamilyInet  = "inet"
+	FamilyInet6 = "inet6"
+)
+
+// Address scopes.
+const (
+	ScopeHost   = "host"
+	ScopeLink   = "link"
+	ScopeGlobal = "global"
+)
+
+// Address represents an IPv4 or IPv6 address assigned to an interface
+type Address struct {
+	Family      string // inet or inet6
+	Address     string
+	Prefix      string // address/prefix length, e.g. 192.0.2.1/24
+	PrefixLen   int
+	Broadcast   string
+	Destination string   // peer address of a point-to-point link
+	Scope       string   // host, link or global
+	Zone        string   // IPv6 scope zone, e.g. em0 for fe80::1%em0
+	Flags       []string // e.g. anycast, tentative, deprecated, autoconf
+	PLTime      string   // preferred lifetime in seconds or "infty"
+	VLTime      string   // valid lifetime in seconds or "infty"
+	VHID        int
+}
+
+// addressFlags are the bare keywords ifconfig prints after an inet6 address.
+var addressFlags = map[string]bool{
+	"anycast":       true,
+	"tentative":     true,
+	"duplicated":    true,
+	"detached":      true,
+	"deprecated":    true,
+	"autoconf":      true,
+	"temporary":     true,
+	"prefer_source": true,
+}
+
+// parseAddress extracts the structured form of "inet" and "inet6" lines, e.g.
+// "inet6 2001:db8::1 prefixlen 64 autoconf pltime 3600 vltime 7200".
+func parseAddress(line string, currentInfo *Info) {
+	fields := strings.Fields(line)
+	if len(fields) < MinFieldsForIP || (fields[0] != FamilyInet && fields[0] != FamilyInet6) {
+		return
+	}
+	addrStr, zone, _ := strings.Cut(fields[IPAddressIndex], "%")
+	ip, err := netip.ParseAddr(addrStr)
+	if err != nil || ip.Is4() != (fields[0] == FamilyInet) {
+		return
+	}
+
+	a := Address{Family: fields[0], Address: ip.String(), Zone: zone}
+	for i := 2; i < len(fields); i++ {
+		key := fields[i]
+		if addressFlags[key] {
+			a.Flags = append(a.Flags, key)
+			continue
+		}
+		if i+1 >= len(fields) {
+			break
+		}
+		value := fields[i+1]
+		switch key {
+		case "netmask":
+			a.PrefixLen = hexNetmaskToCIDR(value)
+		case "prefixlen":
+			a.PrefixLen, _ = strconv.Atoi(value)
+		case "broadcast":
+			a.Broadcast = value
+		case "-->":
+			a.Destination = value
+		case "pltime":
+			a.PLTime = value
+		case "vltime":
+			a.VLTime = value
+		case "vhid":
+			a.VHID, _ = strconv.Atoi(value)
+		default:
+			continue
+		}
+		i++
+	}
+	if a.PrefixLen == 0 {
+		a.PrefixLen = ip.BitLen()
+	}
+	if p, err := ip.Prefix(a.PrefixLen); err == nil {
+		a.Prefix = ip.String() + "/" + strconv.Itoa(p.Bits())
+	}
+	a.Scope = addressScope(ip)
+	currentInfo.Addresses = append(currentInfo.Addresses, a)
+}
+
+func addressScope(ip netip.Addr) string {
+	switch {
+	case ip.IsLoopback():
+		return ScopeHost
+	case ip.IsLinkLocalUnicast():
+		return ScopeLink
+	}
+	return ScopeGlobal
+}
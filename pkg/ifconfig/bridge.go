@@ -0,0 +1,182 @@
+package ifconfig
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+)
+
+// BridgeInfo holds the STP state, members and address cache of a bridge
+type BridgeInfo struct {
+	ID           string
+	Priority     int
+	HelloTime    int
+	FwdDelay     int
+	MaxAge       int
+	HoldCount    int
+	Proto        string
+	MaxAddr      int
+	Timeout      int
+	RootID       string
+	RootPriority int
+	RootCost     int
+	RootPort     int
+	Members      []BridgeMember
+	Addresses    []BridgeAddress
+}
+
+// BridgeMember represents a bridge member (or span port) and its STP state
+type BridgeMember struct {
+	Name      string
+	Flags     []string // e.g. LEARNING, DISCOVER, STP, SPAN, STICKY, PRIVATE
+	IfMaxAddr int
+	Port      int
+	Priority  int
+	PathCost  int
+	Proto     string
+	Role      string
+	State     string
+}
+
+// BridgeAddress represents an entry of the bridge address cache ('ifconfig bridge0 addr')
+type BridgeAddress struct {
+	MAC       string
+	VLAN      int
+	Interface string
+	Expire    int
+	Flags     []string
+}
+
+// parseBridge extracts bridge parameters and member state lines.
+func parseBridge(line string, currentInfo *Info) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return
+	}
+
+	switch {
+	case fields[0] == "id" || fields[0] == "maxage":
+		b := ensureBridge(currentInfo)
+		for k, v := range keyValues(fields) {
+			setBridgeValue(b, k, v)
+		}
+	case fields[0] == "root" && len(fields) > 1 && fields[1] == "id":
+		b := ensureBridge(currentInfo)
+		kv := keyValues(fields[1:])
+		b.RootID = kv["id"]
+		b.RootPriority = atoi(kv["priority"])
+		b.RootCost = atoi(kv["ifcost"])
+		b.RootPort = atoi(kv["port"])
+	case fields[0] == "member:" && len(fields) > 1:
+		b := ensureBridge(currentInfo)
+		m := BridgeMember{Name: fields[1]}
+		if idx := strings.Index(line, "flags="); idx != -1 {
+			m.Flags = bracketList(line[idx:])
+		}
+		b.Members = append(b.Members, m)
+	case currentInfo.Bridge != nil && len(currentInfo.Bridge.Members) > 0 &&
+		(fields[0] == "ifmaxaddr" || fields[0] == "port" || fields[0] == "role"):
+		m := &currentInfo.Bridge.Members[len(currentInfo.Bridge.Members)-1]
+		for k, v := range keyValues(fields) {
+			setMemberValue(m, k, v)
+		}
+	}
+}
+
+func ensureBridge(currentInfo *Info) *BridgeInfo {
+	if currentInfo.Bridge == nil {
+		currentInfo.Bridge = &BridgeInfo{}
+	}
+	return currentInfo.Bridge
+}
+
+func setBridgeValue(b *BridgeInfo, key, value string) {
+	switch key {
+	case "id":
+		b.ID = value
+	case "priority":
+		b.Priority = atoi(value)
+	case "hellotime":
+		b.HelloTime = atoi(value)
+	case "fwddelay":
+		b.FwdDelay = atoi(value)
+	case "maxage":
+		b.MaxAge = atoi(value)
+	case "holdcnt":
+		b.HoldCount = atoi(value)
+	case "proto":
+		b.Proto = value
+	case "maxaddr":
+		b.MaxAddr = atoi(value)
+	case "timeout":
+		b.Timeout = atoi(value)
+	}
+}
+
+func setMemberValue(m *BridgeMember, key, value string) {
+	switch key {
+	case "ifmaxaddr":
+		m.IfMaxAddr = atoi(value)
+	case "port":
+		m.Port = atoi(value)
+	case "priority":
+		m.Priority = atoi(value)
+	case "path cost":
+		m.PathCost = atoi(value)
+	case "proto":
+		m.Proto = value
+	case "role":
+		m.Role = value
+	case "state":
+		m.State = value
+	}
+}
+
+// keyValues turns "key value key value" fields into a map, joining "path cost".
+func keyValues(fields []string) map[string]string {
+	kv := make(map[string]string)
+	for i := 0; i+1 < len(fields); i += 2 {
+		key := fields[i]
+		if key == "path" && i+2 < len(fields) && fields[i+1] == "cost" {
+			key = "path cost"
+			i++
+		}
+		kv[key] = fields[i+1]
+	}
+	return kv
+}
+
+func atoi(s string) int {
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+// ParseBridgeAddr parses the output of 'ifconfig <bridge> addr'.
+func ParseBridgeAddr(output string) []BridgeAddress {
+	var result []BridgeAddress
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		// mac [VlanN] iface expire flags=...
+		if len(fields) < 4 {
+			continue
+		}
+		entry := BridgeAddress{MAC: fields[0]}
+		rest := fields[1:]
+		if strings.HasPrefix(rest[0], "Vlan") {
+			entry.VLAN = atoi(strings.TrimPrefix(rest[0], "Vlan"))
+			rest = rest[1:]
+		}
+		if len(rest) < 3 || !strings.HasPrefix(rest[2], "flags=") {
+			continue
+		}
+		entry.Interface = rest[0]
+		entry.Expire = atoi(rest[1])
+		entry.Flags = bracketList(rest[2])
+		result = append(result, entry)
+	}
+	return result
+}
@@ -24,6 +24,8 @@ const (
 	GIF        string = "gif"
 	GRE        string = "gre"
 	Tap        string = "tap"
+	Epair      string = "epair"
+	WireGuard  string = "wg"
 	Stf        string = "stf"
 	Enc        string = "enc"
 	Unknown    string = "unknown"
@@ -44,12 +46,48 @@ const (
 
 // Info represents information about a network interface
 type Info struct {
-	Name   string
-	Type   string
-	Status string
-	IPv4   []string
-	IPv6   []string
-	MAC    string
+	Name         string
+	Type         string
+	Status       string
+	IPv4         []string
+	IPv6         []string
+	MAC          string
+	Flags        []string
+	MTU          int
+	Description  string
+	FIB          int
+	Groups       []string
+	Capabilities []string
+	LaggProto    string
+	LaggHash     []string
+	LaggPorts    []LaggPort
+	TunnelLocal  string
+	TunnelRemote string
+	GREKey       uint32
+	Bridge       *BridgeInfo
+	CARP         []CARPInfo
+	VLANID       int
+	VLANParent   string
+	VXLANID      int
+	VXLANLocal   string
+	VXLANRemote  string
+	VXLANGroup   string
+	Addresses    []Address
+}
+
+// CARPInfo represents the state of a CARP vhid on an interface
+type CARPInfo struct {
+	State   string // MASTER, BACKUP or INIT
+	VHID    int
+	AdvBase int
+	AdvSkew int
+}
+
+// LaggPort represents a member port of a lagg interface and its state flags
+// (e.g. ACTIVE, COLLECTING, DISTRIBUTING).
+type LaggPort struct {
+	Name  string
+	Flags []string
 }
 
 // ParseIfconfig parses FreeBSD ifconfig output into a slice of Info structs.
@@ -73,6 +111,7 @@ func ParseIfconfig(output string) []Info {
 			}
 			currentInfo = &Info{Name: extractInterfaceName(line)}
 			flags, media, groups = extractFlagsMediaGroups(line)
+			parseHeader(line, currentInfo)
 			continue
 		}
 
@@ -82,8 +121,16 @@ func ParseIfconfig(output string) []Info {
 
 		flags, media, groups = updateFlagsMediaGroups(line, flags, media, groups)
 		parseMAC(line, currentInfo)
+		parseAttributes(line, currentInfo)
+		parseLagg(line, currentInfo)
+		parseTunnel(line, currentInfo)
+		parseBridge(line, currentInfo)
+		parseCARP(line, currentInfo)
+		parseVLAN(line, currentInfo)
+		parseVXLAN(line, currentInfo)
 		parseIPv4(line, currentInfo)
 		parseIPv6(line, currentInfo)
+		parseAddress(line, currentInfo)
 	}
 
 	if currentInfo != nil {
@@ -95,12 +142,16 @@ func ParseIfconfig(output string) []Info {
 	return result
 }
 
+// interfaceLineRegex matches the first line of an interface block. Renamed
+// interfaces may contain upper case letters, dots, dashes and underscores.
+var interfaceLineRegex = regexp.MustCompile(`^([A-Za-z0-9_.-]+):\s+flags=`)
+
 func isNewInterfaceLine(line string) bool {
-	return regexp.MustCompile(`^([a-z0-9]+):\s+flags=`).MatchString(line)
+	return interfaceLineRegex.MatchString(line)
 }
 
 func extractInterfaceName(line string) string {
-	matches := regexp.MustCompile(`^([a-z0-9]+):\s+flags=`).FindStringSubmatch(line)
+	matches := interfaceLineRegex.FindStringSubmatch(line)
 	if matches != nil {
 		return matches[1]
 	}
@@ -127,6 +178,146 @@ func updateFlagsMediaGroups(line, flags, media, groups string) (newFlags, newMed
 	return flags, media, groups
 }
 
+// parseHeader extracts the flag list and MTU from the first line of an interface block.
+func parseHeader(line string, currentInfo *Info) {
+	currentInfo.Flags = bracketList(line[strings.Index(line, "flags="):])
+	fields := strings.Fields(line)
+	for i := 0; i+1 < len(fields); i++ {
+		if fields[i] == "mtu" {
+			if mtu, err := strconv.Atoi(fields[i+1]); err == nil {
+				currentInfo.MTU = mtu
+			}
+		}
+	}
+}
+
+// parseAttributes extracts description, FIB, groups and enabled capabilities.
+func parseAttributes(line string, currentInfo *Info) {
+	switch {
+	case strings.HasPrefix(line, "description: "):
+		currentInfo.Description = strings.TrimPrefix(line, "description: ")
+	case strings.HasPrefix(line, "fib: "):
+		if fib, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "fib: "))); err == nil {
+			currentInfo.FIB = fib
+		}
+	case strings.HasPrefix(line, "groups: "):
+		currentInfo.Groups = strings.Fields(strings.TrimPrefix(line, "groups: "))
+	case strings.HasPrefix(line, "options="):
+		currentInfo.Capabilities = bracketList(line)
+	}
+}
+
+// parseLagg extracts the lagg protocol, hash layers and member port states.
+func parseLagg(line string, currentInfo *Info) {
+	fields := strings.Fields(line)
+	switch {
+	case strings.HasPrefix(line, "laggproto "):
+		if len(fields) >= 2 {
+			currentInfo.LaggProto = fields[1]
+		}
+		for i := 2; i+1 < len(fields); i++ {
+			if fields[i] == "lagghash" {
+				currentInfo.LaggHash = strings.Split(fields[i+1], ",")
+			}
+		}
+	case strings.HasPrefix(line, "laggport: "):
+		if len(fields) >= 2 {
+			port := LaggPort{Name: fields[1]}
+			if idx := strings.Index(line, "flags="); idx != -1 {
+				port.Flags = bracketList(line[idx:])
+			}
+			currentInfo.LaggPorts = append(currentInfo.LaggPorts, port)
+		}
+	}
+}
+
+// parseTunnel extracts outer tunnel endpoints ("tunnel inet A --> B") and the GRE key.
+func parseTunnel(line string, currentInfo *Info) {
+	fields := strings.Fields(line)
+	switch {
+	case strings.HasPrefix(line, "tunnel ") && len(fields) >= 5 && fields[3] == "-->":
+		currentInfo.TunnelLocal = fields[2]
+		currentInfo.TunnelRemote = fields[4]
+	case strings.HasPrefix(line, "grekey: ") && len(fields) >= 2:
+		if key, err := strconv.ParseUint(fields[1], 0, 32); err == nil {
+			currentInfo.GREKey = uint32(key)
+		}
+	}
+}
+
+// parseCARP extracts "carp: MASTER vhid 1 advbase 1 advskew 0" status lines.
+func parseCARP(line string, currentInfo *Info) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 || fields[0] != "carp:" {
+		return
+	}
+	c := CARPInfo{State: fields[1]}
+	for i := 2; i+1 < len(fields); i += 2 {
+		v, err := strconv.Atoi(fields[i+1])
+		if err != nil {
+			continue
+		}
+		switch fields[i] {
+		case "vhid":
+			c.VHID = v
+		case "advbase":
+			c.AdvBase = v
+		case "advskew":
+			c.AdvSkew = v
+		}
+	}
+	currentInfo.CARP = append(currentInfo.CARP, c)
+}
+
+// parseVLAN extracts "vlan: 10 vlanproto: 802.1q vlanpcp: 0 parent interface: em0".
+func parseVLAN(line string, currentInfo *Info) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 || fields[0] != "vlan:" {
+		return
+	}
+	if id, err := strconv.Atoi(fields[1]); err == nil {
+		currentInfo.VLANID = id
+	}
+	if idx := strings.Index(line, "parent interface: "); idx != -1 {
+		currentInfo.VLANParent = strings.TrimSpace(line[idx+len("parent interface: "):])
+	}
+}
+
+// parseVXLAN extracts "vxlan vni 108 local 192.0.2.1:4789 remote 192.0.2.2:4789".
+func parseVXLAN(line string, currentInfo *Info) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 || fields[0] != "vxlan" || fields[1] != "vni" {
+		return
+	}
+	if id, err := strconv.Atoi(fields[2]); err == nil {
+		currentInfo.VXLANID = id
+	}
+	for i := 3; i+1 < len(fields); i += 2 {
+		addr := fields[i+1]
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			addr = host
+		}
+		switch fields[i] {
+		case "local":
+			currentInfo.VXLANLocal = addr
+		case "remote":
+			currentInfo.VXLANRemote = addr
+		case "group":
+			currentInfo.VXLANGroup = addr
+		}
+	}
+}
+
+// bracketList returns the comma separated items of the first <...> group in s.
+func bracketList(s string) []string {
+	start := strings.Index(s, "<")
+	end := strings.Index(s, ">")
+	if start == -1 || end <= start+1 {
+		return nil
+	}
+	return strings.Split(s[start+1:end], ",")
+}
+
 func parseMAC(line string, currentInfo *Info) {
 	if strings.Contains(line, "ether ") {
 		parts := strings.Fields(line)
@@ -186,21 +377,36 @@ func determineInterfaceType(flags, media, groups string) string {
 	if isLoopback(flags) {
 		return Loopback
 	}
-	if isBridge(media) {
+	if hasGroup(groups, GIF) {
+		return GIF
+	}
+	if hasGroup(groups, GRE) {
+		return GRE
+	}
+	if isBridge(media) || hasGroup(groups, Bridge) {
 		return Bridge
 	}
 	if isVLAN(media, groups) {
 		return VLAN
 	}
-	if isVXLAN(media) {
+	if isVXLAN(media, groups) {
 		return VXLAN
 	}
-	if isLAGG(media) {
+	if isLAGG(media, groups) {
 		return LAGG
 	}
 	if isPPP(flags) {
 		return PPP
 	}
+	if hasGroup(groups, Epair) {
+		return Epair
+	}
+	if hasGroup(groups, Tap) {
+		return Tap
+	}
+	if hasGroup(groups, WireGuard) {
+		return WireGuard
+	}
 	if isWireless(media) {
 		return Wireless
 	}
@@ -240,12 +446,21 @@ func isVLAN(media, groups string) bool {
 	return strings.Contains(media, "vlan:") || strings.Contains(groups, "vlan")
 }
 
-func isVXLAN(media string) bool {
-	return strings.Contains(media, "vxlan:")
+func isVXLAN(media, groups string) bool {
+	return strings.Contains(media, "vxlan:") || hasGroup(groups, VXLAN)
 }
 
-func isLAGG(media string) bool {
-	return strings.Contains(media, "laggproto:")
+func isLAGG(media, groups string) bool {
+	return strings.Contains(media, "laggproto:") || hasGroup(groups, "lagg")
+}
+
+func hasGroup(groups, group string) bool {
+	for _, g := range strings.Fields(strings.TrimPrefix(groups, "groups:")) {
+		if g == group {
+			return true
+		}
+	}
+	return false
 }
 
 func isPPP(flags string) bool {
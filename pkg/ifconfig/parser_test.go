@@ -1,6 +1,7 @@
 package ifconfig
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -145,3 +146,326 @@ func findInterface(interfaces []Info, name string) *Info {
 	}
 	return nil
 }
+
+func TestParseIfconfig_Attributes(t *testing.T) {
+	input := `em1: flags=1008843<UP,BROADCAST,RUNNING,SIMPLEX,MULTICAST,LOWER_UP> metric 0 mtu 9000
+	description: uplink
+	options=48505bb<RXCSUM,TXCSUM,VLAN_MTU,TSO4,LRO>
+	ether 08:00:27:20:af:32
+	fib: 2
+	media: Ethernet autoselect (1000baseT <full-duplex>)
+	status: active
+	groups: egress jails
+	nd6 options=23<PERFORMNUD,ACCEPT_RTADV,AUTO_LINKLOCAL>`
+
+	interfaces := ParseIfconfig(input)
+	if len(interfaces) != 1 {
+		t.Fatalf("expected 1 interface, got %d", len(interfaces))
+	}
+	em1 := interfaces[0]
+	if em1.MTU != 9000 {
+		t.Errorf("expected MTU 9000, got %d", em1.MTU)
+	}
+	if em1.Description != "uplink" {
+		t.Errorf("expected description uplink, got %q", em1.Description)
+	}
+	if em1.FIB != 2 {
+		t.Errorf("expected FIB 2, got %d", em1.FIB)
+	}
+	if len(em1.Groups) != 2 || em1.Groups[1] != "jails" {
+		t.Errorf("expected groups [egress jails], got %v", em1.Groups)
+	}
+	if len(em1.Flags) != 6 || em1.Flags[0] != "UP" {
+		t.Errorf("unexpected flags: %v", em1.Flags)
+	}
+	if len(em1.Capabilities) != 5 || em1.Capabilities[4] != "LRO" {
+		t.Errorf("unexpected capabilities: %v", em1.Capabilities)
+	}
+}
+
+func TestParseIfconfig_Lagg(t *testing.T) {
+	input := `lagg0: flags=1008843<UP,BROADCAST,RUNNING,SIMPLEX,MULTICAST,LOWER_UP> metric 0 mtu 1500
+	options=48505bb<RXCSUM,TXCSUM,VLAN_MTU,VLAN_HWTAGGING>
+	ether 08:00:27:20:af:31
+	laggproto lacp lagghash l2,l3,l4
+	laggport: em0 flags=1c<ACTIVE,COLLECTING,DISTRIBUTING>
+	laggport: em1 flags=0<>
+	groups: lagg
+	media: Ethernet autoselect
+	status: active`
+
+	interfaces := ParseIfconfig(input)
+	if len(interfaces) != 1 {
+		t.Fatalf("expected 1 interface, got %d", len(interfaces))
+	}
+	lagg0 := interfaces[0]
+	if lagg0.Type != LAGG {
+		t.Errorf("expected type %s, got %s", LAGG, lagg0.Type)
+	}
+	if lagg0.LaggProto != "lacp" {
+		t.Errorf("expected laggproto lacp, got %q", lagg0.LaggProto)
+	}
+	if len(lagg0.LaggHash) != 3 || lagg0.LaggHash[2] != "l4" {
+		t.Errorf("unexpected lagghash: %v", lagg0.LaggHash)
+	}
+	if len(lagg0.LaggPorts) != 2 {
+		t.Fatalf("expected 2 lagg ports, got %d", len(lagg0.LaggPorts))
+	}
+	em0 := lagg0.LaggPorts[0]
+	if em0.Name != "em0" || len(em0.Flags) != 3 || em0.Flags[0] != "ACTIVE" || em0.Flags[2] != "DISTRIBUTING" {
+		t.Errorf("unexpected em0 port: %+v", em0)
+	}
+	if em1 := lagg0.LaggPorts[1]; em1.Name != "em1" || len(em1.Flags) != 0 {
+		t.Errorf("unexpected em1 port: %+v", em1)
+	}
+}
+
+func TestParseIfconfig_Clones(t *testing.T) {
+	input := `epair0a: flags=1008843<UP,BROADCAST,RUNNING,SIMPLEX,MULTICAST,LOWER_UP> metric 0 mtu 1500
+	options=8<VLAN_MTU>
+	ether 02:5d:a4:5e:11:0a
+	groups: epair
+	media: Ethernet 10Gbase-T (10Gbase-T <full-duplex>)
+	status: active
+tap0: flags=1008802<BROADCAST,SIMPLEX,MULTICAST,LOWER_UP> metric 0 mtu 1500
+	options=80000<LINKSTATE>
+	ether 58:9c:fc:10:ff:ad
+	groups: tap
+	media: Ethernet autoselect
+	status: no carrier
+wg0: flags=10080c1<UP,RUNNING,NOARP,MULTICAST,LOWER_UP> metric 0 mtu 1420
+	options=80000<LINKSTATE>
+	inet 10.10.0.1 netmask 0xffffff00
+	groups: wg
+	nd6 options=109<PERFORMNUD,IFDISABLED,NO_DAD>`
+
+	interfaces := ParseIfconfig(input)
+	if epair := findInterface(interfaces, "epair0a"); epair == nil || epair.Type != Epair {
+		t.Errorf("expected epair0a of type %s, got %+v", Epair, epair)
+	}
+	if tap := findInterface(interfaces, "tap0"); tap == nil || tap.Type != Tap {
+		t.Errorf("expected tap0 of type %s, got %+v", Tap, tap)
+	}
+	if wg := findInterface(interfaces, "wg0"); wg == nil || wg.Type != WireGuard {
+		t.Errorf("expected wg0 of type %s, got %+v", WireGuard, wg)
+	}
+}
+
+func TestParseIfconfig_Tunnels(t *testing.T) {
+	input := `gif0: flags=1008051<UP,POINTOPOINT,RUNNING,MULTICAST,LOWER_UP> metric 0 mtu 1280
+	options=80000<LINKSTATE>
+	tunnel inet 192.0.2.1 --> 198.51.100.1
+	inet6 2001:db8:1::2 --> 2001:db8:1::1 prefixlen 128
+	groups: gif
+	nd6 options=21<PERFORMNUD,AUTO_LINKLOCAL>
+gre0: flags=1008051<UP,POINTOPOINT,RUNNING,MULTICAST,LOWER_UP> metric 0 mtu 1476
+	options=80000<LINKSTATE>
+	tunnel inet6 2001:db8::1 --> 2001:db8::2
+	inet 10.0.0.1 --> 10.0.0.2 netmask 0xffffffff
+	groups: gre
+	grekey: 0x2a (42)
+	nd6 options=29<PERFORMNUD,IFDISABLED,AUTO_LINKLOCAL>`
+
+	interfaces := ParseIfconfig(input)
+	gif0 := findInterface(interfaces, "gif0")
+	if gif0 == nil {
+		t.Fatal("gif0 interface not found")
+	}
+	if gif0.Type != GIF || gif0.TunnelLocal != "192.0.2.1" || gif0.TunnelRemote != "198.51.100.1" {
+		t.Errorf("unexpected gif0: %+v", gif0)
+	}
+	gre0 := findInterface(interfaces, "gre0")
+	if gre0 == nil {
+		t.Fatal("gre0 interface not found")
+	}
+	if gre0.Type != GRE || gre0.TunnelLocal != "2001:db8::1" || gre0.TunnelRemote != "2001:db8::2" {
+		t.Errorf("unexpected gre0: %+v", gre0)
+	}
+	if gre0.GREKey != 42 {
+		t.Errorf("expected GRE key 42, got %d", gre0.GREKey)
+	}
+}
+
+func TestParseIfconfig_Bridge(t *testing.T) {
+	input := `bridge0: flags=1008843<UP,BROADCAST,RUNNING,SIMPLEX,MULTICAST,LOWER_UP> metric 0 mtu 1500
+	options=0
+	ether 58:9c:fc:10:ff:b6
+	id 58:9c:fc:10:ff:b6 priority 4096 hellotime 2 fwddelay 15
+	maxage 20 holdcnt 6 proto rstp maxaddr 2000 timeout 1200
+	root id 58:9c:fc:10:ff:b6 priority 4096 ifcost 0 port 0
+	member: em2 flags=8<SPAN>
+	        ifmaxaddr 0 port 3 priority 128 path cost 20000
+	member: tap0 flags=143<LEARNING,DISCOVER,AUTOEDGE,AUTOPTP>
+	        ifmaxaddr 0 port 6 priority 128 path cost 2000000
+	member: em0 flags=1c7<LEARNING,DISCOVER,STP,AUTOEDGE,PTP,AUTOPTP>
+	        ifmaxaddr 0 port 1 priority 128 path cost 20000 proto rstp
+	        role designated state forwarding
+	groups: bridge
+	nd6 options=9<PERFORMNUD,IFDISABLED>`
+
+	interfaces := ParseIfconfig(input)
+	if len(interfaces) != 1 {
+		t.Fatalf("expected 1 interface, got %d", len(interfaces))
+	}
+	br := interfaces[0]
+	if br.Type != Bridge {
+		t.Errorf("expected type %s, got %s", Bridge, br.Type)
+	}
+	if br.Bridge == nil {
+		t.Fatal("expected bridge info")
+	}
+	b := br.Bridge
+	if b.Priority != 4096 || b.Proto != "rstp" || b.MaxAddr != 2000 || b.Timeout != 1200 || b.MaxAge != 20 {
+		t.Errorf("unexpected bridge parameters: %+v", b)
+	}
+	if b.RootID != "58:9c:fc:10:ff:b6" || b.RootPriority != 4096 {
+		t.Errorf("unexpected root: %+v", b)
+	}
+	if len(b.Members) != 3 {
+		t.Fatalf("expected 3 members, got %d", len(b.Members))
+	}
+	if b.Members[0].Name != "em2" || b.Members[0].Flags[0] != "SPAN" {
+		t.Errorf("unexpected span member: %+v", b.Members[0])
+	}
+	em0 := b.Members[2]
+	if em0.Port != 1 || em0.PathCost != 20000 || em0.Proto != "rstp" || em0.Role != "designated" || em0.State != "forwarding" {
+		t.Errorf("unexpected em0 member: %+v", em0)
+	}
+}
+
+func TestParseBridgeAddr(t *testing.T) {
+	input := `58:9c:fc:00:44:29 Vlan1 tap0 1187 flags=0<>
+00:0c:29:aa:bb:cc Vlan1 em0 0 flags=1<STATIC>
+02:00:00:00:00:01 em1 300 flags=0<>
+garbage`
+
+	addrs := ParseBridgeAddr(input)
+	if len(addrs) != 3 {
+		t.Fatalf("expected 3 entries, got %d: %+v", len(addrs), addrs)
+	}
+	if addrs[0].MAC != "58:9c:fc:00:44:29" || addrs[0].VLAN != 1 || addrs[0].Interface != "tap0" || addrs[0].Expire != 1187 || len(addrs[0].Flags) != 0 {
+		t.Errorf("unexpected first entry: %+v", addrs[0])
+	}
+	if len(addrs[1].Flags) != 1 || addrs[1].Flags[0] != "STATIC" {
+		t.Errorf("unexpected static entry: %+v", addrs[1])
+	}
+	if addrs[2].Interface != "em1" || addrs[2].Expire != 300 {
+		t.Errorf("unexpected entry without vlan: %+v", addrs[2])
+	}
+}
+
+func TestParseIfconfig_CARP(t *testing.T) {
+	input := `em0: flags=8843<UP,BROADCAST,RUNNING,SIMPLEX,MULTICAST> metric 0 mtu 1500
+	ether 00:11:22:33:44:55
+	inet 192.0.2.2 netmask 0xffffff00 broadcast 192.0.2.255
+	inet 192.0.2.10 netmask 0xffffff00 broadcast 192.0.2.255 vhid 1
+	inet 192.0.2.11 netmask 0xffffff00 broadcast 192.0.2.255 vhid 2
+	carp: MASTER vhid 1 advbase 1 advskew 0
+	carp: BACKUP vhid 2 advbase 2 advskew 100
+	media: Ethernet autoselect (1000baseT <full-duplex>)
+	status: active`
+
+	em0 := findInterface(ParseIfconfig(input), "em0")
+	if em0 == nil {
+		t.Fatal("em0 interface not found")
+	}
+	expected := []CARPInfo{
+		{State: "MASTER", VHID: 1, AdvBase: 1, AdvSkew: 0},
+		{State: "BACKUP", VHID: 2, AdvBase: 2, AdvSkew: 100},
+	}
+	if len(em0.CARP) != len(expected) {
+		t.Fatalf("expected %d CARP entries, got %+v", len(expected), em0.CARP)
+	}
+	for i, c := range expected {
+		if em0.CARP[i] != c {
+			t.Errorf("expected CARP %+v, got %+v", c, em0.CARP[i])
+		}
+	}
+	if len(em0.IPv4) != 3 {
+		t.Errorf("expected 3 IPv4 addresses, got %v", em0.IPv4)
+	}
+}
+
+func TestParseIfconfig_VLANVXLAN(t *testing.T) {
+	input := `vlan10: flags=1008843<UP,BROADCAST,RUNNING,SIMPLEX,MULTICAST,LOWER_UP> metric 0 mtu 1500
+	options=4000000<NOMAP>
+	ether 00:11:22:33:44:55
+	inet 10.10.0.1 netmask 0xffffff00 broadcast 10.10.0.255
+	groups: vlan
+	vlan: 10 vlanproto: 802.1q vlanpcp: 0 parent interface: em0
+	media: Ethernet autoselect (1000baseT <full-duplex>)
+	status: active
+vx-tenant1: flags=8843<UP,BROADCAST,RUNNING,SIMPLEX,MULTICAST> metric 0 mtu 1450
+	options=80020<JUMBO_MTU,LINKSTATE>
+	ether 58:9c:fc:10:ff:8a
+	groups: vxlan
+	vxlan vni 1001 local 192.168.1.1:4789 remote 192.168.1.2:4789
+	media: Ethernet autoselect (autoselect <full-duplex>)
+	status: active`
+
+	interfaces := ParseIfconfig(input)
+	vlan10 := findInterface(interfaces, "vlan10")
+	if vlan10 == nil {
+		t.Fatal("vlan10 interface not found")
+	}
+	if vlan10.Type != VLAN || vlan10.VLANID != 10 || vlan10.VLANParent != "em0" {
+		t.Errorf("unexpected vlan10: %+v", vlan10)
+	}
+	vx := findInterface(interfaces, "vx-tenant1")
+	if vx == nil {
+		t.Fatal("vx-tenant1 interface not found")
+	}
+	if vx.Type != VXLAN || vx.VXLANID != 1001 || vx.VXLANLocal != "192.168.1.1" || vx.VXLANRemote != "192.168.1.2" {
+		t.Errorf("unexpected vx-tenant1: %+v", vx)
+	}
+}
+
+func TestParseIfconfig_Addresses(t *testing.T) {
+	input := `em0: flags=8843<UP,BROADCAST,RUNNING,SIMPLEX,MULTICAST> metric 0 mtu 1500
+	ether 00:11:22:33:44:55
+	inet 192.0.2.10 netmask 0xffffff00 broadcast 192.0.2.255 vhid 1
+	inet6 fe80::1%em0 prefixlen 64 scopeid 0x1
+	inet6 2001:db8::5 prefixlen 64 anycast tentative autoconf pltime 604800 vltime infty
+	inet6 2001:db8::6 prefixlen 64 deprecated
+	status: active
+gre0: flags=8051<UP,POINTOPOINT,RUNNING,MULTICAST> metric 0 mtu 1476
+	inet 172.16.0.1 --> 172.16.0.2 netmask 0xffffffff
+	inet6 ::1 prefixlen 128
+	groups: gre`
+
+	interfaces := ParseIfconfig(input)
+	em0 := findInterface(interfaces, "em0")
+	if em0 == nil {
+		t.Fatal("em0 interface not found")
+	}
+	if len(em0.Addresses) != 4 {
+		t.Fatalf("expected 4 addresses, got %+v", em0.Addresses)
+	}
+
+	v4 := em0.Addresses[0]
+	if v4.Family != FamilyInet || v4.Prefix != "192.0.2.10/24" || v4.Broadcast != "192.0.2.255" || v4.VHID != 1 || v4.Scope != ScopeGlobal {
+		t.Errorf("unexpected IPv4 address: %+v", v4)
+	}
+	ll := em0.Addresses[1]
+	if ll.Address != "fe80::1" || ll.Zone != "em0" || ll.Scope != ScopeLink || ll.PrefixLen != 64 {
+		t.Errorf("unexpected link-local address: %+v", ll)
+	}
+	auto := em0.Addresses[2]
+	if auto.PLTime != "604800" || auto.VLTime != "infty" || strings.Join(auto.Flags, ",") != "anycast,tentative,autoconf" {
+		t.Errorf("unexpected autoconf address: %+v", auto)
+	}
+	if dep := em0.Addresses[3]; strings.Join(dep.Flags, ",") != "deprecated" {
+		t.Errorf("unexpected deprecated address: %+v", dep)
+	}
+
+	gre0 := findInterface(interfaces, "gre0")
+	if gre0 == nil || len(gre0.Addresses) != 2 {
+		t.Fatalf("unexpected gre0: %+v", gre0)
+	}
+	if p2p := gre0.Addresses[0]; p2p.Destination != "172.16.0.2" || p2p.Prefix != "172.16.0.1/32" {
+		t.Errorf("unexpected point-to-point address: %+v", p2p)
+	}
+	if lo := gre0.Addresses[1]; lo.Scope != ScopeHost || lo.Prefix != "::1/128" {
+		t.Errorf("unexpected loopback address: %+v", lo)
+	}
+}
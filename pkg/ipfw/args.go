@@ -0,0 +1,118 @@
+package ipfw
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Args returns the rule body as ipfw add arguments, without the rule
+// number:
+//
+//	allow log tcp from any to table(jails) 22 in via em0 setup keep-state // ssh
+func (r Rule) Args() []string {
+	args := []string{r.Action}
+	if r.Target != "" {
+		args = append(args, r.Target)
+	}
+	if r.Log {
+		args = append(args, "log")
+	}
+	if r.Action != ActionCheckState {
+		proto := r.Proto
+		if proto == "" {
+			proto = "ip"
+		}
+		args = append(args, proto)
+		args = appendAddress(append(args, "from"), r.From, r.FromPort)
+		args = appendAddress(append(args, "to"), r.To, r.ToPort)
+		if r.Direction != "" {
+			args = append(args, r.Direction)
+		}
+		if r.Interface != "" {
+			args = append(args, "via", r.Interface)
+		}
+		args = append(args, strings.Fields(r.Options)...)
+		if r.KeepState {
+			args = append(args, "keep-state")
+		}
+	}
+	if r.Comment != "" {
+		args = append(args, "//", r.Comment)
+	}
+	return args
+}
+
+// String returns the rule body as ipfw lists it
+func (r Rule) String() string {
+	return strings.Join(r.Args(), " ")
+}
+
+// Args returns the arguments configuring the NAT instance:
+//
+//	nat 1 config if em0 same_ports redirect_port tcp 10.0.0.5:80 8080
+func (n NAT) Args() []string {
+	args := []string{"nat", strconv.Itoa(n.ID), "config"}
+	if n.Interface != "" {
+		args = append(args, "if", n.Interface)
+	} else {
+		args = append(args, "ip", n.Address)
+	}
+	if n.SamePorts {
+		args = append(args, "same_ports")
+	}
+	if n.UnregOnly {
+		args = append(args, "unreg_only")
+	}
+	if n.Reset {
+		args = append(args, "reset")
+	}
+	for _, r := range n.RedirectPorts {
+		args = append(append(args, "redirect_port"), strings.Fields(r)...)
+	}
+	return args
+}
+
+// Args returns the arguments configuring the pipe:
+//
+//	pipe 1 config bw 10Mbit/s delay 20 queue 50 mask src-ip 0xffffffff
+func (p Pipe) Args() []string {
+	args := []string{"pipe", strconv.Itoa(p.ID), "config"}
+	if p.Bandwidth != "" {
+		args = append(args, "bw", p.Bandwidth)
+	}
+	if p.Delay > 0 {
+		args = append(args, "delay", strconv.Itoa(p.Delay))
+	}
+	if p.QueueSize != "" {
+		args = append(args, "queue", p.QueueSize)
+	}
+	if p.Mask != "" {
+		args = append(append(args, "mask"), strings.Fields(p.Mask)...)
+	}
+	return args
+}
+
+// Args returns the arguments configuring the queue:
+//
+//	queue 1 config pipe 1 weight 10 mask dst-ip 0xffffffff
+func (q Queue) Args() []string {
+	args := []string{"queue", strconv.Itoa(q.ID), "config", "pipe", strconv.Itoa(q.Pipe)}
+	if q.Weight > 0 {
+		args = append(args, "weight", strconv.Itoa(q.Weight))
+	}
+	if q.Mask != "" {
+		args = append(append(args, "mask"), strings.Fields(q.Mask)...)
+	}
+	return args
+}
+
+func appendAddress(args []string, addr, port string) []string {
+	if addr == "" {
+		addr = anyAddr
+	}
+	args = append(args, strings.Fields(addr)...)
+	if port != "" {
+		args = append(args, port)
+	}
+	return args
+}
@@ -0,0 +1,121 @@
+package ipfw
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRuleArgs(t *testing.T) {
+	tests := []struct {
+		rule     Rule
+		expected string
+	}{
+		{Rule{Action: ActionDeny}, "deny ip from any to any"},
+		{Rule{Action: ActionAllow, Log: true, Proto: "tcp", To: "table(jails)", ToPort: "22", Direction: "in", Interface: "em0",
+			Options: "setup", KeepState: true, Comment: "ssh"}, "allow log tcp from any to table(jails) 22 in via em0 setup keep-state // ssh"},
+		{Rule{Action: ActionPipe, Target: "1", From: "10.0.0.5", Direction: "out"}, "pipe 1 ip from 10.0.0.5 to any out"},
+		{Rule{Action: ActionNAT, Target: "1", From: "10.0.0.0/24", To: "not 10.0.0.0/8", Interface: "em0"},
+			"nat 1 ip from 10.0.0.0/24 to not 10.0.0.0/8 via em0"},
+		{Rule{Action: ActionCheckState}, "check-state"},
+	}
+	for _, tt := range tests {
+		if got := tt.rule.String(); got != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, got)
+		}
+		// What fcom loads parses back to the same rule
+		parsed, err := parseRule(tt.rule.Args())
+		if err != nil {
+			t.Fatalf("unexpected error parsing %q: %v", tt.expected, err)
+		}
+		if !reflect.DeepEqual(parsed, tt.rule) {
+			t.Errorf("round trip of %q: expected %+v, got %+v", tt.expected, tt.rule, parsed)
+		}
+	}
+}
+
+func TestConfigArgs(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected []string
+	}{
+		{NAT{ID: 1, Interface: "em0", SamePorts: true, RedirectPorts: []string{"tcp 10.0.0.5:80 8080"}}.Args(),
+			[]string{"nat", "1", "config", "if", "em0", "same_ports", "redirect_port", "tcp", "10.0.0.5:80", "8080"}},
+		{NAT{ID: 2, Address: "192.0.2.1", UnregOnly: true, Reset: true}.Args(),
+			[]string{"nat", "2", "config", "ip", "192.0.2.1", "unreg_only", "reset"}},
+		{Pipe{ID: 1, Bandwidth: "10Mbit/s", Delay: 20, QueueSize: "50", Mask: "src-ip 0xffffffff"}.Args(),
+			[]string{"pipe", "1", "config", "bw", "10Mbit/s", "delay", "20", "queue", "50", "mask", "src-ip", "0xffffffff"}},
+		{Queue{ID: 3, Pipe: 1, Weight: 10, Mask: "dst-ip 0xffffffff"}.Args(),
+			[]string{"queue", "3", "config", "pipe", "1", "weight", "10", "mask", "dst-ip", "0xffffffff"}},
+	}
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.args, tt.expected) {
+			t.Errorf("expected %v, got %v", tt.expected, tt.args)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := &Ruleset{
+		Tables: []Table{{Name: "jails", Entries: []TableEntry{{Address: "10.0.0.5"}, {Address: "2001:db8::/64", Value: "1"}}}},
+		NATs:   []NAT{{ID: 1, Interface: "em0"}},
+		Pipes:  []Pipe{{ID: 1, Bandwidth: "10Mbit/s", Mask: "src-ip 0xffffffff"}},
+		Queues: []Queue{{ID: 1, Pipe: 1, Weight: 10}},
+		Rules: []Rule{
+			{Action: ActionAllow, Proto: "tcp", To: "table(jails)", ToPort: "22,80", KeepState: true},
+			{Action: ActionQueue, Target: "1", From: "10.0.0.5"},
+			{Action: ActionUnreach, Target: "port", Proto: "udp"},
+		},
+	}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	invalid := []*Ruleset{
+		{Rules: []Rule{{Action: "pass"}}},
+		{Rules: []Rule{{Action: ActionPipe}}},
+		{Rules: []Rule{{Action: ActionAllow, Target: "1"}}},
+		{Rules: []Rule{{Action: ActionAllow, ToPort: "22"}}},
+		{Rules: []Rule{{Action: ActionAllow, From: "<jails>"}}},
+		{Rules: []Rule{{Action: ActionCheckState, Proto: "tcp"}}},
+		{Rules: []Rule{{Action: ActionAllow, Comment: "a\nb"}}},
+		{Tables: []Table{{Name: "jails", Entries: []TableEntry{{Address: "jail1"}}}}},
+		{Tables: []Table{{Name: "t"}, {Name: "t"}}},
+		{NATs: []NAT{{ID: 1}}},
+		{NATs: []NAT{{ID: 1, Address: "2001:db8::1"}}},
+		{NATs: []NAT{{ID: 1, Interface: "em0", RedirectPorts: []string{"tcp 80"}}}},
+		{Pipes: []Pipe{{ID: 0}}},
+		{Pipes: []Pipe{{ID: 1, Bandwidth: "fast"}}},
+		{Queues: []Queue{{ID: 1, Pipe: 2}}},
+	}
+	for _, rs := range invalid {
+		if err := rs.Validate(); err == nil {
+			t.Errorf("expected error for %+v but got none", rs)
+		}
+	}
+}
+
+func TestRenumber(t *testing.T) {
+	rs := &Ruleset{Rules: []Rule{{Action: ActionAllow}, {Action: ActionDeny}}}
+	if err := rs.Renumber(10000, 10010); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rs.Rules[0].Number != 10000 || rs.Rules[1].Number != 10010 || rs.Index(10010) != 1 || rs.Index(10005) != -1 {
+		t.Errorf("unexpected numbers %+v", rs.Rules)
+	}
+	if err := rs.Renumber(10000, 10009); err == nil {
+		t.Error("expected error but got none")
+	}
+}
+
+func TestCanonicalAddress(t *testing.T) {
+	for addr, expected := range map[string]string{
+		"10.0.0.5":       "10.0.0.5/32",
+		"10.0.0.5/24":    "10.0.0.0/24",
+		"2001:db8::1":    "2001:db8::1/128",
+		"not-an-address": "not-an-address",
+	} {
+		if got := CanonicalAddress(addr); got != expected {
+			t.Errorf("CanonicalAddress(%q): expected %q, got %q", addr, expected, got)
+		}
+	}
+}
@@ -0,0 +1,425 @@
+// Package ipfw models ipfw(8) rules, lookup tables, in-kernel NAT instances
+// and dummynet pipes and queues, builds the ipfw command arguments that
+// load them and parses the "ipfw -a list" and "ipfw table all list"
+// listings.
+package ipfw
+
+import (
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Rule actions. ipfw prints allow for accept, pass and permit, and deny for
+// drop.
+const (
+	ActionAllow      = "allow"
+	ActionDeny       = "deny"
+	ActionReset      = "reset"   // deny TCP with a RST
+	ActionUnreach    = "unreach" // deny with an ICMP unreachable, Target is the code
+	ActionCount      = "count"
+	ActionNAT        = "nat"    // Target is the NAT instance
+	ActionPipe       = "pipe"   // Target is the dummynet pipe
+	ActionQueue      = "queue"  // Target is the dummynet queue
+	ActionSkipto     = "skipto" // Target is the rule number to continue at
+	ActionCheckState = "check-state"
+)
+
+// RuleStep is the distance between the numbers fcom gives its rules, so
+// rules can be inserted by hand between them
+const RuleStep = 10
+
+const (
+	anyAddr = "any"
+	tcp     = "tcp"
+	udp     = "udp"
+	not     = "not"
+)
+
+var (
+	// nameRegex matches interface, table and protocol names
+	nameRegex = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+	// portRegex matches ports as ipfw writes them: 22, 1000-2000 or 80,443
+	portRegex = regexp.MustCompile(`^[A-Za-z0-9_]+(?:-[A-Za-z0-9_]+)?(?:,[A-Za-z0-9_]+(?:-[A-Za-z0-9_]+)?)*$`)
+	// optionsRegex matches the extra match options of a rule, e.g. "setup"
+	// or "icmptypes 0,8"
+	optionsRegex = regexp.MustCompile(`^[A-Za-z0-9_.,:/! -]+$`)
+	// commentRegex keeps comments on a single line
+	commentRegex = regexp.MustCompile(`^[^\n]{1,80}$`)
+	// bandwidthRegex matches a dummynet bandwidth, e.g. 10Mbit/s
+	bandwidthRegex = regexp.MustCompile(`^[0-9]+(?:[KM]?(?:bit|Byte)/s)?$`)
+	// queueSizeRegex matches a dummynet queue size in slots or KBytes
+	queueSizeRegex = regexp.MustCompile(`^[0-9]+(?:KBytes)?$`)
+	// maskRegex matches a dummynet flow mask, e.g. "src-ip 0xffffffff"
+	maskRegex = regexp.MustCompile(`^(?:(?:all|(?:src|dst)-ip6?|(?:src|dst)-port|proto|flow-id)(?: 0x[0-9a-fA-F]+| [0-9]+)? ?)+$`)
+	// redirectRegex matches a redirect_port spec, e.g. "tcp 10.0.0.5:80 8080"
+	redirectRegex = regexp.MustCompile(`^(?:tcp|udp) [0-9.]+:[0-9]+(?:-[0-9]+)? (?:[0-9.]+:)?[0-9]+(?:-[0-9]+)?$`)
+)
+
+// argActions are the actions followed by an argument
+var argActions = map[string]bool{
+	ActionNAT: true, ActionPipe: true, ActionQueue: true, ActionSkipto: true, ActionUnreach: true,
+	"unreach6": true, "divert": true, "tee": true, "fwd": true, "setfib": true, "call": true,
+	"tag": true, "untag": true, "setdscp": true,
+}
+
+// Rule is an ipfw rule. Empty From and To mean any, an empty Proto means ip.
+type Rule struct {
+	Number    int        `json:"nr"`
+	Set       int        `json:"set,omitempty"`
+	Action    string     `json:"action"`
+	Target    string     `json:"target,omitempty"` // argument of the action
+	Log       bool       `json:"log,omitempty"`
+	Proto     string     `json:"proto,omitempty"`
+	From      string     `json:"from,omitempty"`
+	FromPort  string     `json:"from_port,omitempty"`
+	To        string     `json:"to,omitempty"`
+	ToPort    string     `json:"to_port,omitempty"`
+	Direction string     `json:"direction,omitempty"` // in or out, both if empty
+	Interface string     `json:"interface,omitempty"` // matched with via
+	Options   string     `json:"options,omitempty"`   // other match options, e.g. setup
+	KeepState bool       `json:"keep_state,omitempty"`
+	Comment   string     `json:"comment,omitempty"`
+	Stats     *RuleStats `json:"stats,omitempty"` // counters of a loaded rule
+}
+
+// RuleStats holds the counters printed by "ipfw -a list"
+type RuleStats struct {
+	Packets uint64 `json:"packets"`
+	Bytes   uint64 `json:"bytes"`
+}
+
+// TableEntry is an address or prefix of a lookup table and its value
+type TableEntry struct {
+	Address string `json:"address"`
+	Value   string `json:"value,omitempty"`
+}
+
+// Table is an ipfw lookup table, referred to in rules as table(name)
+type Table struct {
+	Name    string       `json:"name"`
+	Set     int          `json:"set,omitempty"`
+	Entries []TableEntry `json:"entries,omitempty"`
+}
+
+// NAT is an in-kernel NAT instance, used by rules with action nat
+type NAT struct {
+	ID            int      `json:"id"`
+	Interface     string   `json:"interface,omitempty"` // translate to the address of this interface
+	Address       string   `json:"address,omitempty"`   // or to this address
+	SamePorts     bool     `json:"same_ports,omitempty"`
+	UnregOnly     bool     `json:"unreg_only,omitempty"` // only translate RFC 1918 sources
+	Reset         bool     `json:"reset,omitempty"`      // drop the table when the address changes
+	RedirectPorts []string `json:"redirect_ports,omitempty"`
+}
+
+// Pipe is a dummynet pipe, a link with a fixed bandwidth and delay
+type Pipe struct {
+	ID        int    `json:"id"`
+	Bandwidth string `json:"bandwidth,omitempty"` // e.g. 10Mbit/s, unlimited if empty
+	Delay     int    `json:"delay,omitempty"`     // milliseconds
+	QueueSize string `json:"queue_size,omitempty"`
+	Mask      string `json:"mask,omitempty"` // e.g. "src-ip 0xffffffff" for one flow per source
+}
+
+// Queue is a dummynet queue sharing the bandwidth of a pipe by weight
+type Queue struct {
+	ID     int    `json:"id"`
+	Pipe   int    `json:"pipe"`
+	Weight int    `json:"weight,omitempty"`
+	Mask   string `json:"mask,omitempty"`
+}
+
+// Ruleset is the part of the ipfw configuration owned by fcom
+type Ruleset struct {
+	Tables []Table `json:"tables,omitempty"`
+	NATs   []NAT   `json:"nat,omitempty"`
+	Pipes  []Pipe  `json:"pipes,omitempty"`
+	Queues []Queue `json:"queues,omitempty"`
+	Rules  []Rule  `json:"rules,omitempty"`
+}
+
+// Validate checks a rule before it is loaded
+func (r Rule) Validate() error {
+	if err := r.validateAction(); err != nil {
+		return err
+	}
+	if r.Proto != "" && !nameRegex.MatchString(r.Proto) {
+		return fmt.Errorf("invalid protocol %q", r.Proto)
+	}
+	for _, addr := range []string{r.From, r.To} {
+		if addr != "" {
+			if err := validateAddress(addr); err != nil {
+				return err
+			}
+		}
+	}
+	for _, port := range []string{r.FromPort, r.ToPort} {
+		if port == "" {
+			continue
+		}
+		if !portRegex.MatchString(port) {
+			return fmt.Errorf("invalid port %q", port)
+		}
+		if r.Proto != tcp && r.Proto != udp {
+			return fmt.Errorf("ports require proto tcp or udp")
+		}
+	}
+	if r.Direction != "" && r.Direction != "in" && r.Direction != "out" {
+		return fmt.Errorf("invalid direction %q: must be in or out", r.Direction)
+	}
+	if r.Interface != "" && !nameRegex.MatchString(r.Interface) {
+		return fmt.Errorf("invalid interface %q", r.Interface)
+	}
+	if r.Options != "" && !optionsRegex.MatchString(r.Options) {
+		return fmt.Errorf("invalid options %q", r.Options)
+	}
+	if r.Comment != "" && !commentRegex.MatchString(r.Comment) {
+		return fmt.Errorf("invalid comment %q", r.Comment)
+	}
+	return nil
+}
+
+// validateAction checks the action and its argument
+func (r Rule) validateAction() error {
+	switch r.Action {
+	case ActionAllow, ActionDeny, ActionReset, ActionCount:
+		if r.Target != "" {
+			return fmt.Errorf("action %s takes no argument", r.Action)
+		}
+	case ActionCheckState:
+		if r.Target != "" || r != (Rule{Number: r.Number, Set: r.Set, Action: r.Action, Log: r.Log, Comment: r.Comment, Stats: r.Stats}) {
+			return fmt.Errorf("check-state rules take no argument or match options")
+		}
+	case ActionNAT, ActionPipe, ActionQueue, ActionSkipto:
+		if n, err := strconv.Atoi(r.Target); err != nil || n < 1 || n > 65535 {
+			return fmt.Errorf("action %s requires a number between 1 and 65535, got %q", r.Action, r.Target)
+		}
+	case ActionUnreach:
+		if !nameRegex.MatchString(r.Target) {
+			return fmt.Errorf("action unreach requires a code such as host or port, got %q", r.Target)
+		}
+	default:
+		return fmt.Errorf("invalid action %q: must be allow, deny, reset, unreach, count, nat, pipe, queue, skipto or check-state", r.Action)
+	}
+	return nil
+}
+
+// Validate checks a table and its entries
+func (t Table) Validate() error {
+	if !nameRegex.MatchString(t.Name) {
+		return fmt.Errorf("invalid table name %q", t.Name)
+	}
+	for _, e := range t.Entries {
+		if _, err := parsePrefix(e.Address); err != nil {
+			return fmt.Errorf("invalid table address %q: must be an IP address or prefix", e.Address)
+		}
+		if e.Value != "" && !nameRegex.MatchString(e.Value) {
+			return fmt.Errorf("invalid table value %q", e.Value)
+		}
+	}
+	return nil
+}
+
+// Validate checks a NAT instance
+func (n NAT) Validate() error {
+	if err := validateID("nat", n.ID); err != nil {
+		return err
+	}
+	if (n.Interface == "") == (n.Address == "") {
+		return fmt.Errorf("nat %d requires either an interface or an address", n.ID)
+	}
+	if n.Interface != "" && !nameRegex.MatchString(n.Interface) {
+		return fmt.Errorf("invalid interface %q", n.Interface)
+	}
+	if n.Address != "" {
+		if addr, err := netip.ParseAddr(n.Address); err != nil || !addr.Is4() {
+			return fmt.Errorf("invalid nat address %q: must be an IPv4 address", n.Address)
+		}
+	}
+	for _, r := range n.RedirectPorts {
+		if !redirectRegex.MatchString(r) {
+			return fmt.Errorf("invalid redirect_port %q: must be like \"tcp 10.0.0.5:80 8080\"", r)
+		}
+	}
+	return nil
+}
+
+// Validate checks a dummynet pipe
+func (p Pipe) Validate() error {
+	if err := validateID("pipe", p.ID); err != nil {
+		return err
+	}
+	if p.Bandwidth != "" && !bandwidthRegex.MatchString(p.Bandwidth) {
+		return fmt.Errorf("invalid bandwidth %q: must be like 10Mbit/s", p.Bandwidth)
+	}
+	if p.Delay < 0 {
+		return fmt.Errorf("invalid delay %d", p.Delay)
+	}
+	if p.QueueSize != "" && !queueSizeRegex.MatchString(p.QueueSize) {
+		return fmt.Errorf("invalid queue size %q: must be slots or KBytes", p.QueueSize)
+	}
+	return validateMask(p.Mask)
+}
+
+// Validate checks a dummynet queue
+func (q Queue) Validate() error {
+	if err := validateID("queue", q.ID); err != nil {
+		return err
+	}
+	if err := validateID("pipe", q.Pipe); err != nil {
+		return err
+	}
+	if q.Weight < 0 || q.Weight > 100 {
+		return fmt.Errorf("invalid weight %d: must be between 1 and 100", q.Weight)
+	}
+	return validateMask(q.Mask)
+}
+
+// Validate checks every part of the ruleset
+func (rs *Ruleset) Validate() error {
+	seen := make(map[string]bool)
+	check := func(kind string, key interface{}) error {
+		k := fmt.Sprintf("%s %v", kind, key)
+		if seen[k] {
+			return fmt.Errorf("duplicate %s", k)
+		}
+		seen[k] = true
+		return nil
+	}
+	for _, t := range rs.Tables {
+		if err := t.Validate(); err != nil {
+			return err
+		}
+		if err := check("table", t.Name); err != nil {
+			return err
+		}
+	}
+	for _, n := range rs.NATs {
+		if err := n.Validate(); err != nil {
+			return err
+		}
+		if err := check("nat", n.ID); err != nil {
+			return err
+		}
+	}
+	for _, p := range rs.Pipes {
+		if err := p.Validate(); err != nil {
+			return err
+		}
+		if err := check("pipe", p.ID); err != nil {
+			return err
+		}
+	}
+	for _, q := range rs.Queues {
+		if err := q.Validate(); err != nil {
+			return err
+		}
+		if err := check("queue", q.ID); err != nil {
+			return err
+		}
+		if !seen[fmt.Sprintf("pipe %d", q.Pipe)] {
+			return fmt.Errorf("queue %d refers to unknown pipe %d", q.ID, q.Pipe)
+		}
+	}
+	for _, r := range rs.Rules {
+		if err := r.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Table returns the table called name, or nil
+func (rs *Ruleset) Table(name string) *Table {
+	for i := range rs.Tables {
+		if rs.Tables[i].Name == name {
+			return &rs.Tables[i]
+		}
+	}
+	return nil
+}
+
+// Renumber numbers the rules from first, RuleStep apart. It fails if the
+// last rule would be numbered past last.
+func (rs *Ruleset) Renumber(first, last int) error {
+	if n := len(rs.Rules); n > 0 && first+(n-1)*RuleStep > last {
+		return fmt.Errorf("%d rules do not fit in the rule range %d-%d", n, first, last)
+	}
+	for i := range rs.Rules {
+		rs.Rules[i].Number = first + i*RuleStep
+	}
+	return nil
+}
+
+// Index returns the position of the rule numbered nr, or -1
+func (rs *Ruleset) Index(nr int) int {
+	for i, r := range rs.Rules {
+		if r.Number == nr {
+			return i
+		}
+	}
+	return -1
+}
+
+// CanonicalAddress returns a table address the way ipfw lists it, with
+// the prefix length of host addresses, e.g. 10.0.0.5/32
+func CanonicalAddress(addr string) string {
+	p, err := parsePrefix(addr)
+	if err != nil {
+		return addr
+	}
+	return p.Masked().String()
+}
+
+func validateID(kind string, id int) error {
+	if id < 1 || id > 65535 {
+		return fmt.Errorf("invalid %s number %d: must be between 1 and 65535", kind, id)
+	}
+	return nil
+}
+
+func validateMask(mask string) error {
+	if mask != "" && !maskRegex.MatchString(mask) {
+		return fmt.Errorf("invalid mask %q: must be like \"src-ip 0xffffffff\"", mask)
+	}
+	return nil
+}
+
+// validateAddress checks a rule address: any, me, me6, an address or
+// prefix, or a table as table(name), optionally negated with "not ".
+func validateAddress(addr string) error {
+	a := strings.TrimPrefix(addr, not+" ")
+	switch {
+	case a == anyAddr || a == "me" || a == "me6":
+		return nil
+	case strings.HasPrefix(a, "table(") && strings.HasSuffix(a, ")"):
+		name, value, _ := strings.Cut(a[len("table("):len(a)-1], ",")
+		if nameRegex.MatchString(name) && (value == "" || nameRegex.MatchString(value)) {
+			return nil
+		}
+	default:
+		if _, err := parsePrefix(a); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid address %q", addr)
+}
+
+// parsePrefix parses an address or prefix
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid prefix: %w", err)
+		}
+		return p, nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid address: %w", err)
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
@@ -0,0 +1,277 @@
+package ipfw
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// tableHeaderRegex matches the header of each table in "ipfw table all list"
+var tableHeaderRegex = regexp.MustCompile(`^--- table\(([^)]+)\), set\(([0-9]+)\) ---$`)
+
+// natConfigRegex, pipeHeaderRegex and queueHeaderRegex match the lines
+// naming a NAT instance, pipe or queue in the ipfw show commands
+var (
+	natConfigRegex   = regexp.MustCompile(`^ipfw nat ([0-9]+) config\b`)
+	pipeHeaderRegex  = regexp.MustCompile(`^([0-9]+):`)
+	queueHeaderRegex = regexp.MustCompile(`^q([0-9]+)\s`)
+)
+
+// ParseRules parses the rules listed by "ipfw -a list", with or without -S:
+//
+//	10000      35     4218 allow tcp from any to table(jails) 22 in via em0 setup keep-state :default
+//	10010       0        0 set 20 pipe 1 ip from 10.0.0.5 to any out // web
+//
+// Dynamic rules listed after "## Dynamic rules" are skipped.
+func ParseRules(output string) ([]Rule, error) {
+	var rules []Rule
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "## Dynamic rules") {
+			break
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			return nil, fmt.Errorf("invalid rule %q", line)
+		}
+		nr, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule number in %q", line)
+		}
+		packets, perr := strconv.ParseUint(fields[1], 10, 64)
+		bytes, berr := strconv.ParseUint(fields[2], 10, 64)
+		if perr != nil || berr != nil {
+			return nil, fmt.Errorf("invalid counters in %q: list the rules with ipfw -a", line)
+		}
+		r, err := parseRule(fields[3:])
+		if err != nil {
+			return nil, fmt.Errorf("%w in %q", err, line)
+		}
+		r.Number = nr
+		r.Stats = &RuleStats{Packets: packets, Bytes: bytes}
+		rules = append(rules, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scanner error: %w", err)
+	}
+	return rules, nil
+}
+
+// ParseTables parses "ipfw table all list":
+//
+//	--- table(jails), set(0) ---
+//	10.0.0.5/32 0
+//	10.0.0.6/32 0
+func ParseTables(output string) ([]Table, error) {
+	var tables []Table
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if m := tableHeaderRegex.FindStringSubmatch(line); m != nil {
+			set, _ := strconv.Atoi(m[2])
+			tables = append(tables, Table{Name: m[1], Set: set})
+			continue
+		}
+		if len(tables) == 0 {
+			return nil, fmt.Errorf("table entry %q before any table header", line)
+		}
+		fields := strings.Fields(line)
+		e := TableEntry{Address: fields[0]}
+		if len(fields) > 1 {
+			e.Value = fields[1]
+		}
+		t := &tables[len(tables)-1]
+		t.Entries = append(t.Entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scanner error: %w", err)
+	}
+	return tables, nil
+}
+
+// ruleParser walks the fields of a rule body
+type ruleParser struct {
+	fields []string
+	pos    int
+}
+
+func (p *ruleParser) next() string {
+	if p.pos >= len(p.fields) {
+		return ""
+	}
+	f := p.fields[p.pos]
+	p.pos++
+	return f
+}
+
+func (p *ruleParser) peek() string {
+	if p.pos >= len(p.fields) {
+		return ""
+	}
+	return p.fields[p.pos]
+}
+
+// group returns the next field, or a whole "{ ... }" group such as
+// "{ tcp or udp }"
+func (p *ruleParser) group() string {
+	f := p.next()
+	if f != "{" {
+		return f
+	}
+	parts := []string{f}
+	for p.peek() != "" {
+		f = p.next()
+		parts = append(parts, f)
+		if f == "}" {
+			break
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// address returns an address with its "not" prefix
+func (p *ruleParser) address() string {
+	if p.peek() == not {
+		p.next()
+		return not + " " + p.group()
+	}
+	return p.group()
+}
+
+// port returns the port list following an address, if any
+func (p *ruleParser) port() string {
+	if f := p.peek(); f != "" && f[0] >= '0' && f[0] <= '9' && portRegex.MatchString(f) {
+		return p.next()
+	}
+	return ""
+}
+
+// parseRule parses a rule body, starting at the optional "set N"
+func parseRule(fields []string) (Rule, error) {
+	p := &ruleParser{fields: fields}
+	var r Rule
+	if p.peek() == "set" {
+		p.next()
+		set, err := strconv.Atoi(p.next())
+		if err != nil {
+			return r, fmt.Errorf("invalid set")
+		}
+		r.Set = set
+	}
+	r.Action = p.next()
+	if argActions[r.Action] {
+		r.Target = p.next()
+	}
+	if p.peek() == "log" {
+		p.next()
+		r.Log = true
+		if p.peek() == "logamount" {
+			p.pos += 2
+		}
+	}
+	if r.Action == ActionCheckState {
+		if strings.HasPrefix(p.peek(), ":") {
+			p.next()
+		}
+	} else {
+		if p.peek() != "from" {
+			r.Proto = p.group()
+		}
+		if p.next() != "from" {
+			return r, fmt.Errorf("missing from")
+		}
+		r.From = p.address()
+		r.FromPort = p.port()
+		if p.next() != "to" {
+			return r, fmt.Errorf("missing to")
+		}
+		r.To = p.address()
+		r.ToPort = p.port()
+	}
+
+	parseOptions(p, &r)
+	if r.Proto == "ip" {
+		r.Proto = ""
+	}
+	if r.From == anyAddr {
+		r.From = ""
+	}
+	if r.To == anyAddr {
+		r.To = ""
+	}
+	return r, nil
+}
+
+// parseOptions parses the options following the addresses
+func parseOptions(p *ruleParser, r *Rule) {
+	var options []string
+	for p.peek() != "" {
+		switch f := p.next(); f {
+		case "in", "out":
+			r.Direction = f
+		case "via":
+			r.Interface = p.next()
+		case "src-port":
+			r.FromPort = p.next()
+		case "dst-port":
+			r.ToPort = p.next()
+		case "keep-state":
+			r.KeepState = true
+			if strings.HasPrefix(p.peek(), ":") {
+				p.next() // state name, :default
+			}
+		case "//":
+			r.Comment = strings.Join(p.fields[p.pos:], " ")
+			p.pos = len(p.fields)
+		default:
+			options = append(options, f)
+		}
+	}
+	r.Options = strings.Join(options, " ")
+}
+
+// ParseNATIDs returns the NAT instances in "ipfw nat show config":
+//
+//	ipfw nat 1 config if em0 same_ports
+func ParseNATIDs(output string) []int {
+	return parseIDs(output, natConfigRegex)
+}
+
+// ParsePipeIDs returns the pipes in "ipfw pipe show":
+//
+//	00001:  10.000 Mbit/s    0 ms burst 0
+//	q131073  50 sl. 0 flows (1 buckets) sched 65537 weight 0 lmax 0 pri 0 droptail
+func ParsePipeIDs(output string) []int {
+	return parseIDs(output, pipeHeaderRegex)
+}
+
+// ParseQueueIDs returns the queues in "ipfw queue show":
+//
+//	q00002  50 sl. 0 flows (1 buckets) sched 1 weight 10 lmax 0 pri 0 droptail
+//
+// The internal queues of pipes, numbered above 65535, are skipped.
+func ParseQueueIDs(output string) []int {
+	return slices.DeleteFunc(parseIDs(output, queueHeaderRegex), func(id int) bool { return validateID("queue", id) != nil })
+}
+
+func parseIDs(output string, re *regexp.Regexp) []int {
+	var ids []int
+	for _, line := range strings.Split(output, "\n") {
+		if m := re.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			if id, err := strconv.Atoi(m[1]); err == nil {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
@@ -0,0 +1,103 @@
+package ipfw
+
+import (
+	"reflect"
+	"testing"
+)
+
+const rulesList = `00100       12       1008 allow ip from any to any via lo0
+00200        0          0 deny ip from any to 127.0.0.0/8
+10000       35       4218 allow log logamount 100 tcp from any to table(jails) 22 in via em0 setup keep-state :default // ssh
+10010        0          0 set 20 pipe 1 ip from 10.0.0.5 to any out
+10020        3        180 nat 1 ip4 from 10.0.0.0/24 to not 10.0.0.0/8 out via em0
+10030        0          0 check-state :default
+10040        7        420 allow udp from any 1024-65535 to me dst-port 53,853
+10050        0          0 allow { tcp or udp } from any to any
+65535      321      45678 deny ip from any to any
+## Dynamic rules (1):
+10000    4     320 (4s) STATE tcp 10.0.0.5 22 <-> 192.0.2.1 50000 :default
+`
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules(rulesList)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []Rule{
+		{Number: 100, Action: ActionAllow, Interface: "lo0", Stats: &RuleStats{Packets: 12, Bytes: 1008}},
+		{Number: 200, Action: ActionDeny, To: "127.0.0.0/8", Stats: &RuleStats{}},
+		{Number: 10000, Action: ActionAllow, Log: true, Proto: "tcp", To: "table(jails)", ToPort: "22", Direction: "in",
+			Interface: "em0", Options: "setup", KeepState: true, Comment: "ssh", Stats: &RuleStats{Packets: 35, Bytes: 4218}},
+		{Number: 10010, Set: 20, Action: ActionPipe, Target: "1", From: "10.0.0.5", Direction: "out", Stats: &RuleStats{}},
+		{Number: 10020, Action: ActionNAT, Target: "1", Proto: "ip4", From: "10.0.0.0/24", To: "not 10.0.0.0/8",
+			Direction: "out", Interface: "em0", Stats: &RuleStats{Packets: 3, Bytes: 180}},
+		{Number: 10030, Action: ActionCheckState, Stats: &RuleStats{}},
+		{Number: 10040, Action: ActionAllow, Proto: "udp", FromPort: "1024-65535", To: "me", ToPort: "53,853",
+			Stats: &RuleStats{Packets: 7, Bytes: 420}},
+		{Number: 10050, Action: ActionAllow, Proto: "{ tcp or udp }", Stats: &RuleStats{}},
+		{Number: 65535, Action: ActionDeny, Stats: &RuleStats{Packets: 321, Bytes: 45678}},
+	}
+	if !reflect.DeepEqual(rules, expected) {
+		t.Errorf("expected:\n%+v\ngot:\n%+v", expected, rules)
+	}
+}
+
+func TestParseRules_Invalid(t *testing.T) {
+	for _, output := range []string{
+		"00100 allow ip from any to any\n",
+		"00100 0 0 allow ip to any\n",
+		"abc 0 0 allow ip from any to any\n",
+	} {
+		if _, err := ParseRules(output); err == nil {
+			t.Errorf("expected error for %q but got none", output)
+		}
+	}
+}
+
+func TestParseTables(t *testing.T) {
+	tables, err := ParseTables(`--- table(jails), set(0) ---
+10.0.0.5/32 0
+10.0.0.6/32 0
+--- table(empty), set(20) ---
+--- table(fibs), set(0) ---
+2001:db8::/64 2
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []Table{
+		{Name: "jails", Entries: []TableEntry{{Address: "10.0.0.5/32", Value: "0"}, {Address: "10.0.0.6/32", Value: "0"}}},
+		{Name: "empty", Set: 20},
+		{Name: "fibs", Entries: []TableEntry{{Address: "2001:db8::/64", Value: "2"}}},
+	}
+	if !reflect.DeepEqual(tables, expected) {
+		t.Errorf("expected %+v, got %+v", expected, tables)
+	}
+
+	if _, err := ParseTables("10.0.0.5/32 0\n"); err == nil {
+		t.Error("expected error but got none")
+	}
+}
+
+func TestParseIDs(t *testing.T) {
+	if ids := ParseNATIDs("ipfw nat 1 config if em0 same_ports\nipfw nat 12 config ip 192.0.2.1 log\n"); !reflect.DeepEqual(ids, []int{1, 12}) {
+		t.Errorf("expected nat [1 12], got %v", ids)
+	}
+	pipes := `00001:  10.000 Mbit/s    0 ms burst 0
+q131073  50 sl. 0 flows (1 buckets) sched 65537 weight 0 lmax 0 pri 0 droptail
+ sched 65537 type FIFO flags 0x0 0 buckets 0 active
+00003:   1.000 Mbit/s    0 ms burst 0
+`
+	if ids := ParsePipeIDs(pipes); !reflect.DeepEqual(ids, []int{1, 3}) {
+		t.Errorf("expected pipes [1 3], got %v", ids)
+	}
+	queues := `q00002  50 sl. 0 flows (1 buckets) sched 1 weight 10 lmax 0 pri 0 droptail
+q131073  50 sl. 0 flows (1 buckets) sched 65537 weight 0 lmax 0 pri 0 droptail
+`
+	if ids := ParseQueueIDs(queues); !reflect.DeepEqual(ids, []int{2}) {
+		t.Errorf("expected queues [2], got %v", ids)
+	}
+	if ids := ParseNATIDs(""); ids != nil {
+		t.Errorf("expected no nat, got %v", ids)
+	}
+}
@@ -13,6 +13,7 @@ type Info struct {
 	IPv4   string // IPv4
 	IPv6   string // IPv6
 	Path   string
+	FIB    string // exec.fib
 }
 
 // ParseJailList parses the output of 'jail -l' and returns a slice of Info.
@@ -43,6 +44,8 @@ func ParseJailList(output string) ([]Info, error) {
 				jail.IPv6 = fields[i]
 			case "path":
 				jail.Path = fields[i]
+			case "exec.fib":
+				jail.FIB = fields[i]
 			}
 		}
 		jails = append(jails, jail)
@@ -25,6 +25,21 @@ jail3      running  -             fe80::1         /jails/jail3`
 		}
 	})
 
+	t.Run("parses exec.fib column", func(t *testing.T) {
+		output := `name   state    ip4.addr   ip6.addr  path         exec.fib
+mgmt   running  10.1.0.5   -         /jails/mgmt  1`
+		want := []Info{
+			{Name: "mgmt", Status: "running", IPv4: "10.1.0.5", IPv6: "-", Path: "/jails/mgmt", FIB: "1"},
+		}
+		got, err := ParseJailList(output)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	})
+
 	t.Run("empty output returns empty slice", func(t *testing.T) {
 		output := ""
 		got, err := ParseJailList(output)
@@ -0,0 +1,81 @@
+package neighbor
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ND6 flags of "ndp -i"
+const (
+	FlagAcceptRtadv = "accept_rtadv" // configure addresses and routes from router advertisements (SLAAC)
+	FlagDisabled    = "disabled"     // IPv6 is disabled on the interface (IFDISABLED)
+)
+
+// ND6Info holds the IPv6 neighbor discovery settings of an interface
+type ND6Info struct {
+	LinkMTU       int      `json:"linkmtu"`
+	MaxMTU        int      `json:"maxmtu"`
+	CurHopLimit   int      `json:"curhlim"`
+	BaseReachable string   `json:"basereachable"`
+	Reachable     string   `json:"reachable"`
+	Retrans       string   `json:"retrans"`
+	Flags         []string `json:"flags"`
+	AcceptRtadv   bool     `json:"accept_rtadv"`
+	Disabled      bool     `json:"disabled"`
+}
+
+// ParseND6 parses the output of "ndp -i <interface>", which also prints the
+// settings after changing a flag:
+//
+//	linkmtu=0, maxmtu=1500, curhlim=64, basereachable=30s0ms, reachable=36s, retrans=1s0ms
+//	Flags: nud accept_rtadv auto_linklocal
+func ParseND6(output string) (*ND6Info, error) {
+	info := &ND6Info{Flags: []string{}}
+	found := false
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if flags, ok := strings.CutPrefix(line, "Flags:"); ok {
+			info.Flags = append(info.Flags, strings.Fields(flags)...)
+			continue
+		}
+		for _, kv := range strings.Split(line, ",") {
+			key, value, ok := strings.Cut(strings.TrimSpace(kv), "=")
+			if !ok {
+				continue
+			}
+			found = true
+			var err error
+			switch key {
+			case "linkmtu":
+				info.LinkMTU, err = strconv.Atoi(value)
+			case "maxmtu":
+				info.MaxMTU, err = strconv.Atoi(value)
+			case "curhlim":
+				info.CurHopLimit, err = strconv.Atoi(value)
+			case "basereachable":
+				info.BaseReachable = value
+			case "reachable":
+				info.Reachable = value
+			case "retrans":
+				info.Retrans = value
+			}
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q", key, value)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scanner error: %w", err)
+	}
+	if !found {
+		return nil, fmt.Errorf("no neighbor discovery settings in %q", output)
+	}
+	for _, f := range info.Flags {
+		info.AcceptRtadv = info.AcceptRtadv || f == FlagAcceptRtadv
+		info.Disabled = info.Disabled || f == FlagDisabled
+	}
+	return info, nil
+}
@@ -0,0 +1,36 @@
+package neighbor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseND6(t *testing.T) {
+	info, err := ParseND6("linkmtu=0, maxmtu=1500, curhlim=64, basereachable=30s0ms, reachable=36s, retrans=1s0ms\n" +
+		"Flags: nud accept_rtadv auto_linklocal\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := &ND6Info{
+		MaxMTU: 1500, CurHopLimit: 64, BaseReachable: "30s0ms", Reachable: "36s", Retrans: "1s0ms",
+		Flags: []string{"nud", "accept_rtadv", "auto_linklocal"}, AcceptRtadv: true,
+	}
+	if !reflect.DeepEqual(info, expected) {
+		t.Errorf("expected %+v, got %+v", expected, info)
+	}
+
+	info, err = ParseND6("linkmtu=1500, maxmtu=9000, curhlim=64, basereachable=30s0ms, reachable=20s, retrans=1s0ms\nFlags: disabled nud\n")
+	if err != nil || !info.Disabled || info.AcceptRtadv || info.LinkMTU != 1500 {
+		t.Errorf("unexpected settings %+v, %v", info, err)
+	}
+	info, err = ParseND6("linkmtu=0, maxmtu=1500, curhlim=64, basereachable=30s0ms, reachable=36s, retrans=1s0ms\nFlags:\n")
+	if err != nil || len(info.Flags) != 0 {
+		t.Errorf("expected no flags, got %+v, %v", info, err)
+	}
+
+	for _, output := range []string{"", "ndp: SIOCGIFINFO_IN6: Device not configured\n", "linkmtu=abc, maxmtu=1500\n"} {
+		if _, err := ParseND6(output); err == nil {
+			t.Errorf("expected error for %q but got none", output)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+// Package neighbor parses the FreeBSD ARP and NDP neighbor tables printed by
+// "arp -an" and "ndp -an".
+package neighbor
+
+import (
+	"bufio"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Address families.
+const (
+	FamilyInet  = "inet"
+	FamilyInet6 = "inet6"
+)
+
+// incomplete is printed instead of a MAC address for unresolved entries.
+const incomplete = "(incomplete)"
+
+// Entry is a single ARP or NDP neighbor cache entry.
+type Entry struct {
+	Family     string `json:"family"`
+	IP         string `json:"ip"`
+	Zone       string `json:"zone,omitempty"` // IPv6 scope zone of link-local addresses
+	MAC        string `json:"mac,omitempty"`
+	Interface  string `json:"interface"`
+	Expire     int    `json:"expire,omitempty"` // seconds until the entry expires
+	Expired    bool   `json:"expired,omitempty"`
+	Incomplete bool   `json:"incomplete,omitempty"`
+	Permanent  bool   `json:"permanent,omitempty"`
+	Published  bool   `json:"published,omitempty"` // proxy ARP / proxy NDP
+	Router     bool   `json:"router,omitempty"`
+	State      string `json:"state,omitempty"` // NDP neighbor state, e.g. REACHABLE
+	Type       string `json:"type,omitempty"`  // ARP link type, e.g. ethernet
+}
+
+// ndpStates maps the one letter NDP states of ndp(8) to their names.
+var ndpStates = map[string]string{
+	"N": "NOSTATE",
+	"W": "WAITDELETE",
+	"I": "INCOMPLETE",
+	"R": "REACHABLE",
+	"S": "STALE",
+	"D": "DELAY",
+	"P": "PROBE",
+	"?": "UNKNOWN",
+}
+
+// arpLineRegex matches "? (10.0.0.1) at 00:11:22:33:44:55 on em0 ...".
+var arpLineRegex = regexp.MustCompile(`^\S+ \(([^)]+)\) at (\S+) on (\S+)(.*)$`)
+
+// ParseARP parses the output of "arp -an".
+//
+//	? (192.168.1.1) at 00:11:22:33:44:55 on em0 expires in 1193 seconds [ethernet]
+//	? (192.168.1.10) at 58:9c:fc:00:00:01 on em0 permanent [ethernet]
+//	? (192.168.1.20) at (incomplete) on em0 expired [ethernet]
+func ParseARP(output string) []Entry {
+	var entries []Entry
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		m := arpLineRegex.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if m == nil {
+			continue
+		}
+		e := Entry{Family: FamilyInet, IP: m[1], Interface: m[3]}
+		if m[2] == incomplete {
+			e.Incomplete = true
+		} else {
+			e.MAC = m[2]
+		}
+		rest := strings.Fields(m[4])
+		for i := 0; i < len(rest); i++ {
+			switch f := rest[i]; {
+			case f == "permanent":
+				e.Permanent = true
+			case f == "published":
+				e.Published = true
+			case f == "expired":
+				e.Expired = true
+			case f == "expires" && i+2 < len(rest) && rest[i+1] == "in":
+				e.Expire, _ = strconv.Atoi(rest[i+2])
+				i += 2
+			case strings.HasPrefix(f, "[") && strings.HasSuffix(f, "]"):
+				e.Type = strings.Trim(f, "[]")
+			}
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// ParseNDP parses the output of "ndp -an".
+//
+//	Neighbor                             Linklayer Address  Netif Expire    1s 5s
+//	fe80::1%em0                          00:11:22:33:44:55    em0 23h59m58s S R
+//	2001:db8::1                          00:11:22:33:44:66    em0 permanent R
+func ParseNDP(output string) []Entry {
+	var entries []Entry
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || fields[0] == "Neighbor" {
+			continue
+		}
+		ip, zone, _ := strings.Cut(fields[0], "%")
+		e := Entry{Family: FamilyInet6, IP: ip, Zone: zone, Interface: fields[2]}
+		if fields[1] == incomplete {
+			e.Incomplete = true
+		} else {
+			e.MAC = fields[1]
+		}
+		switch fields[3] {
+		case "permanent":
+			e.Permanent = true
+		case "expired":
+			e.Expired = true
+		default:
+			if secs, ok := parseExpire(fields[3]); ok {
+				e.Expire = secs
+			}
+		}
+		if len(fields) > 4 {
+			if state, ok := ndpStates[fields[4]]; ok {
+				e.State = state
+			}
+		}
+		// Flags follow the state: R for routers, p for proxy entries
+		for _, f := range fields[min(5, len(fields)):] {
+			if strings.Contains(f, "R") {
+				e.Router = true
+			}
+			if strings.Contains(f, "p") {
+				e.Published = true
+			}
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// parseExpire converts an ndp expiry such as "4s", "23h59m58s" or
+// "1d2h3m4s" to seconds; time.ParseDuration does not know days.
+func parseExpire(s string) (int, bool) {
+	var days int
+	if d, rest, ok := strings.Cut(s, "d"); ok {
+		n, err := strconv.Atoi(d)
+		if err != nil || n < 0 {
+			return 0, false
+		}
+		days, s = n, rest
+	}
+	var d time.Duration
+	if s != "" {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, false
+		}
+	}
+	return days*86400 + int(d.Seconds()), true
+}
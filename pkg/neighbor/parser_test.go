@@ -0,0 +1,69 @@
+package neighbor
+
+import (
+	"testing"
+)
+
+const arpOutput = `? (192.168.1.1) at 00:11:22:33:44:55 on em0 expires in 1193 seconds [ethernet]
+? (192.168.1.10) at 58:9c:fc:00:00:01 on em0 permanent [ethernet]
+? (192.168.1.20) at (incomplete) on em0 expired [ethernet]
+? (10.0.0.5) at 00:aa:bb:cc:dd:ee on em1 permanent published [ethernet]
+? (10.20.0.7) at 02:00:00:00:00:07 on vlan20 expires in 42 seconds [vlan]
+`
+
+const ndpOutput = `Neighbor                             Linklayer Address  Netif Expire    1s 5s
+fe80::1%em0                          00:11:22:33:44:55    em0 23h59m58s S R
+fe80::1%vlan20                       02:00:00:00:00:07 vlan20 4s        R
+2001:db8::1                          58:9c:fc:00:00:01    em0 permanent R
+2001:db8::99                         (incomplete)         em0 expired   I
+2001:db8::100                        58:9c:fc:00:00:01    em0 permanent R p
+fe80::2%em0                          00:11:22:33:44:77    em0 1d2h3m4s  S R
+`
+
+func TestParseARP(t *testing.T) {
+	expected := []Entry{
+		{Family: FamilyInet, IP: "192.168.1.1", MAC: "00:11:22:33:44:55", Interface: "em0", Expire: 1193, Type: "ethernet"},
+		{Family: FamilyInet, IP: "192.168.1.10", MAC: "58:9c:fc:00:00:01", Interface: "em0", Permanent: true, Type: "ethernet"},
+		{Family: FamilyInet, IP: "192.168.1.20", Interface: "em0", Incomplete: true, Expired: true, Type: "ethernet"},
+		{Family: FamilyInet, IP: "10.0.0.5", MAC: "00:aa:bb:cc:dd:ee", Interface: "em1", Permanent: true, Published: true, Type: "ethernet"},
+		{Family: FamilyInet, IP: "10.20.0.7", MAC: "02:00:00:00:00:07", Interface: "vlan20", Expire: 42, Type: "vlan"},
+	}
+	entries := ParseARP(arpOutput)
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %d entries, got %d: %+v", len(expected), len(entries), entries)
+	}
+	for i, e := range expected {
+		if entries[i] != e {
+			t.Errorf("entry %d: expected %+v, got %+v", i, e, entries[i])
+		}
+	}
+}
+
+func TestParseNDP(t *testing.T) {
+	expected := []Entry{
+		{Family: FamilyInet6, IP: "fe80::1", Zone: "em0", MAC: "00:11:22:33:44:55", Interface: "em0", Expire: 86398, State: "STALE", Router: true},
+		{Family: FamilyInet6, IP: "fe80::1", Zone: "vlan20", MAC: "02:00:00:00:00:07", Interface: "vlan20", Expire: 4, State: "REACHABLE"},
+		{Family: FamilyInet6, IP: "2001:db8::1", MAC: "58:9c:fc:00:00:01", Interface: "em0", Permanent: true, State: "REACHABLE"},
+		{Family: FamilyInet6, IP: "2001:db8::99", Interface: "em0", Incomplete: true, Expired: true, State: "INCOMPLETE"},
+		{Family: FamilyInet6, IP: "2001:db8::100", MAC: "58:9c:fc:00:00:01", Interface: "em0", Permanent: true, State: "REACHABLE", Published: true},
+		{Family: FamilyInet6, IP: "fe80::2", Zone: "em0", MAC: "00:11:22:33:44:77", Interface: "em0", Expire: 93784, State: "STALE", Router: true},
+	}
+	entries := ParseNDP(ndpOutput)
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %d entries, got %d: %+v", len(expected), len(entries), entries)
+	}
+	for i, e := range expected {
+		if entries[i] != e {
+			t.Errorf("entry %d: expected %+v, got %+v", i, e, entries[i])
+		}
+	}
+}
+
+func TestParseEmpty(t *testing.T) {
+	if entries := ParseARP(""); len(entries) != 0 {
+		t.Errorf("expected no ARP entries, got %+v", entries)
+	}
+	if entries := ParseNDP("Neighbor                             Linklayer Address  Netif Expire    1s 5s\n"); len(entries) != 0 {
+		t.Errorf("expected no NDP entries, got %+v", entries)
+	}
+}
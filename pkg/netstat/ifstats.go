@@ -0,0 +1,232 @@
+package netstat
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Counters holds the traffic and error counters of an interface.
+type Counters struct {
+	InPackets  uint64 `json:"in_packets"`
+	InErrors   uint64 `json:"in_errors"`
+	InDrops    uint64 `json:"in_drops"`
+	InBytes    uint64 `json:"in_bytes"`
+	OutPackets uint64 `json:"out_packets"`
+	OutErrors  uint64 `json:"out_errors"`
+	OutDrops   uint64 `json:"out_drops"`
+	OutBytes   uint64 `json:"out_bytes"`
+	Collisions uint64 `json:"collisions"`
+}
+
+// Rates holds per-second counter rates computed from two samples.
+type Rates struct {
+	InPackets  float64 `json:"in_packets"`
+	InErrors   float64 `json:"in_errors"`
+	InDrops    float64 `json:"in_drops"`
+	InBytes    float64 `json:"in_bytes"`
+	OutPackets float64 `json:"out_packets"`
+	OutErrors  float64 `json:"out_errors"`
+	OutDrops   float64 `json:"out_drops"`
+	OutBytes   float64 `json:"out_bytes"`
+	Collisions float64 `json:"collisions"`
+}
+
+// InterfaceStats is the link level row of an interface in "netstat -i".
+type InterfaceStats struct {
+	Name    string `json:"name"`
+	MTU     int    `json:"mtu"`
+	Address string `json:"address,omitempty"` // MAC address, empty for interfaces without one
+	Counters
+}
+
+// counterColumns maps netstat column headers to counters. Both the
+// "netstat -i" (Ipkts, Obytes, ...) and "netstat -w" (packets, bytes, ...)
+// spellings are listed; in the latter the first occurrence of a repeated
+// name is the input and the second the output counter.
+var counterColumns = map[string][2]func(*Counters) *uint64{
+	"ipkts":   {func(c *Counters) *uint64 { return &c.InPackets }},
+	"ierrs":   {func(c *Counters) *uint64 { return &c.InErrors }},
+	"idrop":   {func(c *Counters) *uint64 { return &c.InDrops }},
+	"idrops":  {func(c *Counters) *uint64 { return &c.InDrops }},
+	"ibytes":  {func(c *Counters) *uint64 { return &c.InBytes }},
+	"opkts":   {func(c *Counters) *uint64 { return &c.OutPackets }},
+	"oerrs":   {func(c *Counters) *uint64 { return &c.OutErrors }},
+	"odrop":   {func(c *Counters) *uint64 { return &c.OutDrops }},
+	"drop":    {func(c *Counters) *uint64 { return &c.OutDrops }},
+	"drops":   {func(c *Counters) *uint64 { return &c.OutDrops }},
+	"obytes":  {func(c *Counters) *uint64 { return &c.OutBytes }},
+	"coll":    {func(c *Counters) *uint64 { return &c.Collisions }},
+	"colls":   {func(c *Counters) *uint64 { return &c.Collisions }},
+	"packets": {func(c *Counters) *uint64 { return &c.InPackets }, func(c *Counters) *uint64 { return &c.OutPackets }},
+	"errs":    {func(c *Counters) *uint64 { return &c.InErrors }, func(c *Counters) *uint64 { return &c.OutErrors }},
+	"bytes":   {func(c *Counters) *uint64 { return &c.InBytes }, func(c *Counters) *uint64 { return &c.OutBytes }},
+}
+
+// counterFields returns, for each counter column header, the accessor of the
+// counter it fills.
+func counterFields(headers []string) []func(*Counters) *uint64 {
+	seen := make(map[string]int)
+	fields := make([]func(*Counters) *uint64, len(headers))
+	for i, h := range headers {
+		h = strings.ToLower(h)
+		if accessors, ok := counterColumns[h]; ok && seen[h] < len(accessors) {
+			fields[i] = accessors[seen[h]]
+		}
+		seen[h]++
+	}
+	return fields
+}
+
+// fillCounters parses values into c using the accessors from counterFields.
+// Values that are not numbers ("-") leave the counter at zero.
+func fillCounters(c *Counters, fields []func(*Counters) *uint64, values []string) {
+	for i, v := range values {
+		if i < len(fields) && fields[i] != nil {
+			if n, err := strconv.ParseUint(v, 10, 64); err == nil {
+				*fields[i](c) = n
+			}
+		}
+	}
+}
+
+// ParseInterfaceStats parses the output of "netstat -i -b -n -W" and returns
+// the link level row of every interface. Address rows are skipped.
+//
+//	Name      Mtu Network       Address              Ipkts Ierrs Idrop     Ibytes    Opkts Oerrs     Obytes  Coll
+//	em0      1500 <Link#1>      00:11:22:33:44:55  1234567     0     0  987654321   765432     0  123456789     0
+//	em0         - 192.168.1.0/24 192.168.1.10        12345     -     -    1234567    23456     -    2345678     -
+func ParseInterfaceStats(output string) ([]InterfaceStats, error) {
+	var stats []InterfaceStats
+	var headers []string
+	var fields []func(*Counters) *uint64
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		cols := strings.Fields(scanner.Text())
+		if len(cols) == 0 {
+			continue
+		}
+		if cols[0] == "Name" {
+			headers = cols
+			fields = counterFields(headers)
+			continue
+		}
+		if headers == nil || len(cols) < 3 || !strings.HasPrefix(cols[2], "<Link#") {
+			continue
+		}
+		// Counters are right aligned; the Address column is empty for
+		// interfaces without a link level address
+		counters := len(headers) - 4
+		if len(cols) < 3+counters {
+			continue
+		}
+		s := InterfaceStats{Name: strings.TrimSuffix(cols[0], "*"), MTU: atoi(cols[1])}
+		if len(cols) > 3+counters {
+			s.Address = cols[3]
+		}
+		fillCounters(&s.Counters, fields[4:], cols[len(cols)-counters:])
+		stats = append(stats, s)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scanner error: %w", err)
+	}
+	if headers == nil {
+		return nil, fmt.Errorf("no netstat header found")
+	}
+	return stats, nil
+}
+
+// ParseInterfaceSamples parses the output of "netstat -I <if> -w <wait>".
+// Each sample holds the counter increments of one interval; the header is
+// repeated periodically and skipped.
+//
+//	         input            em0           output
+//	packets  errs idrops      bytes    packets  errs      bytes colls
+//	     12     0     0       1560         10     0       1400     0
+func ParseInterfaceSamples(output string) ([]Counters, error) {
+	var samples []Counters
+	var fields []func(*Counters) *uint64
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		cols := strings.Fields(scanner.Text())
+		if len(cols) == 0 || cols[0] == "input" {
+			continue
+		}
+		if cols[0] == "packets" {
+			fields = counterFields(cols)
+			continue
+		}
+		if fields == nil {
+			continue
+		}
+		var c Counters
+		fillCounters(&c, fields, cols)
+		samples = append(samples, c)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scanner error: %w", err)
+	}
+	if fields == nil {
+		return nil, fmt.Errorf("no netstat header found")
+	}
+	return samples, nil
+}
+
+// CounterDelta returns the increase of a counter from prev to cur. A counter
+// that went backwards is assumed to have wrapped once, at 32 bits if the
+// previous value fit in 32 bits and at 64 bits otherwise.
+func CounterDelta(prev, cur uint64) uint64 {
+	if cur >= prev {
+		return cur - prev
+	}
+	if prev <= math.MaxUint32 && cur <= math.MaxUint32 {
+		return (math.MaxUint32 - prev) + cur + 1
+	}
+	// Unsigned subtraction wraps at 64 bits
+	return cur - prev
+}
+
+// Delta returns the increments of all counters from prev to cur.
+func Delta(prev, cur Counters) Counters {
+	return Counters{
+		InPackets:  CounterDelta(prev.InPackets, cur.InPackets),
+		InErrors:   CounterDelta(prev.InErrors, cur.InErrors),
+		InDrops:    CounterDelta(prev.InDrops, cur.InDrops),
+		InBytes:    CounterDelta(prev.InBytes, cur.InBytes),
+		OutPackets: CounterDelta(prev.OutPackets, cur.OutPackets),
+		OutErrors:  CounterDelta(prev.OutErrors, cur.OutErrors),
+		OutDrops:   CounterDelta(prev.OutDrops, cur.OutDrops),
+		OutBytes:   CounterDelta(prev.OutBytes, cur.OutBytes),
+		Collisions: CounterDelta(prev.Collisions, cur.Collisions),
+	}
+}
+
+// PerSecond converts counter increments over elapsed into per-second rates.
+func PerSecond(delta Counters, elapsed time.Duration) Rates {
+	secs := elapsed.Seconds()
+	if secs <= 0 {
+		return Rates{}
+	}
+	rate := func(v uint64) float64 { return float64(v) / secs }
+	return Rates{
+		InPackets:  rate(delta.InPackets),
+		InErrors:   rate(delta.InErrors),
+		InDrops:    rate(delta.InDrops),
+		InBytes:    rate(delta.InBytes),
+		OutPackets: rate(delta.OutPackets),
+		OutErrors:  rate(delta.OutErrors),
+		OutDrops:   rate(delta.OutDrops),
+		OutBytes:   rate(delta.OutBytes),
+		Collisions: rate(delta.Collisions),
+	}
+}
+
+// Rate returns the per-second rates between two samples taken elapsed apart.
+func Rate(prev, cur Counters, elapsed time.Duration) Rates {
+	return PerSecond(Delta(prev, cur), elapsed)
+}
@@ -0,0 +1,100 @@
+package netstat
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const netstatInterfaces = `Name      Mtu Network            Address                     Ipkts Ierrs Idrop     Ibytes    Opkts Oerrs     Obytes  Coll
+em0      1500 <Link#1>           00:11:22:33:44:55         1234567     3     1  987654321   765432     0  123456789     0
+em0         - 192.168.1.0/24     192.168.1.10                12345     -     -    1234567    23456     -    2345678     -
+em1*     1500 <Link#2>           00:11:22:33:44:56               0     0     0          0        0     0          0     0
+lo0     16384 <Link#3>           lo0                           100     0     0       5000      100     0       5000     0
+lo0         - ::1/128            ::1                             0     -     -          0        0     -          0     -
+gif0     1280 <Link#4>                                          10     0     0        840       12     1       1008     0
+`
+
+const netstatWait = `            input            em0           output
+   packets  errs idrops      bytes    packets  errs      bytes colls
+        12     0     0       1560         10     0       1400     0
+         8     1     2        980          7     0        800     0
+            input            em0           output
+   packets  errs idrops      bytes    packets  errs      bytes colls
+         5     0     0        600          4     0        500     0
+`
+
+func TestParseInterfaceStats(t *testing.T) {
+	stats, err := ParseInterfaceStats(netstatInterfaces)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []InterfaceStats{
+		{Name: "em0", MTU: 1500, Address: "00:11:22:33:44:55", Counters: Counters{
+			InPackets: 1234567, InErrors: 3, InDrops: 1, InBytes: 987654321,
+			OutPackets: 765432, OutBytes: 123456789,
+		}},
+		{Name: "em1", MTU: 1500, Address: "00:11:22:33:44:56"},
+		{Name: "lo0", MTU: 16384, Address: "lo0", Counters: Counters{InPackets: 100, InBytes: 5000, OutPackets: 100, OutBytes: 5000}},
+		{Name: "gif0", MTU: 1280, Counters: Counters{InPackets: 10, InBytes: 840, OutPackets: 12, OutErrors: 1, OutBytes: 1008}},
+	}
+	if !reflect.DeepEqual(stats, expected) {
+		t.Errorf("ParseInterfaceStats() =\n%+v\nwant\n%+v", stats, expected)
+	}
+
+	if _, err := ParseInterfaceStats("no header\n"); err == nil {
+		t.Error("expected error but got none")
+	}
+}
+
+func TestParseInterfaceSamples(t *testing.T) {
+	samples, err := ParseInterfaceSamples(netstatWait)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []Counters{
+		{InPackets: 12, InBytes: 1560, OutPackets: 10, OutBytes: 1400},
+		{InPackets: 8, InErrors: 1, InDrops: 2, InBytes: 980, OutPackets: 7, OutBytes: 800},
+		{InPackets: 5, InBytes: 600, OutPackets: 4, OutBytes: 500},
+	}
+	if !reflect.DeepEqual(samples, expected) {
+		t.Errorf("ParseInterfaceSamples() = %+v, want %+v", samples, expected)
+	}
+}
+
+func TestCounterDelta(t *testing.T) {
+	tests := []struct {
+		name     string
+		prev     uint64
+		cur      uint64
+		expected uint64
+	}{
+		{name: "increase", prev: 100, cur: 250, expected: 150},
+		{name: "unchanged", prev: 100, cur: 100, expected: 0},
+		{name: "32-bit wrap", prev: math.MaxUint32 - 9, cur: 5, expected: 15},
+		{name: "64-bit wrap", prev: math.MaxUint64 - 1, cur: 3, expected: 5},
+		{name: "64-bit counter past 32 bits", prev: math.MaxUint32 + 10, cur: 20, expected: math.MaxUint64 - math.MaxUint32 + 11},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := CounterDelta(tc.prev, tc.cur); got != tc.expected {
+				t.Errorf("CounterDelta(%d, %d) = %d, want %d", tc.prev, tc.cur, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestRate(t *testing.T) {
+	prev := Counters{InPackets: 1000, InBytes: math.MaxUint32 - 999, OutPackets: 500, OutBytes: 10000, InErrors: 2}
+	cur := Counters{InPackets: 1500, InBytes: 4000, OutPackets: 500, OutBytes: 60000, InErrors: 7}
+
+	rates := Rate(prev, cur, 5*time.Second)
+	expected := Rates{InPackets: 100, InBytes: 1000, OutBytes: 10000, InErrors: 1}
+	if rates != expected {
+		t.Errorf("Rate() = %+v, want %+v", rates, expected)
+	}
+	if zero := Rate(prev, cur, 0); zero != (Rates{}) {
+		t.Errorf("expected zero rates for zero interval, got %+v", zero)
+	}
+}
@@ -0,0 +1,302 @@
+package netstat
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Routing socket message types printed by "route -n monitor".
+const (
+	RTMAdd     = "RTM_ADD"
+	RTMDelete  = "RTM_DELETE"
+	RTMChange  = "RTM_CHANGE"
+	RTMIfInfo  = "RTM_IFINFO"
+	RTMNewAddr = "RTM_NEWADDR"
+	RTMDelAddr = "RTM_DELADDR"
+)
+
+const defaultDest = "default"
+
+// RouteMessage is a routing socket message printed by "route -n monitor".
+// Destination and Address carry a prefix length when the message has a
+// netmask that is not a host mask.
+type RouteMessage struct {
+	Time        time.Time `json:"time"`
+	Type        string    `json:"type"`
+	PID         int       `json:"pid,omitempty"`
+	Seq         int       `json:"seq,omitempty"`
+	Errno       int       `json:"errno,omitempty"`
+	Flags       []string  `json:"flags,omitempty"`
+	Family      string    `json:"family,omitempty"`
+	Destination string    `json:"destination,omitempty"` // route destination, "default" for the default route
+	Gateway     string    `json:"gateway,omitempty"`
+	Interface   string    `json:"interface,omitempty"`
+	Address     string    `json:"address,omitempty"` // interface address
+	Broadcast   string    `json:"broadcast,omitempty"`
+	Index       int       `json:"index,omitempty"` // interface index of RTM_IFINFO
+	Link        string    `json:"link,omitempty"`  // link state of RTM_IFINFO: up, down or unknown
+	Metric      int       `json:"metric,omitempty"`
+}
+
+// ParseRouteMessages parses the output of "route -n monitor".
+func ParseRouteMessages(output string) []RouteMessage {
+	var messages []RouteMessage
+	_ = ScanRouteMessages(strings.NewReader(output), func(m RouteMessage) {
+		messages = append(messages, m)
+	})
+	return messages
+}
+
+// ScanRouteMessages reads the output of "route -n monitor" from r and calls
+// fn for each message as soon as it is complete, so it can follow a running
+// monitor. A message ends with its address values line, a blank line, the
+// next message or the end of input; RTM_IFINFO messages carry no addresses
+// and end with their type line.
+//
+//	got message of size 200 on Mon Oct 19 12:00:00 2026
+//	RTM_ADD: Add Route: len 200, pid: 1234, seq 1, errno 0, flags:<UP,GATEWAY,DONE,STATIC>
+//	locks:  inits:
+//	sockaddrs: <DST,GATEWAY,NETMASK>
+//	 10.0.0.0 192.168.1.1 255.255.255.0
+func ScanRouteMessages(r io.Reader, fn func(RouteMessage)) error {
+	var cur *RouteMessage
+	var names []string
+	var received time.Time
+
+	flush := func() {
+		if cur != nil {
+			cur.Family = messageFamily(*cur)
+			fn(*cur)
+		}
+		cur, names = nil, nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+			flush()
+		case strings.HasPrefix(line, "got message of size"):
+			flush()
+			received = time.Time{}
+			if _, date, ok := strings.Cut(line, " on "); ok {
+				if t, err := time.ParseInLocation(time.ANSIC, date, time.Local); err == nil {
+					received = t
+				}
+			}
+		case strings.HasPrefix(line, "RTM_"):
+			flush()
+			m := parseMessageType(line)
+			m.Time = received
+			cur = &m
+			if m.Type == RTMIfInfo {
+				flush()
+			}
+		case strings.HasPrefix(line, "locks:"):
+		case strings.HasPrefix(line, "sockaddrs:"):
+			_, list, _ := strings.Cut(line, ":")
+			if list = strings.Trim(strings.TrimSpace(list), "<>"); list != "" {
+				names = strings.Split(list, ",")
+			}
+		case cur != nil && names != nil:
+			setAddresses(cur, names, strings.Fields(line))
+			flush()
+		}
+	}
+	flush()
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("scanner error: %w", err)
+	}
+	return nil
+}
+
+// parseMessageType parses the line naming the message type:
+//
+//	RTM_ADD: Add Route: len 200, pid: 1234, seq 1, errno 0, flags:<UP,GATEWAY,DONE,STATIC>
+//	RTM_IFINFO: iface status change: len 168, if# 2, link: down, flags:<BROADCAST,SIMPLEX,MULTICAST>
+//	RTM_NEWADDR: address being added to iface: len 156, metric 0, flags:<UP>
+func parseMessageType(line string) RouteMessage {
+	typ, rest, _ := strings.Cut(line, ":")
+	m := RouteMessage{Type: typ}
+
+	// The RTM_CHANGE description ("Change Metrics or flags") contains
+	// "flags:" too
+	if i := strings.LastIndex(rest, "flags:"); i >= 0 {
+		if flags := strings.Trim(strings.TrimSpace(rest[i+len("flags:"):]), "<>"); flags != "" {
+			m.Flags = strings.Split(flags, ",")
+		}
+		rest = rest[:i]
+	}
+	if i := strings.Index(rest, "len "); i >= 0 {
+		rest = rest[i:]
+	}
+	for _, part := range strings.Split(rest, ",") {
+		fields := strings.Fields(strings.ReplaceAll(part, ":", " "))
+		if len(fields) != 2 {
+			continue
+		}
+		switch fields[0] {
+		case "pid":
+			m.PID = atoi(fields[1])
+		case "seq":
+			m.Seq = atoi(fields[1])
+		case "errno":
+			m.Errno = atoi(fields[1])
+		case "if#":
+			m.Index = atoi(fields[1])
+		case "link":
+			m.Link = fields[1]
+		case "metric":
+			m.Metric = atoi(fields[1])
+		}
+	}
+	return m
+}
+
+// setAddresses fills the message from the address values, which are printed
+// in the order of the names in the preceding sockaddrs line.
+func setAddresses(m *RouteMessage, names, values []string) {
+	addrs := make(map[string]string, len(names))
+	for i, name := range names {
+		if i < len(values) {
+			addrs[name] = values[i]
+		}
+	}
+
+	mask := addrs["NETMASK"]
+	if ifp := addrs["IFP"]; ifp != "" {
+		// Link level addresses print as "em0:0.11.22.33.44.55"
+		m.Interface, _, _ = strings.Cut(ifp, ":")
+	}
+	m.Gateway = addrs["GATEWAY"]
+	m.Broadcast = addrs["BRD"]
+	if m.Type == RTMNewAddr || m.Type == RTMDelAddr {
+		m.Address = withMask(addrs["IFA"], mask)
+		return
+	}
+	m.Destination = withMask(addrs["DST"], mask)
+	m.Address = addrs["IFA"]
+}
+
+// withMask appends the prefix length of mask to addr, unless mask is missing
+// or a host mask. An all-zero address with an all-zero mask is "default".
+func withMask(addr, mask string) string {
+	if addr == "" || addr == defaultDest || mask == "" {
+		return addr
+	}
+	bits := 0
+	if mask != defaultDest {
+		m, err := netip.ParseAddr(mask)
+		if err != nil {
+			return addr
+		}
+		if bits = maskBits(m); bits < 0 {
+			return addr
+		}
+	}
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return addr
+	}
+	switch {
+	case bits == ip.BitLen():
+		return addr
+	case bits == 0 && ip.WithZone("").IsUnspecified():
+		return defaultDest
+	}
+	return addr + "/" + strconv.Itoa(bits)
+}
+
+// maskBits returns the prefix length of a netmask, or -1 if the mask is
+// not contiguous.
+func maskBits(mask netip.Addr) int {
+	bits, end := 0, false
+	for _, b := range mask.AsSlice() {
+		for i := 7; i >= 0; i-- {
+			set := b&(1<<i) != 0
+			switch {
+			case set && end:
+				return -1
+			case set:
+				bits++
+			default:
+				end = true
+			}
+		}
+	}
+	return bits
+}
+
+// messageFamily returns "inet" or "inet6" from the first IP address of the
+// message, or "" if it has none.
+func messageFamily(m RouteMessage) string {
+	for _, s := range []string{m.Destination, m.Address, m.Gateway} {
+		if p, err := parsePrefix(s); err == nil {
+			if p.Addr().Is4() {
+				return "inet"
+			}
+			return "inet6"
+		}
+	}
+	return ""
+}
+
+// Matches reports whether the message is of family ("inet", "inet6" or ""
+// for any) and concerns destination. The destination filter is "default",
+// an address or a prefix; a message matches when its destination (or, for
+// address messages, its address) lies within the filter. Messages without a
+// family, such as RTM_IFINFO, pass the family filter but not a destination
+// filter.
+func (m RouteMessage) Matches(family, destination string) bool {
+	if family != "" && m.Family != "" && m.Family != family {
+		return false
+	}
+	if destination == "" {
+		return true
+	}
+	target := m.Destination
+	if m.Type == RTMNewAddr || m.Type == RTMDelAddr {
+		target = m.Address
+	}
+	if target == "" || destination == defaultDest {
+		return target != "" && target == destination
+	}
+
+	want, err := parsePrefix(destination)
+	if err != nil {
+		return false
+	}
+	var got netip.Prefix
+	switch {
+	case target == defaultDest && m.Family == "inet":
+		got = netip.PrefixFrom(netip.IPv4Unspecified(), 0)
+	case target == defaultDest && m.Family == "inet6":
+		got = netip.PrefixFrom(netip.IPv6Unspecified(), 0)
+	default:
+		if got, err = parsePrefix(target); err != nil {
+			return false
+		}
+	}
+	return got.Addr().Is4() == want.Addr().Is4() && got.Bits() >= want.Bits() && want.Contains(got.Addr())
+}
+
+// parsePrefix parses an address or prefix, ignoring any scope zone. A bare
+// address is a host prefix.
+func parsePrefix(s string) (netip.Prefix, error) {
+	addr, bits, hasBits := strings.Cut(s, "/")
+	addr, _, _ = strings.Cut(addr, "%")
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	if !hasBits {
+		return netip.PrefixFrom(ip, ip.BitLen()), nil
+	}
+	return netip.ParsePrefix(ip.String() + "/" + bits)
+}
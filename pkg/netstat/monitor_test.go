@@ -0,0 +1,116 @@
+package netstat
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const routeMonitor = `
+got message of size 200 on Mon Oct 19 12:00:00 2026
+RTM_ADD: Add Route: len 200, pid: 1234, seq 1, errno 0, flags:<UP,GATEWAY,DONE,STATIC>
+locks:  inits: 
+sockaddrs: <DST,GATEWAY,NETMASK>
+ 10.0.0.0 192.168.1.1 255.255.255.0
+
+got message of size 192 on Mon Oct 19 12:00:05 2026
+RTM_DELETE: Delete Route: len 192, pid: 1240, seq 2, errno 0, flags:<GATEWAY,DONE,STATIC>
+locks:  inits: 
+sockaddrs: <DST,GATEWAY,NETMASK>
+ default 192.168.1.1 default
+
+got message of size 168 on Mon Oct 19 12:00:06 2026
+RTM_IFINFO: iface status change: len 168, if# 1, link: down, flags:<BROADCAST,RUNNING,SIMPLEX,MULTICAST>
+got message of size 224 on Mon Oct 19 12:00:07 2026
+RTM_CHANGE: Change Metrics or flags: len 224, pid: 1250, seq 3, errno 0, flags:<UP,GATEWAY,HOST,DONE,STATIC>
+locks:  inits: 
+sockaddrs: <DST,GATEWAY>
+ 2001:db8::5 fe80::1%em0
+
+got message of size 156 on Mon Oct 19 12:00:08 2026
+RTM_NEWADDR: address being added to iface: len 156, metric 0, flags:<UP>
+sockaddrs: <NETMASK,IFP,IFA,BRD>
+ 255.255.255.0 em0:0.11.22.33.44.55 192.168.1.10 192.168.1.255
+
+got message of size 156 on Mon Oct 19 12:00:09 2026
+RTM_DELADDR: address being removed from iface: len 156, metric 0, flags:<UP>
+sockaddrs: <NETMASK,IFP,IFA>
+ ffff:ffff:ffff:ffff:: em0:0.11.22.33.44.55 fe80::1%em0
+`
+
+func monitorTime(sec int) time.Time {
+	return time.Date(2026, time.October, 19, 12, 0, sec, 0, time.Local)
+}
+
+func TestParseRouteMessages(t *testing.T) {
+	expected := []RouteMessage{
+		{Time: monitorTime(0), Type: RTMAdd, PID: 1234, Seq: 1, Flags: []string{"UP", "GATEWAY", "DONE", "STATIC"},
+			Family: "inet", Destination: "10.0.0.0/24", Gateway: "192.168.1.1"},
+		{Time: monitorTime(5), Type: RTMDelete, PID: 1240, Seq: 2, Flags: []string{"GATEWAY", "DONE", "STATIC"},
+			Family: "inet", Destination: "default", Gateway: "192.168.1.1"},
+		{Time: monitorTime(6), Type: RTMIfInfo, Flags: []string{"BROADCAST", "RUNNING", "SIMPLEX", "MULTICAST"},
+			Index: 1, Link: "down"},
+		{Time: monitorTime(7), Type: RTMChange, PID: 1250, Seq: 3, Flags: []string{"UP", "GATEWAY", "HOST", "DONE", "STATIC"},
+			Family: "inet6", Destination: "2001:db8::5", Gateway: "fe80::1%em0"},
+		{Time: monitorTime(8), Type: RTMNewAddr, Flags: []string{"UP"},
+			Family: "inet", Interface: "em0", Address: "192.168.1.10/24", Broadcast: "192.168.1.255"},
+		{Time: monitorTime(9), Type: RTMDelAddr, Flags: []string{"UP"},
+			Family: "inet6", Interface: "em0", Address: "fe80::1%em0/64"},
+	}
+	got := ParseRouteMessages(routeMonitor)
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("ParseRouteMessages() =\n%+v\nwant\n%+v", got, expected)
+	}
+}
+
+func TestScanRouteMessages_Streaming(t *testing.T) {
+	// Without separators each message is complete once its address values
+	// are read, before the next one arrives
+	input := "RTM_ADD: Add Route: len 200, pid: 1, seq 1, errno 0, flags:<UP>\n" +
+		"sockaddrs: <DST,GATEWAY>\n" +
+		" 10.0.0.5 10.0.0.1\n" +
+		"RTM_IFINFO: iface status change: len 168, if# 2, link: up, flags:<UP>\n"
+	var types []string
+	if err := ScanRouteMessages(strings.NewReader(input), func(m RouteMessage) {
+		types = append(types, m.Type)
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(types, []string{RTMAdd, RTMIfInfo}) {
+		t.Errorf("unexpected messages: %v", types)
+	}
+}
+
+func TestRouteMessage_Matches(t *testing.T) {
+	messages := ParseRouteMessages(routeMonitor)
+	tests := []struct {
+		name        string
+		family      string
+		destination string
+		expected    []string
+	}{
+		{name: "all", expected: []string{RTMAdd, RTMDelete, RTMIfInfo, RTMChange, RTMNewAddr, RTMDelAddr}},
+		{name: "inet", family: "inet", expected: []string{RTMAdd, RTMDelete, RTMIfInfo, RTMNewAddr}},
+		{name: "inet6", family: "inet6", expected: []string{RTMIfInfo, RTMChange, RTMDelAddr}},
+		{name: "default", destination: "default", expected: []string{RTMDelete}},
+		{name: "prefix", destination: "10.0.0.0/8", expected: []string{RTMAdd}},
+		{name: "everything inet", destination: "0.0.0.0/0", expected: []string{RTMAdd, RTMDelete, RTMNewAddr}},
+		{name: "host", destination: "2001:db8::5", expected: []string{RTMChange}},
+		{name: "address", destination: "192.168.1.0/24", expected: []string{RTMNewAddr}},
+		{name: "no match", destination: "172.16.0.0/12"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for _, m := range messages {
+				if m.Matches(tc.family, tc.destination) {
+					got = append(got, m.Type)
+				}
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("Matches(%q, %q) selected %v, want %v", tc.family, tc.destination, got, tc.expected)
+			}
+		})
+	}
+}
@@ -5,27 +5,66 @@ import (
 	"bufio"
 	"fmt"
 	"runtime"
+	"strconv"
 	"strings"
 )
 
 // Route represents a parsed route entry from netstat output.
 type Route struct {
-	Destination string `json:"destination"`
-	Gateway     string `json:"gateway"`
-	Genmask     string `json:"genmask,omitempty"`
-	Flags       string `json:"flags,omitempty"`
-	Metric      string `json:"metric,omitempty"`
-	Interface   string `json:"interface"`
+	Destination string   `json:"destination"`
+	Gateway     string   `json:"gateway"`
+	Genmask     string   `json:"genmask,omitempty"`
+	Flags       []string `json:"flags,omitempty"`
+	Metric      string   `json:"metric,omitempty"`
+	Interface   string   `json:"interface"`
+	Refs        int      `json:"refs,omitempty"`
+	Use         int      `json:"use,omitempty"`
+	MTU         int      `json:"mtu,omitempty"`
+	Expire      int      `json:"expire,omitempty"` // seconds until the route expires
+	FIB         int      `json:"fib"`
 }
 
 const minRouteFields = 4
 
+// routeFlags maps the flag letters printed by netstat -r to route flag names.
+var routeFlags = map[rune]string{ //nolint:gochecknoglobals
+	'1': "PROTO1",
+	'2': "PROTO2",
+	'3': "PROTO3",
+	'B': "BLACKHOLE",
+	'b': "BROADCAST",
+	'D': "DYNAMIC",
+	'G': "GATEWAY",
+	'H': "HOST",
+	'L': "LLINFO",
+	'M': "MODIFIED",
+	'R': "REJECT",
+	'S': "STATIC",
+	'U': "UP",
+	'W': "WASCLONED",
+	'X': "XRESOLVE",
+}
+
 // SystemOS returns the current system's OS name in lowercase.
 func SystemOS() string {
 	return strings.ToLower(runtime.GOOS)
 }
 
-// ParseNetstat parses the output of 'netstat -rn' on FreeBSD and returns a slice of Route.
+// DecodeFlags converts netstat flag letters such as "UGS" into flag names.
+// Unknown letters are kept as-is.
+func DecodeFlags(flags string) []string {
+	var names []string
+	for _, c := range flags {
+		if name, ok := routeFlags[c]; ok {
+			names = append(names, name)
+		} else {
+			names = append(names, string(c))
+		}
+	}
+	return names
+}
+
+// ParseNetstat parses the output of 'netstat -rn' or 'netstat -rnW' on FreeBSD and returns a slice of Route.
 func ParseNetstat(output string) ([]Route, error) {
 	var routes []Route
 	var headerIdx = map[string]int{}
@@ -55,23 +94,24 @@ func ParseNetstat(output string) ([]Route, error) {
 		}
 		// Parse fields based on header
 		var r Route
-		if idx, ok := headerIdx["destination"]; ok && idx < len(fields) {
-			r.Destination = fields[idx]
-		}
-		if idx, ok := headerIdx["gateway"]; ok && idx < len(fields) {
-			r.Gateway = fields[idx]
-		}
-		if idx, ok := headerIdx["flags"]; ok && idx < len(fields) {
-			r.Flags = fields[idx]
-		}
-		if idx, ok := headerIdx["netif"]; ok && idx < len(fields) {
-			r.Interface = fields[idx]
-		} else if idx, ok := headerIdx["iface"]; ok && idx < len(fields) {
-			r.Interface = fields[idx]
+		field := func(name string) string {
+			if idx, ok := headerIdx[name]; ok && idx < len(fields) {
+				return fields[idx]
+			}
+			return ""
 		}
-		if idx, ok := headerIdx["metric"]; ok && idx < len(fields) {
-			r.Metric = fields[idx]
+		r.Destination = field("destination")
+		r.Gateway = field("gateway")
+		r.Flags = DecodeFlags(field("flags"))
+		r.Interface = field("netif")
+		if r.Interface == "" {
+			r.Interface = field("iface")
 		}
+		r.Metric = field("metric")
+		r.Refs = atoi(field("refs"))
+		r.Use = atoi(field("use"))
+		r.MTU = atoi(field("mtu"))
+		r.Expire = atoi(field("expire"))
 		// FreeBSD does not have Genmask, so skip
 		// Only add if Destination and Gateway are present
 		if r.Destination != "" && r.Gateway != "" {
@@ -86,3 +126,12 @@ func ParseNetstat(output string) ([]Route, error) {
 	}
 	return routes, nil
 }
+
+// atoi returns the integer value of s, or 0 if s is not a number.
+func atoi(s string) int {
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0
+	}
+	return v
+}
@@ -18,11 +18,11 @@ Destination        Gateway            Flags     Netif Expire
 `
 
 	expected := []Route{
-		{Destination: "10.0.0.0/24", Gateway: "10.0.0.1", Flags: "UGS", Interface: "em0"},
-		{Destination: "10.0.0.1", Gateway: "link#1", Flags: "UHS", Interface: "lo0"},
-		{Destination: "127.0.0.1", Gateway: "link#2", Flags: "UH", Interface: "lo0"},
-		{Destination: "192.168.1.0/24", Gateway: "192.168.1.1", Flags: "UGS", Interface: "em1"},
-		{Destination: "192.168.1.1", Gateway: "link#3", Flags: "UHS", Interface: "lo0"},
+		{Destination: "10.0.0.0/24", Gateway: "10.0.0.1", Flags: []string{"UP", "GATEWAY", "STATIC"}, Interface: "em0"},
+		{Destination: "10.0.0.1", Gateway: "link#1", Flags: []string{"UP", "HOST", "STATIC"}, Interface: "lo0"},
+		{Destination: "127.0.0.1", Gateway: "link#2", Flags: []string{"UP", "HOST"}, Interface: "lo0"},
+		{Destination: "192.168.1.0/24", Gateway: "192.168.1.1", Flags: []string{"UP", "GATEWAY", "STATIC"}, Interface: "em1"},
+		{Destination: "192.168.1.1", Gateway: "link#3", Flags: []string{"UP", "HOST", "STATIC"}, Interface: "lo0"},
 	}
 
 	routes, err := ParseNetstat(sample)
@@ -52,7 +52,7 @@ MALFORMED LINE
 10.0.0.0/24        10.0.0.1           UGS      em0
 `
 	expected := []Route{
-		{Destination: "10.0.0.0/24", Gateway: "10.0.0.1", Flags: "UGS", Interface: "em0"},
+		{Destination: "10.0.0.0/24", Gateway: "10.0.0.1", Flags: []string{"UP", "GATEWAY", "STATIC"}, Interface: "em0"},
 	}
 	routes, err := ParseNetstat(sample)
 	if err != nil {
@@ -62,3 +62,39 @@ MALFORMED LINE
 		t.Errorf("parsed routes do not match expected.\nGot: %#v\nWant: %#v", routes, expected)
 	}
 }
+
+func TestParseNetstat_Wide(t *testing.T) {
+	sample := `Routing tables (fib: 1)
+
+Internet:
+Destination        Gateway            Flags         Use    Mtu      Netif Expire
+default            10.1.0.1           UGS          1042   1500        em1
+10.1.0.0/24        link#2             U               7   1500        em1
+10.9.0.0/16        127.0.0.1          UGSB            0  16384        lo0
+10.1.0.77          00:11:22:33:44:55  UHS             3   1500        em1   1187
+`
+	routes, err := ParseNetstat(sample)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(routes) != 4 {
+		t.Fatalf("expected 4 routes, got %d: %#v", len(routes), routes)
+	}
+	if routes[0].Use != 1042 || routes[0].MTU != 1500 || routes[0].Expire != 0 {
+		t.Errorf("unexpected default route: %#v", routes[0])
+	}
+	if !reflect.DeepEqual(routes[2].Flags, []string{"UP", "GATEWAY", "STATIC", "BLACKHOLE"}) {
+		t.Errorf("unexpected blackhole flags: %v", routes[2].Flags)
+	}
+	if routes[3].Expire != 1187 {
+		t.Errorf("expected expire 1187, got %d", routes[3].Expire)
+	}
+}
+
+func TestDecodeFlags(t *testing.T) {
+	got := DecodeFlags("UGRSq")
+	want := []string{"UP", "GATEWAY", "REJECT", "STATIC", "q"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
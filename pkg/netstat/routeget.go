@@ -0,0 +1,91 @@
+package netstat
+
+import (
+	"bufio"
+	"strings"
+)
+
+// RouteLookup is the result of "route -n get <destination>".
+type RouteLookup struct {
+	RouteTo     string   `json:"route_to"`
+	Destination string   `json:"destination"`
+	Mask        string   `json:"mask,omitempty"`
+	Gateway     string   `json:"gateway,omitempty"`
+	FIB         int      `json:"fib"`
+	Interface   string   `json:"interface"`
+	Flags       []string `json:"flags"`
+	MTU         int      `json:"mtu"`
+	Weight      int      `json:"weight,omitempty"`
+	Expire      int      `json:"expire,omitempty"`
+}
+
+// ParseRouteGet parses the output of "route -n get <destination>".
+//
+//	   route to: 10.0.0.5
+//	destination: 10.0.0.0
+//	       mask: 255.255.255.0
+//	    gateway: 10.0.0.1
+//	        fib: 0
+//	  interface: em0
+//	      flags: <UP,GATEWAY,DONE,STATIC>
+//	 recvpipe  sendpipe  ssthresh  rtt,msec    mtu        weight    expire
+//	       0         0         0         0      1500         1         0
+func ParseRouteGet(output string) *RouteLookup {
+	lookup := &RouteLookup{}
+	var metrics []string
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if metrics != nil {
+			// Values line following the metrics header
+			values := strings.Fields(line)
+			for i, name := range metrics {
+				if i >= len(values) {
+					break
+				}
+				switch name {
+				case "mtu":
+					lookup.MTU = atoi(values[i])
+				case "weight":
+					lookup.Weight = atoi(values[i])
+				case "expire":
+					lookup.Expire = atoi(values[i])
+				}
+			}
+			metrics = nil
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			if strings.HasPrefix(line, "recvpipe") {
+				metrics = strings.Fields(line)
+			}
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "route to":
+			lookup.RouteTo = value
+		case "destination":
+			lookup.Destination = value
+		case "mask":
+			lookup.Mask = value
+		case "gateway":
+			lookup.Gateway = value
+		case "fib":
+			lookup.FIB = atoi(value)
+		case "interface":
+			lookup.Interface = value
+		case "flags":
+			value = strings.Trim(value, "<>")
+			if value != "" {
+				lookup.Flags = strings.Split(value, ",")
+			}
+		}
+	}
+	return lookup
+}
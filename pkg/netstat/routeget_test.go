@@ -0,0 +1,53 @@
+package netstat
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRouteGet(t *testing.T) {
+	sample := `   route to: 10.0.0.5
+destination: 10.0.0.0
+       mask: 255.255.255.0
+    gateway: 10.0.0.1
+        fib: 1
+  interface: em0
+      flags: <UP,GATEWAY,DONE,STATIC>
+ recvpipe  sendpipe  ssthresh  rtt,msec    mtu        weight    expire
+       0         0         0         0      1400         5       300
+`
+	want := &RouteLookup{
+		RouteTo:     "10.0.0.5",
+		Destination: "10.0.0.0",
+		Mask:        "255.255.255.0",
+		Gateway:     "10.0.0.1",
+		FIB:         1,
+		Interface:   "em0",
+		Flags:       []string{"UP", "GATEWAY", "DONE", "STATIC"},
+		MTU:         1400,
+		Weight:      5,
+		Expire:      300,
+	}
+	if got := ParseRouteGet(sample); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
+
+func TestParseRouteGet_OnLink(t *testing.T) {
+	sample := `   route to: fe80::1%em0
+destination: fe80::%em0
+       mask: ffff:ffff:ffff:ffff::
+        fib: 0
+  interface: em0
+      flags: <UP,DONE,PINNED>
+ recvpipe  sendpipe  ssthresh  rtt,msec    mtu        weight    expire
+       0         0         0         0      1500         1         0
+`
+	got := ParseRouteGet(sample)
+	if got.Gateway != "" || got.Interface != "em0" || got.MTU != 1500 {
+		t.Errorf("unexpected lookup: %#v", got)
+	}
+	if !reflect.DeepEqual(got.Flags, []string{"UP", "DONE", "PINNED"}) {
+		t.Errorf("unexpected flags: %v", got.Flags)
+	}
+}
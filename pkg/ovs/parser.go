@@ -0,0 +1,251 @@
+// Package ovs provides parsing utilities for Open vSwitch ovs-vsctl(8) output.
+package ovs
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Bridge is a row of the OVSDB Bridge table.
+type Bridge struct {
+	UUID         string            `json:"uuid"`
+	Name         string            `json:"name"`
+	Ports        []string          `json:"ports"` // Port UUIDs
+	FailMode     string            `json:"fail_mode,omitempty"`
+	DatapathType string            `json:"datapath_type,omitempty"`
+	ExternalIDs  map[string]string `json:"external_ids,omitempty"`
+}
+
+// Port is a row of the OVSDB Port table.
+type Port struct {
+	UUID       string   `json:"uuid"`
+	Name       string   `json:"name"`
+	Interfaces []string `json:"interfaces"` // Interface UUIDs
+	Tag        int      `json:"tag,omitempty"`
+	Trunks     []int    `json:"trunks,omitempty"`
+	VLANMode   string   `json:"vlan_mode,omitempty"`
+}
+
+// Interface is a row of the OVSDB Interface table.
+type Interface struct {
+	UUID       string            `json:"uuid"`
+	Name       string            `json:"name"`
+	Type       string            `json:"type,omitempty"` // empty for system interfaces
+	Options    map[string]string `json:"options,omitempty"`
+	OFPort     int               `json:"ofport"`
+	AdminState string            `json:"admin_state,omitempty"`
+	LinkState  string            `json:"link_state,omitempty"`
+	MAC        string            `json:"mac_in_use,omitempty"`
+	Error      string            `json:"error,omitempty"`
+}
+
+// table is the output of "ovs-vsctl --format=json list <table>"
+type table struct {
+	Headings []string            `json:"headings"`
+	Data     [][]json.RawMessage `json:"data"`
+}
+
+// parseTable decodes the rows of a table into maps from column to value.
+func parseTable(output string) ([]map[string]interface{}, error) {
+	var t table
+	if err := json.Unmarshal([]byte(output), &t); err != nil {
+		return nil, fmt.Errorf("invalid ovs-vsctl JSON: %w", err)
+	}
+	rows := make([]map[string]interface{}, 0, len(t.Data))
+	for _, data := range t.Data {
+		if len(data) != len(t.Headings) {
+			return nil, fmt.Errorf("row has %d columns, expected %d", len(data), len(t.Headings))
+		}
+		row := make(map[string]interface{}, len(data))
+		for i, raw := range data {
+			var v interface{}
+			if err := json.Unmarshal(raw, &v); err != nil {
+				return nil, fmt.Errorf("invalid value of %s: %w", t.Headings[i], err)
+			}
+			row[t.Headings[i]] = v
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// ParseBridges parses the output of "ovs-vsctl --format=json list Bridge".
+func ParseBridges(output string) ([]Bridge, error) {
+	rows, err := parseTable(output)
+	if err != nil {
+		return nil, err
+	}
+	bridges := make([]Bridge, 0, len(rows))
+	for _, row := range rows {
+		bridges = append(bridges, Bridge{
+			UUID:         atom(row["_uuid"]),
+			Name:         atom(row["name"]),
+			Ports:        set(row["ports"]),
+			FailMode:     atom(row["fail_mode"]),
+			DatapathType: atom(row["datapath_type"]),
+			ExternalIDs:  stringMap(row["external_ids"]),
+		})
+	}
+	return bridges, nil
+}
+
+// ParsePorts parses the output of "ovs-vsctl --format=json list Port".
+func ParsePorts(output string) ([]Port, error) {
+	rows, err := parseTable(output)
+	if err != nil {
+		return nil, err
+	}
+	ports := make([]Port, 0, len(rows))
+	for _, row := range rows {
+		p := Port{
+			UUID:       atom(row["_uuid"]),
+			Name:       atom(row["name"]),
+			Interfaces: set(row["interfaces"]),
+			VLANMode:   atom(row["vlan_mode"]),
+		}
+		if tag := set(row["tag"]); len(tag) > 0 {
+			p.Tag, _ = strconv.Atoi(tag[0])
+		}
+		for _, trunk := range set(row["trunks"]) {
+			if n, err := strconv.Atoi(trunk); err == nil {
+				p.Trunks = append(p.Trunks, n)
+			}
+		}
+		ports = append(ports, p)
+	}
+	return ports, nil
+}
+
+// ParseInterfaces parses the output of "ovs-vsctl --format=json list Interface".
+func ParseInterfaces(output string) ([]Interface, error) {
+	rows, err := parseTable(output)
+	if err != nil {
+		return nil, err
+	}
+	ifaces := make([]Interface, 0, len(rows))
+	for _, row := range rows {
+		iface := Interface{
+			UUID:       atom(row["_uuid"]),
+			Name:       atom(row["name"]),
+			Type:       atom(row["type"]),
+			Options:    stringMap(row["options"]),
+			AdminState: atom(row["admin_state"]),
+			LinkState:  atom(row["link_state"]),
+			MAC:        atom(row["mac_in_use"]),
+			Error:      atom(row["error"]),
+			OFPort:     -1,
+		}
+		if ofport := set(row["ofport"]); len(ofport) > 0 {
+			iface.OFPort, _ = strconv.Atoi(ofport[0])
+		}
+		ifaces = append(ifaces, iface)
+	}
+	return ifaces, nil
+}
+
+// atom returns an OVSDB atom ("name", 10, true, ["uuid", "..."]) as a
+// string; empty sets and anything else give "".
+func atom(v interface{}) string {
+	switch a := v.(type) {
+	case string:
+		return a
+	case float64:
+		return strconv.FormatFloat(a, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(a)
+	case []interface{}:
+		if len(a) == 2 && (a[0] == "uuid" || a[0] == "named-uuid") {
+			return atom(a[1])
+		}
+	}
+	return ""
+}
+
+// set returns the members of an OVSDB set (["set", [...]]) as strings. A
+// set with a single member is encoded as the bare atom.
+func set(v interface{}) []string {
+	a, ok := v.([]interface{})
+	if !ok || len(a) != 2 || a[0] != "set" {
+		if s := atom(v); s != "" {
+			return []string{s}
+		}
+		return nil
+	}
+	members, _ := a[1].([]interface{})
+	var values []string
+	for _, m := range members {
+		values = append(values, atom(m))
+	}
+	return values
+}
+
+// stringMap returns an OVSDB map (["map", [[key, value], ...]]).
+func stringMap(v interface{}) map[string]string {
+	a, ok := v.([]interface{})
+	if !ok || len(a) != 2 || a[0] != "map" {
+		return nil
+	}
+	pairs, _ := a[1].([]interface{})
+	if len(pairs) == 0 {
+		return nil
+	}
+	m := make(map[string]string, len(pairs))
+	for _, p := range pairs {
+		if kv, ok := p.([]interface{}); ok && len(kv) == 2 {
+			m[atom(kv[0])] = atom(kv[1])
+		}
+	}
+	return m
+}
+
+// BridgeState is a bridge with its ports and their interfaces resolved.
+type BridgeState struct {
+	Name     string      `json:"name"`
+	FailMode string      `json:"fail_mode,omitempty"`
+	Ports    []PortState `json:"ports"`
+}
+
+// PortState is a port with its interfaces resolved.
+type PortState struct {
+	Name       string      `json:"name"`
+	Tag        int         `json:"tag,omitempty"`
+	Trunks     []int       `json:"trunks,omitempty"`
+	Interfaces []Interface `json:"interfaces"`
+}
+
+// Resolve joins the Bridge, Port and Interface tables. Bridges and ports are
+// sorted by name, as "ovs-vsctl show" lists them.
+func Resolve(bridges []Bridge, ports []Port, ifaces []Interface) []BridgeState {
+	portByUUID := make(map[string]Port, len(ports))
+	for _, p := range ports {
+		portByUUID[p.UUID] = p
+	}
+	ifaceByUUID := make(map[string]Interface, len(ifaces))
+	for _, i := range ifaces {
+		ifaceByUUID[i.UUID] = i
+	}
+
+	states := make([]BridgeState, 0, len(bridges))
+	for _, b := range bridges {
+		state := BridgeState{Name: b.Name, FailMode: b.FailMode, Ports: []PortState{}}
+		for _, uuid := range b.Ports {
+			p, ok := portByUUID[uuid]
+			if !ok {
+				continue
+			}
+			ps := PortState{Name: p.Name, Tag: p.Tag, Trunks: p.Trunks, Interfaces: []Interface{}}
+			for _, iuuid := range p.Interfaces {
+				if i, ok := ifaceByUUID[iuuid]; ok {
+					ps.Interfaces = append(ps.Interfaces, i)
+				}
+			}
+			state.Ports = append(state.Ports, ps)
+		}
+		sort.Slice(state.Ports, func(i, j int) bool { return state.Ports[i].Name < state.Ports[j].Name })
+		states = append(states, state)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Name < states[j].Name })
+	return states
+}
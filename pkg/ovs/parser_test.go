@@ -0,0 +1,176 @@
+package ovs
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// Recorded with ovs-vsctl --format=json list <table>; unused columns trimmed
+const (
+	bridgeJSON = `{"data":[[["uuid","8d1d5c6e-1f5a-4a8e-9a55-0f3b1c2d3e4f"],"",["map",[]],"secure","br1",["set",[["uuid","2b7d1a7c-4b1e-4c5f-8a9d-1e2f3a4b5c6d"]]]],` +
+		`[["uuid","1b6f5d2a-3c4d-4e5f-8a6b-7c8d9e0f1a2b"],"system",["map",[["owner","fcom"]]],["set",[]],"br0",` +
+		`["set",[["uuid","3c8e2b8d-5c2f-4d6a-9bae-2f3a4b5c6d7e"],["uuid","4d9f3c9e-6d3a-4e7b-acbf-3a4b5c6d7e8f"],["uuid","5eaf4daf-7e4b-4f8c-bdc0-4b5c6d7e8f9a"]]]]],` +
+		`"headings":["_uuid","datapath_type","external_ids","fail_mode","name","ports"]}`
+
+	portJSON = `{"data":[[["uuid","3c8e2b8d-5c2f-4d6a-9bae-2f3a4b5c6d7e"],["uuid","a1a1a1a1-0000-4000-8000-000000000001"],"em1",10,["set",[]],["set",[]]],` +
+		`[["uuid","4d9f3c9e-6d3a-4e7b-acbf-3a4b5c6d7e8f"],["uuid","a1a1a1a1-0000-4000-8000-000000000002"],"em2",["set",[]],["set",[20,30]],"trunk"],` +
+		`[["uuid","5eaf4daf-7e4b-4f8c-bdc0-4b5c6d7e8f9a"],["uuid","a1a1a1a1-0000-4000-8000-000000000003"],"vx0",["set",[]],["set",[]],["set",[]]],` +
+		`[["uuid","2b7d1a7c-4b1e-4c5f-8a9d-1e2f3a4b5c6d"],["uuid","a1a1a1a1-0000-4000-8000-000000000004"],"br1",["set",[]],["set",[]],["set",[]]]],` +
+		`"headings":["_uuid","interfaces","name","tag","trunks","vlan_mode"]}`
+
+	interfaceJSON = `{"data":[[["uuid","a1a1a1a1-0000-4000-8000-000000000001"],"up",["set",[]],"up","58:9c:fc:00:00:01","em1",1,["map",[]],""],` +
+		`[["uuid","a1a1a1a1-0000-4000-8000-000000000002"],"up",["set",[]],"down","58:9c:fc:00:00:02","em2",2,["map",[]],""],` +
+		`[["uuid","a1a1a1a1-0000-4000-8000-000000000003"],"up",["set",[]],"up","ee:4b:0a:1c:2d:3e","vx0",3,["map",[["key","100"],["remote_ip","192.0.2.2"]]],"vxlan"],` +
+		`[["uuid","a1a1a1a1-0000-4000-8000-000000000004"],"down","could not open network device br1",["set",[]],["set",[]],"br1",-1,["map",[]],"internal"]],` +
+		`"headings":["_uuid","admin_state","error","link_state","mac_in_use","name","ofport","options","type"]}`
+)
+
+func TestParseBridges(t *testing.T) {
+	bridges, err := ParseBridges(bridgeJSON)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []Bridge{
+		{UUID: "8d1d5c6e-1f5a-4a8e-9a55-0f3b1c2d3e4f", Name: "br1", FailMode: "secure",
+			Ports: []string{"2b7d1a7c-4b1e-4c5f-8a9d-1e2f3a4b5c6d"}},
+		{UUID: "1b6f5d2a-3c4d-4e5f-8a6b-7c8d9e0f1a2b", Name: "br0", DatapathType: "system",
+			ExternalIDs: map[string]string{"owner": "fcom"},
+			Ports: []string{"3c8e2b8d-5c2f-4d6a-9bae-2f3a4b5c6d7e", "4d9f3c9e-6d3a-4e7b-acbf-3a4b5c6d7e8f",
+				"5eaf4daf-7e4b-4f8c-bdc0-4b5c6d7e8f9a"}},
+	}
+	if !reflect.DeepEqual(bridges, expected) {
+		t.Errorf("ParseBridges() =\n%+v\nwant\n%+v", bridges, expected)
+	}
+}
+
+func TestParsePorts(t *testing.T) {
+	ports, err := ParsePorts(portJSON)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ports) != 4 {
+		t.Fatalf("expected 4 ports, got %d", len(ports))
+	}
+	if ports[0].Name != "em1" || ports[0].Tag != 10 || ports[0].Trunks != nil {
+		t.Errorf("unexpected access port: %+v", ports[0])
+	}
+	if ports[1].Name != "em2" || ports[1].Tag != 0 || !reflect.DeepEqual(ports[1].Trunks, []int{20, 30}) || ports[1].VLANMode != "trunk" {
+		t.Errorf("unexpected trunk port: %+v", ports[1])
+	}
+}
+
+func TestParseInterfaces(t *testing.T) {
+	ifaces, err := ParseInterfaces(interfaceJSON)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	vx := Interface{
+		UUID: "a1a1a1a1-0000-4000-8000-000000000003", Name: "vx0", Type: "vxlan",
+		Options: map[string]string{"key": "100", "remote_ip": "192.0.2.2"},
+		OFPort:  3, AdminState: "up", LinkState: "up", MAC: "ee:4b:0a:1c:2d:3e",
+	}
+	if !reflect.DeepEqual(ifaces[2], vx) {
+		t.Errorf("ParseInterfaces()[2] = %+v, want %+v", ifaces[2], vx)
+	}
+	if ifaces[3].Error != "could not open network device br1" || ifaces[3].OFPort != -1 || ifaces[3].LinkState != "" {
+		t.Errorf("unexpected failed interface: %+v", ifaces[3])
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, sample := range []string{"", "not json", `{"data":[["a"]],"headings":["_uuid","name"]}`} {
+		if _, err := ParseBridges(sample); err == nil {
+			t.Errorf("expected error for %q but got none", sample)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	bridges, _ := ParseBridges(bridgeJSON)
+	ports, _ := ParsePorts(portJSON)
+	ifaces, _ := ParseInterfaces(interfaceJSON)
+
+	got, err := json.MarshalIndent(Resolve(bridges, ports, ifaces), "", "  ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	golden := `[
+  {
+    "name": "br0",
+    "ports": [
+      {
+        "name": "em1",
+        "tag": 10,
+        "interfaces": [
+          {
+            "uuid": "a1a1a1a1-0000-4000-8000-000000000001",
+            "name": "em1",
+            "ofport": 1,
+            "admin_state": "up",
+            "link_state": "up",
+            "mac_in_use": "58:9c:fc:00:00:01"
+          }
+        ]
+      },
+      {
+        "name": "em2",
+        "trunks": [
+          20,
+          30
+        ],
+        "interfaces": [
+          {
+            "uuid": "a1a1a1a1-0000-4000-8000-000000000002",
+            "name": "em2",
+            "ofport": 2,
+            "admin_state": "up",
+            "link_state": "down",
+            "mac_in_use": "58:9c:fc:00:00:02"
+          }
+        ]
+      },
+      {
+        "name": "vx0",
+        "interfaces": [
+          {
+            "uuid": "a1a1a1a1-0000-4000-8000-000000000003",
+            "name": "vx0",
+            "type": "vxlan",
+            "options": {
+              "key": "100",
+              "remote_ip": "192.0.2.2"
+            },
+            "ofport": 3,
+            "admin_state": "up",
+            "link_state": "up",
+            "mac_in_use": "ee:4b:0a:1c:2d:3e"
+          }
+        ]
+      }
+    ]
+  },
+  {
+    "name": "br1",
+    "fail_mode": "secure",
+    "ports": [
+      {
+        "name": "br1",
+        "interfaces": [
+          {
+            "uuid": "a1a1a1a1-0000-4000-8000-000000000004",
+            "name": "br1",
+            "type": "internal",
+            "ofport": -1,
+            "admin_state": "down",
+            "error": "could not open network device br1"
+          }
+        ]
+      }
+    ]
+  }
+]`
+	if string(got) != golden {
+		t.Errorf("Resolve() JSON =\n%s\nwant\n%s", got, golden)
+	}
+}
@@ -0,0 +1,327 @@
+package pf
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// statsRegex matches the counters of "pfctl -vvsr", e.g. "Packets: 12"
+var statsRegex = regexp.MustCompile(`(Evaluations|Packets|Bytes|States|State Creations):\s+([0-9]+)`)
+
+// ParseRules parses the filter rules listed by "pfctl -sr", "-vsr" or
+// "-vvsr". Verbose listings number the rules and add their counters:
+//
+//	@0 pass in quick on em0 inet proto tcp from any to any port = ssh flags S/SA keep state label "ssh"
+//	  [ Evaluations: 120       Packets: 35        Bytes: 4218        States: 1     ]
+//	  [ Inserted: uid 0 pid 4120 State Creations: 2     ]
+//
+// Anchor calls, scrub rules and pfctl warnings are skipped.
+func ParseRules(output string) ([]Rule, error) {
+	var rules []Rule
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			if len(rules) > 0 {
+				parseStats(&rules[len(rules)-1], line)
+			}
+			continue
+		}
+		nr, line := ruleNumber(line, len(rules))
+		tokens := tokenize(line)
+		if len(tokens) == 0 {
+			continue
+		}
+		switch tokens[0] {
+		case ActionPass, ActionBlock, ActionMatch:
+		default:
+			continue
+		}
+		r, err := parseRule(tokens)
+		if err != nil {
+			return nil, fmt.Errorf("%w in %q", err, line)
+		}
+		r.Number = nr
+		rules = append(rules, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scanner error: %w", err)
+	}
+	return rules, nil
+}
+
+// ParseTranslations parses the nat and rdr rules listed by "pfctl -sn":
+//
+//	nat on em0 inet from 10.0.0.0/24 to any -> (em0) round-robin static-port
+//	rdr pass on em0 inet proto tcp from any to any port = http -> 10.0.0.5 port 8080
+//
+// "no nat", binat and anchor calls are skipped.
+func ParseTranslations(output string) ([]Translation, error) {
+	var translations []Translation
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		nr, line := ruleNumber(strings.TrimSpace(scanner.Text()), len(translations))
+		tokens := tokenize(line)
+		if len(tokens) == 0 || (tokens[0] != TypeNAT && tokens[0] != TypeRDR) {
+			continue
+		}
+		t, err := parseTranslation(tokens)
+		if err != nil {
+			return nil, fmt.Errorf("%w in %q", err, line)
+		}
+		t.Number = nr
+		translations = append(translations, t)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scanner error: %w", err)
+	}
+	return translations, nil
+}
+
+// ParseTables parses "pfctl -s Tables", one name per line, or its verbose
+// form "pfctl -vs Tables" with the table flags and anchor:
+//
+//	-pa-r--	jails	fcom
+func ParseTables(output string) []Table {
+	var tables []Table
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		switch {
+		case len(fields) == 1 && nameRegex.MatchString(fields[0]):
+			tables = append(tables, Table{Name: fields[0]})
+		case len(fields) >= 2 && len(fields[0]) == 7 && strings.Trim(fields[0], "-cpairh") == "":
+			t := Table{Name: fields[1], Const: fields[0][0] == 'c', Persist: fields[0][1] == 'p'}
+			if len(fields) > 2 {
+				t.Anchor = fields[2]
+			}
+			tables = append(tables, t)
+		}
+	}
+	return tables
+}
+
+// ParseTableAddresses parses "pfctl -t <table> -T show", one address or
+// prefix per line.
+func ParseTableAddresses(output string) []string {
+	var addrs []string
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		addr := strings.Join(strings.Fields(scanner.Text()), "")
+		if addr != "" && ValidateTableAddress(addr) == nil {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
+
+// ruleNumber strips the "@nr" prefix of verbose listings, returning def
+// for lines without one.
+func ruleNumber(line string, def int) (int, string) {
+	if !strings.HasPrefix(line, "@") {
+		return def, line
+	}
+	num, rest, _ := strings.Cut(line[1:], " ")
+	nr, err := strconv.Atoi(num)
+	if err != nil {
+		return def, line
+	}
+	return nr, strings.TrimSpace(rest)
+}
+
+func parseStats(r *Rule, line string) {
+	for _, m := range statsRegex.FindAllStringSubmatch(line, -1) {
+		if r.Stats == nil {
+			r.Stats = &RuleStats{}
+		}
+		n, _ := strconv.ParseUint(m[2], 10, 64)
+		switch m[1] {
+		case "Evaluations":
+			r.Stats.Evaluations = n
+		case "Packets":
+			r.Stats.Packets = n
+		case "Bytes":
+			r.Stats.Bytes = n
+		case "States":
+			r.Stats.States = n
+		case "State Creations":
+			r.Stats.StateCreations = n
+		}
+	}
+}
+
+// ruleParser walks the tokens of a rule
+type ruleParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *ruleParser) next() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	t := p.tokens[p.pos]
+	p.pos++
+	return t
+}
+
+func (p *ruleParser) peek() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	return p.tokens[p.pos]
+}
+
+// match parses the options shared by filter and translation rules, returning
+// false for tokens it does not know.
+func (p *ruleParser) match(tok string, iface, family, proto, from, fromPort, to, toPort *string) bool {
+	switch tok {
+	case "on":
+		*iface = p.address()
+	case inet, inet6:
+		*family = tok
+	case "proto":
+		*proto = p.next()
+	case "all":
+	case "from":
+		*from, *fromPort = p.endpoint()
+	case "to":
+		*to, *toPort = p.endpoint()
+	default:
+		return false
+	}
+	return true
+}
+
+// address reads an address, joining the "!" pfctl prints separately
+func (p *ruleParser) address() string {
+	addr := p.next()
+	if addr == "!" {
+		addr += p.next()
+	}
+	if addr == anyAddr {
+		return ""
+	}
+	return addr
+}
+
+// endpoint reads an address and its optional port
+func (p *ruleParser) endpoint() (string, string) {
+	addr := p.address()
+	if p.peek() != "port" {
+		return addr, ""
+	}
+	p.next()
+	return addr, p.port()
+}
+
+// port reads a port: "= 22", "!= 22", "> 1024", "80:90" or "1000 >< 2000"
+func (p *ruleParser) port() string {
+	port := p.next()
+	switch port {
+	case "=":
+		port = p.next()
+	case "!=", "<", "<=", ">", ">=":
+		port += " " + p.next()
+	}
+	if op := p.peek(); op == "><" || op == "<>" {
+		p.next()
+		port += " " + op + " " + p.next()
+	}
+	return port
+}
+
+func parseRule(tokens []string) (Rule, error) {
+	p := &ruleParser{tokens: tokens}
+	r := Rule{Action: p.next()}
+	for tok := p.next(); tok != ""; tok = p.next() {
+		if p.match(tok, &r.Interface, &r.Family, &r.Proto, &r.From, &r.FromPort, &r.To, &r.ToPort) {
+			continue
+		}
+		switch tok {
+		case "drop":
+		case "return", "return-rst", "return-icmp", "return-icmp6":
+			r.Action = ActionBlockReturn
+		case "in", "out":
+			r.Direction = tok
+		case "log":
+			r.Log = true
+		case "quick":
+			r.Quick = true
+		case "flags":
+			r.Flags = p.next()
+		case "keep", "modulate", "synproxy", "no":
+			if p.peek() == "state" {
+				p.next()
+				r.State = tok
+			}
+		case "label":
+			r.Label = strings.Trim(p.next(), `"`)
+		}
+	}
+	if r.Action == "" {
+		return r, fmt.Errorf("missing action")
+	}
+	return r, nil
+}
+
+func parseTranslation(tokens []string) (Translation, error) {
+	p := &ruleParser{tokens: tokens}
+	t := Translation{Type: p.next()}
+	for tok := p.next(); tok != ""; tok = p.next() {
+		if p.match(tok, &t.Interface, &t.Family, &t.Proto, &t.From, &t.FromPort, &t.To, &t.ToPort) {
+			continue
+		}
+		switch tok {
+		case "pass":
+			t.Pass = true
+		case "log":
+			t.Log = true
+		case "->":
+			t.Target = p.next()
+			if p.peek() == "port" {
+				p.next()
+				t.TargetPort = p.port()
+			}
+		case "static-port":
+			t.StaticPort = true
+		}
+	}
+	if t.Target == "" {
+		return t, fmt.Errorf("missing translation target")
+	}
+	return t, nil
+}
+
+// tokenize splits a rule into words, keeping quoted strings and parenthesized
+// groups such as "(max 100, source-track)" together.
+func tokenize(line string) []string {
+	var tokens []string
+	var cur strings.Builder
+	depth, quoted := 0, false
+	for _, c := range line {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			depth++
+		case c == ')' && depth > 0:
+			depth--
+		case (c == ' ' || c == '\t') && depth == 0:
+			if cur.Len() > 0 {
+				tokens = append(tokens, cur.String())
+				cur.Reset()
+			}
+			continue
+		}
+		cur.WriteRune(c)
+	}
+	if cur.Len() > 0 {
+		tokens = append(tokens, cur.String())
+	}
+	return tokens
+}
@@ -0,0 +1,108 @@
+package pf
+
+import (
+	"reflect"
+	"testing"
+)
+
+const rulesVerbose = `No ALTQ support in kernel
+ALTQ related functions disabled
+@0 block drop in log quick on ! lo0 inet from 127.0.0.0/8 to any
+  [ Evaluations: 1200      Packets: 0         Bytes: 0           States: 0     ]
+  [ Inserted: uid 0 pid 4120 State Creations: 0     ]
+  [ Last Active Time: N/A ]
+@1 pass in quick on em0 inet proto tcp from any to <jails> port = ssh flags S/SA keep state (max 100, source-track rule) label "ssh in"
+  [ Evaluations: 120       Packets: 35        Bytes: 4218        States: 1     ]
+  [ Inserted: uid 0 pid 4120 State Creations: 2     ]
+@2 block return out on em0 inet6 proto udp from ! fe80::/10 port 1000 >< 2000 to any port > 1024
+  [ Evaluations: 7         Packets: 0         Bytes: 0           States: 0     ]
+  [ Inserted: uid 0 pid 4120 State Creations: 0     ]
+@3 pass all flags S/SA keep state
+  [ Evaluations: 50        Packets: 800       Bytes: 96000       States: 3     ]
+  [ Inserted: uid 0 pid 4120 State Creations: 9     ]
+`
+
+func TestParseRules_Verbose(t *testing.T) {
+	rules, err := ParseRules(rulesVerbose)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []Rule{
+		{Number: 0, Action: ActionBlock, Direction: "in", Log: true, Quick: true, Interface: "!lo0", Family: "inet", From: "127.0.0.0/8",
+			Stats: &RuleStats{Evaluations: 1200}},
+		{Number: 1, Action: ActionPass, Direction: "in", Quick: true, Interface: "em0", Family: "inet", Proto: "tcp", To: "<jails>",
+			ToPort: "ssh", Flags: "S/SA", State: "keep", Label: "ssh in",
+			Stats: &RuleStats{Evaluations: 120, Packets: 35, Bytes: 4218, States: 1, StateCreations: 2}},
+		{Number: 2, Action: ActionBlockReturn, Direction: "out", Interface: "em0", Family: "inet6", Proto: "udp",
+			From: "!fe80::/10", FromPort: "1000 >< 2000", ToPort: "> 1024", Stats: &RuleStats{Evaluations: 7}},
+		{Number: 3, Action: ActionPass, Flags: "S/SA", State: "keep",
+			Stats: &RuleStats{Evaluations: 50, Packets: 800, Bytes: 96000, States: 3, StateCreations: 9}},
+	}
+	if !reflect.DeepEqual(rules, expected) {
+		t.Errorf("expected:\n%+v\ngot:\n%+v", expected, rules)
+	}
+}
+
+func TestParseRules_Plain(t *testing.T) {
+	rules, err := ParseRules(`scrub in all fragment reassemble
+anchor "fcom" all
+block drop all
+pass out proto udp from any to 192.0.2.53 port = domain keep state
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []Rule{
+		{Number: 0, Action: ActionBlock},
+		{Number: 1, Action: ActionPass, Direction: "out", Proto: "udp", To: "192.0.2.53", ToPort: "domain", State: "keep"},
+	}
+	if !reflect.DeepEqual(rules, expected) {
+		t.Errorf("expected %+v, got %+v", expected, rules)
+	}
+}
+
+func TestParseTranslations(t *testing.T) {
+	translations, err := ParseTranslations(`nat-anchor "fcom" all
+rdr-anchor "fcom" all
+no nat on em0 inet from 10.0.0.1 to any
+nat on em0 inet from 10.0.0.0/24 to any -> (em0) round-robin static-port
+rdr pass log on em0 inet proto tcp from any to any port = http -> 10.0.0.5 port 8080
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []Translation{
+		{Number: 0, Type: TypeNAT, Interface: "em0", Family: "inet", From: "10.0.0.0/24", Target: "(em0)", StaticPort: true},
+		{Number: 1, Type: TypeRDR, Pass: true, Log: true, Interface: "em0", Family: "inet", Proto: "tcp", ToPort: "http",
+			Target: "10.0.0.5", TargetPort: "8080"},
+	}
+	if !reflect.DeepEqual(translations, expected) {
+		t.Errorf("expected %+v, got %+v", expected, translations)
+	}
+	if _, err := ParseTranslations("nat on em0 from any to any\n"); err == nil {
+		t.Error("expected error for a nat rule without target")
+	}
+}
+
+func TestParseTables(t *testing.T) {
+	tables := ParseTables("jails\nbruteforce\n")
+	if !reflect.DeepEqual(tables, []Table{{Name: "jails"}, {Name: "bruteforce"}}) {
+		t.Errorf("unexpected tables: %+v", tables)
+	}
+	tables = ParseTables("-pa-r--\tjails\tfcom/web\nc-a----\tbogons\n")
+	expected := []Table{
+		{Name: "jails", Persist: true, Anchor: "fcom/web"},
+		{Name: "bogons", Const: true},
+	}
+	if !reflect.DeepEqual(tables, expected) {
+		t.Errorf("expected %+v, got %+v", expected, tables)
+	}
+}
+
+func TestParseTableAddresses(t *testing.T) {
+	addrs := ParseTableAddresses("No ALTQ support in kernel\n   10.0.0.2\n   10.0.1.0/24\n   2001:db8::5\n   ! 10.0.0.9\n")
+	expected := []string{"10.0.0.2", "10.0.1.0/24", "2001:db8::5", "!10.0.0.9"}
+	if !reflect.DeepEqual(addrs, expected) {
+		t.Errorf("expected %v, got %v", expected, addrs)
+	}
+}
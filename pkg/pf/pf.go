@@ -0,0 +1,304 @@
+// Package pf models pf(4) filter rules, translation rules and tables,
+// renders them in pf.conf(5) syntax and parses the pfctl(8) listings of
+// loaded rules, tables and rule counters.
+package pf
+
+import (
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strings"
+)
+
+// Rule actions
+const (
+	ActionPass        = "pass"
+	ActionBlock       = "block"        // block drop
+	ActionBlockReturn = "block return" // TCP RST or ICMP unreachable
+	ActionMatch       = "match"
+)
+
+// Translation types
+const (
+	TypeNAT = "nat"
+	TypeRDR = "rdr"
+)
+
+const (
+	anyAddr = "any"
+	inet    = "inet"
+	inet6   = "inet6"
+)
+
+var (
+	// nameRegex matches interface, table, protocol and service names
+	nameRegex = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+	// portRegex matches a port or port range as written after "port"
+	portRegex = regexp.MustCompile(`^(?:(?:!=|<=|>=|<|>) )?[A-Za-z0-9_-]+(?::[0-9]+| (?:><|<>) [0-9]+)?$`)
+	// flagsRegex matches TCP flags such as "S/SA"
+	flagsRegex = regexp.MustCompile(`^[FSRPAUEW]*/[FSRPAUEW]+$|^any$`)
+	// labelRegex keeps labels printable and free of quotes
+	labelRegex = regexp.MustCompile(`^[^"\\\n]{1,63}$`)
+)
+
+// Rule is a pf filter rule. Empty fields are omitted from the rule; empty
+// From and To mean any.
+type Rule struct {
+	Number    int        `json:"nr"` // position in the ruleset, "@nr" in pfctl -v output
+	Action    string     `json:"action"`
+	Direction string     `json:"direction,omitempty"` // in or out, both if empty
+	Log       bool       `json:"log,omitempty"`
+	Quick     bool       `json:"quick,omitempty"`
+	Interface string     `json:"interface,omitempty"`
+	Family    string     `json:"family,omitempty"` // inet or inet6
+	Proto     string     `json:"proto,omitempty"`
+	From      string     `json:"from,omitempty"`
+	FromPort  string     `json:"from_port,omitempty"`
+	To        string     `json:"to,omitempty"`
+	ToPort    string     `json:"to_port,omitempty"`
+	Flags     string     `json:"flags,omitempty"` // e.g. S/SA
+	State     string     `json:"state,omitempty"` // keep, modulate, synproxy or no
+	Label     string     `json:"label,omitempty"`
+	Stats     *RuleStats `json:"stats,omitempty"` // counters of a loaded rule
+}
+
+// RuleStats holds the counters printed by "pfctl -vvsr"
+type RuleStats struct {
+	Evaluations    uint64 `json:"evaluations"`
+	Packets        uint64 `json:"packets"`
+	Bytes          uint64 `json:"bytes"`
+	States         uint64 `json:"states"`
+	StateCreations uint64 `json:"state_creations"`
+}
+
+// Translation is a nat or rdr rule. Packets matching the rule get Target
+// as source (nat) or destination (rdr) address, and TargetPort as
+// destination port (rdr).
+type Translation struct {
+	Number     int    `json:"nr"`
+	Type       string `json:"type"`           // nat or rdr
+	Pass       bool   `json:"pass,omitempty"` // skip the filter rules
+	Log        bool   `json:"log,omitempty"`
+	Interface  string `json:"interface"`
+	Family     string `json:"family,omitempty"`
+	Proto      string `json:"proto,omitempty"`
+	From       string `json:"from,omitempty"`
+	FromPort   string `json:"from_port,omitempty"`
+	To         string `json:"to,omitempty"`
+	ToPort     string `json:"to_port,omitempty"`
+	Target     string `json:"target"`
+	TargetPort string `json:"target_port,omitempty"`
+	StaticPort bool   `json:"static_port,omitempty"` // nat: keep source ports
+}
+
+// Table is a pf table. Tables written by fcom are persistent so they can be
+// filled before a rule refers to them.
+type Table struct {
+	Name      string   `json:"name"`
+	Persist   bool     `json:"persist,omitempty"`
+	Const     bool     `json:"const,omitempty"`
+	Anchor    string   `json:"anchor,omitempty"` // from "pfctl -vs Tables"
+	Addresses []string `json:"addresses,omitempty"`
+}
+
+// Ruleset is the content of an anchor
+type Ruleset struct {
+	Tables       []Table       `json:"tables,omitempty"`
+	Translations []Translation `json:"translations,omitempty"`
+	Rules        []Rule        `json:"rules,omitempty"`
+}
+
+// Validate checks a rule before it is rendered into pf.conf
+func (r Rule) Validate() error {
+	switch r.Action {
+	case ActionPass, ActionBlock, ActionBlockReturn, ActionMatch:
+	default:
+		return fmt.Errorf("invalid action %q: must be pass, block, block return or match", r.Action)
+	}
+	if r.Direction != "" && r.Direction != "in" && r.Direction != "out" {
+		return fmt.Errorf("invalid direction %q: must be in or out", r.Direction)
+	}
+	if err := validateMatch(r.Interface, r.Family, r.Proto, r.From, r.FromPort, r.To, r.ToPort); err != nil {
+		return err
+	}
+	if r.Flags != "" && !flagsRegex.MatchString(r.Flags) {
+		return fmt.Errorf("invalid TCP flags %q", r.Flags)
+	}
+	switch r.State {
+	case "", "keep", "modulate", "synproxy", "no":
+	default:
+		return fmt.Errorf("invalid state %q: must be keep, modulate, synproxy or no", r.State)
+	}
+	if r.Label != "" && !labelRegex.MatchString(r.Label) {
+		return fmt.Errorf("invalid label %q", r.Label)
+	}
+	return nil
+}
+
+// Validate checks a translation rule before it is rendered into pf.conf
+func (t Translation) Validate() error {
+	if t.Type != TypeNAT && t.Type != TypeRDR {
+		return fmt.Errorf("invalid translation %q: must be nat or rdr", t.Type)
+	}
+	if t.Interface == "" {
+		return fmt.Errorf("%s rule requires an interface", t.Type)
+	}
+	if err := validateMatch(t.Interface, t.Family, t.Proto, t.From, t.FromPort, t.To, t.ToPort); err != nil {
+		return err
+	}
+	if t.Target == "" {
+		return fmt.Errorf("%s rule requires a target address", t.Type)
+	}
+	if err := validateAddress(t.Target); err != nil {
+		return err
+	}
+	if t.TargetPort != "" && (t.Type != TypeRDR || !portRegex.MatchString(t.TargetPort)) {
+		return fmt.Errorf("invalid target port %q: only rdr rules redirect to a port", t.TargetPort)
+	}
+	if t.StaticPort && t.Type != TypeNAT {
+		return fmt.Errorf("static-port only applies to nat rules")
+	}
+	return nil
+}
+
+// Validate checks a table before it is rendered into pf.conf
+func (t Table) Validate() error {
+	if !nameRegex.MatchString(t.Name) {
+		return fmt.Errorf("invalid table name %q", t.Name)
+	}
+	for _, a := range t.Addresses {
+		if err := ValidateTableAddress(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateTableAddress checks a table entry: an address or prefix, optionally
+// negated with "!".
+func ValidateTableAddress(addr string) error {
+	if _, err := parsePrefix(strings.TrimPrefix(addr, "!")); err != nil {
+		return fmt.Errorf("invalid table address %q: must be an IP address or prefix", addr)
+	}
+	return nil
+}
+
+// Validate checks every table, translation and rule of the ruleset
+func (rs *Ruleset) Validate() error {
+	seen := make(map[string]bool, len(rs.Tables))
+	for _, t := range rs.Tables {
+		if err := t.Validate(); err != nil {
+			return err
+		}
+		if seen[t.Name] {
+			return fmt.Errorf("duplicate table %s", t.Name)
+		}
+		seen[t.Name] = true
+	}
+	for _, t := range rs.Translations {
+		if err := t.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, r := range rs.Rules {
+		if err := r.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Table returns the table called name, or nil
+func (rs *Ruleset) Table(name string) *Table {
+	for i := range rs.Tables {
+		if rs.Tables[i].Name == name {
+			return &rs.Tables[i]
+		}
+	}
+	return nil
+}
+
+// Renumber sets the number of every rule and translation to its position
+func (rs *Ruleset) Renumber() {
+	for i := range rs.Translations {
+		rs.Translations[i].Number = i
+	}
+	for i := range rs.Rules {
+		rs.Rules[i].Number = i
+	}
+}
+
+// validateMatch checks the fields shared by filter and translation rules
+func validateMatch(iface, family, proto, from, fromPort, to, toPort string) error {
+	if iface != "" && !nameRegex.MatchString(strings.TrimPrefix(iface, "!")) {
+		return fmt.Errorf("invalid interface %q", iface)
+	}
+	if family != "" && family != inet && family != inet6 {
+		return fmt.Errorf("invalid address family %q: must be inet or inet6", family)
+	}
+	if proto != "" && !nameRegex.MatchString(proto) {
+		return fmt.Errorf("invalid protocol %q", proto)
+	}
+	for _, addr := range []string{from, to} {
+		if addr != "" {
+			if err := validateAddress(addr); err != nil {
+				return err
+			}
+		}
+	}
+	for _, port := range []string{fromPort, toPort} {
+		if port == "" {
+			continue
+		}
+		if !portRegex.MatchString(port) {
+			return fmt.Errorf("invalid port %q", port)
+		}
+		if proto != "tcp" && proto != "udp" {
+			return fmt.Errorf("ports require proto tcp or udp")
+		}
+	}
+	return nil
+}
+
+// validateAddress checks a rule address: any, self, an address or prefix, a
+// table as <name> or the addresses of an interface as (name), optionally
+// negated with "!".
+func validateAddress(addr string) error {
+	a := strings.TrimPrefix(addr, "!")
+	switch {
+	case a == anyAddr || a == "self":
+		return nil
+	case strings.HasPrefix(a, "<") && strings.HasSuffix(a, ">"):
+		if nameRegex.MatchString(a[1 : len(a)-1]) {
+			return nil
+		}
+	case strings.HasPrefix(a, "(") && strings.HasSuffix(a, ")"):
+		if nameRegex.MatchString(a[1 : len(a)-1]) {
+			return nil
+		}
+	default:
+		if _, err := parsePrefix(a); err == nil {
+			return nil
+		}
+		if nameRegex.MatchString(a) {
+			return nil // interface name, meaning its addresses
+		}
+	}
+	return fmt.Errorf("invalid address %q", addr)
+}
+
+// parsePrefix parses an address or prefix
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid prefix: %w", err)
+		}
+		return p, nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid address: %w", err)
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
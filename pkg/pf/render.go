@@ -0,0 +1,123 @@
+package pf
+
+import "strings"
+
+// Render returns the ruleset in pf.conf syntax: tables, then translation
+// rules, then filter rules, as pf.conf requires.
+func Render(rs *Ruleset) string {
+	var b strings.Builder
+	for _, t := range rs.Tables {
+		b.WriteString(t.String())
+		b.WriteByte('\n')
+	}
+	for _, t := range rs.Translations {
+		b.WriteString(t.String())
+		b.WriteByte('\n')
+	}
+	for _, r := range rs.Rules {
+		b.WriteString(r.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// String returns the rule in pf.conf syntax
+//
+//	pass in quick on em0 inet proto tcp from any to <jails> port 22 flags S/SA keep state label "ssh"
+func (r Rule) String() string {
+	parts := []string{r.Action}
+	if r.Direction != "" {
+		parts = append(parts, r.Direction)
+	}
+	if r.Log {
+		parts = append(parts, "log")
+	}
+	if r.Quick {
+		parts = append(parts, "quick")
+	}
+	parts = appendMatch(parts, r.Interface, r.Family, r.Proto, r.From, r.FromPort, r.To, r.ToPort)
+	if r.Flags != "" {
+		parts = append(parts, "flags", r.Flags)
+	}
+	if r.State != "" {
+		parts = append(parts, r.State, "state")
+	}
+	if r.Label != "" {
+		parts = append(parts, "label", `"`+r.Label+`"`)
+	}
+	return strings.Join(parts, " ")
+}
+
+// String returns the translation rule in pf.conf syntax
+//
+//	nat on em0 inet from 10.0.0.0/24 to any -> (em0) static-port
+//	rdr pass on em0 inet proto tcp from any to any port 80 -> 10.0.0.5 port 8080
+func (t Translation) String() string {
+	parts := []string{t.Type}
+	if t.Pass {
+		parts = append(parts, "pass")
+	}
+	if t.Log {
+		parts = append(parts, "log")
+	}
+	parts = appendMatch(parts, t.Interface, t.Family, t.Proto, t.From, t.FromPort, t.To, t.ToPort)
+	parts = append(parts, "->", t.Target)
+	if t.TargetPort != "" {
+		parts = append(parts, "port", t.TargetPort)
+	}
+	if t.StaticPort {
+		parts = append(parts, "static-port")
+	}
+	return strings.Join(parts, " ")
+}
+
+// String returns the table definition in pf.conf syntax
+//
+//	table <jails> persist { 10.0.0.2 10.0.0.3 }
+func (t Table) String() string {
+	parts := []string{"table", "<" + t.Name + ">"}
+	if t.Persist {
+		parts = append(parts, "persist")
+	}
+	if t.Const {
+		parts = append(parts, "const")
+	}
+	if len(t.Addresses) > 0 {
+		parts = append(parts, "{", strings.Join(t.Addresses, " "), "}")
+	}
+	return strings.Join(parts, " ")
+}
+
+// appendMatch appends the interface, family, protocol and addresses shared
+// by filter and translation rules. A rule from and to any matches "all".
+func appendMatch(parts []string, iface, family, proto, from, fromPort, to, toPort string) []string {
+	if iface != "" {
+		parts = append(parts, "on", iface)
+	}
+	if family != "" {
+		parts = append(parts, family)
+	}
+	if proto != "" {
+		parts = append(parts, "proto", proto)
+	}
+	if isAny(from) && isAny(to) && fromPort == "" && toPort == "" {
+		return append(parts, "all")
+	}
+	parts = appendAddress(parts, "from", from, fromPort)
+	return appendAddress(parts, "to", to, toPort)
+}
+
+func appendAddress(parts []string, keyword, addr, port string) []string {
+	if addr == "" {
+		addr = anyAddr
+	}
+	parts = append(parts, keyword, addr)
+	if port != "" {
+		parts = append(parts, "port", port)
+	}
+	return parts
+}
+
+func isAny(addr string) bool {
+	return addr == "" || addr == anyAddr
+}
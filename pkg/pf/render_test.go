@@ -0,0 +1,81 @@
+package pf
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testRuleset() *Ruleset {
+	return &Ruleset{
+		Tables: []Table{{Name: "jails", Persist: true, Addresses: []string{"10.0.0.2", "10.0.0.3"}}},
+		Translations: []Translation{
+			{Type: TypeNAT, Interface: "em0", Family: "inet", From: "<jails>", Target: "(em0)", StaticPort: true},
+			{Type: TypeRDR, Pass: true, Interface: "em0", Family: "inet", Proto: "tcp", ToPort: "80", Target: "10.0.0.2", TargetPort: "8080"},
+		},
+		Rules: []Rule{
+			{Action: ActionBlock, Log: true},
+			{Action: ActionPass, Direction: "in", Quick: true, Interface: "em0", Proto: "tcp", To: "<jails>", ToPort: "22",
+				Flags: "S/SA", State: "keep", Label: "ssh"},
+			{Action: ActionBlockReturn, Direction: "out", Family: "inet6", Proto: "udp", From: "!fe80::/10", ToPort: "> 1024"},
+		},
+	}
+}
+
+func TestRender(t *testing.T) {
+	expected := `table <jails> persist { 10.0.0.2 10.0.0.3 }
+nat on em0 inet from <jails> to any -> (em0) static-port
+rdr pass on em0 inet proto tcp from any to any port 80 -> 10.0.0.2 port 8080
+block log all
+pass in quick on em0 proto tcp from any to <jails> port 22 flags S/SA keep state label "ssh"
+block return out inet6 proto udp from !fe80::/10 to any port > 1024
+`
+	if got := Render(testRuleset()); got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+// TestRenderParse checks that rendered rules read back unchanged.
+func TestRenderParse(t *testing.T) {
+	rs := testRuleset()
+	rs.Renumber()
+	conf := Render(rs)
+
+	rules, err := ParseRules(conf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(rules, rs.Rules) {
+		t.Errorf("expected %+v, got %+v", rs.Rules, rules)
+	}
+	translations, err := ParseTranslations(conf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(translations, rs.Translations) {
+		t.Errorf("expected %+v, got %+v", rs.Translations, translations)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := testRuleset().Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	invalid := []*Ruleset{
+		{Rules: []Rule{{Action: "allow"}}},
+		{Rules: []Rule{{Action: ActionPass, Direction: "both"}}},
+		{Rules: []Rule{{Action: ActionPass, To: "10.0.0.1\npass all"}}},
+		{Rules: []Rule{{Action: ActionPass, ToPort: "22"}}},
+		{Rules: []Rule{{Action: ActionPass, Proto: "tcp", ToPort: "22 }"}}},
+		{Rules: []Rule{{Action: ActionPass, Label: `a"b`}}},
+		{Translations: []Translation{{Type: TypeNAT, Target: "(em0)"}}},
+		{Translations: []Translation{{Type: TypeNAT, Interface: "em0", Target: "(em0)", TargetPort: "80"}}},
+		{Tables: []Table{{Name: "jails", Addresses: []string{"host.example"}}}},
+		{Tables: []Table{{Name: "jails"}, {Name: "jails"}}},
+	}
+	for _, rs := range invalid {
+		if err := rs.Validate(); err == nil {
+			t.Errorf("expected error for %s", strings.TrimSpace(Render(rs)))
+		}
+	}
+}
@@ -0,0 +1,215 @@
+// Package rcconf reads and edits FreeBSD rc.conf style files while preserving
+// comments, blank lines and variables it does not touch.
+package rcconf
+
+import (
+	"FreeBSD-Command-manager/pkg/atomicfile"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// FilePermissions is the mode used when creating a new rc.conf file
+const FilePermissions = 0o644
+
+// assignRegex matches "name=value" lines, ignoring leading whitespace.
+var assignRegex = regexp.MustCompile(`^\s*([A-Za-z_][A-Za-z0-9_]*)=(.*)$`)
+
+// line is a single line of an rc.conf file. Key is empty for comments,
+// blank lines and anything that is not a plain assignment.
+type line struct {
+	raw   string
+	key   string
+	value string
+}
+
+// File is an editable rc.conf file.
+type File struct {
+	lines []line
+}
+
+// Change describes a variable added, changed or deleted by an edit.
+type Change struct {
+	Key    string `json:"key"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+	Action string `json:"action"` // add, change or delete
+}
+
+// Parse parses rc.conf content.
+func Parse(data string) *File {
+	f := &File{}
+	data = strings.TrimSuffix(data, "\n")
+	if data == "" {
+		return f
+	}
+	for _, raw := range strings.Split(data, "\n") {
+		l := line{raw: raw}
+		if m := assignRegex.FindStringSubmatch(raw); m != nil {
+			l.key = m[1]
+			l.value = unquote(m[2])
+		}
+		f.lines = append(f.lines, l)
+	}
+	return f
+}
+
+// Load reads an rc.conf file. A missing file is treated as empty.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // path is provided by the operator
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &File{}, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return Parse(string(data)), nil
+}
+
+// Get returns the value of the last assignment of key, as sh would see it.
+func (f *File) Get(key string) (string, bool) {
+	value, found := "", false
+	for _, l := range f.lines {
+		if l.key == key {
+			value, found = l.value, true
+		}
+	}
+	return value, found
+}
+
+// Keys returns the assigned variable names in file order, without duplicates.
+func (f *File) Keys() []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, l := range f.lines {
+		if l.key != "" && !seen[l.key] {
+			seen[l.key] = true
+			keys = append(keys, l.key)
+		}
+	}
+	return keys
+}
+
+// Set assigns value to key, rewriting the first assignment in place and
+// dropping later duplicates, or appending a new line.
+func (f *File) Set(key, value string) {
+	newLine := line{raw: key + "=" + quote(value), key: key, value: value}
+	var lines []line
+	replaced := false
+	for _, l := range f.lines {
+		if l.key == key {
+			if !replaced {
+				lines = append(lines, newLine)
+				replaced = true
+			}
+			continue
+		}
+		lines = append(lines, l)
+	}
+	if !replaced {
+		lines = append(lines, newLine)
+	}
+	f.lines = lines
+}
+
+// Delete removes every assignment of key.
+func (f *File) Delete(key string) {
+	var lines []line
+	for _, l := range f.lines {
+		if l.key != key {
+			lines = append(lines, l)
+		}
+	}
+	f.lines = lines
+}
+
+// String returns the file content.
+func (f *File) String() string {
+	var b strings.Builder
+	for _, l := range f.lines {
+		b.WriteString(l.raw)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Apply sets each key of vars (deleting those mapped to nil) and returns the
+// resulting changes in the order of keys.
+func (f *File) Apply(keys []string, vars map[string]*string) []Change {
+	var changes []Change
+	for _, key := range keys {
+		before, exists := f.Get(key)
+		after := vars[key]
+		switch {
+		case after == nil && exists:
+			f.Delete(key)
+			changes = append(changes, Change{Key: key, Before: before, Action: "delete"})
+		case after == nil:
+		case !exists:
+			f.Set(key, *after)
+			changes = append(changes, Change{Key: key, After: *after, Action: "add"})
+		case before != *after:
+			f.Set(key, *after)
+			changes = append(changes, Change{Key: key, Before: before, After: *after, Action: "change"})
+		}
+	}
+	return changes
+}
+
+// WriteFile atomically replaces path with the file content, keeping the
+// mode of an existing file.
+func (f *File) WriteFile(path string) error {
+	mode := os.FileMode(FilePermissions)
+	if st, err := os.Stat(path); err == nil {
+		mode = st.Mode().Perm()
+	}
+	return atomicfile.WriteFile(path, []byte(f.String()), mode)
+}
+
+// Name converts an interface name to the form used in rc.conf variable
+// names; rc(8) replaces ".", "-", "/" and "+" with "_".
+func Name(iface string) string {
+	return strings.NewReplacer(".", "_", "-", "_", "/", "_", "+", "_").Replace(iface)
+}
+
+// unquote strips a trailing comment and surrounding quotes from a raw value.
+func unquote(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return ""
+	}
+	switch raw[0] {
+	case '"':
+		var b strings.Builder
+		for i := 1; i < len(raw); i++ {
+			switch raw[i] {
+			case '\\':
+				if i+1 < len(raw) {
+					i++
+					b.WriteByte(raw[i])
+				}
+			case '"':
+				return b.String()
+			default:
+				b.WriteByte(raw[i])
+			}
+		}
+		return b.String()
+	case '\'':
+		if end := strings.IndexByte(raw[1:], '\''); end != -1 {
+			return raw[1 : end+1]
+		}
+		return raw[1:]
+	}
+	if idx := strings.Index(raw, " #"); idx != -1 {
+		raw = raw[:idx]
+	}
+	return strings.TrimSpace(raw)
+}
+
+// quote returns value as a double quoted sh string.
+func quote(value string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`")
+	return `"` + r.Replace(value) + `"`
+}
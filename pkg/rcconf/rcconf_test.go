@@ -0,0 +1,126 @@
+package rcconf
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const sample = `# Network
+hostname="fw1.example.org"
+ifconfig_em0="DHCP"  # uplink
+sshd_enable=YES
+ifconfig_em1='inet 10.0.0.1/24'
+
+# Dup
+sshd_enable="NO"
+`
+
+func TestParse_Get(t *testing.T) {
+	f := Parse(sample)
+	tests := map[string]string{
+		"hostname":     "fw1.example.org",
+		"ifconfig_em0": "DHCP",
+		"ifconfig_em1": "inet 10.0.0.1/24",
+		"sshd_enable":  "NO", // last assignment wins
+	}
+	for key, want := range tests {
+		got, ok := f.Get(key)
+		if !ok || got != want {
+			t.Errorf("Get(%q) = %q, %v; want %q", key, got, ok, want)
+		}
+	}
+	if _, ok := f.Get("missing"); ok {
+		t.Error("expected missing key to be absent")
+	}
+	want := []string{"hostname", "ifconfig_em0", "sshd_enable", "ifconfig_em1"}
+	if got := f.Keys(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Keys() = %v, want %v", got, want)
+	}
+}
+
+func TestFile_SetDelete(t *testing.T) {
+	f := Parse(sample)
+	f.Set("sshd_enable", "YES")
+	f.Set("cloned_interfaces", "bridge0")
+	f.Delete("ifconfig_em1")
+
+	want := `# Network
+hostname="fw1.example.org"
+ifconfig_em0="DHCP"  # uplink
+sshd_enable="YES"
+
+# Dup
+cloned_interfaces="bridge0"
+`
+	if got := f.String(); got != want {
+		t.Errorf("unexpected content:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFile_Apply(t *testing.T) {
+	f := Parse(sample)
+	dhcp, bridge := "DHCP", "bridge0"
+	changes := f.Apply(
+		[]string{"ifconfig_em0", "cloned_interfaces", "ifconfig_em1", "ifconfig_em2"},
+		map[string]*string{"ifconfig_em0": &dhcp, "cloned_interfaces": &bridge},
+	)
+	want := []Change{
+		{Key: "cloned_interfaces", After: "bridge0", Action: "add"},
+		{Key: "ifconfig_em1", Before: "inet 10.0.0.1/24", Action: "delete"},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("Apply() = %+v, want %+v", changes, want)
+	}
+}
+
+func TestQuoteRoundTrip(t *testing.T) {
+	value := `say "hi" $HOME \ ` + "`id`"
+	f := Parse("")
+	f.Set("motd", value)
+	got, _ := Parse(f.String()).Get("motd")
+	if got != value {
+		t.Errorf("round trip = %q, want %q", got, value)
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rc.conf")
+	if err := os.WriteFile(path, []byte(sample), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f.Set("gateway_enable", "YES")
+	if err := f.WriteFile(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	st, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Mode().Perm() != 0o600 {
+		t.Errorf("expected mode 0600 to be kept, got %v", st.Mode().Perm())
+	}
+	f, err = Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := f.Get("gateway_enable"); v != "YES" {
+		t.Errorf("expected gateway_enable=YES, got %q", v)
+	}
+
+	empty, err := Load(filepath.Join(t.TempDir(), "missing.conf"))
+	if err != nil || len(empty.Keys()) != 0 {
+		t.Errorf("expected empty file for missing path, got %v, %v", empty.Keys(), err)
+	}
+}
+
+func TestName(t *testing.T) {
+	if got := Name("br-lan.10"); got != "br_lan_10" {
+		t.Errorf("Name() = %q", got)
+	}
+}
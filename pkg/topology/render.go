@@ -0,0 +1,102 @@
+package topology
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DOT renders the graph in Graphviz DOT format.
+func (g *Graph) DOT() string {
+	var b strings.Builder
+	b.WriteString("graph topology {\n")
+	b.WriteString("  rankdir=LR;\n")
+	for _, n := range g.Nodes {
+		fmt.Fprintf(&b, "  %s [label=%s shape=%s];\n", dotQuote(n.ID), dotQuote(nodeLabel(n, `\n`)), dotShape(n.Kind))
+	}
+	for _, e := range g.Edges {
+		attrs := "style=" + dotStyle(e.Kind)
+		if e.Label != "" {
+			attrs = "label=" + dotQuote(e.Label) + " " + attrs
+		}
+		fmt.Fprintf(&b, "  %s -- %s [%s];\n", dotQuote(e.From), dotQuote(e.To), attrs)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Mermaid renders the graph as a Mermaid flowchart.
+func (g *Graph) Mermaid() string {
+	ids := make(map[string]string, len(g.Nodes))
+	var b strings.Builder
+	b.WriteString("graph LR\n")
+	for i, n := range g.Nodes {
+		id := "n" + strconv.Itoa(i)
+		ids[n.ID] = id
+		open, closing := mermaidShape(n.Kind)
+		fmt.Fprintf(&b, "  %s%s\"%s\"%s\n", id, open, mermaidEscape(nodeLabel(n, "<br/>")), closing)
+	}
+	for _, e := range g.Edges {
+		link := "---"
+		if e.Kind == EdgeTunnel || e.Kind == EdgeRoute {
+			link = "-.-"
+		}
+		if e.Label != "" {
+			link += "|\"" + mermaidEscape(e.Label) + "\"|"
+		}
+		fmt.Fprintf(&b, "  %s %s %s\n", ids[e.From], link, ids[e.To])
+	}
+	return b.String()
+}
+
+// nodeLabel returns the name of a node followed by its type and addresses.
+func nodeLabel(n Node, sep string) string {
+	parts := []string{n.Label}
+	if n.Type != "" {
+		parts = append(parts, n.Type)
+	}
+	parts = append(parts, n.Addresses...)
+	return strings.Join(parts, sep)
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+func dotShape(kind string) string {
+	switch kind {
+	case KindJail:
+		return "folder"
+	case KindRemote:
+		return "ellipse"
+	case KindGateway:
+		return "diamond"
+	}
+	return "box"
+}
+
+func dotStyle(kind string) string {
+	switch kind {
+	case EdgeTunnel, EdgeRoute:
+		return "dashed"
+	case EdgeVNET:
+		return "dotted"
+	}
+	return "solid"
+}
+
+func mermaidShape(kind string) (string, string) {
+	switch kind {
+	case KindJail:
+		return "[[", "]]"
+	case KindRemote:
+		return "((", "))"
+	case KindGateway:
+		return "{", "}"
+	}
+	return "[", "]"
+}
+
+func mermaidEscape(s string) string {
+	return strings.ReplaceAll(s, `"`, "#quot;")
+}
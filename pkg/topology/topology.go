@@ -0,0 +1,275 @@
+// Package topology builds a graph of the network stack (interfaces, their
+// parents, members and peers, tunnel endpoints, jails and gateways) from
+// parsed ifconfig and netstat output, and renders it as JSON, DOT or Mermaid.
+package topology
+
+import (
+	"FreeBSD-Command-manager/pkg/ifconfig"
+	"FreeBSD-Command-manager/pkg/netstat"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// Node kinds.
+const (
+	KindInterface = "interface"
+	KindJail      = "jail"
+	KindRemote    = "remote"  // remote tunnel endpoint
+	KindGateway   = "gateway" // next hop of routes
+)
+
+// Edge kinds.
+const (
+	EdgeVLAN     = "vlan"     // VLAN to its parent interface
+	EdgeMember   = "member"   // bridge to member
+	EdgePort     = "port"     // lagg to port
+	EdgeTunnel   = "tunnel"   // tunnel to its remote endpoint
+	EdgeUnderlay = "underlay" // tunnel to the interface holding its local endpoint
+	EdgePeer     = "peer"     // epair end to the other end
+	EdgeVNET     = "vnet"     // jail to an interface in its vnet
+	EdgeRoute    = "route"    // interface to a gateway
+)
+
+// Node is a vertex of the topology graph
+type Node struct {
+	ID        string   `json:"id"`
+	Kind      string   `json:"kind"`
+	Label     string   `json:"label"`
+	Type      string   `json:"type,omitempty"`
+	Status    string   `json:"status,omitempty"`
+	Addresses []string `json:"addresses,omitempty"`
+	Jail      string   `json:"jail,omitempty"`
+}
+
+// Edge connects two nodes
+type Edge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Kind  string `json:"kind"`
+	Label string `json:"label,omitempty"`
+}
+
+// Graph is the network topology
+type Graph struct {
+	Nodes []Node `json:"nodes"`
+	Edges []Edge `json:"edges"`
+}
+
+// Jail holds the interfaces seen inside a jail
+type Jail struct {
+	Name       string
+	Interfaces []ifconfig.Info
+}
+
+// Input is everything the graph is built from
+type Input struct {
+	Interfaces []ifconfig.Info // host interfaces
+	Routes     []netstat.Route
+	Jails      []Jail
+}
+
+// builder keeps the nodes in insertion order and indexes them by ID
+type builder struct {
+	g     Graph
+	index map[string]int
+	edges map[Edge]bool
+}
+
+// located is an interface with the jail it is in, "" for the host
+type located struct {
+	ifconfig.Info
+	jail string
+}
+
+// Build builds the topology graph. Interfaces a jail sees that are not on the
+// host are the jail's VNET interfaces; the others are shared with the host
+// and ignored.
+func Build(in Input) *Graph {
+	b := &builder{index: make(map[string]int), edges: make(map[Edge]bool)}
+
+	infos := make([]located, 0, len(in.Interfaces))
+	host := make(map[string]bool)
+	for _, info := range in.Interfaces {
+		host[info.Name] = true
+		infos = append(infos, located{Info: info})
+		b.addInterface(info, "")
+	}
+	for _, j := range in.Jails {
+		for _, info := range j.Interfaces {
+			if host[info.Name] {
+				continue
+			}
+			b.addNode(Node{ID: jailID(j.Name), Kind: KindJail, Label: "jail " + j.Name})
+			b.addInterface(info, j.Name)
+			b.addEdge(Edge{From: jailID(j.Name), To: interfaceID(j.Name, info.Name), Kind: EdgeVNET})
+			infos = append(infos, located{Info: info, jail: j.Name})
+		}
+	}
+
+	for _, info := range infos {
+		b.addLinks(info, infos)
+	}
+	b.addRoutes(in.Routes, host)
+	return &b.g
+}
+
+func (b *builder) addNode(n Node) {
+	if _, ok := b.index[n.ID]; ok {
+		return
+	}
+	b.index[n.ID] = len(b.g.Nodes)
+	b.g.Nodes = append(b.g.Nodes, n)
+}
+
+func (b *builder) addEdge(e Edge) {
+	if b.edges[e] {
+		return
+	}
+	b.edges[e] = true
+	b.g.Edges = append(b.g.Edges, e)
+}
+
+// link adds an edge to an interface in the given jail, or on the host for
+// "", provided the interface is known.
+func (b *builder) link(from, jail, toIface, kind, label string) {
+	if _, ok := b.index[interfaceID(jail, toIface)]; ok {
+		b.addEdge(Edge{From: from, To: interfaceID(jail, toIface), Kind: kind, Label: label})
+	}
+}
+
+func (b *builder) addInterface(info ifconfig.Info, jail string) {
+	var addrs []string
+	addrs = append(addrs, info.IPv4...)
+	addrs = append(addrs, info.IPv6...)
+	b.addNode(Node{
+		ID:        interfaceID(jail, info.Name),
+		Kind:      KindInterface,
+		Label:     info.Name,
+		Type:      info.Type,
+		Status:    info.Status,
+		Addresses: addrs,
+		Jail:      jail,
+	})
+}
+
+// addLinks adds the edges from an interface to the interfaces and endpoints
+// it is built on.
+func (b *builder) addLinks(info located, all []located) {
+	id := interfaceID(info.jail, info.Name)
+
+	if info.VLANParent != "" {
+		b.link(id, info.jail, info.VLANParent, EdgeVLAN, "vlan "+strconv.Itoa(info.VLANID))
+	}
+	if info.Bridge != nil {
+		for _, m := range info.Bridge.Members {
+			b.link(id, info.jail, m.Name, EdgeMember, "")
+		}
+	}
+	for _, p := range info.LaggPorts {
+		b.link(id, info.jail, p.Name, EdgePort, "")
+	}
+
+	remote, local, label := info.TunnelRemote, info.TunnelLocal, info.Type
+	if info.Type == ifconfig.VXLAN {
+		remote, local, label = info.VXLANRemote, info.VXLANLocal, "vni "+strconv.Itoa(info.VXLANID)
+		if remote == "" {
+			remote = info.VXLANGroup
+		}
+	}
+	if remote != "" {
+		b.addNode(Node{ID: remoteID(remote), Kind: KindRemote, Label: remote})
+		b.addEdge(Edge{From: id, To: remoteID(remote), Kind: EdgeTunnel, Label: label})
+	}
+	if local != "" {
+		if owner := addressOwner(all, info.jail, local); owner != nil && owner.Name != info.Name {
+			b.link(id, owner.jail, owner.Name, EdgeUnderlay, local)
+		}
+	}
+
+	// The ends of an epair are usually split between the host and a jail
+	if peer := epairPeer(info.Info); peer != "" && info.Name < peer {
+		for _, other := range all {
+			if other.Name == peer {
+				b.link(id, other.jail, peer, EdgePeer, "")
+				break
+			}
+		}
+	}
+}
+
+// addRoutes adds an edge from the outgoing interface to each gateway,
+// labeled with the destinations routed through it.
+func (b *builder) addRoutes(routes []netstat.Route, host map[string]bool) {
+	type hop struct{ iface, gw string }
+	var order []hop
+	dests := make(map[hop][]string)
+	for _, r := range routes {
+		gw, err := netip.ParseAddr(r.Gateway)
+		if err != nil || !host[r.Interface] || !contains(r.Flags, "GATEWAY") {
+			continue
+		}
+		h := hop{iface: r.Interface, gw: gw.String()}
+		if _, ok := dests[h]; !ok {
+			order = append(order, h)
+		}
+		dests[h] = append(dests[h], r.Destination)
+	}
+	for _, h := range order {
+		b.addNode(Node{ID: gatewayID(h.gw), Kind: KindGateway, Label: h.gw})
+		b.addEdge(Edge{From: interfaceID("", h.iface), To: gatewayID(h.gw), Kind: EdgeRoute, Label: strings.Join(dests[h], ", ")})
+	}
+}
+
+// epairPeer returns the name of the other end of an epair.
+func epairPeer(info ifconfig.Info) string {
+	if info.Type != ifconfig.Epair || len(info.Name) < 2 {
+		return ""
+	}
+	base, side := info.Name[:len(info.Name)-1], info.Name[len(info.Name)-1]
+	switch side {
+	case 'a':
+		return base + "b"
+	case 'b':
+		return base + "a"
+	}
+	return ""
+}
+
+// addressOwner returns the interface of the jail ("" for the host) that has
+// addr configured.
+func addressOwner(infos []located, jail, addr string) *located {
+	for i := range infos {
+		if infos[i].jail != jail {
+			continue
+		}
+		for _, a := range infos[i].Addresses {
+			if a.Address == addr {
+				return &infos[i]
+			}
+		}
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func jailID(name string) string    { return "jail:" + name }
+func remoteID(addr string) string  { return "remote:" + addr }
+func gatewayID(addr string) string { return "gw:" + addr }
+
+// interfaceID identifies a host interface as if:<name> and a jail's VNET
+// interface as if:<jail>/<name>, as jails may reuse names such as eth0.
+func interfaceID(jail, name string) string {
+	if jail == "" {
+		return "if:" + name
+	}
+	return "if:" + jail + "/" + name
+}
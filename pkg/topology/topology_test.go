@@ -0,0 +1,221 @@
+package topology
+
+import (
+	"FreeBSD-Command-manager/pkg/ifconfig"
+	"FreeBSD-Command-manager/pkg/netstat"
+	"testing"
+)
+
+const hostIfconfig = `em0: flags=1008843<UP,BROADCAST,RUNNING,SIMPLEX,MULTICAST,LOWER_UP> metric 0 mtu 1500
+	ether 00:11:22:33:44:55
+	inet 203.0.113.10 netmask 0xffffff00 broadcast 203.0.113.255
+	media: Ethernet autoselect (1000baseT <full-duplex>)
+	status: active
+em1: flags=1008843<UP,BROADCAST,RUNNING,SIMPLEX,MULTICAST,LOWER_UP> metric 0 mtu 1500
+	ether 00:11:22:33:44:56
+	media: Ethernet autoselect (1000baseT <full-duplex>)
+	status: active
+em2: flags=1008843<UP,BROADCAST,RUNNING,SIMPLEX,MULTICAST,LOWER_UP> metric 0 mtu 1500
+	ether 00:11:22:33:44:57
+	media: Ethernet autoselect (1000baseT <full-duplex>)
+	status: active
+lagg0: flags=1008843<UP,BROADCAST,RUNNING,SIMPLEX,MULTICAST,LOWER_UP> metric 0 mtu 1500
+	ether 00:11:22:33:44:56
+	inet 10.0.0.1 netmask 0xffffff00 broadcast 10.0.0.255
+	laggproto lacp lagghash l2,l3,l4
+	laggport: em1 flags=1c<ACTIVE,COLLECTING,DISTRIBUTING>
+	laggport: em2 flags=1c<ACTIVE,COLLECTING,DISTRIBUTING>
+	groups: lagg
+	media: Ethernet autoselect
+	status: active
+vlan20: flags=1008843<UP,BROADCAST,RUNNING,SIMPLEX,MULTICAST,LOWER_UP> metric 0 mtu 1500
+	ether 00:11:22:33:44:56
+	groups: vlan
+	vlan: 20 vlanproto: 802.1q vlanpcp: 0 parent interface: lagg0
+	media: Ethernet autoselect (1000baseT <full-duplex>)
+	status: active
+bridge0: flags=1008843<UP,BROADCAST,RUNNING,SIMPLEX,MULTICAST,LOWER_UP> metric 0 mtu 1500
+	ether 58:9c:fc:10:ff:01
+	inet 10.20.0.1 netmask 0xffffff00 broadcast 10.20.0.255
+	id 00:00:00:00:00:00 priority 32768 hellotime 2 fwddelay 15
+	maxage 20 holdcnt 6 proto rstp maxaddr 2000 timeout 1200
+	root id 00:00:00:00:00:00 priority 32768 ifcost 0 port 0
+	groups: bridge
+	member: epair0a flags=143<LEARNING,DISCOVER,AUTOEDGE,AUTOPTP>
+	        ifmaxaddr 0 port 8 priority 128 path cost 2000
+	member: vlan20 flags=143<LEARNING,DISCOVER,AUTOEDGE,AUTOPTP>
+	        ifmaxaddr 0 port 6 priority 128 path cost 20000
+epair0a: flags=1008943<UP,BROADCAST,RUNNING,PROMISC,SIMPLEX,MULTICAST,LOWER_UP> metric 0 mtu 1500
+	ether 02:5d:a4:5e:11:0a
+	groups: epair
+	media: Ethernet 10Gbase-T (10Gbase-T <full-duplex>)
+	status: active
+gre0: flags=1008051<UP,POINTOPOINT,RUNNING,MULTICAST,LOWER_UP> metric 0 mtu 1476
+	tunnel inet 203.0.113.10 --> 198.51.100.1
+	inet 172.16.0.1 --> 172.16.0.2 netmask 0xffffffff
+	groups: gre
+vx0: flags=8843<UP,BROADCAST,RUNNING,SIMPLEX,MULTICAST> metric 0 mtu 1450
+	ether 58:9c:fc:10:ff:8a
+	groups: vxlan
+	vxlan vni 1001 local 203.0.113.10:4789 remote 192.0.2.2:4789
+	status: active
+lo0: flags=1008049<UP,LOOPBACK,RUNNING,MULTICAST,LOWER_UP> metric 0 mtu 16384
+	inet 127.0.0.1 netmask 0xff000000
+	groups: lo
+`
+
+// Seen from inside the jail: its own lo0 (shadowing the host's) and the
+// other end of the epair
+const jailIfconfig = `lo0: flags=1008049<UP,LOOPBACK,RUNNING,MULTICAST,LOWER_UP> metric 0 mtu 16384
+	inet 127.0.0.1 netmask 0xff000000
+	groups: lo
+epair0b: flags=1008843<UP,BROADCAST,RUNNING,SIMPLEX,MULTICAST,LOWER_UP> metric 0 mtu 1500
+	ether 02:5d:a4:5e:11:0b
+	inet 10.20.0.10 netmask 0xffffff00 broadcast 10.20.0.255
+	groups: epair
+	media: Ethernet 10Gbase-T (10Gbase-T <full-duplex>)
+	status: active
+`
+
+const hostRoutes = `Routing tables
+
+Internet:
+Destination        Gateway            Flags     Netif Expire
+default            203.0.113.1        UGS         em0
+10.50.0.0/16       10.0.0.254         UGS       lagg0
+10.60.0.0/16       10.0.0.254         UGS       lagg0
+10.0.0.0/24        link#4             U         lagg0
+127.0.0.1          link#11            UH          lo0
+`
+
+func testGraph(t *testing.T) *Graph {
+	t.Helper()
+	routes, err := netstat.ParseNetstat(hostRoutes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return Build(Input{
+		Interfaces: ifconfig.ParseIfconfig(hostIfconfig),
+		Routes:     routes,
+		Jails: []Jail{
+			{Name: "web", Interfaces: ifconfig.ParseIfconfig(jailIfconfig)},
+			{Name: "plain", Interfaces: ifconfig.ParseIfconfig(hostIfconfig)}, // non-VNET jail
+		},
+	})
+}
+
+func findNode(g *Graph, id string) *Node {
+	for i := range g.Nodes {
+		if g.Nodes[i].ID == id {
+			return &g.Nodes[i]
+		}
+	}
+	return nil
+}
+
+func TestBuild(t *testing.T) {
+	g := testGraph(t)
+
+	expected := []Edge{
+		{From: "jail:web", To: "if:web/epair0b", Kind: EdgeVNET},
+		{From: "if:lagg0", To: "if:em1", Kind: EdgePort},
+		{From: "if:lagg0", To: "if:em2", Kind: EdgePort},
+		{From: "if:vlan20", To: "if:lagg0", Kind: EdgeVLAN, Label: "vlan 20"},
+		{From: "if:bridge0", To: "if:epair0a", Kind: EdgeMember},
+		{From: "if:bridge0", To: "if:vlan20", Kind: EdgeMember},
+		{From: "if:epair0a", To: "if:web/epair0b", Kind: EdgePeer},
+		{From: "if:gre0", To: "remote:198.51.100.1", Kind: EdgeTunnel, Label: "gre"},
+		{From: "if:gre0", To: "if:em0", Kind: EdgeUnderlay, Label: "203.0.113.10"},
+		{From: "if:vx0", To: "remote:192.0.2.2", Kind: EdgeTunnel, Label: "vni 1001"},
+		{From: "if:vx0", To: "if:em0", Kind: EdgeUnderlay, Label: "203.0.113.10"},
+		{From: "if:em0", To: "gw:203.0.113.1", Kind: EdgeRoute, Label: "default"},
+		{From: "if:lagg0", To: "gw:10.0.0.254", Kind: EdgeRoute, Label: "10.50.0.0/16, 10.60.0.0/16"},
+	}
+	if len(g.Edges) != len(expected) {
+		t.Fatalf("expected %d edges, got %d: %+v", len(expected), len(g.Edges), g.Edges)
+	}
+	for i, e := range expected {
+		if g.Edges[i] != e {
+			t.Errorf("edge %d: expected %+v, got %+v", i, e, g.Edges[i])
+		}
+	}
+
+	if n := findNode(g, "if:web/epair0b"); n == nil || n.Jail != "web" || len(n.Addresses) != 1 {
+		t.Errorf("expected epair0b to belong to jail web, got %+v", n)
+	}
+	if n := findNode(g, "if:lo0"); n == nil || n.Jail != "" {
+		t.Errorf("expected lo0 to be a host interface, got %+v", n)
+	}
+	if findNode(g, "jail:plain") != nil {
+		t.Error("expected no node for a jail without VNET interfaces")
+	}
+}
+
+func TestBuild_SameNameInJails(t *testing.T) {
+	// both jails renamed their end of an epair to eth0
+	eth0 := func(addr string) []ifconfig.Info {
+		return ifconfig.ParseIfconfig("eth0: flags=1008843<UP,BROADCAST,RUNNING,SIMPLEX,MULTICAST,LOWER_UP> metric 0 mtu 1500\n" +
+			"\tinet " + addr + " netmask 0xffffff00 broadcast 10.20.0.255\n\tgroups: epair\n")
+	}
+	g := Build(Input{
+		Interfaces: ifconfig.ParseIfconfig(hostIfconfig),
+		Jails: []Jail{
+			{Name: "web", Interfaces: eth0("10.20.0.10")},
+			{Name: "db", Interfaces: eth0("10.20.0.11")},
+		},
+	})
+	for _, jail := range []string{"web", "db"} {
+		id := "if:" + jail + "/eth0"
+		if n := findNode(g, id); n == nil || n.Jail != jail {
+			t.Errorf("expected %s in jail %s, got %+v", id, jail, n)
+		}
+		found := false
+		for _, e := range g.Edges {
+			found = found || e == Edge{From: "jail:" + jail, To: id, Kind: EdgeVNET}
+		}
+		if !found {
+			t.Errorf("expected a vnet edge from jail %s to %s", jail, id)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	g := &Graph{
+		Nodes: []Node{
+			{ID: "if:em0", Kind: KindInterface, Label: "em0", Type: "ethernet", Addresses: []string{"192.0.2.1/24"}},
+			{ID: "jail:web", Kind: KindJail, Label: "jail web"},
+			{ID: "if:web/epair0b", Kind: KindInterface, Label: "epair0b", Jail: "web"},
+			{ID: "gw:192.0.2.254", Kind: KindGateway, Label: "192.0.2.254"},
+		},
+		Edges: []Edge{
+			{From: "jail:web", To: "if:web/epair0b", Kind: EdgeVNET},
+			{From: "if:em0", To: "gw:192.0.2.254", Kind: EdgeRoute, Label: "default"},
+		},
+	}
+
+	wantDOT := `graph topology {
+  rankdir=LR;
+  "if:em0" [label="em0\nethernet\n192.0.2.1/24" shape=box];
+  "jail:web" [label="jail web" shape=folder];
+  "if:web/epair0b" [label="epair0b" shape=box];
+  "gw:192.0.2.254" [label="192.0.2.254" shape=diamond];
+  "jail:web" -- "if:web/epair0b" [style=dotted];
+  "if:em0" -- "gw:192.0.2.254" [label="default" style=dashed];
+}
+`
+	if got := g.DOT(); got != wantDOT {
+		t.Errorf("unexpected DOT:\n%s\nwant:\n%s", got, wantDOT)
+	}
+
+	wantMermaid := `graph LR
+  n0["em0<br/>ethernet<br/>192.0.2.1/24"]
+  n1[["jail web"]]
+  n2["epair0b"]
+  n3{"192.0.2.254"}
+  n1 --- n2
+  n0 -.-|"default"| n3
+`
+	if got := g.Mermaid(); got != wantMermaid {
+		t.Errorf("unexpected Mermaid:\n%s\nwant:\n%s", got, wantMermaid)
+	}
+}
@@ -0,0 +1,77 @@
+// Package vale provides parsing utilities for valectl(8) output.
+package vale
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// HostSuffix marks the port connecting a switch to the host stack of an
+// interface attached with "valectl -h".
+const HostSuffix = "^"
+
+// Switch represents a VALE switch and its ports.
+type Switch struct {
+	Name   string `json:"name"`
+	Bridge int    `json:"bridge"` // index of the switch in the kernel
+	Ports  []Port `json:"ports"`
+}
+
+// Port represents a port of a VALE switch.
+type Port struct {
+	Name  string `json:"name"`
+	Index int    `json:"index"`
+	Host  bool   `json:"host"` // host stack side of an attached interface
+}
+
+// ParseList parses the output of "valectl" (list all ports) and groups the
+// ports by switch, in the order they are listed.
+//
+//	vale0:em0 bridge:0 port:0
+//	vale0:em0^ bridge:0 port:1
+//	vale0:vp0 bridge:0 port:2
+func ParseList(output string) ([]Switch, error) {
+	var switches []Switch
+	index := make(map[string]int)
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		sw, port, ok := strings.Cut(fields[0], ":")
+		if !ok || port == "" {
+			return nil, fmt.Errorf("invalid VALE port %q", fields[0])
+		}
+		p := Port{Name: strings.TrimSuffix(port, HostSuffix), Host: strings.HasSuffix(port, HostSuffix)}
+		bridge := -1
+		for _, f := range fields[1:] {
+			key, value, _ := strings.Cut(f, ":")
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s in %q: %v", key, scanner.Text(), err)
+			}
+			switch key {
+			case "bridge":
+				bridge = n
+			case "port":
+				p.Index = n
+			}
+		}
+
+		i, exists := index[sw]
+		if !exists {
+			i = len(switches)
+			index[sw] = i
+			switches = append(switches, Switch{Name: sw, Bridge: bridge})
+		}
+		switches[i].Ports = append(switches[i].Ports, p)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scanner error: %w", err)
+	}
+	return switches, nil
+}
@@ -0,0 +1,47 @@
+package vale
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseList(t *testing.T) {
+	sample := `vale0:em0 bridge:0 port:0
+vale0:em0^ bridge:0 port:1
+vale0:vp0 bridge:0 port:2
+valebhyve:tap0 bridge:1 port:0
+`
+	expected := []Switch{
+		{Name: "vale0", Bridge: 0, Ports: []Port{
+			{Name: "em0", Index: 0},
+			{Name: "em0", Index: 1, Host: true},
+			{Name: "vp0", Index: 2},
+		}},
+		{Name: "valebhyve", Bridge: 1, Ports: []Port{{Name: "tap0", Index: 0}}},
+	}
+	switches, err := ParseList(sample)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(switches, expected) {
+		t.Errorf("ParseList() = %+v, want %+v", switches, expected)
+	}
+}
+
+func TestParseList_Empty(t *testing.T) {
+	switches, err := ParseList("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(switches) != 0 {
+		t.Errorf("expected no switches, got %+v", switches)
+	}
+}
+
+func TestParseList_Invalid(t *testing.T) {
+	for _, sample := range []string{"vale0 bridge:0 port:0\n", "vale0:em0 bridge:x port:0\n"} {
+		if _, err := ParseList(sample); err == nil {
+			t.Errorf("expected error for %q but got none", sample)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+// Package vpp provides parsing utilities for VPP vppctl output.
+package vpp
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Interface is a VPP interface as shown by "show interface", with the
+// addresses from "show interface addr".
+type Interface struct {
+	Name      string            `json:"name"`
+	Index     int               `json:"index"`
+	State     string            `json:"state"` // up or down
+	MTU       int               `json:"mtu"`   // L3 MTU
+	Counters  map[string]uint64 `json:"counters,omitempty"`
+	Addresses []string          `json:"addresses,omitempty"`
+}
+
+// BridgeDomain is a VPP L2 bridge domain.
+type BridgeDomain struct {
+	ID         int            `json:"id"`
+	Index      int            `json:"index"`
+	Learning   bool           `json:"learning"`
+	Forwarding bool           `json:"forwarding"`
+	Flooding   bool           `json:"flooding"`
+	UUFlood    string         `json:"uu_flood,omitempty"` // flood or drop
+	ARPTerm    bool           `json:"arp_term"`
+	BVI        string         `json:"bvi,omitempty"`
+	Members    []BridgeMember `json:"members,omitempty"` // only with "detail"
+}
+
+// BridgeMember is an interface in a bridge domain.
+type BridgeMember struct {
+	Name  string `json:"name"`
+	Index int    `json:"index"`
+	SHG   int    `json:"shg"` // split horizon group
+	BVI   bool   `json:"bvi"`
+}
+
+// Mode is the forwarding mode of an interface as shown by "show mode".
+type Mode struct {
+	Interface    string `json:"interface"`
+	Mode         string `json:"mode"`           // l3, xconnect or bridge
+	Peer         string `json:"peer,omitempty"` // xconnect output interface
+	BridgeDomain int    `json:"bridge_domain,omitempty"`
+	SHG          int    `json:"shg,omitempty"`
+	BVI          bool   `json:"bvi,omitempty"`
+}
+
+const (
+	minInterfaceFields = 4
+	// ModeL3, ModeXConnect and ModeBridge are the interface modes
+	ModeL3       = "l3"
+	ModeXConnect = "xconnect"
+	ModeBridge   = "bridge"
+)
+
+// ParseInterfaces parses the output of "show interface". Counters follow the
+// interface on the same and the indented lines below it.
+//
+//	              Name               Idx    State  MTU (L3/IP4/IP6/MPLS)     Counter          Count
+//	host-eth0                         1      up          9000/0/0/0     rx packets                    42
+//	                                                                    rx bytes                    3528
+//	local0                            0     down          0/0/0/0
+func ParseInterfaces(output string) ([]Interface, error) {
+	var ifaces []Interface
+	header := false
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+			continue
+		case fields[0] == "Name" && len(fields) > 1 && fields[1] == "Idx":
+			header = true
+			continue
+		case !header:
+			continue
+		case line[0] == ' ' || line[0] == '\t':
+			if len(ifaces) > 0 {
+				addCounter(&ifaces[len(ifaces)-1], fields)
+			}
+			continue
+		}
+		if len(fields) < minInterfaceFields {
+			return nil, fmt.Errorf("invalid interface line %q", line)
+		}
+		index, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid interface index in %q", line)
+		}
+		iface := Interface{Name: fields[0], Index: index, State: fields[2]}
+		mtu, _, _ := strings.Cut(fields[3], "/")
+		iface.MTU, _ = strconv.Atoi(mtu)
+		addCounter(&iface, fields[minInterfaceFields:])
+		ifaces = append(ifaces, iface)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scanner error: %w", err)
+	}
+	if !header && strings.TrimSpace(output) != "" {
+		return nil, fmt.Errorf("no interface header found")
+	}
+	return ifaces, nil
+}
+
+// addCounter adds a counter from its name words followed by its value
+func addCounter(iface *Interface, fields []string) {
+	if len(fields) < 2 {
+		return
+	}
+	value, err := strconv.ParseUint(fields[len(fields)-1], 10, 64)
+	if err != nil {
+		return
+	}
+	if iface.Counters == nil {
+		iface.Counters = make(map[string]uint64)
+	}
+	iface.Counters[strings.Join(fields[:len(fields)-1], " ")] = value
+}
+
+// ParseInterfaceAddresses parses the output of "show interface addr" and
+// returns the L3 addresses of each interface.
+//
+//	host-eth0 (up):
+//	  L3 10.0.0.1/24
+//	local0 (dn):
+func ParseInterfaceAddresses(output string) map[string][]string {
+	addrs := make(map[string][]string)
+	var current string
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+		case line[0] != ' ' && line[0] != '\t':
+			current = fields[0]
+			addrs[current] = nil
+		case fields[0] == "L3" && len(fields) > 1 && current != "":
+			addrs[current] = append(addrs[current], fields[1])
+		}
+	}
+	return addrs
+}
+
+// ParseBridgeDomains parses the output of "show bridge-domain", or of
+// "show bridge-domain <id> detail" which adds the member interfaces.
+//
+//	BD-ID   Index   BSN  Age(min)  Learning  U-Forwrd   UU-Flood   Flooding  ARP-Term  arp-ufwd Learn-co Learn-li   BVI-Intf
+//	 10       1      0     off        on        on       flood        on       off       off        1    16777216     loop0
+//
+//	         Interface           If-idx  ISN  SHG  BVI  TxFlood        VLAN-Tag-Rewrite
+//	           loop0               3      3    0    *      *                 none
+func ParseBridgeDomains(output string) ([]BridgeDomain, error) {
+	var domains []BridgeDomain
+	var headers []string
+	members := false
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		switch {
+		case len(fields) == 0:
+			continue
+		case fields[0] == "BD-ID":
+			headers, members = fields, false
+			continue
+		case fields[0] == "Interface" && len(fields) > 1 && fields[1] == "If-idx":
+			headers, members = fields, true
+			continue
+		case len(fields) != len(headers):
+			continue
+		}
+
+		values := make(map[string]string, len(headers))
+		for i, h := range headers {
+			values[h] = fields[i]
+		}
+		if members {
+			if len(domains) == 0 {
+				continue
+			}
+			bd := &domains[len(domains)-1]
+			bd.Members = append(bd.Members, BridgeMember{
+				Name:  values["Interface"],
+				Index: atoi(values["If-idx"]),
+				SHG:   atoi(values["SHG"]),
+				BVI:   values["BVI"] == "*",
+			})
+			continue
+		}
+
+		id, err := strconv.Atoi(values["BD-ID"])
+		if err != nil {
+			return nil, fmt.Errorf("invalid bridge domain ID %q", values["BD-ID"])
+		}
+		bd := BridgeDomain{
+			ID:         id,
+			Index:      atoi(values["Index"]),
+			Learning:   values["Learning"] == "on",
+			Forwarding: values["U-Forwrd"] == "on",
+			Flooding:   values["Flooding"] == "on",
+			UUFlood:    values["UU-Flood"],
+			ARPTerm:    values["ARP-Term"] == "on",
+		}
+		if bvi := values["BVI-Intf"]; bvi != "N/A" {
+			bd.BVI = bvi
+		}
+		domains = append(domains, bd)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scanner error: %w", err)
+	}
+	return domains, nil
+}
+
+// ParseModes parses the output of "show mode".
+//
+//	l3 local0
+//	l2 xconnect host-eth0 tap0
+//	l2 bridge tap1 bd_id 10 shg 0
+//	l2 bridge loop0 bd_id 10 bvi shg 0
+func ParseModes(output string) []Mode {
+	var modes []Mode
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		switch {
+		case len(fields) == 2 && fields[0] == ModeL3:
+			modes = append(modes, Mode{Interface: fields[1], Mode: ModeL3})
+		case len(fields) == 4 && fields[0] == "l2" && fields[1] == ModeXConnect:
+			modes = append(modes, Mode{Interface: fields[2], Mode: ModeXConnect, Peer: fields[3]})
+		case len(fields) >= 3 && fields[0] == "l2" && fields[1] == ModeBridge:
+			m := Mode{Interface: fields[2], Mode: ModeBridge}
+			for i := 3; i < len(fields); i++ {
+				switch fields[i] {
+				case "bvi":
+					m.BVI = true
+				case "bd_id":
+					if i+1 < len(fields) {
+						m.BridgeDomain = atoi(fields[i+1])
+						i++
+					}
+				case "shg":
+					if i+1 < len(fields) {
+						m.SHG = atoi(fields[i+1])
+						i++
+					}
+				}
+			}
+			modes = append(modes, m)
+		}
+	}
+	return modes
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
@@ -0,0 +1,107 @@
+package vpp
+
+import (
+	"reflect"
+	"testing"
+)
+
+const showInterface = `              Name               Idx    State  MTU (L3/IP4/IP6/MPLS)     Counter          Count     
+host-eth0                         1      up          9000/0/0/0     rx packets                    42
+                                                                    rx bytes                    3528
+                                                                    drops                         42
+local0                            0     down          0/0/0/0       
+memif1/0                          3     down         9000/0/0/0     
+tap0                              2      up          1500/0/0/0     tx packets                     7
+                                                                    tx bytes                     586
+`
+
+func TestParseInterfaces(t *testing.T) {
+	ifaces, err := ParseInterfaces(showInterface)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []Interface{
+		{Name: "host-eth0", Index: 1, State: "up", MTU: 9000, Counters: map[string]uint64{"rx packets": 42, "rx bytes": 3528, "drops": 42}},
+		{Name: "local0", Index: 0, State: "down", MTU: 0},
+		{Name: "memif1/0", Index: 3, State: "down", MTU: 9000},
+		{Name: "tap0", Index: 2, State: "up", MTU: 1500, Counters: map[string]uint64{"tx packets": 7, "tx bytes": 586}},
+	}
+	if !reflect.DeepEqual(ifaces, expected) {
+		t.Errorf("ParseInterfaces() =\n%+v\nwant\n%+v", ifaces, expected)
+	}
+
+	if _, err := ParseInterfaces("vppctl: cannot connect to /run/vpp/cli.sock\n"); err == nil {
+		t.Error("expected error but got none")
+	}
+}
+
+func TestParseInterfaceAddresses(t *testing.T) {
+	sample := `host-eth0 (up):
+  L3 10.0.0.1/24
+  L3 2001:db8::1/64
+local0 (dn):
+tap0 (up):
+  L2 bridge bd-id 10 idx 1 shg 0
+`
+	expected := map[string][]string{
+		"host-eth0": {"10.0.0.1/24", "2001:db8::1/64"},
+		"local0":    nil,
+		"tap0":      nil,
+	}
+	if got := ParseInterfaceAddresses(sample); !reflect.DeepEqual(got, expected) {
+		t.Errorf("ParseInterfaceAddresses() = %v, want %v", got, expected)
+	}
+}
+
+func TestParseBridgeDomains(t *testing.T) {
+	sample := `  BD-ID   Index   BSN  Age(min)  Learning  U-Forwrd   UU-Flood   Flooding  ARP-Term  arp-ufwd Learn-co Learn-li   BVI-Intf 
+    1       1      0     off        on        on       flood        on       off       off        0    16777216     N/A    
+   10       2      0     off        off       on       drop         on       on        off        1    16777216     loop0  
+`
+	domains, err := ParseBridgeDomains(sample)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []BridgeDomain{
+		{ID: 1, Index: 1, Learning: true, Forwarding: true, Flooding: true, UUFlood: "flood"},
+		{ID: 10, Index: 2, Forwarding: true, Flooding: true, UUFlood: "drop", ARPTerm: true, BVI: "loop0"},
+	}
+	if !reflect.DeepEqual(domains, expected) {
+		t.Errorf("ParseBridgeDomains() =\n%+v\nwant\n%+v", domains, expected)
+	}
+}
+
+func TestParseBridgeDomains_Detail(t *testing.T) {
+	sample := `  BD-ID   Index   BSN  Age(min)  Learning  U-Forwrd   UU-Flood   Flooding  ARP-Term  arp-ufwd Learn-co Learn-li   BVI-Intf 
+   10       2      0     off        on        on       flood        on       off       off        2    16777216     loop0  
+
+           Interface           If-idx  ISN  SHG  BVI  TxFlood        VLAN-Tag-Rewrite       
+             loop0               4      3    0    *      *                 none             
+             tap0                2      1    1    -      *                 none             
+`
+	domains, err := ParseBridgeDomains(sample)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []BridgeMember{{Name: "loop0", Index: 4, BVI: true}, {Name: "tap0", Index: 2, SHG: 1}}
+	if len(domains) != 1 || !reflect.DeepEqual(domains[0].Members, expected) {
+		t.Errorf("unexpected bridge domains: %+v", domains)
+	}
+}
+
+func TestParseModes(t *testing.T) {
+	sample := `l3 local0
+l2 xconnect host-eth0 tap0
+l2 bridge tap1 bd_id 10 shg 1
+l2 bridge loop0 bd_id 10 bvi shg 0
+`
+	expected := []Mode{
+		{Interface: "local0", Mode: ModeL3},
+		{Interface: "host-eth0", Mode: ModeXConnect, Peer: "tap0"},
+		{Interface: "tap1", Mode: ModeBridge, BridgeDomain: 10, SHG: 1},
+		{Interface: "loop0", Mode: ModeBridge, BridgeDomain: 10, BVI: true},
+	}
+	if got := ParseModes(sample); !reflect.DeepEqual(got, expected) {
+		t.Errorf("ParseModes() = %+v, want %+v", got, expected)
+	}
+}
@@ -0,0 +1,128 @@
+// Package wg provides parsing utilities for WireGuard wg(8) output.
+package wg
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Interface represents a WireGuard interface and its peers.
+// The private key is never serialized.
+type Interface struct {
+	Name       string `json:"name"`
+	PrivateKey string `json:"-"`
+	PublicKey  string `json:"public_key"`
+	ListenPort int    `json:"listen_port"`
+	FwMark     int    `json:"fwmark,omitempty"`
+	Peers      []Peer `json:"peers"`
+}
+
+// Peer represents a WireGuard peer as reported by 'wg show dump'.
+type Peer struct {
+	PublicKey           string   `json:"public_key"`
+	HasPresharedKey     bool     `json:"has_preshared_key"`
+	Endpoint            string   `json:"endpoint,omitempty"`
+	AllowedIPs          []string `json:"allowed_ips"`
+	LatestHandshake     int64    `json:"latest_handshake"`
+	TransferRx          int64    `json:"transfer_rx"`
+	TransferTx          int64    `json:"transfer_tx"`
+	PersistentKeepalive int      `json:"persistent_keepalive,omitempty"`
+}
+
+const (
+	interfaceFields    = 4
+	peerFields         = 8
+	allInterfaceFields = interfaceFields + 1
+	allPeerFields      = peerFields + 1
+	noneValue          = "(none)"
+	offValue           = "off"
+)
+
+// ParseDump parses the output of 'wg show all dump' or 'wg show <iface> dump'.
+// For the single interface form the name is taken from the iface argument.
+func ParseDump(output, iface string) ([]Interface, error) {
+	var result []Interface
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		name := iface
+		if len(fields) == allInterfaceFields || len(fields) == allPeerFields {
+			name = fields[0]
+			fields = fields[1:]
+		}
+
+		switch len(fields) {
+		case interfaceFields:
+			port, err := strconv.Atoi(fields[2])
+			if err != nil {
+				return nil, fmt.Errorf("invalid listen port %q: %w", fields[2], err)
+			}
+			result = append(result, Interface{
+				Name:       name,
+				PrivateKey: fields[0],
+				PublicKey:  fields[1],
+				ListenPort: port,
+				FwMark:     parseOff(fields[3]),
+				Peers:      []Peer{},
+			})
+		case peerFields:
+			if len(result) == 0 || result[len(result)-1].Name != name {
+				return nil, fmt.Errorf("peer line before interface line: %q", line)
+			}
+			peer, err := parsePeer(fields)
+			if err != nil {
+				return nil, err
+			}
+			current := &result[len(result)-1]
+			current.Peers = append(current.Peers, peer)
+		default:
+			return nil, fmt.Errorf("unexpected wg dump line: %q", line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scanner error: %w", err)
+	}
+	return result, nil
+}
+
+func parsePeer(fields []string) (Peer, error) {
+	peer := Peer{
+		PublicKey:           fields[0],
+		HasPresharedKey:     fields[1] != noneValue,
+		PersistentKeepalive: parseOff(fields[7]),
+		AllowedIPs:          []string{},
+	}
+	if fields[2] != noneValue {
+		peer.Endpoint = fields[2]
+	}
+	if fields[3] != noneValue && fields[3] != "" {
+		peer.AllowedIPs = strings.Split(fields[3], ",")
+	}
+	counters := []*int64{&peer.LatestHandshake, &peer.TransferRx, &peer.TransferTx}
+	for i, c := range counters {
+		v, err := strconv.ParseInt(fields[4+i], 10, 64)
+		if err != nil {
+			return Peer{}, fmt.Errorf("invalid peer counter %q: %w", fields[4+i], err)
+		}
+		*c = v
+	}
+	return peer, nil
+}
+
+// parseOff converts a numeric (decimal or 0x hex) field that wg prints as "off" when unset.
+func parseOff(s string) int {
+	if s == offValue {
+		return 0
+	}
+	v, err := strconv.ParseInt(s, 0, 32)
+	if err != nil {
+		return 0
+	}
+	return int(v)
+}
@@ -0,0 +1,79 @@
+package wg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDump_All(t *testing.T) {
+	sample := "wg0\tcFHkX1FlJ0TTo1c0J2z7m7bN8Ah2SK5RGnb6Y9j2w2w=\tTQ0r3mDgKG5aF0FT0hGYh3U1f2f1E9EJ2b2Zf5X1yW8=\t51820\toff\n" +
+		"wg0\tSd8xGIpNkzj7g0pPg7/4RVw+E2d6Xr5kGQJ5dV3mCzE=\t(none)\t203.0.113.5:51820\t10.10.0.2/32,192.168.20.0/24\t1720000000\t1024\t2048\t25\n" +
+		"wg0\tUe8xGIpNkzj7g0pPg7/4RVw+E2d6Xr5kGQJ5dV3mCzE=\tqJ1xGIpNkzj7g0pPg7/4RVw+E2d6Xr5kGQJ5dV3mCzE=\t(none)\t(none)\t0\t0\t0\toff\n" +
+		"wg1\tYFHkX1FlJ0TTo1c0J2z7m7bN8Ah2SK5RGnb6Y9j2w2w=\tZQ0r3mDgKG5aF0FT0hGYh3U1f2f1E9EJ2b2Zf5X1yW8=\t51821\t0x2a\n"
+
+	expected := []Interface{
+		{
+			Name:       "wg0",
+			PrivateKey: "cFHkX1FlJ0TTo1c0J2z7m7bN8Ah2SK5RGnb6Y9j2w2w=",
+			PublicKey:  "TQ0r3mDgKG5aF0FT0hGYh3U1f2f1E9EJ2b2Zf5X1yW8=",
+			ListenPort: 51820,
+			Peers: []Peer{
+				{
+					PublicKey:           "Sd8xGIpNkzj7g0pPg7/4RVw+E2d6Xr5kGQJ5dV3mCzE=",
+					Endpoint:            "203.0.113.5:51820",
+					AllowedIPs:          []string{"10.10.0.2/32", "192.168.20.0/24"},
+					LatestHandshake:     1720000000,
+					TransferRx:          1024,
+					TransferTx:          2048,
+					PersistentKeepalive: 25,
+				},
+				{
+					PublicKey:       "Ue8xGIpNkzj7g0pPg7/4RVw+E2d6Xr5kGQJ5dV3mCzE=",
+					HasPresharedKey: true,
+					AllowedIPs:      []string{},
+				},
+			},
+		},
+		{
+			Name:       "wg1",
+			PrivateKey: "YFHkX1FlJ0TTo1c0J2z7m7bN8Ah2SK5RGnb6Y9j2w2w=",
+			PublicKey:  "ZQ0r3mDgKG5aF0FT0hGYh3U1f2f1E9EJ2b2Zf5X1yW8=",
+			ListenPort: 51821,
+			FwMark:     42,
+			Peers:      []Peer{},
+		},
+	}
+
+	got, err := ParseDump(sample, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("parsed dump does not match expected.\nGot: %#v\nWant: %#v", got, expected)
+	}
+}
+
+func TestParseDump_SingleInterface(t *testing.T) {
+	sample := "cFHkX1FlJ0TTo1c0J2z7m7bN8Ah2SK5RGnb6Y9j2w2w=\tTQ0r3mDgKG5aF0FT0hGYh3U1f2f1E9EJ2b2Zf5X1yW8=\t51820\toff\n" +
+		"Sd8xGIpNkzj7g0pPg7/4RVw+E2d6Xr5kGQJ5dV3mCzE=\t(none)\t(none)\t10.10.0.2/32\t0\t0\t0\toff\n"
+
+	got, err := ParseDump(sample, "wg0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 1 || got[0].Name != "wg0" || len(got[0].Peers) != 1 {
+		t.Fatalf("unexpected result: %#v", got)
+	}
+	if got[0].Peers[0].AllowedIPs[0] != "10.10.0.2/32" {
+		t.Errorf("unexpected allowed ips: %v", got[0].Peers[0].AllowedIPs)
+	}
+}
+
+func TestParseDump_Malformed(t *testing.T) {
+	if _, err := ParseDump("wg0\tonly\tthree\n", ""); err == nil {
+		t.Error("expected error for malformed line")
+	}
+	if _, err := ParseDump("wg0\tk\t(none)\t(none)\t(none)\t0\t0\t0\toff\n", ""); err == nil {
+		t.Error("expected error for peer without interface")
+	}
+}